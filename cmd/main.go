@@ -4,9 +4,12 @@ import "go-db/internal/manager"
 
 const DEFAULT_BUFFER_POOL_SIZE = 2048
 
+// write every page to the double write buffer first so torn pages can be recovered
+const ENABLE_DOUBLE_WRITE = true
+
 func main() {
 	// use the http server as host
 	// to receive the client SQL request
-	db := manager.InitDatabase("test.db", DEFAULT_BUFFER_POOL_SIZE)
+	db := manager.InitDatabase("test.db", DEFAULT_BUFFER_POOL_SIZE, ENABLE_DOUBLE_WRITE)
 	db.RunDB()
 }
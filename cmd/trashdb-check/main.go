@@ -0,0 +1,55 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go-db/internal/checker"
+	"os"
+)
+
+// trashdb-check validates a database file offline, the database
+// server must not have the file open while the check is running
+func main() {
+	repair := flag.Bool("repair", false, "repair orphaned pages, broken prev links and overlapping tuples")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-repair] <database file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// opening a missing file would create an empty database
+	if _, err := os.Stat(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	diskManager, err := checker.OpenDatabase(flag.Arg(0), *repair)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	report, err := checker.NewChecker(diskManager, *repair).Check()
+	diskManager.ShutDown()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	for _, issue := range report.Issues {
+		fmt.Println(issue)
+	}
+
+	fmt.Printf("%d pages, %d tables, %d free pages, %d issues\n", report.PageCount, report.TableCount, report.FreePages, len(report.Issues))
+
+	if !report.IsClean() {
+		os.Exit(1)
+	}
+}
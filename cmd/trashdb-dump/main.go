@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-db/internal/common/types"
+	"go-db/internal/inspector"
+	"go-db/internal/storage/disk"
+	"os"
+)
+
+// trashdb-dump prints the layout of every page in a database file
+func main() {
+	jsonOutput := flag.Bool("json", false, "print the pages as JSON")
+	pageID := flag.Int("page", -1, "only dump the given page")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: %s [-json] [-page id] <database file>\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// opening a missing file would create an empty database
+	if _, err := os.Stat(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	diskManager, err := disk.NewDiskStorage(flag.Arg(0))
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	pageInspector := inspector.NewInspector(diskManager)
+
+	var dumps []*inspector.PageDump
+
+	if *pageID >= 0 {
+		dump, err := pageInspector.DumpPage(types.Page_id_t(*pageID))
+
+		if err == nil {
+			dumps = append(dumps, dump)
+		}
+	} else {
+		dumps, err = pageInspector.DumpPages()
+	}
+
+	diskManager.ShutDown()
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+
+		if err := encoder.Encode(dumps); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	for _, dump := range dumps {
+		printPage(dump)
+	}
+}
+
+func printPage(dump *inspector.PageDump) {
+	fmt.Printf("page %d: %s checksum=%08x valid=%t\n", dump.PageID, dump.Type, dump.Checksum, dump.ChecksumValid)
+
+	if dump.SuperBlock != nil {
+		fmt.Printf("  version=%d page_size=%d catalog_root=%d free_list_head=%d checkpoint_lsn=%d\n",
+			dump.SuperBlock.Version, dump.SuperBlock.PageSize, dump.SuperBlock.CatalogRootPageID, dump.SuperBlock.FreeListHead, dump.SuperBlock.CheckpointLSN)
+	}
+
+	if dump.Table != "" {
+		fmt.Printf("  table=%s\n", dump.Table)
+	}
+
+	if dump.PrevPageID != nil {
+		fmt.Printf("  prev=%d\n", *dump.PrevPageID)
+	}
+
+	if dump.NextPageID != nil {
+		fmt.Printf("  next=%d\n", *dump.NextPageID)
+	}
+
+	if dump.DataPageID != nil {
+		fmt.Printf("  data_page=%d\n", *dump.DataPageID)
+	}
+
+	for i, c := range dump.Columns {
+		fmt.Printf("  column %d: %s %s(%d)\n", i, c.Name, c.Type, c.Size)
+	}
+
+	if dump.IsLeaf != nil {
+		fmt.Printf("  is_leaf=%t key_size=%d key_count=%d\n", *dump.IsLeaf, *dump.KeySize, *dump.KeyCount)
+	}
+
+	if dump.FreeSpacePointer != nil {
+		fmt.Printf("  free_space_pointer=%d tuple_count=%d\n", *dump.FreeSpacePointer, *dump.TupleCount)
+	}
+
+	for _, t := range dump.Tuples {
+		fmt.Printf("  tuple %d: offset=%d size=%d", t.Index, t.Offset, t.Size)
+
+		if t.Deleted {
+			fmt.Printf(" deleted")
+		}
+
+		if t.Error != "" {
+			fmt.Printf(" error=%q", t.Error)
+		}
+
+		if t.Values != nil {
+			fmt.Printf(" values=%v", t.Values)
+		}
+
+		fmt.Println()
+	}
+}
@@ -51,13 +51,20 @@ func (p *BufferPoolManager) FetchPage(pageID types.Page_id_t) (*page.Page, error
 				return nil, errors.ErrNoPageCanReplace
 			}
 		}
-		page := p.BufferPool[frame_id]
-
 		data, err := p.DiskManager.ReadPage(pageID)
+
+		if err == nil && !page.IsChecksumValid(data) {
+			err = errors.ErrPageCorrupted
+		}
+
 		if err != nil {
+			p.FreePageList = append(p.FreePageList, frame_id)
 			return nil, err
 		}
+
+		page := p.BufferPool[frame_id]
 		copy(page.GetData(), data)
+		page.SetPageID(pageID)
 		p.PageTable[pageID] = frame_id
 	}
 
@@ -124,9 +131,30 @@ func (p *BufferPoolManager) FlushAllPage() {
 	}
 }
 
-//TODO
-func (p *BufferPoolManager) DeletePage(pageID types.Page_id_t) {
+// DeletePage drops the page from the buffer pool and gives it back
+// to the disk free page list, a pinned page can not be deleted
+func (p *BufferPoolManager) DeletePage(pageID types.Page_id_t) error {
+	p.Lock.Lock()
+	if frame_id, exist := p.PageTable[pageID]; exist {
+		page := p.BufferPool[frame_id]
+
+		if page.GetPinCount() > 0 {
+			p.Lock.Unlock()
+			return errors.ErrPagePinned
+		}
+
+		// remove the frame from the replacer so it can not be victim twice
+		p.Replacer.Pin(frame_id)
+		delete(p.PageTable, pageID)
+
+		page.ResetPageData()
+		page.SetPageID(constant.INVALID_PAGE_ID)
+		page.SetDirty(false)
+		p.FreePageList = append(p.FreePageList, frame_id)
+	}
+	p.Lock.Unlock()
 
+	return p.DiskManager.DeallocatePage(pageID)
 }
 
 func (p *BufferPoolManager) getFromFreeList() types.Frame_id_t {
@@ -139,6 +167,7 @@ func (p *BufferPoolManager) getFromFreeList() types.Frame_id_t {
 }
 
 func (p *BufferPoolManager) flushPageData(page *page.Page) error {
+	page.UpdateChecksum()
 	return p.DiskManager.WritePage(page.GetPageID(), page.GetData())
 }
 
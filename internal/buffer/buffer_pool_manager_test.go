@@ -3,9 +3,12 @@ package buffer
 import (
 	"bytes"
 	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"go-db/internal/storage/disk"
+	"go-db/internal/storage/page"
 	"math/rand"
+	"os"
 	"testing"
 )
 
@@ -44,7 +47,7 @@ func Test_BufferPoolManager_Simple(t *testing.T) {
 		bufferpool.UnpinPage(pages[i])
 	}
 
-	page, err := bufferpool.FetchPage(0)
+	page, err := bufferpool.FetchPage(pages[0])
 
 	if err != nil {
 		t.Fatal(err)
@@ -65,6 +68,7 @@ func Test_BufferPoolManager_full(t *testing.T) {
 
 	bufferpool := NewBufferPoolManager(lruReplacer, diskManager, 5)
 
+	pages := make([]types.Page_id_t, 0, 5)
 	randomData := make([][]byte, 5)
 
 	for i := range randomData {
@@ -73,12 +77,15 @@ func Test_BufferPoolManager_full(t *testing.T) {
 
 	for i := 0; i < 5; i++ {
 		rand.Read(randomData[i])
+		// the checksum field is owned by the buffer pool
+		page.SetChecksum(randomData[i])
 
 		page, err := bufferpool.NewPage()
 
 		if err != nil {
 			t.Fatal(err)
 		}
+		pages = append(pages, page.GetPageID())
 		copy(page.GetData(), randomData[i])
 
 		if !bytes.Equal(page.GetData(), randomData[i]) {
@@ -87,7 +94,7 @@ func Test_BufferPoolManager_full(t *testing.T) {
 		bufferpool.FlushPage(page.GetPageID())
 	}
 
-	bufferpool.UnpinPage(0)
+	bufferpool.UnpinPage(pages[0])
 
 	fullPage, err := bufferpool.NewPage()
 	newData := make([]byte, constant.PAGE_SIZE)
@@ -103,9 +110,9 @@ func Test_BufferPoolManager_full(t *testing.T) {
 		t.Fatal("wrong data")
 	}
 
-	bufferpool.UnpinPage(1)
+	bufferpool.UnpinPage(pages[1])
 
-	oldPage, err := bufferpool.FetchPage(0)
+	oldPage, err := bufferpool.FetchPage(pages[0])
 
 	if err != nil {
 		t.Fatal(err)
@@ -115,3 +122,96 @@ func Test_BufferPoolManager_full(t *testing.T) {
 		t.Fatal("wrong data")
 	}
 }
+
+func Test_BufferPoolManager_DeletePage(t *testing.T) {
+	lruReplacer := NewLRUReplacer()
+	diskManager, err := disk.NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	bufferpool := NewBufferPoolManager(lruReplacer, diskManager, 5)
+
+	page, err := bufferpool.NewPage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pageID := page.GetPageID()
+
+	if err := bufferpool.DeletePage(pageID); err == nil {
+		t.Error("pinned page should not be deleted")
+	}
+
+	bufferpool.UnpinPage(pageID)
+
+	if err := bufferpool.DeletePage(pageID); err != nil {
+		t.Fatal(err)
+	}
+
+	if lruReplacer.Size() != 0 {
+		t.Error("deleted frame still in replacer")
+	}
+
+	if diskManager.GetFreeListHead() != pageID {
+		t.Error("deleted page not in free list")
+	}
+
+	newPage, err := bufferpool.NewPage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if newPage.GetPageID() != pageID {
+		t.Error("deleted page should be reused", newPage.GetPageID())
+	}
+}
+
+func Test_BufferPoolManager_Checksum(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	bufferpool := NewBufferPoolManager(NewLRUReplacer(), diskManager, 5)
+
+	newPage, err := bufferpool.NewPage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pageID := newPage.GetPageID()
+	copy(newPage.GetData()[constant.PAGE_SIZE-5:], []byte("12345"))
+	bufferpool.FlushPage(pageID)
+	bufferpool.UnpinPage(pageID)
+
+	data, err := diskManager.ReadPage(pageID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !page.IsChecksumValid(data) {
+		t.Fatal("flushed page should have valid checksum")
+	}
+
+	// flip one byte on disk behind the buffer pool
+	data[constant.PAGE_SIZE-1] = '0'
+
+	if err := diskManager.WritePage(pageID, data); err != nil {
+		t.Fatal(err)
+	}
+
+	bufferpool = NewBufferPoolManager(NewLRUReplacer(), diskManager, 5)
+
+	if _, err := bufferpool.FetchPage(pageID); err != errors.ErrPageCorrupted {
+		t.Error("corrupted page should be detected", err)
+	}
+}
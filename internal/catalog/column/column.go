@@ -1,16 +1,23 @@
 package column
 
-import "go-db/internal/common/types"
+import (
+	"fmt"
+	"go-db/internal/common/types"
+)
 
 type Column struct {
 	ColumnType types.COLUMN_TYPE
 	Name       string
 	Size       int32
+	// the digits of a DECIMAL and how many of them are after the point,
+	// a bare DECIMAL has no precision and keeps the scale of every value
+	Precision int32
+	Scale     int32
 }
 
-func NewColumn(types types.COLUMN_TYPE, size int32, name string) *Column {
+func NewColumn(columnType types.COLUMN_TYPE, size int32, name string) *Column {
 	c := &Column{
-		ColumnType: types,
+		ColumnType: columnType,
 		Name:       name,
 	}
 
@@ -19,6 +26,19 @@ func NewColumn(types types.COLUMN_TYPE, size int32, name string) *Column {
 	return c
 }
 
+func NewDecimalColumn(precision int32, scale int32, name string) *Column {
+	c := &Column{
+		ColumnType: types.DECIMAL_TYPE,
+		Name:       name,
+		Precision:  precision,
+		Scale:      scale,
+	}
+
+	c.SetTypeSize(0)
+
+	return c
+}
+
 func (c *Column) SetTypeSize(size int32) {
 	switch c.ColumnType {
 	case types.BOOL_TYPE:
@@ -29,10 +49,42 @@ func (c *Column) SetTypeSize(size int32) {
 		size = types.INT_SIZE
 	case types.LONG_INT_TYPE:
 		size = types.LONG_INT_SIZE
+	case types.DATE_TYPE:
+		size = types.DATE_SIZE
+	case types.TIME_TYPE:
+		size = types.TIME_SIZE
+	case types.TIMESTAMP_TYPE:
+		size = types.TIMESTAMP_SIZE
+	case types.INTERVAL_TYPE:
+		size = types.INTERVAL_SIZE
+	case types.DECIMAL_TYPE:
+		size = types.DECIMAL_SIZE
+
+		if c.Precision == 0 {
+			size += types.DECIMAL_SCALE_SIZE
+		}
 	case types.VAR_CHAR_TYPE:
 		if size == 0 {
 			size = types.VAR_CHAR_SIZE
 		}
+	case types.JSON_TYPE:
+		if size == 0 {
+			size = types.JSON_SIZE
+		}
+	case types.SMALL_INT_TYPE:
+		size = types.SMALL_INT_SIZE
+	case types.REAL_TYPE:
+		size = types.REAL_SIZE
+	case types.UUID_TYPE:
+		size = types.UUID_SIZE
+	case types.BYTEA_TYPE:
+		if size == 0 {
+			size = types.BYTEA_SIZE
+		}
+	case types.CHAR_TYPE:
+		if size == 0 {
+			size = types.CHAR_SIZE
+		}
 	}
 	c.Size = size
 }
@@ -48,3 +100,51 @@ func (c *Column) GetColumnType() types.COLUMN_TYPE {
 func (c *Column) GetColumnSize() int32 {
 	return c.Size
 }
+
+// GetTypeName adds the precision and scale to the name of a DECIMAL
+func (c *Column) GetTypeName() string {
+	if c.ColumnType == types.DECIMAL_TYPE && c.Precision != 0 {
+		return fmt.Sprintf("%s(%d,%d)", types.COLUMN_TYPE_DECIMAL, c.Precision, c.Scale)
+	}
+
+	return GetColumnTypeName(c.ColumnType)
+}
+
+func GetColumnTypeName(columnType types.COLUMN_TYPE) string {
+	switch columnType {
+	case types.VAR_CHAR_TYPE:
+		return types.COLUMN_TYPE_VAR_CHAR
+	case types.INT_TYPE:
+		return types.COLUMN_TYPE_INT
+	case types.LONG_INT_TYPE:
+		return types.COLUMN_TYPE_LONGINT
+	case types.FLOAT_TYPE:
+		return types.COLUMN_TYPE_FLOAT
+	case types.BOOL_TYPE:
+		return types.COLUMN_TYPE_BOOL
+	case types.DATE_TYPE:
+		return types.COLUMN_TYPE_DATE
+	case types.TIME_TYPE:
+		return types.COLUMN_TYPE_TIME
+	case types.TIMESTAMP_TYPE:
+		return types.COLUMN_TYPE_TIMESTAMP
+	case types.INTERVAL_TYPE:
+		return types.COLUMN_TYPE_INTERVAL
+	case types.DECIMAL_TYPE:
+		return types.COLUMN_TYPE_DECIMAL
+	case types.JSON_TYPE:
+		return types.COLUMN_TYPE_JSON
+	case types.SMALL_INT_TYPE:
+		return types.COLUMN_TYPE_SMALLINT
+	case types.REAL_TYPE:
+		return types.COLUMN_TYPE_REAL
+	case types.UUID_TYPE:
+		return types.COLUMN_TYPE_UUID
+	case types.BYTEA_TYPE:
+		return types.COLUMN_TYPE_BYTEA
+	case types.CHAR_TYPE:
+		return types.COLUMN_TYPE_CHAR
+	}
+
+	return types.COLUMN_TYPE_INVALID
+}
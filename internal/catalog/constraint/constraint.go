@@ -0,0 +1,198 @@
+package constraint
+
+import (
+	"fmt"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strings"
+)
+
+const COLUMN_NAMES_SEPARATOR = ","
+
+// Constraint is a rule over the columns of a table, PRIMARY KEY and UNIQUE
+// are enforced through the unique index which has the name of the constraint.
+// The DEFAULT of a column is kept as a constraint on that column as well,
+// so is an index which CREATE INDEX builds on an expression
+type Constraint struct {
+	Name    string
+	Type    string
+	Columns []string
+
+	// the text of a CHECK condition, a DEFAULT value or an indexed expression
+	Expression string
+
+	// a FOREIGN KEY looks its rows up in the index of the referenced constraint
+	RefTable   string
+	RefColumns []string
+	RefIndex   string
+	OnDelete   string
+	OnUpdate   string
+}
+
+func NewConstraint(name string, constraintType string, columns []string) *Constraint {
+	return &Constraint{
+		Name:    name,
+		Type:    constraintType,
+		Columns: columns,
+	}
+}
+
+// NewForeignKey leaves the referenced columns empty when they are the primary key,
+// a missing action is NO ACTION
+func NewForeignKey(name string, columns []string, refTable string, refColumns []string, onDelete string, onUpdate string) *Constraint {
+	if onDelete == "" {
+		onDelete = types.FOREIGN_KEY_NO_ACTION
+	}
+
+	if onUpdate == "" {
+		onUpdate = types.FOREIGN_KEY_NO_ACTION
+	}
+
+	return &Constraint{
+		Name:       name,
+		Type:       types.CONSTRAINT_FOREIGN_KEY,
+		Columns:    columns,
+		RefTable:   refTable,
+		RefColumns: refColumns,
+		OnDelete:   onDelete,
+		OnUpdate:   onUpdate,
+	}
+}
+
+// NewExpressionConstraint is a CHECK, DEFAULT or an expression index, the columns
+// of a CHECK or an index are the ones its expression references
+func NewExpressionConstraint(name string, constraintType string, columns []string, expression string) *Constraint {
+	return &Constraint{
+		Name:       name,
+		Type:       constraintType,
+		Columns:    columns,
+		Expression: expression,
+	}
+}
+
+// HasIndex reports whether the constraint is backed by a B+ tree
+func (c *Constraint) HasIndex() bool {
+	return c.Type == types.CONSTRAINT_PRIMARY_KEY || c.Type == types.CONSTRAINT_UNIQUE || c.IsExpressionIndex()
+}
+
+// IsExpressionIndex reports whether the constraint is an index on an expression
+func (c *Constraint) IsExpressionIndex() bool {
+	return c.Type == types.CONSTRAINT_INDEX || c.Type == types.CONSTRAINT_UNIQUE_INDEX
+}
+
+// IsUnique reports whether two rows can not have the same key in the index
+func (c *Constraint) IsUnique() bool {
+	return c.HasIndex() && c.Type != types.CONSTRAINT_INDEX
+}
+
+// hasExpression reports whether the definition of the constraint is its expression
+func (c *Constraint) hasExpression() bool {
+	return c.Type == types.CONSTRAINT_CHECK || c.Type == types.CONSTRAINT_DEFAULT || c.IsExpressionIndex()
+}
+
+// IsNotNull reports whether the columns of the constraint can not be NULL
+func (c *Constraint) IsNotNull() bool {
+	return c.Type == types.CONSTRAINT_PRIMARY_KEY || c.Type == types.CONSTRAINT_NOT_NULL
+}
+
+// GetDefaultName names the constraint like PostgreSQL does
+// table_pkey, table_column_key and table_column_not_null
+func GetDefaultName(tableName string, constraintType string, columns []string) string {
+	switch constraintType {
+	case types.CONSTRAINT_PRIMARY_KEY:
+		return tableName + "_pkey"
+	case types.CONSTRAINT_UNIQUE:
+		return tableName + "_" + strings.Join(columns, "_") + "_key"
+	case types.CONSTRAINT_NOT_NULL:
+		return tableName + "_" + strings.Join(columns, "_") + "_not_null"
+	case types.CONSTRAINT_FOREIGN_KEY:
+		return tableName + "_" + strings.Join(columns, "_") + "_fkey"
+	case types.CONSTRAINT_CHECK:
+		if len(columns) == 0 {
+			return tableName + "_check"
+		}
+
+		return tableName + "_" + strings.Join(columns, "_") + "_check"
+	case types.CONSTRAINT_DEFAULT:
+		return tableName + "_" + strings.Join(columns, "_") + "_default"
+	case types.CONSTRAINT_INDEX, types.CONSTRAINT_UNIQUE_INDEX:
+		return tableName + "_" + strings.Join(columns, "_") + "_idx"
+	}
+
+	return tableName + "_" + strings.Join(columns, "_")
+}
+
+func JoinColumns(columns []string) string {
+	return strings.Join(columns, COLUMN_NAMES_SEPARATOR)
+}
+
+func SplitColumns(columnNames string) []string {
+	if columnNames == "" {
+		return []string{}
+	}
+
+	return strings.Split(columnNames, COLUMN_NAMES_SEPARATOR)
+}
+
+func IsForeignKeyAction(action string) bool {
+	switch action {
+	case types.FOREIGN_KEY_RESTRICT, types.FOREIGN_KEY_NO_ACTION, types.FOREIGN_KEY_CASCADE,
+		types.FOREIGN_KEY_SET_NULL, types.FOREIGN_KEY_SET_DEFAULT:
+		return true
+	}
+
+	return false
+}
+
+// GetDefinition is the text kept in the definition column of sys_constraints,
+// the expression of CHECK, DEFAULT and an index or for a FOREIGN KEY
+// REFERENCES parent (column1,column2) ON DELETE action ON UPDATE action
+func (c *Constraint) GetDefinition() string {
+	if c.hasExpression() {
+		return c.Expression
+	}
+
+	if c.Type != types.CONSTRAINT_FOREIGN_KEY {
+		return ""
+	}
+
+	return fmt.Sprintf("REFERENCES %s (%s) ON DELETE %s ON UPDATE %s", c.RefTable, JoinColumns(c.RefColumns), c.OnDelete, c.OnUpdate)
+}
+
+// SetDefinition reads back the text written by GetDefinition
+func (c *Constraint) SetDefinition(definition string) error {
+	if c.hasExpression() {
+		c.Expression = definition
+		return nil
+	}
+
+	if c.Type != types.CONSTRAINT_FOREIGN_KEY {
+		return nil
+	}
+
+	var refColumns string
+
+	rest := strings.TrimPrefix(definition, "REFERENCES ")
+	parts := strings.SplitN(rest, " ON UPDATE ", 2)
+
+	if len(parts) != 2 {
+		return errors.ErrSyntax
+	}
+
+	c.OnUpdate = parts[1]
+	parts = strings.SplitN(parts[0], " ON DELETE ", 2)
+
+	if len(parts) != 2 {
+		return errors.ErrSyntax
+	}
+
+	c.OnDelete = parts[1]
+
+	if _, err := fmt.Sscanf(parts[0], "%s %s", &c.RefTable, &refColumns); err != nil {
+		return errors.ErrSyntax
+	}
+
+	c.RefColumns = SplitColumns(strings.TrimSuffix(strings.TrimPrefix(refColumns, "("), ")"))
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package expression
+
+import (
+	"fmt"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strconv"
+	"text/scanner"
+)
+
+/**
+ *  CAST(value AS type) and value::type
+ *  +---------------------------------+---------------------------------------------+
+ *  | Example                         | Result                                      |
+ *  +---------------------------------+---------------------------------------------+
+ *  | '42'::INT                       | 42, 'x'::INT is invalid input syntax        |
+ *  | 2.5::INT, CAST(2.5 AS INT)      | 3, a DECIMAL rounds half away from zero     |
+ *  | 'abcdef'::VARCHAR(3)            | 'abc', a length cuts the text               |
+ *  | 3.14159::DECIMAL(5,2)           | 3.14, DECIMAL(p,s) rounds to the scale      |
+ *  | 1::BOOL, TIMESTAMP '...'::DATE  | TRUE, the day of the timestamp              |
+ *  +---------------------------------+---------------------------------------------+
+ *
+ *  :: binds tighter than every other operator, -1::VARCHAR is -(1::VARCHAR). A type without
+ *  a length or precision keeps the whole value. The conversions are in tuple.CastValue.
+ */
+
+const (
+	KEYWORD_CAST  = "CAST"
+	KEYWORD_AS    = "AS"
+	OPERATOR_CAST = "::"
+)
+
+// Cast converts its operand to the type of the column, a size of 0 is a type without a length
+type Cast struct {
+	Operand Expression
+	Type    *column.Column
+}
+
+// the names of the types CAST takes besides the types of the typed literals
+var castTypes = map[string]types.COLUMN_TYPE{
+	types.COLUMN_TYPE_BOOL:      types.BOOL_TYPE,
+	types.COLUMN_TYPE_INT:       types.INT_TYPE,
+	types.COLUMN_TYPE_LONGINT:   types.LONG_INT_TYPE,
+	types.COLUMN_TYPE_INT2:      types.SMALL_INT_TYPE,
+	types.COLUMN_TYPE_FLOAT:     types.FLOAT_TYPE,
+	types.COLUMN_TYPE_FLOAT8:    types.FLOAT_TYPE,
+	types.COLUMN_TYPE_FLOAT4:    types.REAL_TYPE,
+	types.COLUMN_TYPE_DECIMAL:   types.DECIMAL_TYPE,
+	types.COLUMN_TYPE_NUMERIC:   types.DECIMAL_TYPE,
+	types.COLUMN_TYPE_VAR_CHAR:  types.VAR_CHAR_TYPE,
+	types.COLUMN_TYPE_CHARACTER: types.CHAR_TYPE,
+}
+
+func getCastType(name string) (types.COLUMN_TYPE, bool) {
+	if valueType, exist := literalTypes[name]; exist {
+		return valueType, true
+	}
+
+	valueType, exist := castTypes[name]
+
+	return valueType, exist
+}
+
+func (c *Cast) Evaluate(row *Row) (*tuple.Value, error) {
+	value, err := c.Operand.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	size := c.Type.Size
+
+	if size == 0 {
+		size = getTypeSize(c.Type.ColumnType)
+	}
+
+	converted, err := tuple.CastValue(value, c.Type.ColumnType, size)
+
+	if err != nil || c.Type.Size == 0 {
+		return converted, err
+	}
+
+	return tuple.FitValue(tuple.TruncateText(converted, c.Type.Size), c.Type)
+}
+
+func (c *Cast) String() string {
+	return "CAST(" + c.Operand.String() + " " + KEYWORD_AS + " " + getCastTypeName(c.Type) + ")"
+}
+
+func getCastTypeName(c *column.Column) string {
+	name := column.GetColumnTypeName(c.ColumnType)
+
+	if c.Size == 0 {
+		return name
+	}
+
+	switch c.ColumnType {
+	case types.DECIMAL_TYPE:
+		return fmt.Sprintf("%s(%d,%d)", name, c.Precision, c.Scale)
+	case types.VAR_CHAR_TYPE, types.CHAR_TYPE, types.JSON_TYPE:
+		return fmt.Sprintf("%s(%d)", name, c.Size)
+	case types.BYTEA_TYPE:
+		return fmt.Sprintf("%s(%d)", name, c.Size-types.BYTEA_LENGTH_SIZE)
+	}
+
+	return name
+}
+
+// parseCast reads CAST(value AS type), the current token is the opening bracket
+func (p *parser) parseCast() (Expression, error) {
+	p.next()
+	operand, err := p.parseExpression(precedenceOr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyword() != KEYWORD_AS {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+	valueType, err := p.parseType()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.text != ")" {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	return &Cast{Operand: operand, Type: valueType}, nil
+}
+
+// parseType reads a type such as INT, VARCHAR(10), DECIMAL(5,2) or DOUBLE PRECISION
+func (p *parser) parseType() (*column.Column, error) {
+	name := p.keyword()
+
+	if name == "" {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	if name == types.COLUMN_TYPE_DOUBLE {
+		if p.keyword() != types.COLUMN_TYPE_PRECISION {
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+		name = types.COLUMN_TYPE_FLOAT
+	}
+
+	valueType, exist := getCastType(name)
+
+	if !exist {
+		return nil, errors.ErrSyntax
+	}
+
+	sizes := make([]int32, 0, 2)
+
+	if p.text == "(" {
+		for {
+			p.next()
+
+			if p.token != scanner.Int {
+				return nil, errors.ErrSyntax
+			}
+
+			size, err := strconv.ParseInt(p.text, 10, 32)
+
+			if err != nil {
+				return nil, errors.ErrSyntax
+			}
+
+			sizes = append(sizes, int32(size))
+			p.next()
+
+			if p.text == ")" {
+				p.next()
+				break
+			}
+
+			if p.text != "," {
+				return nil, errors.ErrSyntax
+			}
+		}
+	}
+
+	switch valueType {
+	case types.DECIMAL_TYPE:
+		if len(sizes) == 0 {
+			return &column.Column{ColumnType: valueType}, nil
+		}
+
+		if len(sizes) == 1 {
+			sizes = append(sizes, 0)
+		}
+
+		if len(sizes) > 2 || sizes[0] < 1 || sizes[0] > types.MAX_DECIMAL_PRECISION || sizes[1] < 0 || sizes[1] > sizes[0] {
+			return nil, errors.ErrInvalidDecimal
+		}
+
+		return column.NewDecimalColumn(sizes[0], sizes[1], ""), nil
+	case types.VAR_CHAR_TYPE, types.CHAR_TYPE, types.JSON_TYPE, types.BYTEA_TYPE:
+		c := &column.Column{ColumnType: valueType}
+
+		if len(sizes) > 1 || (len(sizes) == 1 && sizes[0] < 1) {
+			return nil, errors.ErrSyntax
+		}
+
+		if len(sizes) == 1 {
+			c.Size = sizes[0]
+		} else if valueType == types.CHAR_TYPE {
+			c.Size = types.CHAR_SIZE
+		}
+
+		if c.Size != 0 && valueType == types.BYTEA_TYPE {
+			c.Size += types.BYTEA_LENGTH_SIZE
+		}
+
+		return c, nil
+	}
+
+	if len(sizes) != 0 {
+		return nil, errors.ErrSyntax
+	}
+
+	return column.NewColumn(valueType, 0, ""), nil
+}
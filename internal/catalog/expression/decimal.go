@@ -0,0 +1,107 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math/big"
+)
+
+/**
+ *  DECIMAL arithmetic
+ *  +----------+-----------------------------+
+ *  | Operator | Scale of the result         |
+ *  +----------+-----------------------------+
+ *  | + - %    | max(s1, s2)                 |
+ *  | *        | s1 + s2                     |
+ *  | /        | max(16, s1, s2), rounded    |
+ *  +----------+-----------------------------+
+ *
+ *  INT and BIGINT become DECIMAL with scale 0 next to a DECIMAL, a FLOAT makes the result FLOAT.
+ *  Rounding is half away from zero like the rounding to the scale of a column.
+ */
+
+func NewDecimal(value tuple.Decimal) *tuple.Value {
+	return tuple.GetValue(value, types.DECIMAL_TYPE, types.DECIMAL_SIZE)
+}
+
+func toDecimal(v *tuple.Value) tuple.Decimal {
+	switch v.GetType() {
+	case types.INT_TYPE, types.SMALL_INT_TYPE:
+		return tuple.NewDecimal(int64(v.INT), 0)
+	case types.LONG_INT_TYPE:
+		return tuple.NewDecimal(v.LONG_INT, 0)
+	}
+
+	return v.DECIMAL
+}
+
+// alignDecimals returns the unscaled values of both decimals at the larger scale
+func alignDecimals(left tuple.Decimal, right tuple.Decimal) (*big.Int, *big.Int, int32) {
+	scale := left.Scale
+
+	if right.Scale > scale {
+		scale = right.Scale
+	}
+
+	return left.Rescale(scale).Unscaled, right.Rescale(scale).Unscaled, scale
+}
+
+func compareDecimal(left *tuple.Value, right *tuple.Value) int {
+	l, r, _ := alignDecimals(toDecimal(left), toDecimal(right))
+
+	return l.Cmp(r)
+}
+
+func decimalArithmetic(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, error) {
+	l, r := toDecimal(left), toDecimal(right)
+	var result tuple.Decimal
+
+	switch operator {
+	case OPERATOR_PLUS, OPERATOR_MINUS:
+		lUnscaled, rUnscaled, scale := alignDecimals(l, r)
+
+		if operator == OPERATOR_PLUS {
+			result = tuple.Decimal{Unscaled: new(big.Int).Add(lUnscaled, rUnscaled), Scale: scale}
+		} else {
+			result = tuple.Decimal{Unscaled: new(big.Int).Sub(lUnscaled, rUnscaled), Scale: scale}
+		}
+	case OPERATOR_MULTIPLY:
+		result = tuple.Decimal{Unscaled: new(big.Int).Mul(l.Unscaled, r.Unscaled), Scale: l.Scale + r.Scale}
+	case OPERATOR_DIVIDE, OPERATOR_MODULO:
+		if r.Digits() == 0 {
+			return nil, errors.ErrDivisionByZero
+		}
+
+		if operator == OPERATOR_MODULO {
+			lUnscaled, rUnscaled, scale := alignDecimals(l, r)
+			result = tuple.Decimal{Unscaled: new(big.Int).Rem(lUnscaled, rUnscaled), Scale: scale}
+
+			break
+		}
+
+		scale := int32(tuple.DECIMAL_DIVISION_SCALE)
+
+		for _, s := range []int32{l.Scale, r.Scale} {
+			if s > scale {
+				scale = s
+			}
+		}
+
+		// l / r at the scale is l * 10^(scale + r.Scale - l.Scale) / r without the points
+		dividend := l.Rescale(scale + r.Scale).Unscaled
+
+		result = tuple.Decimal{Unscaled: tuple.DivideRound(dividend, r.Unscaled), Scale: scale}
+	}
+
+	// no column can hold more digits before the point
+	if result.Digits()-result.Scale > types.MAX_DECIMAL_PRECISION {
+		return nil, errors.ErrNumericOverflow
+	}
+
+	if result.Scale > types.MAX_DECIMAL_PRECISION {
+		result = result.Rescale(types.MAX_DECIMAL_PRECISION)
+	}
+
+	return NewDecimal(result), nil
+}
@@ -0,0 +1,785 @@
+package expression
+
+import (
+	"bytes"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"strconv"
+	"strings"
+)
+
+/**
+ *  Expression is the tree of a scalar expression such as the condition of WHERE,
+ *  every node evaluates to a tuple value against the row.
+ *
+ *  NULL follows the SQL rules, an operator with a NULL operand is NULL
+ *  except AND and OR which use three valued logic.
+ */
+
+type Expression interface {
+	Evaluate(row *Row) (*tuple.Value, error)
+	// String returns the expression in a form Parse reads back
+	String() string
+}
+
+// Row is the tuple the column references are resolved against,
+// nextval and currval find their sequences through it and the row
+// of a group keeps the results of its aggregate calls. A qualified
+// reference such as EXCLUDED.name is resolved against the row of
+// Tables under the lower case name. The row of a subquery resolves
+// the columns it does not have against Outer.
+type Row struct {
+	Columns    []*column.Column
+	Values     []*tuple.Value
+	Sequences  Sequences
+	Aggregates map[*Function]*tuple.Value
+	Tables     map[string]*Row
+	Outer      *Row
+	Queries    Queries
+}
+
+type Sequences interface {
+	NextValue(name string) (int64, error)
+	CurrentValue(name string) (int64, error)
+}
+
+func NewRow(columns []*column.Column, values []*tuple.Value) *Row {
+	return &Row{Columns: columns, Values: values}
+}
+
+type Literal struct {
+	Value *tuple.Value
+}
+
+// ColumnRef is a column, Table is the qualifier of table.column
+type ColumnRef struct {
+	Table string
+	Name  string
+}
+
+type Unary struct {
+	Operator string
+	Operand  Expression
+}
+
+type Binary struct {
+	Operator string
+	Left     Expression
+	Right    Expression
+}
+
+type IsNull struct {
+	Operand Expression
+	Not     bool
+}
+
+// CurrentTime is CURRENT_TIMESTAMP, CURRENT_DATE or CURRENT_TIME
+type CurrentTime struct {
+	Keyword string
+}
+
+// Function is a call such as nextval('name'), its name is kept in lower case
+type Function struct {
+	Name string
+	Args []Expression
+}
+
+const (
+	OPERATOR_AND           = "AND"
+	OPERATOR_OR            = "OR"
+	OPERATOR_NOT           = "NOT"
+	OPERATOR_IS            = "IS"
+	OPERATOR_EQUAL         = "="
+	OPERATOR_NOT_EQUAL     = "<>"
+	OPERATOR_NOT_EQUAL_ALT = "!="
+	OPERATOR_LESS          = "<"
+	OPERATOR_LESS_EQUAL    = "<="
+	OPERATOR_GREATER       = ">"
+	OPERATOR_GREATER_EQUAL = ">="
+	OPERATOR_PLUS          = "+"
+	OPERATOR_MINUS         = "-"
+	OPERATOR_MULTIPLY      = "*"
+	OPERATOR_DIVIDE        = "/"
+	OPERATOR_MODULO        = "%"
+)
+
+const (
+	LITERAL_NULL  = "NULL"
+	LITERAL_TRUE  = "TRUE"
+	LITERAL_FALSE = "FALSE"
+)
+
+const (
+	CURRENT_TIMESTAMP = "CURRENT_TIMESTAMP"
+	CURRENT_DATE      = "CURRENT_DATE"
+	CURRENT_TIME      = "CURRENT_TIME"
+)
+
+const (
+	FUNCTION_NEXTVAL = "nextval"
+	FUNCTION_CURRVAL = "currval"
+)
+
+func NewNull() *tuple.Value {
+	return tuple.GetNullValue(types.INVALID_TYPE, 0)
+}
+
+func NewBool(value bool) *tuple.Value {
+	return tuple.GetValue(value, types.BOOL_TYPE, types.BOOL_SIZE)
+}
+
+func NewText(value string) *tuple.Value {
+	return tuple.GetValue(value, types.VAR_CHAR_TYPE, int32(len(value)))
+}
+
+// EvaluateCondition reports whether the row passes the condition,
+// a nil condition passes every row and NULL does not pass
+func EvaluateCondition(condition Expression, row *Row) (bool, error) {
+	if condition == nil {
+		return true, nil
+	}
+
+	value, err := condition.Evaluate(row)
+
+	if err != nil {
+		return false, err
+	}
+
+	if value.IsNull() {
+		return false, nil
+	}
+
+	if value.GetType() != types.BOOL_TYPE {
+		return false, errors.ErrTypeMismatch
+	}
+
+	return value.BOOL, nil
+}
+
+// Walk visits the expression and every expression below it
+func Walk(expr Expression, visit func(Expression)) {
+	visit(expr)
+
+	switch e := expr.(type) {
+	case *Unary:
+		Walk(e.Operand, visit)
+	case *IsNull:
+		Walk(e.Operand, visit)
+	case *Cast:
+		Walk(e.Operand, visit)
+	case *Case:
+		if e.Operand != nil {
+			Walk(e.Operand, visit)
+		}
+
+		for _, when := range e.Whens {
+			Walk(when.Condition, visit)
+			Walk(when.Result, visit)
+		}
+
+		if e.Else != nil {
+			Walk(e.Else, visit)
+		}
+	case *Binary:
+		Walk(e.Left, visit)
+		Walk(e.Right, visit)
+	case *In:
+		Walk(e.Operand, visit)
+
+		for _, item := range e.List {
+			Walk(item, visit)
+		}
+	case *Function:
+		for _, arg := range e.Args {
+			Walk(arg, visit)
+		}
+	}
+}
+
+// UsesSequence reports whether evaluating the expression changes a sequence
+func UsesSequence(expr Expression) bool {
+	uses := false
+
+	Walk(expr, func(e Expression) {
+		if f, ok := e.(*Function); ok && f.Name == FUNCTION_NEXTVAL {
+			uses = true
+		}
+	})
+
+	return uses
+}
+
+// GetColumnNames returns the columns the expression references, each of them once
+func GetColumnNames(expr Expression) []string {
+	names := make([]string, 0)
+	seen := make(map[string]bool)
+
+	Walk(expr, func(e Expression) {
+		if ref, ok := e.(*ColumnRef); ok && !seen[ref.Name] {
+			seen[ref.Name] = true
+			names = append(names, ref.Name)
+		}
+	})
+
+	return names
+}
+
+// RenameColumn changes the references to the column in place
+func RenameColumn(expr Expression, columnName string, newColumnName string) {
+	Walk(expr, func(e Expression) {
+		if ref, ok := e.(*ColumnRef); ok && ref.Name == columnName {
+			ref.Name = newColumnName
+		}
+	})
+}
+
+// CheckReferences reports a column or a function the expression references which the rows
+// of the table can not resolve, before any row is evaluated. An aggregate call is an error
+// because the expression is evaluated for every row.
+func CheckReferences(expr Expression, tableName string, columns []*column.Column) error {
+	var err error
+
+	Walk(expr, func(e Expression) {
+		if err != nil {
+			return
+		}
+
+		switch e := e.(type) {
+		case *ColumnRef:
+			if e.Table != "" && strings.ToLower(e.Table) != strings.ToLower(tableName) {
+				err = errors.ErrColumnNotExist
+				return
+			}
+
+			for _, c := range columns {
+				if c.Name == e.Name {
+					return
+				}
+			}
+
+			err = errors.ErrColumnNotExist
+		case *Function:
+			if _, exist := getAggregate(e.Name); exist {
+				err = errors.ErrAggregateCall
+				return
+			}
+
+			registry.RLock()
+			defer registry.RUnlock()
+
+			if !isRegistered(e.Name) {
+				err = errors.ErrNoFunction
+			}
+		}
+	})
+
+	return err
+}
+
+func (l *Literal) Evaluate(row *Row) (*tuple.Value, error) {
+	return l.Value, nil
+}
+
+func (l *Literal) String() string {
+	v := l.Value
+
+	if v.IsNull() {
+		return LITERAL_NULL
+	}
+
+	switch v.GetType() {
+	case types.BOOL_TYPE:
+		if v.BOOL {
+			return LITERAL_TRUE
+		}
+
+		return LITERAL_FALSE
+	case types.INT_TYPE:
+		return strconv.FormatInt(int64(v.INT), 10)
+	case types.LONG_INT_TYPE:
+		return strconv.FormatInt(v.LONG_INT, 10)
+	case types.FLOAT_TYPE:
+		text := strconv.FormatFloat(v.FLOAT, 'f', -1, 64)
+
+		if !strings.Contains(text, ".") {
+			text += ".0"
+		}
+
+		return text
+	case types.DATE_TYPE, types.TIME_TYPE, types.TIMESTAMP_TYPE, types.INTERVAL_TYPE, types.JSON_TYPE,
+		types.SMALL_INT_TYPE, types.REAL_TYPE, types.UUID_TYPE, types.BYTEA_TYPE, types.CHAR_TYPE:
+		return column.GetColumnTypeName(v.GetType()) + " " + strconv.Quote(tuple.GetValueText(v))
+	case types.DECIMAL_TYPE:
+		return v.DECIMAL.String()
+	}
+
+	return strconv.Quote(string(v.VAR_CHAR))
+}
+
+func (c *ColumnRef) Evaluate(row *Row) (*tuple.Value, error) {
+	for ; row != nil; row = row.Outer {
+		if c.Table != "" {
+			if table := row.Tables[strings.ToLower(c.Table)]; table != nil {
+				return table.getColumn(c.Name)
+			}
+
+			continue
+		}
+
+		if value, err := row.getColumn(c.Name); err == nil {
+			return value, nil
+		}
+	}
+
+	return nil, errors.ErrColumnNotExist
+}
+
+func (r *Row) getColumn(name string) (*tuple.Value, error) {
+	for i, col := range r.Columns {
+		if col.Name == name {
+			return r.Values[i], nil
+		}
+	}
+
+	return nil, errors.ErrColumnNotExist
+}
+
+func (c *ColumnRef) String() string {
+	if c.Table != "" {
+		return c.Table + "." + c.Name
+	}
+
+	return c.Name
+}
+
+func (c *CurrentTime) Evaluate(row *Row) (*tuple.Value, error) {
+	now := wallClock()
+
+	switch c.Keyword {
+	case CURRENT_DATE:
+		return NewDate(tuple.TimeToDate(now)), nil
+	case CURRENT_TIME:
+		return NewTime(tuple.TimeOfDay(now)), nil
+	}
+
+	return NewTimestamp(tuple.TimeToTimestamp(now)), nil
+}
+
+func (c *CurrentTime) String() string {
+	return c.Keyword
+}
+
+func (f *Function) evaluateSequence(row *Row) (*tuple.Value, error) {
+	if len(f.Args) != 1 {
+		return nil, errors.ErrSyntax
+	}
+
+	name, err := f.Args[0].Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if name.IsNull() {
+		return NewNull(), nil
+	}
+
+	if name.GetType() != types.VAR_CHAR_TYPE {
+		return nil, errors.ErrTypeMismatch
+	}
+
+	// a CHECK is evaluated without sequences
+	if row == nil || row.Sequences == nil {
+		return nil, errors.ErrNoSequence
+	}
+
+	var value int64
+
+	if f.Name == FUNCTION_NEXTVAL {
+		value, err = row.Sequences.NextValue(string(name.VAR_CHAR))
+	} else {
+		value, err = row.Sequences.CurrentValue(string(name.VAR_CHAR))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tuple.GetValue(value, types.LONG_INT_TYPE, types.LONG_INT_SIZE), nil
+}
+
+func (f *Function) String() string {
+	args := make([]string, len(f.Args))
+
+	for i, arg := range f.Args {
+		args[i] = arg.String()
+	}
+
+	return f.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+func (u *Unary) Evaluate(row *Row) (*tuple.Value, error) {
+	operand, err := u.Operand.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Operator == OPERATOR_NOT {
+		if operand.IsNull() {
+			return NewNull(), nil
+		}
+
+		if operand.GetType() != types.BOOL_TYPE {
+			return nil, errors.ErrTypeMismatch
+		}
+
+		return NewBool(!operand.BOOL), nil
+	}
+
+	if !operand.IsNull() && operand.GetType() == types.INTERVAL_TYPE {
+		negated, err := negateInterval(operand.INTERVAL)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return NewInterval(negated), nil
+	}
+
+	return arithmetic(OPERATOR_MINUS, tuple.GetValue(int32(0), types.INT_TYPE, types.INT_SIZE), operand)
+}
+
+func (u *Unary) String() string {
+	if u.Operator == OPERATOR_NOT {
+		return "(" + OPERATOR_NOT + " " + u.Operand.String() + ")"
+	}
+
+	return "(" + u.Operator + u.Operand.String() + ")"
+}
+
+func (i *IsNull) Evaluate(row *Row) (*tuple.Value, error) {
+	operand, err := i.Operand.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBool(operand.IsNull() != i.Not), nil
+}
+
+func (i *IsNull) String() string {
+	if i.Not {
+		return "(" + i.Operand.String() + " IS NOT NULL)"
+	}
+
+	return "(" + i.Operand.String() + " IS NULL)"
+}
+
+func (b *Binary) Evaluate(row *Row) (*tuple.Value, error) {
+	left, err := b.Left.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	right, err := b.Right.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.Operator {
+	case OPERATOR_AND, OPERATOR_OR:
+		return logic(b.Operator, left, right)
+	case OPERATOR_PLUS, OPERATOR_MINUS, OPERATOR_MULTIPLY, OPERATOR_DIVIDE, OPERATOR_MODULO:
+		return arithmetic(b.Operator, left, right)
+	case OPERATOR_ARROW, OPERATOR_ARROW_TEXT, OPERATOR_PATH, OPERATOR_PATH_TEXT:
+		return jsonOperator(b.Operator, left, right)
+	}
+
+	if left.IsNull() || right.IsNull() {
+		return NewNull(), nil
+	}
+
+	result, err := Compare(left, right)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch b.Operator {
+	case OPERATOR_EQUAL:
+		return NewBool(result == 0), nil
+	case OPERATOR_NOT_EQUAL, OPERATOR_NOT_EQUAL_ALT:
+		return NewBool(result != 0), nil
+	case OPERATOR_LESS:
+		return NewBool(result < 0), nil
+	case OPERATOR_LESS_EQUAL:
+		return NewBool(result <= 0), nil
+	case OPERATOR_GREATER:
+		return NewBool(result > 0), nil
+	case OPERATOR_GREATER_EQUAL:
+		return NewBool(result >= 0), nil
+	}
+
+	return nil, errors.ErrSyntax
+}
+
+func (b *Binary) String() string {
+	return "(" + b.Left.String() + " " + b.Operator + " " + b.Right.String() + ")"
+}
+
+// Compare orders two values which are not NULL, text is converted
+// to the type of the other value when the types differ
+func Compare(left *tuple.Value, right *tuple.Value) (int, error) {
+	left, right, err := coerce(left, right)
+
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case isNumeric(left) && isNumeric(right):
+		if isFloat(left) || isFloat(right) {
+			l, r := toFloat(left), toFloat(right)
+
+			if l < r {
+				return -1, nil
+			} else if l > r {
+				return 1, nil
+			}
+
+			return 0, nil
+		}
+
+		if left.GetType() == types.DECIMAL_TYPE || right.GetType() == types.DECIMAL_TYPE {
+			return compareDecimal(left, right), nil
+		}
+
+		l, r := toInt(left), toInt(right)
+
+		if l < r {
+			return -1, nil
+		} else if l > r {
+			return 1, nil
+		}
+
+		return 0, nil
+	case isText(left) && isText(right):
+		// the trailing spaces of CHAR are padding, 'a' equals 'a  ' next to a CHAR
+		if left.GetType() == types.CHAR_TYPE || right.GetType() == types.CHAR_TYPE {
+			return bytes.Compare(bytes.TrimRight(left.VAR_CHAR, " "), bytes.TrimRight(right.VAR_CHAR, " ")), nil
+		}
+
+		return bytes.Compare(left.VAR_CHAR, right.VAR_CHAR), nil
+	case left.GetType() == types.UUID_TYPE && right.GetType() == types.UUID_TYPE:
+		return bytes.Compare(left.UUID[:], right.UUID[:]), nil
+	case left.GetType() == types.BYTEA_TYPE && right.GetType() == types.BYTEA_TYPE:
+		return bytes.Compare(left.BYTEA, right.BYTEA), nil
+	case isTemporal(left) && left.GetType() == right.GetType():
+		return compareTemporal(left, right), nil
+	case left.GetType() == types.JSON_TYPE && right.GetType() == types.JSON_TYPE:
+		// the binary form is canonical so equal documents have equal bytes
+		return bytes.Compare(left.JSON, right.JSON), nil
+	case left.GetType() == types.BOOL_TYPE && right.GetType() == types.BOOL_TYPE:
+		if left.BOOL == right.BOOL {
+			return 0, nil
+		} else if right.BOOL {
+			return -1, nil
+		}
+
+		return 1, nil
+	}
+
+	return 0, errors.ErrTypeMismatch
+}
+
+func coerce(left *tuple.Value, right *tuple.Value) (*tuple.Value, *tuple.Value, error) {
+	var err error
+
+	// a DATE is the midnight of its day next to a TIMESTAMP
+	if left.GetType() == types.DATE_TYPE && right.GetType() == types.TIMESTAMP_TYPE {
+		left = NewTimestamp(int64(left.DATE) * tuple.MICROSECONDS_PER_DAY)
+	} else if right.GetType() == types.DATE_TYPE && left.GetType() == types.TIMESTAMP_TYPE {
+		right = NewTimestamp(int64(right.DATE) * tuple.MICROSECONDS_PER_DAY)
+	}
+
+	if left.GetType() == types.VAR_CHAR_TYPE && !isText(right) {
+		left, err = tuple.ConvertValue(left, right.GetType(), right.GetSize())
+	} else if right.GetType() == types.VAR_CHAR_TYPE && !isText(left) {
+		right, err = tuple.ConvertValue(right, left.GetType(), left.GetSize())
+	}
+
+	return left, right, err
+}
+
+func logic(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, error) {
+	for _, v := range []*tuple.Value{left, right} {
+		if !v.IsNull() && v.GetType() != types.BOOL_TYPE {
+			return nil, errors.ErrTypeMismatch
+		}
+	}
+
+	// FALSE decides AND and TRUE decides OR even when the other side is NULL
+	decisive := operator == OPERATOR_OR
+
+	if (!left.IsNull() && left.BOOL == decisive) || (!right.IsNull() && right.BOOL == decisive) {
+		return NewBool(decisive), nil
+	}
+
+	if left.IsNull() || right.IsNull() {
+		return NewNull(), nil
+	}
+
+	return NewBool(!decisive), nil
+}
+
+// arithmetic keeps INT when both operands are INT and fails when the result does not fit,
+// the operands take the wider type of SMALLINT < INT < BIGINT < DECIMAL < REAL < FLOAT
+func arithmetic(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, error) {
+	if left.IsNull() || right.IsNull() {
+		return NewNull(), nil
+	}
+
+	if isTemporal(left) || isTemporal(right) {
+		return temporalArithmetic(operator, left, right)
+	}
+
+	if !isNumeric(left) || !isNumeric(right) {
+		return nil, errors.ErrTypeMismatch
+	}
+
+	if isFloat(left) || isFloat(right) {
+		l, r := toFloat(left), toFloat(right)
+		var result float64
+
+		switch operator {
+		case OPERATOR_PLUS:
+			result = l + r
+		case OPERATOR_MINUS:
+			result = l - r
+		case OPERATOR_MULTIPLY:
+			result = l * r
+		case OPERATOR_DIVIDE, OPERATOR_MODULO:
+			if r == 0 {
+				return nil, errors.ErrDivisionByZero
+			}
+
+			if operator == OPERATOR_DIVIDE {
+				result = l / r
+			} else {
+				result = math.Mod(l, r)
+			}
+		}
+
+		if math.IsInf(result, 0) || math.IsNaN(result) {
+			return nil, errors.ErrNumericOverflow
+		}
+
+		// REAL stays REAL only next to another REAL
+		if left.GetType() == types.REAL_TYPE && right.GetType() == types.REAL_TYPE {
+			if math.IsInf(float64(float32(result)), 0) {
+				return nil, errors.ErrNumericOverflow
+			}
+
+			return tuple.GetValue(result, types.REAL_TYPE, types.REAL_SIZE), nil
+		}
+
+		return tuple.GetValue(result, types.FLOAT_TYPE, types.FLOAT_SIZE), nil
+	}
+
+	if left.GetType() == types.DECIMAL_TYPE || right.GetType() == types.DECIMAL_TYPE {
+		return decimalArithmetic(operator, left, right)
+	}
+
+	l, r := toInt(left), toInt(right)
+	var result int64
+
+	switch operator {
+	case OPERATOR_PLUS:
+		result = l + r
+
+		if (result > l) != (r > 0) {
+			return nil, errors.ErrNumericOverflow
+		}
+	case OPERATOR_MINUS:
+		result = l - r
+
+		if (result < l) != (r > 0) {
+			return nil, errors.ErrNumericOverflow
+		}
+	case OPERATOR_MULTIPLY:
+		result = l * r
+
+		if l != 0 && (result/l != r || (l == -1 && r == math.MinInt64)) {
+			return nil, errors.ErrNumericOverflow
+		}
+	case OPERATOR_DIVIDE, OPERATOR_MODULO:
+		if r == 0 {
+			return nil, errors.ErrDivisionByZero
+		}
+
+		if l == math.MinInt64 && r == -1 {
+			return nil, errors.ErrNumericOverflow
+		}
+
+		if operator == OPERATOR_DIVIDE {
+			result = l / r
+		} else {
+			result = l % r
+		}
+	}
+
+	if left.GetType() == types.LONG_INT_TYPE || right.GetType() == types.LONG_INT_TYPE {
+		return tuple.GetValue(result, types.LONG_INT_TYPE, types.LONG_INT_SIZE), nil
+	}
+
+	if result > math.MaxInt32 || result < math.MinInt32 {
+		return nil, errors.ErrNumericOverflow
+	}
+
+	return tuple.GetValue(int32(result), types.INT_TYPE, types.INT_SIZE), nil
+}
+
+func isNumeric(v *tuple.Value) bool {
+	switch v.GetType() {
+	case types.INT_TYPE, types.LONG_INT_TYPE, types.DECIMAL_TYPE, types.FLOAT_TYPE, types.SMALL_INT_TYPE, types.REAL_TYPE:
+		return true
+	}
+
+	return false
+}
+
+func isFloat(v *tuple.Value) bool {
+	return v.GetType() == types.FLOAT_TYPE || v.GetType() == types.REAL_TYPE
+}
+
+// isText is true for VARCHAR and CHAR, both keep their text in VAR_CHAR
+func isText(v *tuple.Value) bool {
+	return v.GetType() == types.VAR_CHAR_TYPE || v.GetType() == types.CHAR_TYPE
+}
+
+func toInt(v *tuple.Value) int64 {
+	if v.GetType() == types.INT_TYPE || v.GetType() == types.SMALL_INT_TYPE {
+		return int64(v.INT)
+	}
+
+	return v.LONG_INT
+}
+
+func toFloat(v *tuple.Value) float64 {
+	switch v.GetType() {
+	case types.INT_TYPE, types.SMALL_INT_TYPE:
+		return float64(v.INT)
+	case types.LONG_INT_TYPE:
+		return float64(v.LONG_INT)
+	case types.DECIMAL_TYPE:
+		return v.DECIMAL.Float()
+	}
+
+	return v.FLOAT
+}
@@ -0,0 +1,1109 @@
+package expression
+
+import (
+	"errors"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	errs "go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"reflect"
+	"strings"
+	"testing"
+	"text/scanner"
+)
+
+func parse(t *testing.T, query string) (Expression, string) {
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Error = func(*scanner.Scanner, string) {}
+
+	expr, end, err := Parse(&s)
+
+	if err != nil {
+		t.Fatal(query, err)
+	}
+
+	return expr, end
+}
+
+func Test_ParseExpression(t *testing.T) {
+	tests := []struct {
+		query  string
+		result string
+		end    string
+	}{
+		{"1 + 2 * 3", "(1 + (2 * 3))", ""},
+		{"(1 + 2) * 3 where", "((1 + 2) * 3)", "where"},
+		{"a >= 1 AND NOT b <> 'x' OR c IS NOT NULL", "(((a >= 1) AND (NOT (b <> \"x\"))) OR (c IS NOT NULL))", ""},
+		{"-a - 1.5, b", "((-a) - 1.5)", ","},
+		{"x != null", "(x != NULL)", ""},
+		{"EXCLUDED.score + t.score", "(EXCLUDED.score + t.score)", ""},
+	}
+
+	for _, test := range tests {
+		expr, end := parse(t, test.query)
+
+		if expr.String() != test.result {
+			t.Errorf("%s parsed as %s", test.query, expr.String())
+		}
+
+		if end != test.end {
+			t.Errorf("%s ended at %s", test.query, end)
+		}
+
+		again, _ := parse(t, expr.String())
+
+		if again.String() != expr.String() {
+			t.Errorf("%s is not read back", expr.String())
+		}
+	}
+
+	for _, query := range []string{"", "1 +", "(1", "a IS 1", "AND a"} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+
+		if _, _, err := Parse(&s); !errors.Is(err, errs.ErrSyntax) {
+			t.Errorf("%s should be a syntax error", query)
+		}
+	}
+}
+
+func Test_EvaluateExpression(t *testing.T) {
+	row := NewRow(
+		[]*column.Column{
+			{Name: "id", ColumnType: types.INT_TYPE, Size: types.INT_SIZE},
+			{Name: "name", ColumnType: types.VAR_CHAR_TYPE, Size: 10},
+			{Name: "score", ColumnType: types.FLOAT_TYPE, Size: types.FLOAT_SIZE},
+		},
+		[]*tuple.Value{
+			tuple.GetValue(int32(3), types.INT_TYPE, types.INT_SIZE),
+			tuple.GetValue("bob", types.VAR_CHAR_TYPE, 10),
+			tuple.GetNullValue(types.FLOAT_TYPE, types.FLOAT_SIZE),
+		},
+	)
+
+	conditions := map[string]bool{
+		"id = 3":                       true,
+		"id + 1 > 3.5":                 true,
+		"id = '3'":                     true,
+		"name = 'bob' AND id % 2 = 1":  true,
+		"score > 1":                    false,
+		"NOT score > 1":                false,
+		"score > 1 OR id = 3":          true,
+		"score IS NULL AND id / 2 = 1": true,
+		"name <> \"bob\"":              false,
+		"-id < -2":                     true,
+	}
+
+	for query, want := range conditions {
+		expr, _ := parse(t, query)
+		result, err := EvaluateCondition(expr, row)
+
+		if err != nil {
+			t.Fatal(query, err)
+		}
+
+		if result != want {
+			t.Errorf("%s should be %v", query, want)
+		}
+	}
+
+	value, err := (&Binary{Operator: OPERATOR_AND, Left: &Literal{Value: NewNull()}, Right: &Literal{Value: NewBool(false)}}).Evaluate(row)
+
+	if err != nil || value.IsNull() || value.BOOL {
+		t.Error("NULL AND FALSE should be FALSE")
+	}
+
+	failures := map[string]error{
+		"id / 0":              errs.ErrDivisionByZero,
+		"2147483647 + id":     errs.ErrNumericOverflow,
+		"name + 1":            errs.ErrTypeMismatch,
+		"missing = 1":         errs.ErrColumnNotExist,
+		"id AND TRUE":         errs.ErrTypeMismatch,
+		"name = 1":            errs.ErrInvalidValue,
+		"9223372036854775808": errs.ErrNumericOverflow,
+	}
+
+	for query, want := range failures {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		expr, _, err := Parse(&s)
+
+		if err == nil {
+			_, err = expr.Evaluate(row)
+		}
+
+		if !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	value, err = parseAndEvaluate(t, "2147483648 + 1", row)
+
+	if err != nil || value.GetType() != types.LONG_INT_TYPE || value.LONG_INT != 2147483649 {
+		t.Error("a literal outside INT should be BIGINT")
+	}
+}
+
+func parseAndEvaluate(t *testing.T, query string, row *Row) (*tuple.Value, error) {
+	expr, _ := parse(t, query)
+	return expr.Evaluate(row)
+}
+
+func Test_ParseText(t *testing.T) {
+	expr, err := ParseText("(price > 0) AND (name <> 'x' OR price * 2 < total)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if columns := GetColumnNames(expr); !reflect.DeepEqual(columns, []string{"price", "name", "total"}) {
+		t.Error("get the wrong column names", columns)
+	}
+
+	RenameColumn(expr, "price", "cost")
+
+	if text := expr.String(); strings.Contains(text, "price") || !strings.Contains(text, "cost") {
+		t.Error("column should be renamed", text)
+	}
+
+	for _, text := range []string{"1 +", "a > 0)", "(a > 0", "a b"} {
+		if _, err := ParseText(text); err != errs.ErrSyntax {
+			t.Error(text, "should be syntax error", err)
+		}
+	}
+
+	// a quote is written twice in a literal and a backslash is kept as it is
+	for text, want := range map[string]string{
+		"'it''s'":     "it's",
+		"''''":        "'",
+		`'C:\path'`:   `C:\path`,
+		`'\x0102'`:    `\x0102`,
+		`'a\'`:        `a\`,
+		`"tab\there"`: "tab\there",
+	} {
+		expr, err := ParseText(text)
+
+		if err != nil || tuple.GetValueText(expr.(*Literal).Value) != want {
+			t.Errorf("%s should be %q, got %v %v", text, want, expr, err)
+			continue
+		}
+
+		if printed, err := ParseText(expr.String()); err != nil || printed.String() != expr.String() {
+			t.Error("the printed literal should be read back", expr.String(), err)
+		}
+	}
+
+	for _, text := range []string{"'abc", "'abc''", "'a' 'b'"} {
+		if _, err := ParseText(text); err != errs.ErrSyntax {
+			t.Error(text, "should be syntax error", err)
+		}
+	}
+
+	value, err := parseAndEvaluate(t, "CURRENT_DATE", nil)
+
+	if err != nil || value.GetType() != types.DATE_TYPE {
+		t.Error("CURRENT_DATE should be a date", value, err)
+	}
+}
+
+type counter struct {
+	values map[string]int64
+}
+
+func (c *counter) NextValue(name string) (int64, error) {
+	if _, exist := c.values[name]; !exist {
+		return 0, errs.ErrNoSequence
+	}
+
+	c.values[name]++
+
+	return c.values[name], nil
+}
+
+func (c *counter) CurrentValue(name string) (int64, error) {
+	return c.values[name], nil
+}
+
+func Test_SequenceFunctions(t *testing.T) {
+	expr, err := ParseText("NEXTVAL('ids') * 10 + currval(\"ids\")")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if text := expr.String(); text != `((nextval("ids") * 10) + currval("ids"))` {
+		t.Error("get the wrong text", text)
+	}
+
+	if !UsesSequence(expr) {
+		t.Error("nextval should use the sequence")
+	}
+
+	row := &Row{Sequences: &counter{values: map[string]int64{"ids": 0}}}
+
+	if value, err := expr.Evaluate(row); err != nil || value.GetType() != types.LONG_INT_TYPE || value.LONG_INT != 11 {
+		t.Error("get the wrong value", value, err)
+	}
+
+	failures := map[string]error{
+		"nextval('missing')": errs.ErrNoSequence,
+		"nextval(1)":         errs.ErrTypeMismatch,
+		"nextval()":          errs.ErrSyntax,
+		"unknown('ids')":     errs.ErrNoFunction,
+	}
+
+	for text, want := range failures {
+		expr, err := ParseText(text)
+
+		if err == nil {
+			_, err = expr.Evaluate(row)
+		}
+
+		if err != want {
+			t.Errorf("%s should fail with %v, got %v", text, want, err)
+		}
+	}
+
+	for _, text := range []string{"nextval('ids'", "nextval('ids' 'x')", "nextval(,)"} {
+		if _, err := ParseText(text); err != errs.ErrSyntax {
+			t.Error(text, "should be syntax error", err)
+		}
+	}
+
+	if _, err := (&Function{Name: FUNCTION_NEXTVAL, Args: []Expression{&Literal{Value: NewText("ids")}}}).Evaluate(nil); err != errs.ErrNoSequence {
+		t.Error("nextval should fail without sequences", err)
+	}
+}
+
+func Test_TemporalExpressions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.TIMESTAMP_TYPE, 0, "ts"),
+		column.NewColumn(types.DATE_TYPE, 0, "d"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue("2024-01-31 10:30:15.25", types.TIMESTAMP_TYPE, types.TIMESTAMP_SIZE),
+		tuple.GetValue("2024-03-01", types.DATE_TYPE, types.DATE_SIZE),
+	})
+
+	tests := map[string]interface{}{
+		"ts + INTERVAL '1 month'":                       "2024-02-29T10:30:15.25",
+		"ts - '1 day 00:30'":                            "2024-01-30T10:00:15.25",
+		"d - 1":                                         "2024-02-29",
+		"d - DATE '2024-01-01'":                         int32(60),
+		"d - '2024-02-01'":                              int32(29),
+		"d + TIME '12:00'":                              "2024-03-01T12:00:00",
+		"TIMESTAMP '2024-03-01 12:00' - ts":             "P30DT1H29M44.75S",
+		"INTERVAL '1 hour' * 2.5 - INTERVAL '1 day'":    "P-1DT2H30M",
+		"-INTERVAL '2 mons' / 4":                        "P-15D",
+		"TIME '23:00' + INTERVAL '2 hours'":             "01:00:00",
+		"ts < d":                                        true,
+		"d = TIMESTAMP '2024-03-01 00:00'":              true,
+		"ts >= '2024-01-31'":                            true,
+		"INTERVAL '1 month' = INTERVAL '30 days'":       true,
+		"INTERVAL '1 day' > INTERVAL '23 hours'":        true,
+		"date_trunc('month', ts)":                       "2024-01-01T00:00:00",
+		"date_trunc('week', d)":                         "2024-02-26T00:00:00",
+		"date_trunc('Hours', '2024-05-06 07:08:09')":    "2024-05-06T07:00:00",
+		"extract(year FROM ts)":                         2024.0,
+		"EXTRACT(SECOND FROM ts)":                       15.25,
+		"extract(dow FROM d)":                           5.0,
+		"date_part('doy', d)":                           61.0,
+		"date_part('epoch', INTERVAL '1 day 1 second')": 86401.0,
+		"extract(minute FROM TIME '10:30')":             30.0,
+		"ts + NULL":                                     nil,
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil || tuple.GetValueInterface(value) != want {
+			t.Errorf("%s should be %v, got %v %v", query, want, tuple.GetValueInterface(value), err)
+		}
+	}
+
+	failures := map[string]error{
+		"ts + ts":     errs.ErrTypeMismatch,
+		"d * 2":       errs.ErrTypeMismatch,
+		"ts + 'soon'": errs.ErrInvalidValue,
+		"d + 3000000": errs.ErrDatetimeRange,
+		"TIMESTAMP '9999-12-31' + INTERVAL '1 day'": errs.ErrDatetimeRange,
+		"INTERVAL '1 day' / 0":                      errs.ErrDivisionByZero,
+		"date_trunc('fortnight', ts)":               errs.ErrInvalidUnit,
+		"extract(doy FROM TIME '10:00')":            errs.ErrInvalidUnit,
+		"date_part('year')":                         errs.ErrSyntax,
+		"now(1)":                                    errs.ErrSyntax,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	if _, err := ParseText("DATE '2024-02-30'"); !errors.Is(err, errs.ErrInvalidValue) {
+		t.Error("invalid DATE literal should fail", err)
+	}
+
+	expr, err := ParseText("extract(year FROM date) > 2000 AND ts < TIMESTAMP '2030-01-01' + INTERVAL '1.5 days'")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	text := expr.String()
+
+	if !strings.Contains(text, `date_part("year", date)`) || !strings.Contains(text, `INTERVAL "1 day 12:00:00"`) {
+		t.Error("get the wrong text", text)
+	}
+
+	if again, err := ParseText(text); err != nil || again.String() != text {
+		t.Error("temporal literals should be read back", text, err)
+	}
+
+	if value, err := parseAndEvaluate(t, "now() - CURRENT_TIMESTAMP < INTERVAL '1 minute'", nil); err != nil || !value.BOOL {
+		t.Error("now() should be the current timestamp", value, err)
+	}
+}
+
+func Test_DecimalExpressions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewDecimalColumn(10, 2, "price"),
+		column.NewColumn(types.INT_TYPE, 0, "quantity"),
+		column.NewColumn(types.FLOAT_TYPE, 0, "rate"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue("19.99", types.DECIMAL_TYPE, types.DECIMAL_SIZE),
+		tuple.GetValue(int32(3), types.INT_TYPE, types.INT_SIZE),
+		tuple.GetValue(0.5, types.FLOAT_TYPE, types.FLOAT_SIZE),
+	})
+
+	tests := map[string]interface{}{
+		"0.1 + 0.2":                     "0.3",
+		"0.1 + 0.2 = 0.3":               true,
+		"price * quantity":              "59.97",
+		"price * 1.10":                  "21.9890",
+		"price - 20":                    "-0.01",
+		"1 / 3.0":                       "0.3333333333333333",
+		"-2 / 3.0":                      "-0.6666666666666667",
+		"10.5 % 3":                      "1.5",
+		"-price":                        "-19.99",
+		"price * rate":                  9.995,
+		"price > 19.989":                true,
+		"price = '19.990'":              true,
+		"price < rate":                  false,
+		"1.5e2":                         "150",
+		"INTERVAL '1 hour' * 1.5":       "PT1H30M",
+		"99999999999999999999.5 * 1.00": "99999999999999999999.500",
+		"price + NULL":                  nil,
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil || tuple.GetValueInterface(value) != want {
+			t.Errorf("%s should be %v, got %v %v", query, want, tuple.GetValueInterface(value), err)
+		}
+	}
+
+	failures := map[string]error{
+		"price / 0":   errs.ErrDivisionByZero,
+		"price % 0.0": errs.ErrDivisionByZero,
+		"price = 'x'": errs.ErrInvalidValue,
+		"99999999999999999999999999999999999999.0 * 10": errs.ErrNumericOverflow,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+}
+
+func Test_JSONExpressions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.JSON_TYPE, types.JSON_SIZE, "doc"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 64, "raw"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue(`{"name": "Ann", "age": 31, "tags": ["a", "b"], "address": {"city": "Oslo"}, "none": null}`, types.JSON_TYPE, types.JSON_SIZE),
+		tuple.GetValue(`{"n": 1}`, types.VAR_CHAR_TYPE, 64),
+	})
+
+	tests := map[string]interface{}{
+		"doc ->> 'name'":                                 "Ann",
+		"doc -> 'name'":                                  `"Ann"`,
+		"doc -> 'tags' ->> 0":                            "a",
+		"doc -> 'tags' ->> -1":                           "b",
+		"doc #> '{address,city}'":                        `"Oslo"`,
+		"doc #>> '{tags,1}'":                             "b",
+		"doc ->> 'age' = '31'":                           "true",
+		"doc -> 'address' = JSONB '{\"city\":\"Oslo\"}'": "true",
+		"doc ->> 'missing'":                              nil,
+		"doc ->> 'none'":                                 nil,
+		"doc -> 'none'":                                  "null",
+		"doc -> 'tags' -> 5":                             nil,
+		"doc -> 'name' -> 'x'":                           nil,
+		"raw -> 'n'":                                     "1",
+		"json_extract_path_text(doc, 'address', 'city')": "Oslo",
+		"jsonb_extract_path(doc, 'tags')":                `["a", "b"]`,
+		"jsonb_typeof(doc -> 'age')":                     "number",
+		"json_array_length(doc -> 'tags')":               "2",
+		"doc ->> NULL":                                   nil,
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil {
+			t.Errorf("%s should be %v, got %v", query, want, err)
+			continue
+		}
+
+		if want == nil && !value.IsNull() || want != nil && tuple.GetValueText(value) != want {
+			t.Errorf("%s should be %v, got %v", query, want, tuple.GetValueText(value))
+		}
+	}
+
+	failures := map[string]error{
+		"doc -> 1.5":                      errs.ErrTypeMismatch,
+		"doc #> 'city'":                   errs.ErrInvalidValue,
+		"'{' -> 'a'":                      errs.ErrInvalidValue,
+		"json_array_length(doc -> 'age')": errs.ErrTypeMismatch,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	if expr, _ := parse(t, "doc ->> 'name' = 'Ann'"); expr.String() != `((doc ->> "name") = "Ann")` {
+		t.Error("-> should bind tighter than =", expr.String())
+	}
+}
+
+func Test_TypeFamilyExpressions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.SMALL_INT_TYPE, 0, "small"),
+		column.NewColumn(types.REAL_TYPE, 0, "ratio"),
+		column.NewColumn(types.UUID_TYPE, 0, "id"),
+		column.NewColumn(types.BYTEA_TYPE, 0, "data"),
+		column.NewColumn(types.CHAR_TYPE, 4, "code"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue(int32(7), types.SMALL_INT_TYPE, types.SMALL_INT_SIZE),
+		tuple.GetValue("0.5", types.REAL_TYPE, types.REAL_SIZE),
+		tuple.GetValue("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", types.UUID_TYPE, types.UUID_SIZE),
+		tuple.GetValue(`\xdead`, types.BYTEA_TYPE, types.BYTEA_SIZE),
+		tuple.GetValue("ab", types.CHAR_TYPE, 4),
+	})
+
+	tests := map[string]string{
+		"small + 1":     "8",
+		"small * 1.5":   "10.5",
+		"ratio * ratio": "0.25",
+		"ratio + 0.25":  "0.75",
+		"small > 6":     "true",
+		"id = 'A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11'":  "true",
+		"id = UUID 'a0eebc999c0b4ef8bb6d6bb9bd380a11'": "true",
+		"data = BYTEA '\\xdead'":                       "true",
+		"data > '\\xde'":                               "true",
+		"code = 'ab'":                                  "true",
+		"code = 'ab  '":                                "true",
+		"code":                                         "ab  ",
+		"data":                                         `\xdead`,
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil || tuple.GetValueText(value) != want {
+			t.Errorf("%s should be %v, got %v %v", query, want, tuple.GetValueText(value), err)
+		}
+	}
+
+	for query, want := range map[string]types.COLUMN_TYPE{
+		"small + small":     types.INT_TYPE,
+		"ratio * ratio":     types.REAL_TYPE,
+		"ratio * 2.0":       types.FLOAT_TYPE,
+		"gen_random_uuid()": types.UUID_TYPE,
+	} {
+		if value, err := parseAndEvaluate(t, query, row); err != nil || value.GetType() != want {
+			t.Errorf("%s should have the type %v, got %v", query, want, err)
+		}
+	}
+
+	first, _ := parseAndEvaluate(t, "gen_random_uuid()", row)
+	second, _ := parseAndEvaluate(t, "gen_random_uuid()", row)
+
+	if first.UUID == second.UUID {
+		t.Error("random UUIDs should differ")
+	}
+
+	failures := map[string]error{
+		"id = 'x'":           errs.ErrInvalidValue,
+		"id = data":          errs.ErrTypeMismatch,
+		"gen_random_uuid(1)": errs.ErrSyntax,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	if expr, _ := parse(t, "ratio = REAL '1.5'"); expr.String() != `(ratio = REAL "1.5")` {
+		t.Error("REAL literal should keep its type", expr.String())
+	}
+}
+
+func Test_CastExpressions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.VAR_CHAR_TYPE, 16, "name"),
+		column.NewColumn(types.FLOAT_TYPE, 0, "rate"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue("12", types.VAR_CHAR_TYPE, 16),
+		tuple.GetValue(2.75, types.FLOAT_TYPE, types.FLOAT_SIZE),
+	})
+
+	tests := map[string]string{
+		"'42'::INT + 1":                       "43",
+		"CAST(name AS BIGINT) * 2":            "24",
+		"2.5::INT":                            "3",
+		"-2.5::INT":                           "-3",
+		"rate::INT":                           "3",
+		"rate::DECIMAL(4,1)":                  "2.8",
+		"3.14159::NUMERIC(5,2)":               "3.14",
+		"'abcdef'::VARCHAR(3)":                "abc",
+		"CAST('abcdef' AS VARCHAR)":           "abcdef",
+		"'x'::CHAR(3)":                        "x  ",
+		"1::BOOL":                             "true",
+		"TRUE::INT":                           "1",
+		"'2024-02-29 10:30'::TIMESTAMP::DATE": "2024-02-29",
+		"12::VARCHAR = name":                  "true",
+		"rate::DOUBLE PRECISION":              "2.75",
+		"'1'::JSONB -> 0":                     "",
+		"NULL::INT":                           "",
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil || (want == "" && !value.IsNull()) || tuple.GetValueText(value) != want && want != "" {
+			t.Errorf("%s should be %v, got %v %v", query, want, tuple.GetValueText(value), err)
+		}
+	}
+
+	failures := map[string]error{
+		"'x'::INT":                  errs.ErrInvalidValue,
+		"'1.5'::INT":                errs.ErrInvalidValue,
+		"70000::SMALLINT":           errs.ErrNumericOverflow,
+		"123.456::DECIMAL(4,2)":     errs.ErrNumericOverflow,
+		"CAST(name AS UUID)":        errs.ErrInvalidValue,
+		"'{\"a\": }'::JSON ->> 'a'": errs.ErrInvalidValue,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	for _, query := range []string{"1::", "1::NOTYPE", "CAST(1 INT)", "CAST(1 AS INT", "1::VARCHAR(0)", "1::INT(4)"} {
+		if _, err := ParseText(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+
+	if _, err := ParseText("1::DECIMAL(40,2)"); err != errs.ErrInvalidDecimal {
+		t.Error("DECIMAL(40,2) should be invalid", err)
+	}
+
+	if expr, _ := parse(t, "-name::VARCHAR(8)::DECIMAL(5,2) + 1"); expr.String() != "((-CAST(CAST(name AS VARCHAR(8)) AS DECIMAL(5,2))) + 1)" {
+		t.Error(":: should bind tighter than the unary minus", expr.String())
+	}
+
+	if _, err := ParseText("CAST(CAST(name AS VARCHAR(8)) AS DECIMAL(5,2))"); err != nil {
+		t.Error("the text of a cast should be parsed back", err)
+	}
+}
+
+func Test_ScalarFunctions(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.VAR_CHAR_TYPE, 32, "name"),
+		column.NewColumn(types.INT_TYPE, 0, "qty"),
+		column.NewDecimalColumn(6, 2, "price"),
+		column.NewColumn(types.INT_TYPE, 0, "missing"),
+	}
+
+	row := NewRow(columns, []*tuple.Value{
+		tuple.GetValue("  Grüße  ", types.VAR_CHAR_TYPE, 32),
+		tuple.GetValue(int32(-7), types.INT_TYPE, types.INT_SIZE),
+		tuple.GetValue("-12.35", types.DECIMAL_TYPE, types.DECIMAL_SIZE),
+		tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE),
+	})
+
+	tests := map[string]interface{}{
+		"upper(trim(name))":                           "GRÜßE",
+		"lower('ABC')":                                "abc",
+		"length(trim(name))":                          "5",
+		"length(name)":                                "9",
+		"substring('database', 5)":                    "base",
+		"substring('database', 0, 3)":                 "da",
+		"substring('database' FROM 2 FOR 3)":          "ata",
+		"substring('database', 20)":                   "",
+		"ltrim('xxaxx', 'x')":                         "axx",
+		"rtrim(name)":                                 "  Grüße",
+		"concat('a', NULL, qty, '-', TRUE)":           "a-7-true",
+		"replace('a-b-c', '-', '+')":                  "a+b+c",
+		"abs(qty)":                                    "7",
+		"abs(price)":                                  "12.35",
+		"round(price)":                                "-12",
+		"round(price, 1)":                             "-12.4",
+		"round(1234, -2)":                             "1200",
+		"round(2.5::FLOAT)":                           "2",
+		"floor(price)":                                "-13",
+		"ceil(price)":                                 "-12",
+		"ceiling(1.01)":                               "2",
+		"mod(17, 5)":                                  "2",
+		"power(2, 10)":                                "1024",
+		"coalesce(missing, qty, 1)":                   "-7",
+		"coalesce(missing, NULL)":                     nil,
+		"nullif(qty, -7)":                             nil,
+		"nullif(qty, 3)":                              "-7",
+		"greatest(1, qty, missing, 4.5)":              "4.5",
+		"least('b', 'a', NULL)":                       "a",
+		"lower(missing::VARCHAR)":                     nil,
+		"CASE WHEN qty > 0 THEN 'up' ELSE 'down' END": "down",
+		"CASE WHEN qty > 0 THEN 'up' WHEN qty < 0 THEN 'neg' END":     "neg",
+		"CASE qty WHEN 7 THEN 'seven' WHEN -7 THEN 'minus seven' END": "minus seven",
+		"CASE missing WHEN NULL THEN 'null' ELSE 'unknown' END":       "unknown",
+		"CASE WHEN missing > 0 THEN 1 END":                            nil,
+		"upper(CASE WHEN length(name) > 3 THEN 'long' END) = 'LONG'":  "true",
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil {
+			t.Errorf("%s should be %v, got %v", query, want, err)
+			continue
+		}
+
+		if want == nil && !value.IsNull() || want != nil && tuple.GetValueText(value) != want {
+			t.Errorf("%s should be %v, got %v", query, want, tuple.GetValueText(value))
+		}
+	}
+
+	failures := map[string]error{
+		"lower(1)":                 errs.ErrTypeMismatch,
+		"abs('x')":                 errs.ErrTypeMismatch,
+		"abs(-2147483647 - 1)":     errs.ErrNumericOverflow,
+		"substring('abc', 1, -1)":  errs.ErrInvalidValue,
+		"mod(1, 0)":                errs.ErrDivisionByZero,
+		"power(10, 400)":           errs.ErrNumericOverflow,
+		"replace('a', 'b')":        errs.ErrSyntax,
+		"coalesce()":               errs.ErrSyntax,
+		"no_such_function(1)":      errs.ErrNoFunction,
+		"CASE WHEN qty THEN 1 END": errs.ErrTypeMismatch,
+		"greatest(1, 'x')":         errs.ErrInvalidValue,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, row); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	for _, query := range []string{"CASE END", "CASE WHEN 1 END", "CASE WHEN TRUE THEN 1", "substring('a' FOR 1)", "WHEN"} {
+		if _, err := ParseText(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+
+	expr, _ := parse(t, "CASE qty WHEN 1 THEN substring(name FROM 2) ELSE coalesce(name, 'x') END")
+
+	if expr.String() != `CASE qty WHEN 1 THEN substring(name, 2) ELSE coalesce(name, "x") END` {
+		t.Error("get the wrong text", expr.String())
+	}
+
+	if names := GetColumnNames(expr); !reflect.DeepEqual(names, []string{"qty", "name"}) {
+		t.Error("CASE should reference its columns", names)
+	}
+}
+
+type sumAccumulator struct {
+	sum   int64
+	count int
+}
+
+func (s *sumAccumulator) Step(args []*tuple.Value) error {
+	s.sum += args[0].LONG_INT
+	s.count++
+
+	return nil
+}
+
+func (s *sumAccumulator) Result() (*tuple.Value, error) {
+	if s.count == 0 {
+		return nil, nil
+	}
+
+	return tuple.GetValue(s.sum, types.LONG_INT_TYPE, types.LONG_INT_SIZE), nil
+}
+
+func Test_UserDefinedFunctions(t *testing.T) {
+	twice := func(args []*tuple.Value) (*tuple.Value, error) {
+		return tuple.GetValue(args[0].LONG_INT*2, types.LONG_INT_TYPE, types.LONG_INT_SIZE), nil
+	}
+	join := func(args []*tuple.Value) (*tuple.Value, error) {
+		parts := make([]string, 0, len(args))
+
+		for _, arg := range args {
+			parts = append(parts, tuple.GetValueText(arg))
+		}
+
+		// the INT result is read from the text
+		return NewText(strings.Join(parts, "")), nil
+	}
+	newSum := func() Accumulator { return &sumAccumulator{} }
+
+	if err := RegisterFunction("Twice", Signature{Args: []types.COLUMN_TYPE{types.LONG_INT_TYPE}, Return: types.LONG_INT_TYPE}, true, twice); err != nil {
+		t.Fatal(err)
+	}
+
+	defer UnregisterFunction("twice")
+
+	if err := RegisterFunction("join_digits", Signature{Args: []types.COLUMN_TYPE{types.INT_TYPE}, Variadic: true, Return: types.INT_TYPE}, false, join); err != nil {
+		t.Fatal(err)
+	}
+
+	defer UnregisterFunction("join_digits")
+
+	if err := RegisterAggregate("my_sum", Signature{Args: []types.COLUMN_TYPE{types.LONG_INT_TYPE}, Return: types.LONG_INT_TYPE}, true, newSum); err != nil {
+		t.Fatal(err)
+	}
+
+	defer UnregisterFunction("my_sum")
+
+	registrations := map[string]error{
+		"twice":    errs.ErrFunctionExist,
+		"lower":    errs.ErrFunctionExist,
+		"nextval":  errs.ErrFunctionExist,
+		"my_sum":   errs.ErrFunctionExist,
+		"1st":      errs.ErrSyntax,
+		"geo-hash": errs.ErrSyntax,
+		"":         errs.ErrSyntax,
+	}
+
+	for name, want := range registrations {
+		if err := RegisterFunction(name, Signature{Return: types.INT_TYPE}, false, twice); err != want {
+			t.Errorf("registering %q should fail with %v, got %v", name, want, err)
+		}
+	}
+
+	if err := RegisterFunction("bad_type", Signature{Args: []types.COLUMN_TYPE{255}, Return: types.INT_TYPE}, false, twice); err != errs.ErrInvalidValue {
+		t.Error("an unknown argument type should not be registered", err)
+	}
+
+	if err := UnregisterFunction("lower"); err != errs.ErrNoFunction {
+		t.Error("a built-in function should not be removed", err)
+	}
+
+	columns := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "qty"), column.NewColumn(types.BOOL_TYPE, 0, "flag")}
+	rows := []*Row{
+		NewRow(columns, []*tuple.Value{tuple.GetValue(int32(3), types.INT_TYPE, types.INT_SIZE), NewBool(true)}),
+		NewRow(columns, []*tuple.Value{tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE), NewBool(false)}),
+		NewRow(columns, []*tuple.Value{tuple.GetValue(int32(4), types.INT_TYPE, types.INT_SIZE), NewBool(true)}),
+	}
+
+	tests := map[string]interface{}{
+		"TWICE(qty)":               "6",
+		"twice('21')":              "42",
+		"twice(1.5)":               "4",
+		"twice(NULL)":              nil,
+		"join_digits(1, qty, 2.4)": "132",
+		"join_digits(NULL, 7)":     "7",
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, rows[0])
+
+		if err != nil {
+			t.Errorf("%s should be %v, got %v", query, want, err)
+			continue
+		}
+
+		if want == nil && !value.IsNull() || want != nil && tuple.GetValueText(value) != want {
+			t.Errorf("%s should be %v, got %v", query, want, tuple.GetValueText(value))
+		}
+	}
+
+	if value, _ := parseAndEvaluate(t, "twice(qty)", rows[0]); value.GetType() != types.LONG_INT_TYPE {
+		t.Error("the result should have the return type", value.GetType())
+	}
+
+	var argumentError *errs.ArgumentError
+
+	if _, err := parseAndEvaluate(t, "join_digits(1, flag)", rows[0]); !errors.As(err, &argumentError) || !errors.Is(err, errs.ErrTypeMismatch) {
+		t.Error("a BOOL argument should not be converted to INT", err)
+	} else if err.Error() != "argument 2 of join_digits is BOOL, the function takes INT" {
+		t.Error("get the wrong message", err)
+	}
+
+	failures := map[string]error{
+		"twice()":             errs.ErrSyntax,
+		"twice(1, 2)":         errs.ErrSyntax,
+		"twice('x')":          errs.ErrInvalidValue,
+		"my_sum(qty)":         errs.ErrAggregateCall,
+		"my_sum(qty) + 1":     errs.ErrAggregateCall,
+		"join_digits(1, 'x')": errs.ErrInvalidValue,
+	}
+
+	for query, want := range failures {
+		if _, err := parseAndEvaluate(t, query, rows[0]); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	sum := parseOnly(t, "twice(my_sum(qty)) + 1")
+	empty := parseOnly(t, "my_sum(qty)")
+
+	if !IsAggregate(sum) || IsAggregate(parseOnly(t, "twice(qty)")) {
+		t.Error("get the wrong aggregate calls")
+	}
+
+	group, err := Aggregate([]Expression{sum}, rows)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if value, err := sum.Evaluate(group); err != nil || tuple.GetValueText(value) != "15" {
+		t.Error("the aggregate should skip NULL", value, err)
+	}
+
+	if group, err := Aggregate([]Expression{empty}, nil); err != nil {
+		t.Error(err)
+	} else if value, _ := empty.Evaluate(group); !value.IsNull() {
+		t.Error("an aggregate of no rows should be NULL")
+	}
+
+	aggregateFailures := map[string]error{
+		"my_sum(qty) + qty":   errs.ErrUngroupedColumn,
+		"my_sum(my_sum(qty))": errs.ErrAggregateCall,
+		"my_sum()":            errs.ErrSyntax,
+		"my_sum(flag)":        errs.ErrTypeMismatch,
+	}
+
+	for query, want := range aggregateFailures {
+		if _, err := Aggregate([]Expression{parseOnly(t, query)}, rows); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+}
+
+func parseOnly(t *testing.T, query string) Expression {
+	expr, _ := parse(t, query)
+
+	return expr
+}
+
+func Test_QualifiedColumn(t *testing.T) {
+	columns := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "score")}
+	row := NewRow(columns, []*tuple.Value{tuple.GetValue(int32(1), types.INT_TYPE, types.INT_SIZE)})
+	row.Tables = map[string]*Row{
+		"excluded": NewRow(columns, []*tuple.Value{tuple.GetValue(int32(10), types.INT_TYPE, types.INT_SIZE)}),
+		"t":        row,
+	}
+
+	if value, err := parseAndEvaluate(t, "Excluded.score + t.score + score", row); err != nil || tuple.GetValueText(value) != "12" {
+		t.Error("get the wrong value", value, err)
+	}
+
+	for _, query := range []string{"other.score", "excluded.missing"} {
+		if _, err := parseAndEvaluate(t, query, row); err != errs.ErrColumnNotExist {
+			t.Errorf("%s should fail with %v, got %v", query, errs.ErrColumnNotExist, err)
+		}
+	}
+
+	if _, err := ParseText("excluded."); err == nil {
+		t.Error("a qualifier needs a column")
+	}
+}
+
+func Test_InExpression(t *testing.T) {
+	columns := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "a"), column.NewColumn(types.INT_TYPE, 0, "b")}
+	row := NewRow(columns, []*tuple.Value{tuple.GetValue(int32(2), types.INT_TYPE, types.INT_SIZE), NewNull()})
+
+	tests := map[string]string{
+		"a IN (1, 2, 3)":        "true",
+		"a IN (1.5, 2.0)":       "true",
+		"a NOT IN (1, 3)":       "true",
+		"a IN (1, NULL)":        "NULL",
+		"a NOT IN (1, NULL)":    "NULL",
+		"a IN (2, NULL)":        "true",
+		"b IN (1, 2)":           "NULL",
+		"a + 1 IN (a, a * 1.5)": "true",
+		"NOT a IN (4)":          "true",
+	}
+
+	for query, want := range tests {
+		value, err := parseAndEvaluate(t, query, row)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", query, err)
+			continue
+		}
+
+		if got := tuple.GetValueText(value); (value.IsNull() && want != "NULL") || (!value.IsNull() && got != want) {
+			t.Errorf("%s should be %s, got %s", query, want, got)
+		}
+	}
+
+	if expr := parseOnly(t, "a NOT IN (1, b + 1) AND b IN (2)"); expr.String() != "((a NOT IN (1, (b + 1))) AND (b IN (2)))" {
+		t.Error("get the wrong text", expr.String())
+	}
+
+	// NOT which is not followed by IN ends the expression, such as DEFAULT 0 NOT NULL
+	if _, end := parse(t, "0 NOT NULL"); end != "NOT" {
+		t.Error("NOT should end the expression", end)
+	}
+
+	for _, query := range []string{"a IN 1", "a IN (1", "a IN ()", "a NOT INTO (1)"} {
+		if _, err := ParseText(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
+
+type stubQuery struct {
+	text string
+	rows [][]*tuple.Value
+	cols int
+}
+
+func (q *stubQuery) String() string {
+	return q.text
+}
+
+type stubQueries struct {
+	outers []*Row
+}
+
+func (s *stubQueries) RunQuery(query Query, outer *Row) ([][]*tuple.Value, int, error) {
+	s.outers = append(s.outers, outer)
+	stub := query.(*stubQuery)
+
+	return stub.rows, stub.cols, nil
+}
+
+func Test_SubqueryExpressions(t *testing.T) {
+	one := tuple.GetValue(int32(1), types.INT_TYPE, types.INT_SIZE)
+	two := tuple.GetValue(int32(2), types.INT_TYPE, types.INT_SIZE)
+
+	none := &stubQuery{text: "SELECT x FROM none", cols: 1}
+	single := &stubQuery{text: "SELECT x FROM single", rows: [][]*tuple.Value{{two}}, cols: 1}
+	many := &stubQuery{text: "SELECT x FROM many", rows: [][]*tuple.Value{{one}, {two}, {NewNull()}}, cols: 1}
+	wide := &stubQuery{text: "SELECT x, y FROM wide", rows: [][]*tuple.Value{{one, two}}, cols: 2}
+
+	queries := &stubQueries{}
+	row := NewRow([]*column.Column{column.NewColumn(types.INT_TYPE, 0, "a")}, []*tuple.Value{two})
+	row.Queries = queries
+
+	tests := []struct {
+		expr Expression
+		want string
+	}{
+		{&Subquery{Query: single}, "2"},
+		{&Subquery{Query: none}, "NULL"},
+		{&Exists{Query: none}, "false"},
+		{&Exists{Query: wide}, "true"},
+		{&In{Operand: &ColumnRef{Name: "a"}, Query: many}, "true"},
+		{&In{Operand: &ColumnRef{Name: "a"}, Query: many, Not: true}, "false"},
+		{&In{Operand: &Literal{Value: tuple.GetValue(int32(3), types.INT_TYPE, types.INT_SIZE)}, Query: many}, "NULL"},
+		{&In{Operand: &Literal{Value: NewNull()}, Query: none, Not: true}, "true"},
+	}
+
+	for _, test := range tests {
+		value, err := test.expr.Evaluate(row)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", test.expr, err)
+			continue
+		}
+
+		if got := tuple.GetValueText(value); (value.IsNull() && test.want != "NULL") || (!value.IsNull() && got != test.want) {
+			t.Errorf("%s should be %s, got %s", test.expr, test.want, got)
+		}
+	}
+
+	if queries.outers[0] != row {
+		t.Error("a subquery should get the row as its outer row")
+	}
+
+	failures := map[Expression]error{
+		&Subquery{Query: many}:                                    errs.ErrSubqueryRows,
+		&Subquery{Query: wide}:                                    errs.ErrSubqueryColumns,
+		&In{Operand: &ColumnRef{Name: "a"}, Query: wide}:          errs.ErrSubqueryColumns,
+		&Exists{Query: none}:                                      errs.ErrSubquery,
+		&In{Operand: &Literal{Value: NewBool(true)}, Query: many}: errs.ErrTypeMismatch,
+	}
+
+	for expr, want := range failures {
+		target := row
+
+		if want == errs.ErrSubquery {
+			target = NewRow(row.Columns, row.Values)
+		}
+
+		if _, err := expr.Evaluate(target); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", expr, want, err)
+		}
+	}
+
+	// an outer row resolves the columns the row of the subquery does not have
+	inner := NewRow([]*column.Column{column.NewColumn(types.INT_TYPE, 0, "b")}, []*tuple.Value{one})
+	inner.Outer = row
+	inner.Tables = map[string]*Row{"s": inner}
+	row.Tables = map[string]*Row{"t": row}
+
+	if value, err := parseAndEvaluate(t, "a + b + t.a + s.b", inner); err != nil || tuple.GetValueText(value) != "6" {
+		t.Error("get the wrong value", value, err)
+	}
+
+	SetQueryParser(func(scan *scanner.Scanner) (Query, string, error) {
+		text := "SELECT"
+
+		for scan.Scan() != scanner.EOF && scan.TokenText() != ")" {
+			text += " " + scan.TokenText()
+		}
+
+		return &stubQuery{text: text}, scan.TokenText(), nil
+	})
+
+	defer SetQueryParser(nil)
+
+	expr := parseOnly(t, "EXISTS (SELECT x FROM s) AND a IN (SELECT x FROM s) OR (SELECT x FROM s) > 1")
+
+	if expr.String() != "((EXISTS (SELECT x FROM s) AND (a IN (SELECT x FROM s))) OR ((SELECT x FROM s) > 1))" {
+		t.Error("get the wrong text", expr.String())
+	}
+
+	if !HasSubquery(expr) || HasSubquery(parseOnly(t, "a IN (1, 2)")) {
+		t.Error("HasSubquery should only find a query")
+	}
+
+	for _, query := range []string{"EXISTS SELECT x", "EXISTS (x)", "(SELECT x FROM s"} {
+		if _, err := ParseText(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
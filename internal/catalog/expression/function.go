@@ -0,0 +1,372 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"strings"
+)
+
+/**
+ *  Function registry
+ *  +--------------+--------------------------------------------------------------------+
+ *  | Group        | Functions                                                          |
+ *  +--------------+--------------------------------------------------------------------+
+ *  | text         | lower upper length substring trim ltrim rtrim concat replace       |
+ *  | math         | abs round floor ceil mod power                                     |
+ *  | conditional  | coalesce nullif greatest least, CASE WHEN is an expression itself  |
+ *  | date/time    | now date_trunc date_part, extract(field FROM value)                |
+ *  | JSON         | json_extract_path(_text) json_typeof json_array_length and jsonb_  |
+ *  | other        | gen_random_uuid, nextval and currval need the sequences of the row |
+ *  +--------------+--------------------------------------------------------------------+
+ *
+ *  A strict function is NULL when an argument is NULL without being called, coalesce, nullif,
+ *  greatest, least and concat look at their NULL arguments. A call with a number of arguments
+ *  the function does not take is a syntax error. The user-defined functions of udf.go join
+ *  the registry with a signature.
+ */
+
+// VARIADIC is the MaxArgs of a function which takes any number of arguments
+const VARIADIC = -1
+
+const (
+	KEYWORD_CASE = "CASE"
+	KEYWORD_WHEN = "WHEN"
+	KEYWORD_THEN = "THEN"
+	KEYWORD_ELSE = "ELSE"
+	KEYWORD_END  = "END"
+)
+
+const (
+	FUNCTION_COALESCE = "coalesce"
+	FUNCTION_NULLIF   = "nullif"
+	FUNCTION_GREATEST = "greatest"
+	FUNCTION_LEAST    = "least"
+)
+
+// ScalarFunction is a function of the registry, Call gets the values of the arguments,
+// only a user-defined function has a Signature
+type ScalarFunction struct {
+	MinArgs   int
+	MaxArgs   int
+	Strict    bool
+	Signature *Signature
+	Call      func(args []*tuple.Value) (*tuple.Value, error)
+}
+
+var functions = map[string]*ScalarFunction{
+	FUNCTION_NOW:        {MinArgs: 0, MaxArgs: 0, Call: withName(FUNCTION_NOW, evaluateTemporal)},
+	FUNCTION_DATE_TRUNC: {MinArgs: 2, MaxArgs: 2, Call: withName(FUNCTION_DATE_TRUNC, evaluateTemporal)},
+	FUNCTION_DATE_PART:  {MinArgs: 2, MaxArgs: 2, Call: withName(FUNCTION_DATE_PART, evaluateTemporal)},
+
+	FUNCTION_JSON_EXTRACT_PATH:       {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_JSON_EXTRACT_PATH, evaluateJSON)},
+	FUNCTION_JSON_EXTRACT_PATH_TEXT:  {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_JSON_EXTRACT_PATH_TEXT, evaluateJSON)},
+	FUNCTION_JSONB_EXTRACT_PATH:      {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_JSONB_EXTRACT_PATH, evaluateJSON)},
+	FUNCTION_JSONB_EXTRACT_PATH_TEXT: {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_JSONB_EXTRACT_PATH_TEXT, evaluateJSON)},
+	FUNCTION_JSON_TYPEOF:             {MinArgs: 1, MaxArgs: 1, Call: withName(FUNCTION_JSON_TYPEOF, evaluateJSON)},
+	FUNCTION_JSONB_TYPEOF:            {MinArgs: 1, MaxArgs: 1, Call: withName(FUNCTION_JSONB_TYPEOF, evaluateJSON)},
+	FUNCTION_JSON_ARRAY_LENGTH:       {MinArgs: 1, MaxArgs: 1, Call: withName(FUNCTION_JSON_ARRAY_LENGTH, evaluateJSON)},
+	FUNCTION_JSONB_ARRAY_LENGTH:      {MinArgs: 1, MaxArgs: 1, Call: withName(FUNCTION_JSONB_ARRAY_LENGTH, evaluateJSON)},
+
+	FUNCTION_GEN_RANDOM_UUID: {MinArgs: 0, MaxArgs: 0, Call: func([]*tuple.Value) (*tuple.Value, error) { return newRandomUUID() }},
+
+	FUNCTION_LOWER:     {MinArgs: 1, MaxArgs: 1, Strict: true, Call: lower},
+	FUNCTION_UPPER:     {MinArgs: 1, MaxArgs: 1, Strict: true, Call: upper},
+	FUNCTION_LENGTH:    {MinArgs: 1, MaxArgs: 1, Strict: true, Call: length},
+	FUNCTION_SUBSTRING: {MinArgs: 2, MaxArgs: 3, Strict: true, Call: substring},
+	FUNCTION_TRIM:      {MinArgs: 1, MaxArgs: 2, Strict: true, Call: withName(FUNCTION_TRIM, trim)},
+	FUNCTION_LTRIM:     {MinArgs: 1, MaxArgs: 2, Strict: true, Call: withName(FUNCTION_LTRIM, trim)},
+	FUNCTION_RTRIM:     {MinArgs: 1, MaxArgs: 2, Strict: true, Call: withName(FUNCTION_RTRIM, trim)},
+	FUNCTION_CONCAT:    {MinArgs: 1, MaxArgs: VARIADIC, Call: concat},
+	FUNCTION_REPLACE:   {MinArgs: 3, MaxArgs: 3, Strict: true, Call: replace},
+
+	FUNCTION_ABS:     {MinArgs: 1, MaxArgs: 1, Strict: true, Call: abs},
+	FUNCTION_ROUND:   {MinArgs: 1, MaxArgs: 2, Strict: true, Call: round},
+	FUNCTION_FLOOR:   {MinArgs: 1, MaxArgs: 1, Strict: true, Call: withName(FUNCTION_FLOOR, floorCeil)},
+	FUNCTION_CEIL:    {MinArgs: 1, MaxArgs: 1, Strict: true, Call: withName(FUNCTION_CEIL, floorCeil)},
+	FUNCTION_CEILING: {MinArgs: 1, MaxArgs: 1, Strict: true, Call: withName(FUNCTION_CEIL, floorCeil)},
+	FUNCTION_MOD:     {MinArgs: 2, MaxArgs: 2, Strict: true, Call: mod},
+	FUNCTION_POWER:   {MinArgs: 2, MaxArgs: 2, Strict: true, Call: power},
+	FUNCTION_POW:     {MinArgs: 2, MaxArgs: 2, Strict: true, Call: power},
+
+	FUNCTION_COALESCE: {MinArgs: 1, MaxArgs: VARIADIC, Call: coalesce},
+	FUNCTION_NULLIF:   {MinArgs: 2, MaxArgs: 2, Call: nullif},
+	FUNCTION_GREATEST: {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_GREATEST, extreme)},
+	FUNCTION_LEAST:    {MinArgs: 1, MaxArgs: VARIADIC, Call: withName(FUNCTION_LEAST, extreme)},
+}
+
+// withName hands the name of the call to an evaluator which serves several functions
+func withName(name string, evaluate func(string, []*tuple.Value) (*tuple.Value, error)) func([]*tuple.Value) (*tuple.Value, error) {
+	return func(args []*tuple.Value) (*tuple.Value, error) {
+		return evaluate(name, args)
+	}
+}
+
+func (f *Function) Evaluate(row *Row) (*tuple.Value, error) {
+	if f.Name == FUNCTION_NEXTVAL || f.Name == FUNCTION_CURRVAL {
+		return f.evaluateSequence(row)
+	}
+
+	if _, exist := getAggregate(f.Name); exist {
+		return row.getAggregate(f)
+	}
+
+	registry.RLock()
+	function, exist := functions[f.Name]
+	registry.RUnlock()
+
+	if !exist {
+		return nil, errors.ErrNoFunction
+	}
+
+	if len(f.Args) < function.MinArgs || (function.MaxArgs != VARIADIC && len(f.Args) > function.MaxArgs) {
+		return nil, errors.ErrSyntax
+	}
+
+	args, err := f.evaluateArgs(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if function.Strict && hasNull(args) {
+		return NewNull(), nil
+	}
+
+	if function.Signature == nil {
+		return function.Call(args)
+	}
+
+	if args, err = function.Signature.convertArgs(f.Name, args); err != nil {
+		return nil, err
+	}
+
+	result, err := function.Call(args)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return function.Signature.convertResult(result)
+}
+
+func (f *Function) evaluateArgs(row *Row) ([]*tuple.Value, error) {
+	args := make([]*tuple.Value, len(f.Args))
+
+	for i, arg := range f.Args {
+		value, err := arg.Evaluate(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = value
+	}
+
+	return args, nil
+}
+
+func coalesce(args []*tuple.Value) (*tuple.Value, error) {
+	for _, arg := range args {
+		if !arg.IsNull() {
+			return arg, nil
+		}
+	}
+
+	return NewNull(), nil
+}
+
+// nullif is NULL when both values are equal, otherwise the first value
+func nullif(args []*tuple.Value) (*tuple.Value, error) {
+	if args[0].IsNull() || args[1].IsNull() {
+		return args[0], nil
+	}
+
+	result, err := Compare(args[0], args[1])
+
+	if err != nil {
+		return nil, err
+	}
+
+	if result == 0 {
+		return NewNull(), nil
+	}
+
+	return args[0], nil
+}
+
+// extreme is greatest or least, the NULL values are left out like in PostgreSQL
+func extreme(name string, args []*tuple.Value) (*tuple.Value, error) {
+	var found *tuple.Value
+
+	for _, arg := range args {
+		if arg.IsNull() {
+			continue
+		}
+
+		if found == nil {
+			found = arg
+			continue
+		}
+
+		result, err := Compare(arg, found)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if (name == FUNCTION_GREATEST && result > 0) || (name == FUNCTION_LEAST && result < 0) {
+			found = arg
+		}
+	}
+
+	if found == nil {
+		return NewNull(), nil
+	}
+
+	return found, nil
+}
+
+// Case is CASE WHEN condition THEN result ... [ELSE result] END, with an operand
+// CASE operand WHEN value THEN result ... compares the operand to every value
+type Case struct {
+	Operand Expression
+	Whens   []*When
+	Else    Expression
+}
+
+type When struct {
+	Condition Expression
+	Result    Expression
+}
+
+// Evaluate returns the result of the first WHEN which holds, NULL without ELSE
+func (c *Case) Evaluate(row *Row) (*tuple.Value, error) {
+	var operand *tuple.Value
+
+	if c.Operand != nil {
+		value, err := c.Operand.Evaluate(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		operand = value
+	}
+
+	for _, when := range c.Whens {
+		matched, err := c.matches(operand, when, row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if matched {
+			return when.Result.Evaluate(row)
+		}
+	}
+
+	if c.Else == nil {
+		return NewNull(), nil
+	}
+
+	return c.Else.Evaluate(row)
+}
+
+func (c *Case) matches(operand *tuple.Value, when *When, row *Row) (bool, error) {
+	if c.Operand == nil {
+		return EvaluateCondition(when.Condition, row)
+	}
+
+	value, err := when.Condition.Evaluate(row)
+
+	if err != nil {
+		return false, err
+	}
+
+	if operand.IsNull() || value.IsNull() {
+		return false, nil
+	}
+
+	result, err := Compare(operand, value)
+
+	return result == 0, err
+}
+
+func (c *Case) String() string {
+	parts := []string{KEYWORD_CASE}
+
+	if c.Operand != nil {
+		parts = append(parts, c.Operand.String())
+	}
+
+	for _, when := range c.Whens {
+		parts = append(parts, KEYWORD_WHEN, when.Condition.String(), KEYWORD_THEN, when.Result.String())
+	}
+
+	if c.Else != nil {
+		parts = append(parts, KEYWORD_ELSE, c.Else.String())
+	}
+
+	return strings.Join(append(parts, KEYWORD_END), " ")
+}
+
+// parseCase reads the rest of CASE ... END, the current token is CASE
+func (p *parser) parseCase() (Expression, error) {
+	c := &Case{}
+	p.next()
+
+	if p.keyword() != KEYWORD_WHEN {
+		operand, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Operand = operand
+	}
+
+	for p.keyword() == KEYWORD_WHEN {
+		p.next()
+		condition, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.keyword() != KEYWORD_THEN {
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+		result, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Whens = append(c.Whens, &When{Condition: condition, Result: result})
+	}
+
+	if len(c.Whens) == 0 {
+		return nil, errors.ErrSyntax
+	}
+
+	if p.keyword() == KEYWORD_ELSE {
+		p.next()
+		result, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		c.Else = result
+	}
+
+	if p.keyword() != KEYWORD_END {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	return c, nil
+}
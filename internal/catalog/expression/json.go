@@ -0,0 +1,233 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strconv"
+	"strings"
+)
+
+/**
+ *  JSON operators
+ *  +----------+------------------+--------+---------------------------------------------+
+ *  | Operator | Right operand    | Result | Example                                     |
+ *  +----------+------------------+--------+---------------------------------------------+
+ *  | ->       | key or index     | JSON   | doc -> 'tags' -> 0                          |
+ *  | ->>      | key or index     | text   | doc ->> 'name'                              |
+ *  | #>       | path             | JSON   | doc #> '{address,city}'                     |
+ *  | #>>      | path             | text   | doc #>> '{tags,-1}'                         |
+ *  +----------+------------------+--------+---------------------------------------------+
+ *
+ *  A missing key, an index out of range or a path through a scalar is NULL like in PostgreSQL,
+ *  a negative index counts from the end of the array. The text of a JSON string has no quotes
+ *  and the text of a JSON null is NULL.
+ */
+
+const (
+	OPERATOR_ARROW      = "->"
+	OPERATOR_ARROW_TEXT = "->>"
+	OPERATOR_PATH       = "#>"
+	OPERATOR_PATH_TEXT  = "#>>"
+)
+
+const (
+	FUNCTION_JSON_EXTRACT_PATH       = "json_extract_path"
+	FUNCTION_JSON_EXTRACT_PATH_TEXT  = "json_extract_path_text"
+	FUNCTION_JSONB_EXTRACT_PATH      = "jsonb_extract_path"
+	FUNCTION_JSONB_EXTRACT_PATH_TEXT = "jsonb_extract_path_text"
+	FUNCTION_JSON_TYPEOF             = "json_typeof"
+	FUNCTION_JSONB_TYPEOF            = "jsonb_typeof"
+	FUNCTION_JSON_ARRAY_LENGTH       = "json_array_length"
+	FUNCTION_JSONB_ARRAY_LENGTH      = "jsonb_array_length"
+)
+
+func NewJSON(doc []byte) *tuple.Value {
+	return tuple.GetValue(doc, types.JSON_TYPE, int32(len(doc)))
+}
+
+// IsTextExtraction reports whether the expression always evaluates to text taken out of a document
+func IsTextExtraction(expr Expression) bool {
+	switch e := expr.(type) {
+	case *Binary:
+		return e.Operator == OPERATOR_ARROW_TEXT || e.Operator == OPERATOR_PATH_TEXT
+	case *Function:
+		return e.Name == FUNCTION_JSON_EXTRACT_PATH_TEXT || e.Name == FUNCTION_JSONB_EXTRACT_PATH_TEXT
+	}
+
+	return false
+}
+
+// toJSON reads text as a document so that '{"a": 1}' -> 'a' works without a JSON literal
+func toJSON(v *tuple.Value) ([]byte, error) {
+	switch v.GetType() {
+	case types.JSON_TYPE:
+		return v.JSON, nil
+	case types.VAR_CHAR_TYPE:
+		doc, ok := tuple.ParseJSON(string(v.VAR_CHAR))
+
+		if !ok {
+			return nil, errors.ErrInvalidValue
+		}
+
+		return doc, nil
+	}
+
+	return nil, errors.ErrTypeMismatch
+}
+
+func jsonOperator(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, error) {
+	if left.IsNull() || right.IsNull() {
+		return NewNull(), nil
+	}
+
+	doc, err := toJSON(left)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var path []string
+
+	switch {
+	case operator == OPERATOR_PATH || operator == OPERATOR_PATH_TEXT:
+		if right.GetType() != types.VAR_CHAR_TYPE {
+			return nil, errors.ErrTypeMismatch
+		}
+
+		if path, err = parsePath(string(right.VAR_CHAR)); err != nil {
+			return nil, err
+		}
+	case right.GetType() == types.VAR_CHAR_TYPE:
+		path = []string{string(right.VAR_CHAR)}
+	case isInteger(right):
+		// an index only looks into an array, the text of the number is not a key
+		element, found := tuple.GetJSONElement(doc, int(toInt(right)))
+
+		return extractResult(element, found, operator == OPERATOR_ARROW_TEXT)
+	default:
+		return nil, errors.ErrTypeMismatch
+	}
+
+	element, found := extractPath(doc, path)
+
+	return extractResult(element, found, operator == OPERATOR_ARROW_TEXT || operator == OPERATOR_PATH_TEXT)
+}
+
+// extractPath follows the keys of the objects and the indexes of the arrays
+func extractPath(doc []byte, path []string) ([]byte, bool) {
+	for _, step := range path {
+		found := false
+
+		if tuple.GetJSONType(doc) == tuple.JSON_TYPE_ARRAY {
+			if index, err := strconv.Atoi(step); err == nil {
+				doc, found = tuple.GetJSONElement(doc, index)
+			}
+		} else {
+			doc, found = tuple.GetJSONField(doc, step)
+		}
+
+		if !found {
+			return nil, false
+		}
+	}
+
+	return doc, true
+}
+
+func extractResult(doc []byte, found bool, asText bool) (*tuple.Value, error) {
+	if !found {
+		return NewNull(), nil
+	}
+
+	if !asText {
+		return NewJSON(doc), nil
+	}
+
+	text, ok := tuple.GetJSONScalarText(doc)
+
+	if !ok {
+		return NewNull(), nil
+	}
+
+	return NewText(text), nil
+}
+
+// parsePath reads a path written as a text array such as '{address,city}' or '{"a b",0}'
+func parsePath(text string) ([]string, error) {
+	text = strings.TrimSpace(text)
+
+	if !strings.HasPrefix(text, "{") || !strings.HasSuffix(text, "}") {
+		return nil, errors.ErrInvalidValue
+	}
+
+	inner := strings.TrimSpace(text[1 : len(text)-1])
+	path := make([]string, 0)
+
+	if inner == "" {
+		return path, nil
+	}
+
+	for _, step := range strings.Split(inner, ",") {
+		step = strings.TrimSpace(step)
+
+		if len(step) >= 2 && step[0] == '"' && step[len(step)-1] == '"' {
+			step = step[1 : len(step)-1]
+		}
+
+		path = append(path, step)
+	}
+
+	return path, nil
+}
+
+func evaluateJSON(name string, args []*tuple.Value) (*tuple.Value, error) {
+	if len(args) == 0 {
+		return nil, errors.ErrSyntax
+	}
+
+	for _, arg := range args {
+		if arg.IsNull() {
+			return NewNull(), nil
+		}
+	}
+
+	doc, err := toJSON(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch name {
+	case FUNCTION_JSON_TYPEOF, FUNCTION_JSONB_TYPEOF, FUNCTION_JSON_ARRAY_LENGTH, FUNCTION_JSONB_ARRAY_LENGTH:
+		if len(args) != 1 {
+			return nil, errors.ErrSyntax
+		}
+
+		if name == FUNCTION_JSON_TYPEOF || name == FUNCTION_JSONB_TYPEOF {
+			return NewText(tuple.GetJSONType(doc)), nil
+		}
+
+		length, ok := tuple.GetJSONArrayLength(doc)
+
+		if !ok {
+			return nil, errors.ErrTypeMismatch
+		}
+
+		return tuple.GetValue(int32(length), types.INT_TYPE, types.INT_SIZE), nil
+	}
+
+	path := make([]string, 0, len(args)-1)
+
+	for _, arg := range args[1:] {
+		if arg.GetType() != types.VAR_CHAR_TYPE {
+			return nil, errors.ErrTypeMismatch
+		}
+
+		path = append(path, string(arg.VAR_CHAR))
+	}
+
+	element, found := extractPath(doc, path)
+
+	return extractResult(element, found, name == FUNCTION_JSON_EXTRACT_PATH_TEXT || name == FUNCTION_JSONB_EXTRACT_PATH_TEXT)
+}
@@ -0,0 +1,178 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"math/big"
+)
+
+/**
+ *  Math functions keep the type of their argument
+ *  +---------------------+---------------------------------------------+
+ *  | abs(x)              | the smallest integer of a type overflows    |
+ *  | round(x [, digits]) | DECIMAL half away from zero, FLOAT to even  |
+ *  | floor(x), ceil(x)   | a DECIMAL keeps no digits after the point   |
+ *  | mod(x, y)           | like x % y                                  |
+ *  | power(x, y)         | always FLOAT                                |
+ *  +---------------------+---------------------------------------------+
+ *
+ *  round with digits makes an integer a DECIMAL, a negative number of digits rounds
+ *  to tens, hundreds and so on.
+ */
+
+const (
+	FUNCTION_ABS     = "abs"
+	FUNCTION_ROUND   = "round"
+	FUNCTION_FLOOR   = "floor"
+	FUNCTION_CEIL    = "ceil"
+	FUNCTION_CEILING = "ceiling"
+	FUNCTION_MOD     = "mod"
+	FUNCTION_POWER   = "power"
+	FUNCTION_POW     = "pow"
+)
+
+func checkNumeric(args []*tuple.Value) error {
+	for _, arg := range args {
+		if !isNumeric(arg) {
+			return errors.ErrTypeMismatch
+		}
+	}
+
+	return nil
+}
+
+func abs(args []*tuple.Value) (*tuple.Value, error) {
+	v := args[0]
+
+	if err := checkNumeric(args); err != nil {
+		return nil, err
+	}
+
+	switch v.GetType() {
+	case types.INT_TYPE, types.SMALL_INT_TYPE:
+		if v.INT == math.MinInt32 || (v.GetType() == types.SMALL_INT_TYPE && v.INT == math.MinInt16) {
+			return nil, errors.ErrNumericOverflow
+		}
+
+		if v.INT < 0 {
+			return tuple.GetValue(-v.INT, v.GetType(), v.GetSize()), nil
+		}
+	case types.LONG_INT_TYPE:
+		if v.LONG_INT == math.MinInt64 {
+			return nil, errors.ErrNumericOverflow
+		}
+
+		if v.LONG_INT < 0 {
+			return tuple.GetValue(-v.LONG_INT, v.GetType(), v.GetSize()), nil
+		}
+	case types.DECIMAL_TYPE:
+		d := v.DECIMAL.Rescale(v.DECIMAL.Scale)
+
+		return NewDecimal(tuple.Decimal{Unscaled: d.Unscaled.Abs(d.Unscaled), Scale: d.Scale}), nil
+	default:
+		return tuple.GetValue(math.Abs(v.FLOAT), v.GetType(), v.GetSize()), nil
+	}
+
+	return v, nil
+}
+
+func round(args []*tuple.Value) (*tuple.Value, error) {
+	if err := checkNumeric(args[:1]); err != nil {
+		return nil, err
+	}
+
+	v := args[0]
+
+	if len(args) == 1 {
+		if isFloat(v) {
+			return tuple.GetValue(math.RoundToEven(v.FLOAT), v.GetType(), v.GetSize()), nil
+		}
+
+		if v.GetType() != types.DECIMAL_TYPE {
+			return v, nil
+		}
+
+		return NewDecimal(v.DECIMAL.Rescale(0)), nil
+	}
+
+	if !isInteger(args[1]) {
+		return nil, errors.ErrTypeMismatch
+	}
+
+	digits := toInt(args[1])
+
+	if digits > types.MAX_DECIMAL_PRECISION {
+		digits = types.MAX_DECIMAL_PRECISION
+	} else if digits < -types.MAX_DECIMAL_PRECISION {
+		digits = -types.MAX_DECIMAL_PRECISION
+	}
+
+	if isFloat(v) {
+		scale := math.Pow(10, float64(digits))
+
+		return tuple.GetValue(math.RoundToEven(v.FLOAT*scale)/scale, v.GetType(), v.GetSize()), nil
+	}
+
+	// rounded to a negative scale the digits are put back in front of the point
+	rounded := toDecimal(v).Rescale(int32(digits))
+
+	if digits < 0 {
+		rounded = rounded.Rescale(0)
+	}
+
+	return NewDecimal(rounded), nil
+}
+
+func floorCeil(name string, args []*tuple.Value) (*tuple.Value, error) {
+	if err := checkNumeric(args); err != nil {
+		return nil, err
+	}
+
+	v := args[0]
+
+	switch {
+	case isFloat(v):
+		if name == FUNCTION_CEIL {
+			return tuple.GetValue(math.Ceil(v.FLOAT), v.GetType(), v.GetSize()), nil
+		}
+
+		return tuple.GetValue(math.Floor(v.FLOAT), v.GetType(), v.GetSize()), nil
+	case v.GetType() != types.DECIMAL_TYPE || v.DECIMAL.Scale <= 0:
+		return v, nil
+	}
+
+	d := v.DECIMAL.Rescale(v.DECIMAL.Scale)
+	quotient, remainder := new(big.Int).QuoRem(d.Unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.Scale)), nil), new(big.Int))
+
+	if name == FUNCTION_CEIL && remainder.Sign() > 0 {
+		quotient.Add(quotient, big.NewInt(1))
+	} else if name == FUNCTION_FLOOR && remainder.Sign() < 0 {
+		quotient.Sub(quotient, big.NewInt(1))
+	}
+
+	return NewDecimal(tuple.Decimal{Unscaled: quotient, Scale: 0}), nil
+}
+
+func mod(args []*tuple.Value) (*tuple.Value, error) {
+	return arithmetic(OPERATOR_MODULO, args[0], args[1])
+}
+
+func power(args []*tuple.Value) (*tuple.Value, error) {
+	if err := checkNumeric(args); err != nil {
+		return nil, err
+	}
+
+	result := math.Pow(toFloat(args[0]), toFloat(args[1]))
+
+	if math.IsNaN(result) {
+		return nil, errors.ErrInvalidValue
+	}
+
+	if math.IsInf(result, 0) {
+		return nil, errors.ErrNumericOverflow
+	}
+
+	return tuple.GetValue(result, types.FLOAT_TYPE, types.FLOAT_SIZE), nil
+}
@@ -0,0 +1,547 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"strconv"
+	"strings"
+	"text/scanner"
+)
+
+/**
+ *  The operators from the loosest to the tightest binding
+ *
+ *  OR
+ *  AND
+ *  NOT
+ *  = <> != < <= > >= IS [NOT] NULL [NOT] IN
+ *  -> ->> #> #>>
+ *  + -
+ *  * / %
+ *  - (unary)
+ *  ::
+ */
+
+const (
+	precedenceOr = iota + 1
+	precedenceAnd
+	precedenceNot
+	precedenceCompare
+	precedenceJSON
+	precedenceAdd
+	precedenceMultiply
+	precedenceUnary
+	precedenceCast
+)
+
+type parser struct {
+	scan  *scanner.Scanner
+	token rune
+	text  string
+}
+
+// Parse reads an expression starting at the next token and returns the token which ends it,
+// the ending token is empty at the end of the query
+func Parse(scan *scanner.Scanner) (Expression, string, error) {
+	p := &parser{scan: scan}
+	p.next()
+
+	expr, err := p.parseExpression(precedenceOr)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return expr, p.text, nil
+}
+
+// ParseText reads an expression which makes up the whole text, such as a stored definition
+func ParseText(text string) (Expression, error) {
+	scan := scanner.Scanner{}
+	scan.Init(strings.NewReader(text))
+	scan.Error = func(*scanner.Scanner, string) {}
+
+	expr, end, err := Parse(&scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end != "" {
+		return nil, errors.ErrSyntax
+	}
+
+	return expr, nil
+}
+
+// next scans a token and joins the operators written with two characters
+func (p *parser) next() {
+	// the scanner would read 'text' as a Go char literal with backslash escapes
+	mode := p.scan.Mode
+	p.scan.Mode &^= scanner.ScanChars
+	p.token = p.scan.Scan()
+	p.scan.Mode = mode
+	p.text = p.scan.TokenText()
+
+	if p.token == scanner.EOF {
+		p.text = ""
+		return
+	}
+
+	if p.token == '\'' {
+		p.token = scanner.Char
+		p.text = p.scanQuoted()
+		return
+	}
+
+	peek := p.scan.Peek()
+
+	if (p.text == "<" && (peek == '=' || peek == '>')) || ((p.text == ">" || p.text == "!") && peek == '=') {
+		p.text += string(p.scan.Next())
+	}
+
+	if p.text == ":" && peek == ':' {
+		p.text += string(p.scan.Next())
+	}
+
+	// -> ->> #> #>>, a minus is never followed by > otherwise
+	if (p.text == "-" || p.text == "#") && peek == '>' {
+		p.text += string(p.scan.Next())
+
+		if p.scan.Peek() == '>' {
+			p.text += string(p.scan.Next())
+		}
+	}
+}
+
+// scanQuoted reads a 'text' literal after its opening quote, a quote inside it is written
+// twice and a backslash is only a backslash. It returns nothing when the literal is not closed.
+func (p *parser) scanQuoted() string {
+	var text strings.Builder
+	text.WriteRune('\'')
+
+	for {
+		ch := p.scan.Next()
+
+		if ch == scanner.EOF {
+			return ""
+		}
+
+		text.WriteRune(ch)
+
+		if ch == '\'' {
+			if p.scan.Peek() != '\'' {
+				return text.String()
+			}
+
+			text.WriteRune(p.scan.Next())
+		}
+	}
+}
+
+func (p *parser) keyword() string {
+	if p.token != scanner.Ident {
+		return ""
+	}
+
+	return strings.ToUpper(p.text)
+}
+
+// binaryPrecedence returns 0 when the current token does not continue the expression
+func (p *parser) binaryPrecedence() int {
+	switch p.keyword() {
+	case OPERATOR_OR:
+		return precedenceOr
+	case OPERATOR_AND:
+		return precedenceAnd
+	case OPERATOR_IS, OPERATOR_IN:
+		return precedenceCompare
+	case OPERATOR_NOT:
+		if p.followedByIn() {
+			return precedenceCompare
+		}
+
+		return 0
+	}
+
+	switch p.text {
+	case OPERATOR_EQUAL, OPERATOR_NOT_EQUAL, OPERATOR_NOT_EQUAL_ALT,
+		OPERATOR_LESS, OPERATOR_LESS_EQUAL, OPERATOR_GREATER, OPERATOR_GREATER_EQUAL:
+		return precedenceCompare
+	case OPERATOR_ARROW, OPERATOR_ARROW_TEXT, OPERATOR_PATH, OPERATOR_PATH_TEXT:
+		return precedenceJSON
+	case OPERATOR_PLUS, OPERATOR_MINUS:
+		return precedenceAdd
+	case OPERATOR_MULTIPLY, OPERATOR_DIVIDE, OPERATOR_MODULO:
+		return precedenceMultiply
+	case OPERATOR_CAST:
+		return precedenceCast
+	}
+
+	return 0
+}
+
+func (p *parser) parseExpression(precedence int) (Expression, error) {
+	left, err := p.parsePrefix()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		current := p.binaryPrecedence()
+
+		if current == 0 || current < precedence {
+			return left, nil
+		}
+
+		if p.keyword() == OPERATOR_IS {
+			p.next()
+			not := false
+
+			if p.keyword() == OPERATOR_NOT {
+				not = true
+				p.next()
+			}
+
+			if p.keyword() != LITERAL_NULL {
+				return nil, errors.ErrSyntax
+			}
+
+			p.next()
+			left = &IsNull{Operand: left, Not: not}
+			continue
+		}
+
+		if p.keyword() == OPERATOR_IN {
+			if left, err = p.parseIn(left, false); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if p.keyword() == OPERATOR_NOT {
+			p.next()
+
+			if p.keyword() != OPERATOR_IN {
+				return nil, errors.ErrSyntax
+			}
+
+			if left, err = p.parseIn(left, true); err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		if p.text == OPERATOR_CAST {
+			p.next()
+			valueType, err := p.parseType()
+
+			if err != nil {
+				return nil, err
+			}
+
+			left = &Cast{Operand: left, Type: valueType}
+			continue
+		}
+
+		operator := p.text
+
+		if p.token == scanner.Ident {
+			operator = p.keyword()
+		}
+
+		p.next()
+		right, err := p.parseExpression(current + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = &Binary{Operator: operator, Left: left, Right: right}
+	}
+}
+
+func (p *parser) parsePrefix() (Expression, error) {
+	switch p.token {
+	case scanner.Int:
+		value, err := strconv.ParseInt(p.text, 10, 64)
+
+		if err != nil {
+			if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+				return nil, errors.ErrNumericOverflow
+			}
+
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+
+		if value > math.MaxInt32 {
+			return &Literal{Value: tuple.GetValue(value, types.LONG_INT_TYPE, types.LONG_INT_SIZE)}, nil
+		}
+
+		return &Literal{Value: tuple.GetValue(int32(value), types.INT_TYPE, types.INT_SIZE)}, nil
+	case scanner.Float:
+		// like PostgreSQL a number with a point is exact, only an exponent out of range makes it FLOAT
+		if decimal, ok := tuple.ParseDecimal(p.text); ok {
+			p.next()
+
+			return &Literal{Value: NewDecimal(decimal)}, nil
+		}
+
+		value, err := strconv.ParseFloat(p.text, 64)
+
+		if err != nil {
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+
+		return &Literal{Value: tuple.GetValue(value, types.FLOAT_TYPE, types.FLOAT_SIZE)}, nil
+	case scanner.String, scanner.Char:
+		text, err := unquote(p.text)
+
+		if err != nil {
+			return nil, err
+		}
+
+		p.next()
+
+		return &Literal{Value: NewText(text)}, nil
+	case scanner.Ident:
+		switch p.keyword() {
+		case LITERAL_NULL:
+			p.next()
+			return &Literal{Value: NewNull()}, nil
+		case LITERAL_TRUE, LITERAL_FALSE:
+			value := p.keyword() == LITERAL_TRUE
+			p.next()
+			return &Literal{Value: NewBool(value)}, nil
+		case OPERATOR_NOT:
+			p.next()
+			operand, err := p.parseExpression(precedenceNot)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &Unary{Operator: OPERATOR_NOT, Operand: operand}, nil
+		case CURRENT_TIMESTAMP, CURRENT_DATE, CURRENT_TIME:
+			keyword := p.keyword()
+			p.next()
+			return &CurrentTime{Keyword: keyword}, nil
+		case KEYWORD_CASE:
+			return p.parseCase()
+		case KEYWORD_EXISTS:
+			p.next()
+
+			if p.text != "(" {
+				return nil, errors.ErrSyntax
+			}
+
+			p.next()
+
+			if p.keyword() != KEYWORD_SELECT {
+				return nil, errors.ErrSyntax
+			}
+
+			query, err := p.parseQuery()
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &Exists{Query: query}, nil
+		case OPERATOR_AND, OPERATOR_OR, OPERATOR_IS, KEYWORD_WHEN, KEYWORD_THEN, KEYWORD_ELSE, KEYWORD_END:
+			return nil, errors.ErrSyntax
+		}
+
+		name := p.text
+		p.next()
+
+		if p.text == "(" && strings.ToLower(name) == FUNCTION_EXTRACT {
+			return p.parseExtract()
+		}
+
+		if p.text == "(" && strings.ToUpper(name) == KEYWORD_CAST {
+			return p.parseCast()
+		}
+
+		if p.text == "(" {
+			return p.parseFunction(name)
+		}
+
+		// a quoted literal after a type name such as DATE '2024-01-02', a column may still be named date
+		if valueType, exist := literalTypes[strings.ToUpper(name)]; exist && (p.token == scanner.String || p.token == scanner.Char) {
+			text, err := unquote(p.text)
+
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := tuple.ParseValue(text, valueType, getTypeSize(valueType))
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.next()
+
+			return &Literal{Value: value}, nil
+		}
+
+		// a qualified column such as EXCLUDED.name
+		if p.text == "." {
+			p.next()
+
+			if p.token != scanner.Ident {
+				return nil, errors.ErrSyntax
+			}
+
+			ref := &ColumnRef{Table: name, Name: p.text}
+			p.next()
+
+			return ref, nil
+		}
+
+		return &ColumnRef{Name: name}, nil
+	}
+
+	switch p.text {
+	case "(":
+		p.next()
+
+		if p.keyword() == KEYWORD_SELECT {
+			query, err := p.parseQuery()
+
+			if err != nil {
+				return nil, err
+			}
+
+			return &Subquery{Query: query}, nil
+		}
+
+		expr, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p.text != ")" {
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+
+		return expr, nil
+	case OPERATOR_MINUS, OPERATOR_PLUS:
+		operator := p.text
+		p.next()
+		operand, err := p.parseExpression(precedenceUnary)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if operator == OPERATOR_PLUS {
+			return operand, nil
+		}
+
+		return &Unary{Operator: OPERATOR_MINUS, Operand: operand}, nil
+	}
+
+	return nil, errors.ErrSyntax
+}
+
+// the keywords which may separate the arguments of substring instead of commas
+var substringKeywords = map[int]string{1: KEYWORD_FROM, 2: KEYWORD_FOR}
+
+// parseFunction reads the arguments of the call, the current token is the opening bracket
+func (p *parser) parseFunction(name string) (Expression, error) {
+	function := &Function{Name: strings.ToLower(name), Args: []Expression{}}
+	p.next()
+
+	if p.text == ")" {
+		p.next()
+		return function, nil
+	}
+
+	for {
+		arg, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		function.Args = append(function.Args, arg)
+
+		switch {
+		case p.text == ",":
+			p.next()
+		case p.text == ")":
+			p.next()
+			return function, nil
+		case function.Name == FUNCTION_SUBSTRING && p.keyword() != "" && p.keyword() == substringKeywords[len(function.Args)]:
+			// substring(text FROM start FOR count) is substring(text, start, count)
+			p.next()
+		default:
+			return nil, errors.ErrSyntax
+		}
+	}
+}
+
+// parseExtract reads EXTRACT(field FROM value) as date_part('field', value), the current token is the opening bracket
+func (p *parser) parseExtract() (Expression, error) {
+	p.next()
+
+	if p.token != scanner.Ident {
+		return nil, errors.ErrSyntax
+	}
+
+	field := strings.ToLower(p.text)
+	p.next()
+
+	if p.keyword() != KEYWORD_FROM {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+	value, err := p.parseExpression(precedenceOr)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.text != ")" {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	return &Function{Name: FUNCTION_DATE_PART, Args: []Expression{&Literal{Value: NewText(field)}, value}}, nil
+}
+
+// unquote takes 'text' with the quotes written twice and "text" with the backslash escapes
+// which the stored definitions are printed with
+func unquote(text string) (string, error) {
+	if strings.HasPrefix(text, "'") {
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return "", errors.ErrSyntax
+		}
+
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	value, err := strconv.Unquote(text)
+
+	if err != nil {
+		return "", errors.ErrSyntax
+	}
+
+	return value, nil
+}
@@ -0,0 +1,184 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strings"
+	"unicode/utf8"
+)
+
+/**
+ *  Text functions count characters, not bytes, and take VARCHAR or CHAR arguments
+ *  +------------------------------------+-----------+
+ *  | substring('database', 5)           | 'base'    |
+ *  | substring('database' FROM 1 FOR 4) | 'data'    |
+ *  | trim('xxaxx', 'x'), ltrim, rtrim   | 'a'       |
+ *  | concat('a', NULL, 1)               | 'a1'      |
+ *  | replace('a-b-c', '-', '+')         | 'a+b+c'   |
+ *  | length('héllo')                    | 5         |
+ *  +------------------------------------+-----------+
+ *
+ *  The padding of a CHAR is not part of its text, length of a BYTEA counts its bytes.
+ */
+
+const (
+	FUNCTION_LOWER     = "lower"
+	FUNCTION_UPPER     = "upper"
+	FUNCTION_LENGTH    = "length"
+	FUNCTION_SUBSTRING = "substring"
+	FUNCTION_TRIM      = "trim"
+	FUNCTION_LTRIM     = "ltrim"
+	FUNCTION_RTRIM     = "rtrim"
+	FUNCTION_CONCAT    = "concat"
+	FUNCTION_REPLACE   = "replace"
+	KEYWORD_FOR        = "FOR"
+)
+
+func toText(v *tuple.Value) (string, error) {
+	if !isText(v) {
+		return "", errors.ErrTypeMismatch
+	}
+
+	return string(v.VAR_CHAR), nil
+}
+
+func lower(args []*tuple.Value) (*tuple.Value, error) {
+	text, err := toText(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewText(strings.ToLower(text)), nil
+}
+
+func upper(args []*tuple.Value) (*tuple.Value, error) {
+	text, err := toText(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewText(strings.ToUpper(text)), nil
+}
+
+func length(args []*tuple.Value) (*tuple.Value, error) {
+	if args[0].GetType() == types.BYTEA_TYPE {
+		return tuple.GetValue(int32(len(args[0].BYTEA)), types.INT_TYPE, types.INT_SIZE), nil
+	}
+
+	text, err := toText(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tuple.GetValue(int32(utf8.RuneCountInString(text)), types.INT_TYPE, types.INT_SIZE), nil
+}
+
+// substring starts at the 1 based position, a start before 1 still counts towards the length
+func substring(args []*tuple.Value) (*tuple.Value, error) {
+	text, err := toText(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arg := range args[1:] {
+		if !isInteger(arg) {
+			return nil, errors.ErrTypeMismatch
+		}
+	}
+
+	runes := []rune(text)
+	start, end := toInt(args[1]), int64(len(runes))+1
+
+	if len(args) == 3 {
+		count := toInt(args[2])
+
+		if count < 0 {
+			return nil, errors.ErrInvalidValue
+		}
+
+		if start+count < end {
+			end = start + count
+		}
+	}
+
+	if start < 1 {
+		start = 1
+	}
+
+	if start >= end {
+		return NewText(""), nil
+	}
+
+	return NewText(string(runes[start-1 : end-1])), nil
+}
+
+func trim(name string, args []*tuple.Value) (*tuple.Value, error) {
+	text, err := toText(args[0])
+
+	if err != nil {
+		return nil, err
+	}
+
+	characters := " "
+
+	if len(args) == 2 {
+		if characters, err = toText(args[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	switch name {
+	case FUNCTION_LTRIM:
+		text = strings.TrimLeft(text, characters)
+	case FUNCTION_RTRIM:
+		text = strings.TrimRight(text, characters)
+	default:
+		text = strings.Trim(text, characters)
+	}
+
+	return NewText(text), nil
+}
+
+// concat writes every value as text and leaves out NULL
+func concat(args []*tuple.Value) (*tuple.Value, error) {
+	builder := strings.Builder{}
+
+	for _, arg := range args {
+		if arg.IsNull() {
+			continue
+		}
+
+		if isText(arg) {
+			builder.Write(arg.VAR_CHAR)
+		} else {
+			builder.WriteString(tuple.GetValueText(arg))
+		}
+	}
+
+	return NewText(builder.String()), nil
+}
+
+func replace(args []*tuple.Value) (*tuple.Value, error) {
+	texts := make([]string, len(args))
+
+	for i, arg := range args {
+		text, err := toText(arg)
+
+		if err != nil {
+			return nil, err
+		}
+
+		texts[i] = text
+	}
+
+	if texts[1] == "" {
+		return NewText(texts[0]), nil
+	}
+
+	return NewText(strings.ReplaceAll(texts[0], texts[1], texts[2])), nil
+}
@@ -0,0 +1,317 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"strings"
+	"text/scanner"
+)
+
+/**
+ *  Subqueries
+ *  +---------------------------+----------------------------------------------------------+
+ *  | Form                      | Value                                                    |
+ *  +---------------------------+----------------------------------------------------------+
+ *  | (SELECT ...)              | the column of the only row, NULL without a row           |
+ *  | EXISTS (SELECT ...)       | whether the query returns a row                          |
+ *  | x [NOT] IN (SELECT ...)   | whether a row equals x, NULL when none does and x or a   |
+ *  | x [NOT] IN (a, b, ...)    | row is NULL, the list works the same way                 |
+ *  +---------------------------+----------------------------------------------------------+
+ *
+ *  A subquery may reference the columns of the queries around it, a column the subquery
+ *  does not have is resolved against the outer row. The ast package reads the SELECT and
+ *  the Queries of the row run it, CHECK and DEFAULT have no Queries.
+ */
+
+const (
+	OPERATOR_IN    = "IN"
+	KEYWORD_EXISTS = "EXISTS"
+	KEYWORD_SELECT = "SELECT"
+)
+
+// Query is a SELECT inside of an expression
+type Query interface {
+	String() string
+}
+
+// Queries runs the subqueries of a statement, it returns the rows of the query for the outer
+// row and the number of the columns
+type Queries interface {
+	RunQuery(query Query, outer *Row) ([][]*tuple.Value, int, error)
+}
+
+// Subquery is a SELECT of one column which is used as a value
+type Subquery struct {
+	Query Query
+}
+
+type Exists struct {
+	Query Query
+}
+
+// In compares the operand with the values of List or with the rows of Query
+type In struct {
+	Operand Expression
+	List    []Expression
+	Query   Query
+	Not     bool
+}
+
+// parseQuery reads a SELECT which starts after its keyword and returns the token which ends it
+var parseQuery func(scan *scanner.Scanner) (Query, string, error)
+
+// SetQueryParser gives the expression parser the reader of a SELECT, the ast package
+// which has it imports this one
+func SetQueryParser(parse func(scan *scanner.Scanner) (Query, string, error)) {
+	parseQuery = parse
+}
+
+// HasSubquery reports whether the expression runs a query
+func HasSubquery(expr Expression) bool {
+	found := false
+
+	Walk(expr, func(e Expression) {
+		switch e := e.(type) {
+		case *Subquery, *Exists:
+			found = true
+		case *In:
+			found = found || e.Query != nil
+		}
+	})
+
+	return found
+}
+
+// runQuery returns the rows of the query, a query used as a value has one column
+func runQuery(query Query, row *Row, single bool) ([][]*tuple.Value, error) {
+	if row == nil || row.Queries == nil {
+		return nil, errors.ErrSubquery
+	}
+
+	rows, width, err := row.Queries.RunQuery(query, row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if single && width != 1 {
+		return nil, errors.ErrSubqueryColumns
+	}
+
+	return rows, nil
+}
+
+func (s *Subquery) Evaluate(row *Row) (*tuple.Value, error) {
+	rows, err := runQuery(s.Query, row, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) > 1 {
+		return nil, errors.ErrSubqueryRows
+	}
+
+	if len(rows) == 0 {
+		return NewNull(), nil
+	}
+
+	return rows[0][0], nil
+}
+
+func (s *Subquery) String() string {
+	return "(" + s.Query.String() + ")"
+}
+
+func (e *Exists) Evaluate(row *Row) (*tuple.Value, error) {
+	rows, err := runQuery(e.Query, row, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewBool(len(rows) != 0), nil
+}
+
+func (e *Exists) String() string {
+	return KEYWORD_EXISTS + " (" + e.Query.String() + ")"
+}
+
+func (i *In) Evaluate(row *Row) (*tuple.Value, error) {
+	operand, err := i.Operand.Evaluate(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := i.values(row)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(values) == 0 {
+		return NewBool(i.Not), nil
+	}
+
+	if operand.IsNull() {
+		return NewNull(), nil
+	}
+
+	null := false
+
+	for _, value := range values {
+		if value.IsNull() {
+			null = true
+			continue
+		}
+
+		result, err := Compare(operand, value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if result == 0 {
+			return NewBool(!i.Not), nil
+		}
+	}
+
+	if null {
+		return NewNull(), nil
+	}
+
+	return NewBool(i.Not), nil
+}
+
+// values evaluates the list, or returns the column of the rows of the query
+func (i *In) values(row *Row) ([]*tuple.Value, error) {
+	if i.Query == nil {
+		values := make([]*tuple.Value, 0, len(i.List))
+
+		for _, expr := range i.List {
+			value, err := expr.Evaluate(row)
+
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		return values, nil
+	}
+
+	rows, err := runQuery(i.Query, row, true)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*tuple.Value, 0, len(rows))
+
+	for _, r := range rows {
+		values = append(values, r[0])
+	}
+
+	return values, nil
+}
+
+func (i *In) String() string {
+	operator := OPERATOR_IN
+
+	if i.Not {
+		operator = OPERATOR_NOT + " " + OPERATOR_IN
+	}
+
+	if i.Query != nil {
+		return "(" + i.Operand.String() + " " + operator + " (" + i.Query.String() + "))"
+	}
+
+	items := make([]string, 0, len(i.List))
+
+	for _, expr := range i.List {
+		items = append(items, expr.String())
+	}
+
+	return "(" + i.Operand.String() + " " + operator + " (" + strings.Join(items, ", ") + "))"
+}
+
+// parseQuery reads the SELECT after the opening bracket, the current token is SELECT
+// and the closing bracket is read as well
+func (p *parser) parseQuery() (Query, error) {
+	if parseQuery == nil {
+		return nil, errors.ErrSubquery
+	}
+
+	query, end, err := parseQuery(p.scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end != ")" {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	return query, nil
+}
+
+// parseIn reads the list or the SELECT after IN, the current token is IN
+func (p *parser) parseIn(operand Expression, not bool) (Expression, error) {
+	in := &In{Operand: operand, Not: not}
+	p.next()
+
+	if p.text != "(" {
+		return nil, errors.ErrSyntax
+	}
+
+	p.next()
+
+	if p.keyword() == KEYWORD_SELECT {
+		query, err := p.parseQuery()
+
+		if err != nil {
+			return nil, err
+		}
+
+		in.Query = query
+
+		return in, nil
+	}
+
+	for {
+		expr, err := p.parseExpression(precedenceOr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		in.List = append(in.List, expr)
+
+		if p.text == ")" {
+			p.next()
+			return in, nil
+		}
+
+		if p.text != "," {
+			return nil, errors.ErrSyntax
+		}
+
+		p.next()
+	}
+}
+
+// followedByIn reports whether the next word starts like IN, NOT is an operator
+// then and otherwise it ends the expression, such as DEFAULT 0 NOT NULL
+func (p *parser) followedByIn() bool {
+	for p.scan.Whitespace&(1<<uint(p.scan.Peek())) != 0 {
+		p.scan.Next()
+	}
+
+	next := p.scan.Peek()
+
+	return next == 'I' || next == 'i'
+}
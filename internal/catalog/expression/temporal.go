@@ -0,0 +1,542 @@
+package expression
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"strings"
+	"time"
+)
+
+/**
+ *  DATE, TIME, TIMESTAMP and INTERVAL arithmetic follows PostgreSQL
+ *  +--------------------------------+-----------+
+ *  | DATE + INT, DATE - INT         | DATE      |
+ *  | DATE - DATE                    | INT       |
+ *  | DATE + TIME                    | TIMESTAMP |
+ *  | DATE, TIMESTAMP +- INTERVAL    | TIMESTAMP |
+ *  | TIMESTAMP - TIMESTAMP          | INTERVAL  |
+ *  | TIME +- INTERVAL               | TIME      |
+ *  | TIME - TIME                    | INTERVAL  |
+ *  | INTERVAL +- INTERVAL           | INTERVAL  |
+ *  | INTERVAL * number, / number    | INTERVAL  |
+ *  +--------------------------------+-----------+
+ *
+ *  Adding months keeps the day of the month unless the month is shorter, 2024-01-31 + 1 month is 2024-02-29.
+ *  TIMESTAMP has no time zone, now() and CURRENT_TIMESTAMP read the clock of the server.
+ */
+
+const (
+	FUNCTION_EXTRACT    = "extract"
+	FUNCTION_NOW        = "now"
+	FUNCTION_DATE_TRUNC = "date_trunc"
+	FUNCTION_DATE_PART  = "date_part"
+	KEYWORD_FROM        = "FROM"
+)
+
+// the fields of date_part besides the units
+const (
+	FIELD_DOW    = "dow"
+	FIELD_ISODOW = "isodow"
+	FIELD_DOY    = "doy"
+	FIELD_EPOCH  = "epoch"
+)
+
+// the column types a quoted literal can be prefixed with, such as DATE '2024-01-02'
+var literalTypes = map[string]types.COLUMN_TYPE{
+	types.COLUMN_TYPE_DATE:      types.DATE_TYPE,
+	types.COLUMN_TYPE_TIME:      types.TIME_TYPE,
+	types.COLUMN_TYPE_TIMESTAMP: types.TIMESTAMP_TYPE,
+	types.COLUMN_TYPE_INTERVAL:  types.INTERVAL_TYPE,
+	types.COLUMN_TYPE_JSON:      types.JSON_TYPE,
+	types.COLUMN_TYPE_JSONB:     types.JSON_TYPE,
+	types.COLUMN_TYPE_SMALLINT:  types.SMALL_INT_TYPE,
+	types.COLUMN_TYPE_REAL:      types.REAL_TYPE,
+	types.COLUMN_TYPE_UUID:      types.UUID_TYPE,
+	types.COLUMN_TYPE_BYTEA:     types.BYTEA_TYPE,
+	types.COLUMN_TYPE_CHAR:      types.CHAR_TYPE,
+}
+
+func NewDate(days int32) *tuple.Value {
+	return tuple.GetValue(days, types.DATE_TYPE, types.DATE_SIZE)
+}
+
+func NewTime(microseconds int64) *tuple.Value {
+	return tuple.GetValue(microseconds, types.TIME_TYPE, types.TIME_SIZE)
+}
+
+func NewTimestamp(microseconds int64) *tuple.Value {
+	return tuple.GetValue(microseconds, types.TIMESTAMP_TYPE, types.TIMESTAMP_SIZE)
+}
+
+func NewInterval(interval tuple.Interval) *tuple.Value {
+	return tuple.GetValue(interval, types.INTERVAL_TYPE, types.INTERVAL_SIZE)
+}
+
+func isTemporal(v *tuple.Value) bool {
+	switch v.GetType() {
+	case types.DATE_TYPE, types.TIME_TYPE, types.TIMESTAMP_TYPE, types.INTERVAL_TYPE:
+		return true
+	}
+
+	return false
+}
+
+// wallClock returns the time the clock of the server shows, as if it was UTC
+func wallClock() time.Time {
+	now := time.Now()
+
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), now.Nanosecond(), time.UTC)
+}
+
+// toTime returns the time of a DATE or TIMESTAMP
+func toTime(v *tuple.Value) time.Time {
+	if v.GetType() == types.DATE_TYPE {
+		return tuple.DateToTime(v.DATE)
+	}
+
+	return tuple.TimestampToTime(v.TIMESTAMP)
+}
+
+func compareTemporal(left *tuple.Value, right *tuple.Value) int {
+	var l, r int64
+
+	switch left.GetType() {
+	case types.DATE_TYPE:
+		l, r = int64(left.DATE), int64(right.DATE)
+	case types.TIME_TYPE:
+		l, r = left.TIME, right.TIME
+	case types.TIMESTAMP_TYPE:
+		l, r = left.TIMESTAMP, right.TIMESTAMP
+	case types.INTERVAL_TYPE:
+		// a month counts 30 days like PostgreSQL, so 1 month equals 30 days
+		l, r = intervalDays(left.INTERVAL), intervalDays(right.INTERVAL)
+
+		if l == r {
+			l, r = floorMod(left.INTERVAL.Microseconds, tuple.MICROSECONDS_PER_DAY), floorMod(right.INTERVAL.Microseconds, tuple.MICROSECONDS_PER_DAY)
+		}
+	}
+
+	if l < r {
+		return -1
+	} else if l > r {
+		return 1
+	}
+
+	return 0
+}
+
+func intervalDays(i tuple.Interval) int64 {
+	return int64(i.Months)*tuple.DAYS_PER_MONTH + int64(i.Days) + floorDiv(i.Microseconds, tuple.MICROSECONDS_PER_DAY)
+}
+
+// temporalArithmetic evaluates an operator with a DATE, TIME, TIMESTAMP or INTERVAL operand which is not NULL
+func temporalArithmetic(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, error) {
+	left, right, err := coerceTemporal(operator, left, right)
+
+	if err != nil {
+		return nil, err
+	}
+
+	l, r := left.GetType(), right.GetType()
+
+	switch operator {
+	case OPERATOR_PLUS, OPERATOR_MINUS:
+		plus := operator == OPERATOR_PLUS
+
+		switch {
+		case l == types.DATE_TYPE && isInteger(right):
+			return addDays(left.DATE, toInt(right), plus)
+		case isInteger(left) && r == types.DATE_TYPE && plus:
+			return addDays(right.DATE, toInt(left), plus)
+		case l == types.DATE_TYPE && r == types.DATE_TYPE && !plus:
+			return tuple.GetValue(left.DATE-right.DATE, types.INT_TYPE, types.INT_SIZE), nil
+		case l == types.DATE_TYPE && r == types.TIME_TYPE && plus:
+			return NewTimestamp(int64(left.DATE)*tuple.MICROSECONDS_PER_DAY + right.TIME), nil
+		case l == types.TIME_TYPE && r == types.DATE_TYPE && plus:
+			return NewTimestamp(int64(right.DATE)*tuple.MICROSECONDS_PER_DAY + left.TIME), nil
+		case (l == types.DATE_TYPE || l == types.TIMESTAMP_TYPE) && r == types.INTERVAL_TYPE:
+			return addInterval(toTime(left), right.INTERVAL, plus)
+		case l == types.INTERVAL_TYPE && (r == types.DATE_TYPE || r == types.TIMESTAMP_TYPE) && plus:
+			return addInterval(toTime(right), left.INTERVAL, plus)
+		case l == types.TIMESTAMP_TYPE && r == types.TIMESTAMP_TYPE && !plus:
+			difference := left.TIMESTAMP - right.TIMESTAMP
+
+			return NewInterval(tuple.Interval{
+				Days:         int32(difference / tuple.MICROSECONDS_PER_DAY),
+				Microseconds: difference % tuple.MICROSECONDS_PER_DAY,
+			}), nil
+		case l == types.TIME_TYPE && r == types.INTERVAL_TYPE:
+			microseconds := right.INTERVAL.Microseconds % tuple.MICROSECONDS_PER_DAY
+
+			if !plus {
+				microseconds = -microseconds
+			}
+
+			// the time wraps around midnight and the days and months of the interval are ignored
+			return NewTime(floorMod(left.TIME+microseconds, tuple.MICROSECONDS_PER_DAY)), nil
+		case l == types.INTERVAL_TYPE && r == types.TIME_TYPE && plus:
+			return NewTime(floorMod(right.TIME+left.INTERVAL.Microseconds%tuple.MICROSECONDS_PER_DAY, tuple.MICROSECONDS_PER_DAY)), nil
+		case l == types.TIME_TYPE && r == types.TIME_TYPE && !plus:
+			return NewInterval(tuple.Interval{Microseconds: left.TIME - right.TIME}), nil
+		case l == types.INTERVAL_TYPE && r == types.INTERVAL_TYPE:
+			sign := int64(1)
+
+			if !plus {
+				sign = -1
+			}
+
+			return combineIntervals(left.INTERVAL, right.INTERVAL, sign)
+		}
+	case OPERATOR_MULTIPLY:
+		if l == types.INTERVAL_TYPE && isNumeric(right) {
+			return scaleInterval(left.INTERVAL, toFloat(right))
+		}
+
+		if isNumeric(left) && r == types.INTERVAL_TYPE {
+			return scaleInterval(right.INTERVAL, toFloat(left))
+		}
+	case OPERATOR_DIVIDE:
+		if l == types.INTERVAL_TYPE && isNumeric(right) {
+			if toFloat(right) == 0 {
+				return nil, errors.ErrDivisionByZero
+			}
+
+			return scaleInterval(left.INTERVAL, 1/toFloat(right))
+		}
+	}
+
+	return nil, errors.ErrTypeMismatch
+}
+
+// coerceTemporal reads text next to a temporal value like PostgreSQL, as the type of the other operand
+// when it is subtracted from it and as an INTERVAL otherwise, so that ts + '1 day' and d - '2024-01-01' work
+func coerceTemporal(operator string, left *tuple.Value, right *tuple.Value) (*tuple.Value, *tuple.Value, error) {
+	var err error
+
+	if left.GetType() == types.VAR_CHAR_TYPE {
+		left, err = readTemporalText(operator, left, right)
+	} else if right.GetType() == types.VAR_CHAR_TYPE {
+		right, err = readTemporalText(operator, right, left)
+	}
+
+	return left, right, err
+}
+
+// readTemporalText falls back to a TIMESTAMP when the text is not an INTERVAL
+func readTemporalText(operator string, text *tuple.Value, other *tuple.Value) (*tuple.Value, error) {
+	candidates := []types.COLUMN_TYPE{types.INTERVAL_TYPE, types.TIMESTAMP_TYPE}
+
+	if operator == OPERATOR_MINUS && other.GetType() != types.INTERVAL_TYPE {
+		candidates = []types.COLUMN_TYPE{other.GetType(), types.INTERVAL_TYPE}
+	}
+
+	for _, candidate := range candidates {
+		if converted, err := tuple.ConvertValue(text, candidate, getTypeSize(candidate)); err == nil {
+			return converted, nil
+		}
+	}
+
+	return nil, errors.ErrInvalidValue
+}
+
+func getTypeSize(valueType types.COLUMN_TYPE) int32 {
+	return column.NewColumn(valueType, 0, "").Size
+}
+
+func addDays(date int32, days int64, plus bool) (*tuple.Value, error) {
+	if !plus {
+		days = -days
+	}
+
+	if days > math.MaxInt32 || days < math.MinInt32 {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	result := int64(date) + days
+
+	if result > math.MaxInt32 || result < math.MinInt32 || !tuple.IsTimeInRange(tuple.DateToTime(int32(result))) {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	return NewDate(int32(result)), nil
+}
+
+func addInterval(t time.Time, interval tuple.Interval, plus bool) (*tuple.Value, error) {
+	if !plus {
+		negated, err := negateInterval(interval)
+
+		if err != nil {
+			return nil, err
+		}
+
+		interval = negated
+	}
+
+	t = addMonths(t, int(interval.Months)).AddDate(0, 0, int(interval.Days))
+
+	if !tuple.IsTimeInRange(t) {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	result := tuple.TimeToTimestamp(t) + interval.Microseconds
+
+	if !tuple.IsTimeInRange(tuple.TimestampToTime(result)) || (result > tuple.TimeToTimestamp(t)) != (interval.Microseconds > 0) {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	return NewTimestamp(result), nil
+}
+
+// addMonths moves to the last day of the month when the day does not exist in it
+func addMonths(t time.Time, months int) time.Time {
+	year, month, day := t.Date()
+	first := time.Date(year, month+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+
+	if last := time.Date(first.Year(), first.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+		day = last
+	}
+
+	return time.Date(first.Year(), first.Month(), day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func negateInterval(i tuple.Interval) (tuple.Interval, error) {
+	if i.Months == math.MinInt32 || i.Days == math.MinInt32 || i.Microseconds == math.MinInt64 {
+		return tuple.Interval{}, errors.ErrDatetimeRange
+	}
+
+	return tuple.Interval{Months: -i.Months, Days: -i.Days, Microseconds: -i.Microseconds}, nil
+}
+
+func combineIntervals(left tuple.Interval, right tuple.Interval, sign int64) (*tuple.Value, error) {
+	months := int64(left.Months) + sign*int64(right.Months)
+	days := int64(left.Days) + sign*int64(right.Days)
+	microseconds := left.Microseconds + sign*right.Microseconds
+
+	if months > math.MaxInt32 || months < math.MinInt32 || days > math.MaxInt32 || days < math.MinInt32 ||
+		(microseconds > left.Microseconds) != (sign*right.Microseconds > 0) {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	return NewInterval(tuple.Interval{Months: int32(months), Days: int32(days), Microseconds: microseconds}), nil
+}
+
+func scaleInterval(i tuple.Interval, factor float64) (*tuple.Value, error) {
+	interval, ok := tuple.NewInterval(float64(i.Months)*factor, float64(i.Days)*factor, float64(i.Microseconds)*factor)
+
+	if !ok {
+		return nil, errors.ErrDatetimeRange
+	}
+
+	return NewInterval(interval), nil
+}
+
+// evaluateTemporal runs now(), date_trunc(unit, value) and date_part(field, value)
+func evaluateTemporal(name string, args []*tuple.Value) (*tuple.Value, error) {
+	if name == FUNCTION_NOW {
+		if len(args) != 0 {
+			return nil, errors.ErrSyntax
+		}
+
+		return NewTimestamp(tuple.TimeToTimestamp(wallClock())), nil
+	}
+
+	if len(args) != 2 {
+		return nil, errors.ErrSyntax
+	}
+
+	if args[0].IsNull() || args[1].IsNull() {
+		return NewNull(), nil
+	}
+
+	if args[0].GetType() != types.VAR_CHAR_TYPE {
+		return nil, errors.ErrTypeMismatch
+	}
+
+	field, value := strings.ToLower(string(args[0].VAR_CHAR)), args[1]
+
+	if unit := tuple.GetUnit(field); unit != "" {
+		field = unit
+	}
+
+	if value.GetType() == types.VAR_CHAR_TYPE {
+		converted, err := tuple.ConvertValue(value, types.TIMESTAMP_TYPE, types.TIMESTAMP_SIZE)
+
+		if err != nil {
+			return nil, err
+		}
+
+		value = converted
+	}
+
+	if name == FUNCTION_DATE_PART {
+		part, err := datePart(field, value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return tuple.GetValue(part, types.FLOAT_TYPE, types.FLOAT_SIZE), nil
+	}
+
+	if value.GetType() != types.DATE_TYPE && value.GetType() != types.TIMESTAMP_TYPE {
+		return nil, errors.ErrTypeMismatch
+	}
+
+	t, err := dateTrunc(field, toTime(value))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTimestamp(tuple.TimeToTimestamp(t)), nil
+}
+
+// dateTrunc starts a week on Monday and a century and a millennium with the year ending in 1
+func dateTrunc(unit string, t time.Time) (time.Time, error) {
+	year, month, day := t.Date()
+
+	switch unit {
+	case tuple.UNIT_MICROSECOND:
+		return t.Truncate(time.Microsecond), nil
+	case tuple.UNIT_MILLISECOND:
+		return t.Truncate(time.Millisecond), nil
+	case tuple.UNIT_SECOND:
+		return t.Truncate(time.Second), nil
+	case tuple.UNIT_MINUTE:
+		return t.Truncate(time.Minute), nil
+	case tuple.UNIT_HOUR:
+		return t.Truncate(time.Hour), nil
+	case tuple.UNIT_DAY:
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_WEEK:
+		return time.Date(year, month, day-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_MONTH:
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_QUARTER:
+		return time.Date(year, month-(month-1)%3, 1, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_YEAR:
+		return time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_DECADE:
+		return time.Date(year-year%10, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_CENTURY:
+		return time.Date((year-1)/100*100+1, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	case tuple.UNIT_MILLENNIUM:
+		return time.Date((year-1)/1000*1000+1, 1, 1, 0, 0, 0, 0, time.UTC), nil
+	}
+
+	return time.Time{}, errors.ErrInvalidUnit
+}
+
+// datePart returns the seconds with their fraction, millisecond and microsecond also count the seconds
+func datePart(field string, value *tuple.Value) (float64, error) {
+	switch value.GetType() {
+	case types.TIME_TYPE:
+		return clockPart(field, value.TIME, float64(value.TIME)/tuple.MICROSECONDS_PER_SECOND)
+	case types.INTERVAL_TYPE:
+		return intervalPart(field, value.INTERVAL)
+	case types.DATE_TYPE, types.TIMESTAMP_TYPE:
+	default:
+		return 0, errors.ErrTypeMismatch
+	}
+
+	t := toTime(value)
+	year := t.Year()
+
+	switch field {
+	case tuple.UNIT_DAY:
+		return float64(t.Day()), nil
+	case tuple.UNIT_WEEK:
+		_, week := t.ISOWeek()
+		return float64(week), nil
+	case tuple.UNIT_MONTH:
+		return float64(t.Month()), nil
+	case tuple.UNIT_QUARTER:
+		return float64((t.Month()-1)/3 + 1), nil
+	case tuple.UNIT_YEAR:
+		return float64(year), nil
+	case tuple.UNIT_DECADE:
+		return float64(year / 10), nil
+	case tuple.UNIT_CENTURY:
+		return float64((year-1)/100 + 1), nil
+	case tuple.UNIT_MILLENNIUM:
+		return float64((year-1)/1000 + 1), nil
+	case FIELD_DOW:
+		return float64(t.Weekday()), nil
+	case FIELD_ISODOW:
+		return float64((int(t.Weekday())+6)%7 + 1), nil
+	case FIELD_DOY:
+		return float64(t.YearDay()), nil
+	case FIELD_EPOCH:
+		return float64(tuple.TimeToTimestamp(t)) / tuple.MICROSECONDS_PER_SECOND, nil
+	}
+
+	return clockPart(field, tuple.TimeOfDay(t), float64(tuple.TimeToTimestamp(t))/tuple.MICROSECONDS_PER_SECOND)
+}
+
+func clockPart(field string, microseconds int64, epoch float64) (float64, error) {
+	seconds := microseconds % tuple.MICROSECONDS_PER_MINUTE
+
+	switch field {
+	case tuple.UNIT_HOUR:
+		return float64(microseconds / tuple.MICROSECONDS_PER_HOUR), nil
+	case tuple.UNIT_MINUTE:
+		return float64(microseconds / tuple.MICROSECONDS_PER_MINUTE % 60), nil
+	case tuple.UNIT_SECOND:
+		return float64(seconds) / tuple.MICROSECONDS_PER_SECOND, nil
+	case tuple.UNIT_MILLISECOND:
+		return float64(seconds) / 1000, nil
+	case tuple.UNIT_MICROSECOND:
+		return float64(seconds), nil
+	case FIELD_EPOCH:
+		return epoch, nil
+	}
+
+	return 0, errors.ErrInvalidUnit
+}
+
+// intervalPart counts a year as 365.25 days and a month as 30 days for the epoch
+func intervalPart(field string, i tuple.Interval) (float64, error) {
+	years := i.Months / 12
+
+	switch field {
+	case tuple.UNIT_DAY:
+		return float64(i.Days), nil
+	case tuple.UNIT_MONTH:
+		return float64(i.Months % 12), nil
+	case tuple.UNIT_QUARTER:
+		return float64(i.Months%12/3 + 1), nil
+	case tuple.UNIT_YEAR:
+		return float64(years), nil
+	case tuple.UNIT_DECADE:
+		return float64(years / 10), nil
+	case tuple.UNIT_CENTURY:
+		return float64(years / 100), nil
+	case tuple.UNIT_MILLENNIUM:
+		return float64(years / 1000), nil
+	case FIELD_EPOCH:
+		days := float64(years)*365.25 + float64(i.Months%12*tuple.DAYS_PER_MONTH) + float64(i.Days)
+
+		return days*86400 + float64(i.Microseconds)/tuple.MICROSECONDS_PER_SECOND, nil
+	}
+
+	return clockPart(field, i.Microseconds, 0)
+}
+
+func isInteger(v *tuple.Value) bool {
+	return v.GetType() == types.INT_TYPE || v.GetType() == types.LONG_INT_TYPE || v.GetType() == types.SMALL_INT_TYPE
+}
+
+func floorDiv(a int64, b int64) int64 {
+	if a%b != 0 && (a < 0) != (b < 0) {
+		return a/b - 1
+	}
+
+	return a / b
+}
+
+func floorMod(a int64, b int64) int64 {
+	return a - floorDiv(a, b)*b
+}
@@ -0,0 +1,376 @@
+package expression
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+/**
+ *  User-defined functions
+ *  +-----------+-----------------------------------------------------------------------+
+ *  | Kind      | Call                                                                  |
+ *  +-----------+-----------------------------------------------------------------------+
+ *  | scalar    | once for every row like the built-in functions                        |
+ *  | aggregate | a new accumulator for every query, Step for every row, Result at last |
+ *  +-----------+-----------------------------------------------------------------------+
+ *
+ *  The signature types the arguments. An argument of another type is converted when the pair
+ *  converts without a CAST and is an ArgumentError otherwise, NULL becomes a NULL of the type and
+ *  ANY_TYPE takes every value as it is. The result is converted to the return type the same way.
+ *  An aggregate in the SELECT list makes all the rows one group, a column outside of an aggregate
+ *  call is an error then.
+ */
+
+// ANY_TYPE is an argument or return type of a signature which takes every type
+const ANY_TYPE = types.INVALID_TYPE
+
+// Signature is the typed arguments and result of a user-defined function,
+// with Variadic the last argument type repeats
+type Signature struct {
+	Args     []types.COLUMN_TYPE
+	Variadic bool
+	Return   types.COLUMN_TYPE
+}
+
+// Accumulator collects the arguments of an aggregate call row by row
+type Accumulator interface {
+	Step(args []*tuple.Value) error
+	Result() (*tuple.Value, error)
+}
+
+// AggregateFunction is a user-defined aggregate, a strict aggregate skips the rows
+// with a NULL argument
+type AggregateFunction struct {
+	Signature      *Signature
+	Strict         bool
+	NewAccumulator func() Accumulator
+}
+
+var (
+	aggregates = map[string]*AggregateFunction{}
+	registry   sync.RWMutex
+)
+
+// RegisterFunction adds a scalar function which SQL calls by the name in any case
+func RegisterFunction(name string, signature Signature, strict bool, call func(args []*tuple.Value) (*tuple.Value, error)) error {
+	name, err := checkRegistration(name, &signature)
+
+	if err != nil {
+		return err
+	}
+
+	maxArgs := len(signature.Args)
+
+	if signature.Variadic {
+		maxArgs = VARIADIC
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if isRegistered(name) {
+		return errors.ErrFunctionExist
+	}
+
+	functions[name] = &ScalarFunction{MinArgs: len(signature.Args), MaxArgs: maxArgs, Strict: strict, Signature: &signature, Call: call}
+
+	return nil
+}
+
+// RegisterAggregate adds an aggregate function, every call in a query gets its own accumulator
+func RegisterAggregate(name string, signature Signature, strict bool, newAccumulator func() Accumulator) error {
+	name, err := checkRegistration(name, &signature)
+
+	if err != nil {
+		return err
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if isRegistered(name) {
+		return errors.ErrFunctionExist
+	}
+
+	aggregates[name] = &AggregateFunction{Signature: &signature, Strict: strict, NewAccumulator: newAccumulator}
+
+	return nil
+}
+
+// UnregisterFunction removes a user-defined scalar or aggregate function, the built-in functions stay
+func UnregisterFunction(name string) error {
+	name = strings.ToLower(name)
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if _, exist := aggregates[name]; exist {
+		delete(aggregates, name)
+		return nil
+	}
+
+	if function, exist := functions[name]; exist && function.Signature != nil {
+		delete(functions, name)
+		return nil
+	}
+
+	return errors.ErrNoFunction
+}
+
+// checkRegistration returns the name in lower case, it has to be an identifier
+// and every type of the signature a column type
+func checkRegistration(name string, signature *Signature) (string, error) {
+	name = strings.ToLower(name)
+
+	if name == "" || (signature.Variadic && len(signature.Args) == 0) {
+		return "", errors.ErrSyntax
+	}
+
+	for i, r := range name {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return "", errors.ErrSyntax
+		}
+	}
+
+	// the registry holds a copy of the argument types
+	signature.Args = append([]types.COLUMN_TYPE(nil), signature.Args...)
+
+	for _, valueType := range append([]types.COLUMN_TYPE{signature.Return}, signature.Args...) {
+		if valueType != ANY_TYPE && column.GetColumnTypeName(valueType) == types.COLUMN_TYPE_INVALID {
+			return "", errors.ErrInvalidValue
+		}
+	}
+
+	return name, nil
+}
+
+func isRegistered(name string) bool {
+	_, scalar := functions[name]
+	_, aggregate := aggregates[name]
+
+	return scalar || aggregate || name == FUNCTION_NEXTVAL || name == FUNCTION_CURRVAL
+}
+
+func getAggregate(name string) (*AggregateFunction, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	aggregate, exist := aggregates[name]
+
+	return aggregate, exist
+}
+
+func (s *Signature) accepts(count int) bool {
+	return count == len(s.Args) || (s.Variadic && count > len(s.Args))
+}
+
+// convertArgs converts every argument to its type in the signature
+func (s *Signature) convertArgs(name string, args []*tuple.Value) ([]*tuple.Value, error) {
+	converted := make([]*tuple.Value, len(args))
+
+	for i, arg := range args {
+		argType := s.Args[len(s.Args)-1]
+
+		if i < len(s.Args) {
+			argType = s.Args[i]
+		}
+
+		if argType == ANY_TYPE || arg.GetType() == argType {
+			converted[i] = arg
+			continue
+		}
+
+		if !arg.IsNull() && !tuple.CanConvert(arg.GetType(), argType) {
+			return nil, &errors.ArgumentError{
+				Function: name,
+				Position: i + 1,
+				Type:     column.GetColumnTypeName(arg.GetType()),
+				Expected: column.GetColumnTypeName(argType),
+			}
+		}
+
+		value, err := tuple.ConvertValue(arg, argType, getTypeSize(argType))
+
+		if err != nil {
+			return nil, err
+		}
+
+		converted[i] = value
+	}
+
+	return converted, nil
+}
+
+// convertResult converts the result of a call to the return type, nil is NULL
+func (s *Signature) convertResult(result *tuple.Value) (*tuple.Value, error) {
+	if result == nil {
+		result = NewNull()
+	}
+
+	if s.Return == ANY_TYPE || result.GetType() == s.Return {
+		return result, nil
+	}
+
+	return tuple.ConvertValue(result, s.Return, getTypeSize(s.Return))
+}
+
+// IsAggregate reports whether the expression calls an aggregate function
+func IsAggregate(expr Expression) bool {
+	found := false
+
+	Walk(expr, func(e Expression) {
+		if f, ok := e.(*Function); ok {
+			if _, exist := getAggregate(f.Name); exist {
+				found = true
+			}
+		}
+	})
+
+	return found
+}
+
+// Aggregate evaluates the aggregate calls of the expressions over the rows, the expressions
+// are evaluated against the row it returns afterwards
+func Aggregate(exprs []Expression, rows []*Row) (*Row, error) {
+	result := &Row{Aggregates: make(map[*Function]*tuple.Value)}
+
+	for _, expr := range exprs {
+		if expr == nil {
+			return nil, errors.ErrUngroupedColumn
+		}
+
+		calls, err := findAggregateCalls(expr)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, call := range calls {
+			value, err := call.aggregate(rows)
+
+			if err != nil {
+				return nil, err
+			}
+
+			result.Aggregates[call] = value
+		}
+	}
+
+	return result, nil
+}
+
+// findAggregateCalls returns the aggregate calls of the expression, a column has to be
+// inside of a call and a call can not be inside of another one
+func findAggregateCalls(expr Expression) ([]*Function, error) {
+	calls := make([]*Function, 0)
+	columns := countColumns(expr)
+
+	Walk(expr, func(e Expression) {
+		if f, ok := e.(*Function); ok {
+			if _, exist := getAggregate(f.Name); exist {
+				calls = append(calls, f)
+			}
+		}
+	})
+
+	for _, call := range calls {
+		for _, arg := range call.Args {
+			if IsAggregate(arg) {
+				return nil, errors.ErrAggregateCall
+			}
+
+			columns -= countColumns(arg)
+		}
+	}
+
+	if columns != 0 {
+		return nil, errors.ErrUngroupedColumn
+	}
+
+	return calls, nil
+}
+
+func countColumns(expr Expression) int {
+	count := 0
+
+	Walk(expr, func(e Expression) {
+		if _, ok := e.(*ColumnRef); ok {
+			count++
+		}
+	})
+
+	return count
+}
+
+// aggregate steps a new accumulator through the arguments of every row
+func (f *Function) aggregate(rows []*Row) (*tuple.Value, error) {
+	function, exist := getAggregate(f.Name)
+
+	if !exist {
+		return nil, errors.ErrNoFunction
+	}
+
+	if !function.Signature.accepts(len(f.Args)) {
+		return nil, errors.ErrSyntax
+	}
+
+	accumulator := function.NewAccumulator()
+
+	for _, row := range rows {
+		args, err := f.evaluateArgs(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if function.Strict && hasNull(args) {
+			continue
+		}
+
+		if args, err = function.Signature.convertArgs(f.Name, args); err != nil {
+			return nil, err
+		}
+
+		if err := accumulator.Step(args); err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := accumulator.Result()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return function.Signature.convertResult(result)
+}
+
+// getAggregate returns the result of the call, which only the row of a group has
+func (r *Row) getAggregate(f *Function) (*tuple.Value, error) {
+	if r == nil {
+		return nil, errors.ErrAggregateCall
+	}
+
+	value, exist := r.Aggregates[f]
+
+	if !exist {
+		return nil, errors.ErrAggregateCall
+	}
+
+	return value, nil
+}
+
+func hasNull(args []*tuple.Value) bool {
+	for _, arg := range args {
+		if arg.IsNull() {
+			return true
+		}
+	}
+
+	return false
+}
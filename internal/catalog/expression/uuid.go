@@ -0,0 +1,23 @@
+package expression
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/types"
+)
+
+// gen_random_uuid() returns a version 4 UUID, a DEFAULT calls it for every row
+const FUNCTION_GEN_RANDOM_UUID = "gen_random_uuid"
+
+func NewUUID(uuid tuple.UUID) *tuple.Value {
+	return tuple.GetValue(uuid, types.UUID_TYPE, types.UUID_SIZE)
+}
+
+func newRandomUUID() (*tuple.Value, error) {
+	uuid, err := tuple.NewRandomUUID()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return NewUUID(uuid), nil
+}
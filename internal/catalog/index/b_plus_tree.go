@@ -0,0 +1,367 @@
+package index
+
+import (
+	"bytes"
+	"go-db/internal/buffer"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+)
+
+/**
+ *  BPlusTree is a unique index from the key to the RID of the tuple.
+ *
+ *  The root page never moves so its page id can be kept in the catalog,
+ *  when the root is full its entries move to a new page and the root becomes
+ *  the parent of the two halves. Deleted keys do not merge the pages.
+ */
+
+type BPlusTree struct {
+	bufferPoolManager *buffer.BufferPoolManager
+	rootPageID        types.Page_id_t
+}
+
+// pathEntry is a page on the way from the root with the entry taken to the next page
+type pathEntry struct {
+	pageID     types.Page_id_t
+	childIndex int32
+}
+
+func NewBPlusTree(bufferPoolManager *buffer.BufferPoolManager, keySize int32) (*BPlusTree, error) {
+	if keySize > MAX_KEY_SIZE {
+		return nil, errors.ErrKeyTooLarge
+	}
+
+	rootPage, err := bufferPoolManager.NewPage()
+
+	if err != nil {
+		return nil, err
+	}
+
+	GetIndexPage(rootPage).IndexPageInit(true, keySize)
+	bufferPoolManager.FlushPage(rootPage.GetPageID())
+	bufferPoolManager.UnpinPage(rootPage.GetPageID())
+
+	return GetBPlusTree(bufferPoolManager, rootPage.GetPageID()), nil
+}
+
+func GetBPlusTree(bufferPoolManager *buffer.BufferPoolManager, rootPageID types.Page_id_t) *BPlusTree {
+	return &BPlusTree{
+		bufferPoolManager: bufferPoolManager,
+		rootPageID:        rootPageID,
+	}
+}
+
+func (b *BPlusTree) GetRootPageID() types.Page_id_t {
+	return b.rootPageID
+}
+
+func (b *BPlusTree) Search(key []byte) (types.RID, bool, error) {
+	leafPage, _, err := b.findLeaf(key)
+
+	if err != nil {
+		return types.RID{}, false, err
+	}
+
+	defer b.bufferPoolManager.UnpinPage(leafPage.GetPageID())
+
+	index := leafPage.lowerBound(key)
+
+	if index == leafPage.GetKeyCount() || !bytes.Equal(leafPage.getKey(index), key) {
+		return types.RID{}, false, nil
+	}
+
+	return leafPage.GetRID(index), true, nil
+}
+
+// SearchPrefix returns the RIDs of the keys which start with the prefix in key order,
+// it follows the leaves to the right until a key has another prefix
+func (b *BPlusTree) SearchPrefix(prefix []byte) ([]types.RID, error) {
+	leafPage, _, err := b.findLeaf(prefix)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rids := make([]types.RID, 0)
+
+	for index := leafPage.lowerBound(prefix); ; index++ {
+		if index == leafPage.GetKeyCount() {
+			nextPageID := leafPage.GetNextPageID()
+			b.bufferPoolManager.UnpinPage(leafPage.GetPageID())
+
+			if nextPageID == constant.INVALID_PAGE_ID {
+				return rids, nil
+			}
+
+			p, err := b.bufferPoolManager.FetchPage(nextPageID)
+
+			if err != nil {
+				return nil, err
+			}
+
+			leafPage, index = GetIndexPage(p), -1
+			continue
+		}
+
+		if !bytes.HasPrefix(leafPage.getKey(index), prefix) {
+			b.bufferPoolManager.UnpinPage(leafPage.GetPageID())
+			return rids, nil
+		}
+
+		rids = append(rids, leafPage.GetRID(index))
+	}
+}
+
+// Insert returns ErrDuplicateKey when the key is already in the index
+func (b *BPlusTree) Insert(key []byte, rid types.RID) error {
+	leafPage, path, err := b.findLeaf(key)
+
+	if err != nil {
+		return err
+	}
+
+	index := leafPage.lowerBound(key)
+
+	if index < leafPage.GetKeyCount() && bytes.Equal(leafPage.getKey(index), key) {
+		b.bufferPoolManager.UnpinPage(leafPage.GetPageID())
+		return errors.ErrDuplicateKey
+	}
+
+	leafPage.insertLeafEntry(index, key, rid)
+
+	return b.splitPage(leafPage, path)
+}
+
+// Delete returns ErrKeyNotFound when the key is not in the index
+func (b *BPlusTree) Delete(key []byte) error {
+	leafPage, _, err := b.findLeaf(key)
+
+	if err != nil {
+		return err
+	}
+
+	defer b.bufferPoolManager.UnpinPage(leafPage.GetPageID())
+
+	index := leafPage.lowerBound(key)
+
+	if index == leafPage.GetKeyCount() || !bytes.Equal(leafPage.getKey(index), key) {
+		return errors.ErrKeyNotFound
+	}
+
+	leafPage.removeEntry(index)
+	b.bufferPoolManager.FlushPage(leafPage.GetPageID())
+
+	return nil
+}
+
+// Clear removes every key, the root stays as an empty leaf with the new key size
+func (b *BPlusTree) Clear(keySize int32) error {
+	if keySize > MAX_KEY_SIZE {
+		return errors.ErrKeyTooLarge
+	}
+
+	pageIDs, err := b.GetPageIDs()
+
+	if err != nil {
+		return err
+	}
+
+	for _, pageID := range pageIDs[1:] {
+		if err := b.bufferPoolManager.DeletePage(pageID); err != nil {
+			return err
+		}
+	}
+
+	rootPage, err := b.bufferPoolManager.FetchPage(b.rootPageID)
+
+	if err != nil {
+		return err
+	}
+
+	GetIndexPage(rootPage).IndexPageInit(true, keySize)
+	b.bufferPoolManager.FlushPage(b.rootPageID)
+	b.bufferPoolManager.UnpinPage(b.rootPageID)
+
+	return nil
+}
+
+// Destroy gives every page of the index back to the free page list
+func (b *BPlusTree) Destroy() error {
+	pageIDs, err := b.GetPageIDs()
+
+	if err != nil {
+		return err
+	}
+
+	for _, pageID := range pageIDs {
+		if err := b.bufferPoolManager.DeletePage(pageID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetPageIDs returns the pages of the index, the root comes first
+func (b *BPlusTree) GetPageIDs() ([]types.Page_id_t, error) {
+	pageIDs := []types.Page_id_t{b.rootPageID}
+
+	for i := 0; i < len(pageIDs); i++ {
+		p, err := b.bufferPoolManager.FetchPage(pageIDs[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		indexPage := GetIndexPage(p)
+
+		if !indexPage.IsLeaf() {
+			for index := int32(0); index < indexPage.GetKeyCount(); index++ {
+				pageIDs = append(pageIDs, indexPage.GetChildPageID(index))
+			}
+		}
+
+		b.bufferPoolManager.UnpinPage(pageIDs[i])
+	}
+
+	return pageIDs, nil
+}
+
+// findLeaf returns the pinned leaf which covers the key and the internal pages above it
+func (b *BPlusTree) findLeaf(key []byte) (*IndexPage, []pathEntry, error) {
+	path := make([]pathEntry, 0)
+	pageID := b.rootPageID
+
+	for {
+		p, err := b.bufferPoolManager.FetchPage(pageID)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		indexPage := GetIndexPage(p)
+
+		if p.GetPageTye() != types.INDEX_PAGE_TYPE {
+			b.bufferPoolManager.UnpinPage(pageID)
+			return nil, nil, errors.ErrPageCorrupted
+		}
+
+		if indexPage.IsLeaf() {
+			return indexPage, path, nil
+		}
+
+		childIndex := indexPage.getChildIndex(key)
+		path = append(path, pathEntry{pageID: pageID, childIndex: childIndex})
+		b.bufferPoolManager.UnpinPage(pageID)
+		pageID = indexPage.GetChildPageID(childIndex)
+	}
+}
+
+// splitPage flushes and unpins the page, a page over the limit gives its upper
+// half to a new page and the separator goes up to the parent on the path
+func (b *BPlusTree) splitPage(indexPage *IndexPage, path []pathEntry) error {
+	for {
+		if indexPage.GetKeyCount() <= indexPage.GetMaxKeyCount() {
+			b.bufferPoolManager.FlushPage(indexPage.GetPageID())
+			b.bufferPoolManager.UnpinPage(indexPage.GetPageID())
+			return nil
+		}
+
+		if len(path) == 0 {
+			return b.splitRoot(indexPage)
+		}
+
+		separator, newPageID, err := b.moveUpperHalf(indexPage)
+
+		b.bufferPoolManager.FlushPage(indexPage.GetPageID())
+		b.bufferPoolManager.UnpinPage(indexPage.GetPageID())
+
+		if err != nil {
+			return err
+		}
+
+		parent := path[len(path)-1]
+		path = path[:len(path)-1]
+
+		p, err := b.bufferPoolManager.FetchPage(parent.pageID)
+
+		if err != nil {
+			return err
+		}
+
+		indexPage = GetIndexPage(p)
+		indexPage.insertChildEntry(parent.childIndex+1, separator, newPageID)
+	}
+}
+
+// splitRoot moves the entries of the root to a new page which is split as usual,
+// the root is left with the two halves as its children
+func (b *BPlusTree) splitRoot(rootPage *IndexPage) error {
+	defer b.bufferPoolManager.UnpinPage(rootPage.GetPageID())
+
+	p, err := b.bufferPoolManager.NewPage()
+
+	if err != nil {
+		return err
+	}
+
+	leftPage := GetIndexPage(p)
+	leftPage.IndexPageInit(rootPage.IsLeaf(), rootPage.GetKeySize())
+	rootPage.moveEntries(0, leftPage)
+
+	separator, rightPageID, err := b.moveUpperHalf(leftPage)
+
+	b.bufferPoolManager.FlushPage(leftPage.GetPageID())
+	b.bufferPoolManager.UnpinPage(leftPage.GetPageID())
+
+	if err != nil {
+		return err
+	}
+
+	rootPage.IndexPageInit(false, leftPage.GetKeySize())
+	rootPage.insertChildEntry(0, nil, leftPage.GetPageID())
+	rootPage.insertChildEntry(1, separator, rightPageID)
+	b.bufferPoolManager.FlushPage(rootPage.GetPageID())
+
+	return nil
+}
+
+// moveUpperHalf returns the first key of the new page as the separator
+func (b *BPlusTree) moveUpperHalf(indexPage *IndexPage) ([]byte, types.Page_id_t, error) {
+	p, err := b.bufferPoolManager.NewPage()
+
+	if err != nil {
+		return nil, constant.INVALID_PAGE_ID, err
+	}
+
+	defer b.bufferPoolManager.UnpinPage(p.GetPageID())
+
+	newPage := GetIndexPage(p)
+	newPage.IndexPageInit(indexPage.IsLeaf(), indexPage.GetKeySize())
+	indexPage.moveEntries(indexPage.GetKeyCount()/2, newPage)
+
+	if indexPage.IsLeaf() {
+		nextPageID := indexPage.GetNextPageID()
+
+		if nextPageID != constant.INVALID_PAGE_ID {
+			nextPage, err := b.bufferPoolManager.FetchPage(nextPageID)
+
+			if err != nil {
+				return nil, constant.INVALID_PAGE_ID, err
+			}
+
+			GetIndexPage(nextPage).SetPrevPageID(newPage.GetPageID())
+			b.bufferPoolManager.FlushPage(nextPageID)
+			b.bufferPoolManager.UnpinPage(nextPageID)
+		}
+
+		newPage.SetNextPageID(nextPageID)
+		newPage.SetPrevPageID(indexPage.GetPageID())
+		indexPage.SetNextPageID(newPage.GetPageID())
+	}
+
+	b.bufferPoolManager.FlushPage(newPage.GetPageID())
+
+	return newPage.GetKey(0), newPage.GetPageID(), nil
+}
@@ -0,0 +1,204 @@
+package index
+
+import (
+	"bytes"
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"os"
+	"sort"
+	"testing"
+)
+
+func Test_BPlusTree(t *testing.T) {
+	fileName := "test_b_plus_tree.db"
+	defer os.Remove(fileName)
+
+	diskManager, err := disk.NewDiskStorage(fileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 64)
+
+	tree, err := NewBPlusTree(bufferPool, types.INT_SIZE)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootPageID := tree.GetRootPageID()
+	count := int32(5000)
+
+	// insert in a shuffled order so the splits happen all over the tree
+	for i := int32(0); i < count; i++ {
+		value := (i * 7919) % count
+		key, _ := EncodeKey([]*tuple.Value{tuple.GetValue(value-count/2, types.INT_TYPE, types.INT_SIZE)})
+
+		if err := tree.Insert(key, types.RID{PageID: types.Page_id_t(value), Index: value}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tree.GetRootPageID() != rootPageID {
+		t.Fatal("root page moved")
+	}
+
+	for i := int32(0); i < count; i++ {
+		key, _ := EncodeKey([]*tuple.Value{tuple.GetValue(i-count/2, types.INT_TYPE, types.INT_SIZE)})
+		rid, found, err := tree.Search(key)
+
+		if err != nil || !found || rid.Index != i {
+			t.Fatalf("key %d not found, got %v %v %v", i-count/2, rid, found, err)
+		}
+	}
+
+	key, _ := EncodeKey([]*tuple.Value{tuple.GetValue(int32(1), types.INT_TYPE, types.INT_SIZE)})
+
+	if err := tree.Insert(key, types.RID{}); err != errors.ErrDuplicateKey {
+		t.Fatal("duplicate key inserted")
+	}
+
+	if err := tree.Delete(key); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, _ := tree.Search(key); found {
+		t.Fatal("deleted key found")
+	}
+
+	if err := tree.Delete(key); err != errors.ErrKeyNotFound {
+		t.Fatal("deleted key deleted again")
+	}
+
+	pageIDs, err := tree.GetPageIDs()
+
+	if err != nil || len(pageIDs) < 3 {
+		t.Fatal("tree did not split", err)
+	}
+
+	if err := tree.Clear(types.INT_SIZE); err != nil {
+		t.Fatal(err)
+	}
+
+	if pageIDs, _ := tree.GetPageIDs(); len(pageIDs) != 1 {
+		t.Fatal("cleared tree still has pages")
+	}
+
+	if err := tree.Insert(key, types.RID{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func Test_EncodeKey(t *testing.T) {
+	values := [][]*tuple.Value{
+		{tuple.GetValue(-1.5, types.FLOAT_TYPE, types.FLOAT_SIZE), tuple.GetValue("b", types.VAR_CHAR_TYPE, 4)},
+		{tuple.GetValue(-0.5, types.FLOAT_TYPE, types.FLOAT_SIZE), tuple.GetValue("a", types.VAR_CHAR_TYPE, 4)},
+		{tuple.GetValue(0.0, types.FLOAT_TYPE, types.FLOAT_SIZE), tuple.GetValue("a", types.VAR_CHAR_TYPE, 4)},
+		{tuple.GetValue(0.0, types.FLOAT_TYPE, types.FLOAT_SIZE), tuple.GetValue("ab", types.VAR_CHAR_TYPE, 4)},
+		{tuple.GetValue(2.0, types.FLOAT_TYPE, types.FLOAT_SIZE), tuple.GetValue("", types.VAR_CHAR_TYPE, 4)},
+	}
+
+	keys := make([][]byte, 0, len(values))
+
+	for _, v := range values {
+		key, ok := EncodeKey(v)
+
+		if !ok {
+			t.Fatal("key not encoded")
+		}
+
+		keys = append(keys, key)
+	}
+
+	if !sort.SliceIsSorted(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 }) {
+		t.Fatal("keys are not ordered like the values")
+	}
+
+	if _, ok := EncodeKey([]*tuple.Value{tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE)}); ok {
+		t.Fatal("NULL key encoded")
+	}
+}
+
+func Test_EncodeDecimalKey(t *testing.T) {
+	// the values of a bare DECIMAL keep their own scale
+	size := int32(types.DECIMAL_SIZE + types.DECIMAL_SCALE_SIZE)
+	texts := []string{"-123.4", "-99.99", "-1.5", "-0.001", "0", "0.0001", "0.5", "1.5", "1.51", "10", "99999999999999999999999999999999999999"}
+	keys := make([][]byte, 0, len(texts))
+
+	for _, text := range texts {
+		key, ok := EncodeKey([]*tuple.Value{tuple.GetValue(text, types.DECIMAL_TYPE, size)})
+
+		if !ok {
+			t.Fatal("key not encoded", text)
+		}
+
+		keys = append(keys, key)
+	}
+
+	if !sort.SliceIsSorted(keys, func(i, j int) bool { return bytes.Compare(keys[i], keys[j]) < 0 }) {
+		t.Fatal("keys are not ordered like the values")
+	}
+
+	for _, pair := range [][]string{{"1.5", "1.500"}, {"-10", "-10.0"}, {"0", "0.00"}} {
+		left, _ := EncodeKey([]*tuple.Value{tuple.GetValue(pair[0], types.DECIMAL_TYPE, size)})
+		right, _ := EncodeKey([]*tuple.Value{tuple.GetValue(pair[1], types.DECIMAL_TYPE, size)})
+
+		if !bytes.Equal(left, right) {
+			t.Error("equal values of another scale should share the key", pair)
+		}
+	}
+}
+
+func Test_BPlusTreeSearchPrefix(t *testing.T) {
+	fileName := "test_b_plus_tree_prefix.db"
+	defer os.Remove(fileName)
+
+	diskManager, err := disk.NewDiskStorage(fileName)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 64)
+
+	tree, err := NewBPlusTree(bufferPool, 2*types.INT_SIZE)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// many keys share a prefix so the matches span several leaves
+	for i := int32(0); i < 3000; i++ {
+		key, _ := EncodeKey([]*tuple.Value{
+			tuple.GetValue(i%3, types.INT_TYPE, types.INT_SIZE),
+			tuple.GetValue(i, types.INT_TYPE, types.INT_SIZE),
+		})
+
+		if err := tree.Insert(key, types.RID{PageID: types.Page_id_t(i % 3), Index: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prefix, _ := EncodeKey([]*tuple.Value{tuple.GetValue(int32(1), types.INT_TYPE, types.INT_SIZE)})
+	rids, err := tree.SearchPrefix(prefix)
+
+	if err != nil || len(rids) != 1000 {
+		t.Fatal("get the wrong number of keys", len(rids), err)
+	}
+
+	for i, rid := range rids {
+		if rid.PageID != 1 || rid.Index != int32(i*3+1) {
+			t.Fatal("get the wrong key in order", i, rid)
+		}
+	}
+
+	prefix, _ = EncodeKey([]*tuple.Value{tuple.GetValue(int32(7), types.INT_TYPE, types.INT_SIZE)})
+
+	if rids, err := tree.SearchPrefix(prefix); err != nil || len(rids) != 0 {
+		t.Error("no key should match", rids, err)
+	}
+}
@@ -0,0 +1,234 @@
+package index
+
+import (
+	"bytes"
+	"encoding/binary"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/page"
+)
+
+/**
+ *  INDEX_PAGE_TYPE
+ *  +-------------+---------------+---------------+-------------+------------+--------------+-------------+
+ *  | PageType (4)| PrevPageId (4)| NextPageId (4)| Checksum (4)| IsLeaf (4) | KeyCount (4) | KeySize (4) |
+ *  +-------------+---------------+---------------+-------------+------------+--------------+-------------+
+ *  +---------------+------------+-----------+-----
+ *  | Key (KeySize) | PageID (4) | Index (4) | ...        leaf entry, the RID of the tuple
+ *  +---------------+------------+-----------+-----
+ *  +---------------+-----------------+-----
+ *  | Key (KeySize) | ChildPageID (4) | ...             internal entry, the key of the first entry is unused
+ *  +---------------+-----------------+-----
+ *
+ *  The entries are sorted by key, the leaves are linked in key order through PrevPageId and NextPageId.
+ */
+
+const (
+	RID_SIZE      = 8
+	CHILD_ID_SIZE = 4
+)
+
+type IndexPage struct {
+	*page.Page
+}
+
+func GetIndexPage(page *page.Page) *IndexPage {
+	return &IndexPage{Page: page}
+}
+
+func (p *IndexPage) IndexPageInit(isLeaf bool, keySize int32) {
+	data := p.GetData()[types.PAGE_TYPE_OFFSET:]
+
+	for i := range data {
+		data[i] = 0
+	}
+
+	p.SetPageType(types.INDEX_PAGE_TYPE)
+	p.SetPrevPageID(constant.INVALID_PAGE_ID)
+	p.SetNextPageID(constant.INVALID_PAGE_ID)
+	p.setLeaf(isLeaf)
+	p.setKeySize(keySize)
+}
+
+func (p *IndexPage) GetPrevPageID() types.Page_id_t {
+	return types.Page_id_t(binary.BigEndian.Uint32(p.GetData()[types.PAGE_TYPE_OFFSET:types.PREV_PAGE_ID_OFFSET]))
+}
+
+func (p *IndexPage) SetPrevPageID(pageID types.Page_id_t) {
+	binary.BigEndian.PutUint32(p.GetData()[types.PAGE_TYPE_OFFSET:types.PREV_PAGE_ID_OFFSET], uint32(pageID))
+}
+
+func (p *IndexPage) GetNextPageID() types.Page_id_t {
+	return types.Page_id_t(binary.BigEndian.Uint32(p.GetData()[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET]))
+}
+
+func (p *IndexPage) SetNextPageID(pageID types.Page_id_t) {
+	binary.BigEndian.PutUint32(p.GetData()[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET], uint32(pageID))
+}
+
+func (p *IndexPage) IsLeaf() bool {
+	return binary.BigEndian.Uint32(p.GetData()[types.PAGE_CHECKSUM_OFFSET:types.INDEX_IS_LEAF_OFFSET]) == 1
+}
+
+func (p *IndexPage) GetKeyCount() int32 {
+	return int32(binary.BigEndian.Uint32(p.GetData()[types.INDEX_IS_LEAF_OFFSET:types.INDEX_KEY_COUNT_OFFSET]))
+}
+
+func (p *IndexPage) GetKeySize() int32 {
+	return int32(binary.BigEndian.Uint32(p.GetData()[types.INDEX_KEY_COUNT_OFFSET:types.INDEX_KEY_SIZE_OFFSET]))
+}
+
+// GetMaxKeyCount keeps one entry free, a page may overflow by one entry before it is split
+func (p *IndexPage) GetMaxKeyCount() int32 {
+	return (constant.PAGE_SIZE-types.INDEX_KEY_SIZE_OFFSET)/p.getEntrySize() - 1
+}
+
+// IsReadable reports whether every entry lies inside the page
+func (p *IndexPage) IsReadable() bool {
+	keySize, keyCount := p.GetKeySize(), p.GetKeyCount()
+
+	return keySize >= 0 && keySize <= MAX_KEY_SIZE && keyCount >= 0 && keyCount <= p.GetMaxKeyCount()
+}
+
+func (p *IndexPage) GetKey(index int32) []byte {
+	offset := p.getEntryOffset(index)
+	key := make([]byte, p.GetKeySize())
+	copy(key, p.GetData()[offset:offset+p.GetKeySize()])
+
+	return key
+}
+
+func (p *IndexPage) GetRID(index int32) types.RID {
+	offset := p.getEntryOffset(index) + p.GetKeySize()
+
+	return types.RID{
+		PageID: types.Page_id_t(binary.BigEndian.Uint32(p.GetData()[offset : offset+4])),
+		Index:  int32(binary.BigEndian.Uint32(p.GetData()[offset+4 : offset+RID_SIZE])),
+	}
+}
+
+func (p *IndexPage) GetChildPageID(index int32) types.Page_id_t {
+	offset := p.getEntryOffset(index) + p.GetKeySize()
+
+	return types.Page_id_t(binary.BigEndian.Uint32(p.GetData()[offset : offset+CHILD_ID_SIZE]))
+}
+
+// lowerBound returns the first entry whose key is not less than the key
+func (p *IndexPage) lowerBound(key []byte) int32 {
+	low, high := int32(0), p.GetKeyCount()
+
+	for low < high {
+		middle := (low + high) / 2
+
+		if bytes.Compare(p.getKey(middle), key) < 0 {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+
+	return low
+}
+
+// getChildIndex returns the last entry whose key is not greater than the key,
+// the first entry covers every key smaller than the second one
+func (p *IndexPage) getChildIndex(key []byte) int32 {
+	low, high := int32(1), p.GetKeyCount()
+
+	for low < high {
+		middle := (low + high) / 2
+
+		if bytes.Compare(p.getKey(middle), key) <= 0 {
+			low = middle + 1
+		} else {
+			high = middle
+		}
+	}
+
+	return low - 1
+}
+
+func (p *IndexPage) insertLeafEntry(index int32, key []byte, rid types.RID) {
+	value := make([]byte, RID_SIZE)
+	binary.BigEndian.PutUint32(value[:4], uint32(rid.PageID))
+	binary.BigEndian.PutUint32(value[4:], uint32(rid.Index))
+	p.insertEntry(index, key, value)
+}
+
+func (p *IndexPage) insertChildEntry(index int32, key []byte, childPageID types.Page_id_t) {
+	value := make([]byte, CHILD_ID_SIZE)
+	binary.BigEndian.PutUint32(value, uint32(childPageID))
+	p.insertEntry(index, key, value)
+}
+
+func (p *IndexPage) insertEntry(index int32, key []byte, value []byte) {
+	keyCount := p.GetKeyCount()
+	data := p.GetData()
+
+	copy(data[p.getEntryOffset(index+1):p.getEntryOffset(keyCount+1)], data[p.getEntryOffset(index):p.getEntryOffset(keyCount)])
+
+	offset := p.getEntryOffset(index)
+	keyData := data[offset : offset+p.GetKeySize()]
+
+	for i := range keyData {
+		keyData[i] = 0
+	}
+
+	copy(keyData, key)
+	copy(data[offset+p.GetKeySize():p.getEntryOffset(index+1)], value)
+	p.setKeyCount(keyCount + 1)
+}
+
+func (p *IndexPage) removeEntry(index int32) {
+	keyCount := p.GetKeyCount()
+	data := p.GetData()
+
+	copy(data[p.getEntryOffset(index):], data[p.getEntryOffset(index+1):p.getEntryOffset(keyCount)])
+	p.setKeyCount(keyCount - 1)
+}
+
+// moveEntries moves the entries from index on to the end of the other page
+func (p *IndexPage) moveEntries(index int32, other *IndexPage) {
+	keyCount, otherCount := p.GetKeyCount(), other.GetKeyCount()
+	moved := keyCount - index
+
+	copy(other.GetData()[other.getEntryOffset(otherCount):], p.GetData()[p.getEntryOffset(index):p.getEntryOffset(keyCount)])
+	other.setKeyCount(otherCount + moved)
+	p.setKeyCount(index)
+}
+
+func (p *IndexPage) getKey(index int32) []byte {
+	offset := p.getEntryOffset(index)
+
+	return p.GetData()[offset : offset+p.GetKeySize()]
+}
+
+func (p *IndexPage) getEntryOffset(index int32) int32 {
+	return types.INDEX_KEY_SIZE_OFFSET + index*p.getEntrySize()
+}
+
+func (p *IndexPage) getEntrySize() int32 {
+	if p.IsLeaf() {
+		return p.GetKeySize() + RID_SIZE
+	}
+
+	return p.GetKeySize() + CHILD_ID_SIZE
+}
+
+func (p *IndexPage) setLeaf(isLeaf bool) {
+	var leaf uint32
+
+	if isLeaf {
+		leaf = 1
+	}
+
+	binary.BigEndian.PutUint32(p.GetData()[types.PAGE_CHECKSUM_OFFSET:types.INDEX_IS_LEAF_OFFSET], leaf)
+}
+
+func (p *IndexPage) setKeyCount(keyCount int32) {
+	binary.BigEndian.PutUint32(p.GetData()[types.INDEX_IS_LEAF_OFFSET:types.INDEX_KEY_COUNT_OFFSET], uint32(keyCount))
+}
+
+func (p *IndexPage) setKeySize(keySize int32) {
+	binary.BigEndian.PutUint32(p.GetData()[types.INDEX_KEY_COUNT_OFFSET:types.INDEX_KEY_SIZE_OFFSET], uint32(keySize))
+}
@@ -0,0 +1,150 @@
+package index
+
+import (
+	"encoding/binary"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"math/big"
+)
+
+/**
+ *  KEY format
+ *  +----------------+----------------+-----+
+ *  | Data 1 Payload | Data 2 Payload | ... |
+ *  +----------------+----------------+-----+
+ *
+ *  The payloads are encoded so that comparing the keys byte by byte orders them like the values,
+ *  the numbers are big endian with the sign bit flipped and VARCHAR is padded with zero bytes.
+ */
+
+const MAX_KEY_SIZE = 1024
+
+// GetKeySize returns the size of the keys built from the columns
+func GetKeySize(columns []*column.Column) (int32, error) {
+	var size int32
+
+	for _, c := range columns {
+		size += c.Size
+	}
+
+	if size > MAX_KEY_SIZE {
+		return 0, errors.ErrKeyTooLarge
+	}
+
+	return size, nil
+}
+
+// EncodeKey returns false when a value is NULL, rows with a NULL key are not indexed
+func EncodeKey(values []*tuple.Value) ([]byte, bool) {
+	key := make([]byte, 0)
+
+	for _, v := range values {
+		if v.IsNull() {
+			return nil, false
+		}
+
+		data := make([]byte, v.GetSize())
+
+		switch v.GetType() {
+		case types.BOOL_TYPE:
+			if v.BOOL {
+				binary.BigEndian.PutUint32(data, 1)
+			}
+		case types.INT_TYPE:
+			binary.BigEndian.PutUint32(data, uint32(v.INT)^(1<<31))
+		case types.LONG_INT_TYPE:
+			binary.BigEndian.PutUint64(data, uint64(v.LONG_INT)^(1<<63))
+		case types.FLOAT_TYPE:
+			bits := math.Float64bits(v.FLOAT)
+
+			// a negative number flips every bit so a larger magnitude sorts first
+			if bits&(1<<63) != 0 {
+				bits = ^bits
+			} else {
+				bits ^= 1 << 63
+			}
+
+			binary.BigEndian.PutUint64(data, bits)
+		case types.VAR_CHAR_TYPE:
+			copy(data, v.VAR_CHAR)
+		case types.JSON_TYPE:
+			copy(data, v.JSON)
+		case types.CHAR_TYPE:
+			copy(data, v.VAR_CHAR)
+		case types.SMALL_INT_TYPE:
+			binary.BigEndian.PutUint16(data, uint16(v.INT)^(1<<15))
+		case types.REAL_TYPE:
+			bits := math.Float32bits(float32(v.FLOAT))
+
+			if bits&(1<<31) != 0 {
+				bits = ^bits
+			} else {
+				bits ^= 1 << 31
+			}
+
+			binary.BigEndian.PutUint32(data, bits)
+		case types.UUID_TYPE:
+			copy(data, v.UUID[:])
+		case types.BYTEA_TYPE:
+			// the length goes last so that the bytes order the keys and a trailing zero byte still counts
+			copy(data, v.BYTEA)
+			binary.BigEndian.PutUint32(data[len(data)-types.BYTEA_LENGTH_SIZE:], uint32(len(v.BYTEA)))
+		case types.DATE_TYPE:
+			binary.BigEndian.PutUint32(data, uint32(v.DATE)^(1<<31))
+		case types.TIME_TYPE:
+			binary.BigEndian.PutUint64(data, uint64(v.TIME)^(1<<63))
+		case types.TIMESTAMP_TYPE:
+			binary.BigEndian.PutUint64(data, uint64(v.TIMESTAMP)^(1<<63))
+		case types.INTERVAL_TYPE:
+			// ordered by months, then days and then microseconds
+			binary.BigEndian.PutUint32(data[0:4], uint32(v.INTERVAL.Months)^(1<<31))
+			binary.BigEndian.PutUint32(data[4:8], uint32(v.INTERVAL.Days)^(1<<31))
+			binary.BigEndian.PutUint64(data[8:16], uint64(v.INTERVAL.Microseconds)^(1<<63))
+		case types.DECIMAL_TYPE:
+			if len(data) > types.DECIMAL_SIZE {
+				putDecimalKey(data, v.DECIMAL)
+				break
+			}
+
+			// the values of a column share its scale so the unscaled values order them
+			tuple.PutDecimal(data, v.DECIMAL)
+			data[0] ^= 0x80
+		}
+
+		key = append(key, data...)
+	}
+
+	return key, true
+}
+
+// putDecimalKey orders the values of a bare DECIMAL, which differ in scale, by the position of
+// their first digit and then by the digits moved to the left, 1.5 and 1.50 get the same key
+func putDecimalKey(data []byte, d tuple.Decimal) {
+	unscaled := d.Rescale(d.Scale).Unscaled
+
+	if unscaled.Sign() == 0 {
+		data[0] = 0x80
+		return
+	}
+
+	digits := d.Digits()
+	exponent := digits - d.Scale
+	mantissa := new(big.Int).Abs(unscaled)
+	mantissa.Mul(mantissa, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(types.MAX_DECIMAL_PRECISION-digits)), nil))
+	mantissa.FillBytes(data[1:])
+
+	if unscaled.Sign() > 0 {
+		data[0] = byte(0xC0 + exponent)
+		return
+	}
+
+	// a negative number with more digits before the point is smaller
+	data[0] = byte(0x40 - exponent)
+
+	for i := 1; i < len(data); i++ {
+		data[i] = ^data[i]
+	}
+}
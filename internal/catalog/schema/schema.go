@@ -13,12 +13,22 @@ import (
 
 /**
 *  META_TABLE_TYPE
-*  +------------+----------------+----------------+------------------+-----------------+----------------+
-*  | PageType(4)| PrevPageID (4) |  NextPageID (4)| Data PageID (4)  | TABLE_NAME (256)|Column count (4)|
-*  +------------+----------------+----------------+------------------+-----------------+----------------+
-*  +-------------------+-----------------+-----------------------+
-*  |Column 1 Name(128) | Column 1 Type(4)| Column 1 Size(4) ...  | ....
-*  +-------------------+-----------------+-----------------------+
+*  +------------+----------------+----------------+-------------+------------------+-----------------+----------------+
+*  | PageType(4)| PrevPageID (4) |  NextPageID (4)| Checksum (4)| Data PageID (4)  | TABLE_NAME (240)|Column count (4)|
+*  +------------+----------------+----------------+-------------+------------------+-----------------+----------------+
+*  +--------------------+-----------------+-----------------+-------------------------+
+*  |Column 1 NameSize(4)| Column 1 Type(4)| Column 1 Size(4)| Column 1 Name(NameSize) | ....
+*  +--------------------+-----------------+-----------------+-------------------------+
+*
+*  When the page is full the following columns continue on the page at NextPageID,
+*  a continuation page only uses the column count and the column entries.
+*
+*  Column Type
+*  +-------------+---------------+-----------------+
+*  | Scale (1)   | Precision (1) | COLUMN_TYPE (2) |
+*  +-------------+---------------+-----------------+
+*
+*  Precision and scale are only set for DECIMAL, the other types keep them zero.
 **/
 
 type Schema struct {
@@ -32,6 +42,39 @@ func GetSchema(page *page.Page) *Schema {
 	}
 }
 
+func (m *Schema) SchemaInit() {
+	m.RLock.Lock()
+	m.SetPageType(types.META_PAGE_TYPE)
+	m.RLock.Unlock()
+
+	m.SetPrevPageID(constant.INVALID_PAGE_ID)
+	m.SetNextPageID(constant.INVALID_PAGE_ID)
+}
+
+func (m *Schema) GetPrevPageID() types.Page_id_t {
+	m.RLock.RLock()
+	defer m.RLock.RUnlock()
+	return types.Page_id_t(binary.BigEndian.Uint32(m.GetData()[types.PAGE_TYPE_OFFSET:types.PREV_PAGE_ID_OFFSET]))
+}
+
+func (m *Schema) SetPrevPageID(pageID types.Page_id_t) {
+	m.RLock.Lock()
+	defer m.RLock.Unlock()
+	binary.BigEndian.PutUint32(m.GetData()[types.PAGE_TYPE_OFFSET:types.PREV_PAGE_ID_OFFSET], uint32(pageID))
+}
+
+func (m *Schema) GetNextPageID() types.Page_id_t {
+	m.RLock.RLock()
+	defer m.RLock.RUnlock()
+	return types.Page_id_t(binary.BigEndian.Uint32(m.GetData()[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET]))
+}
+
+func (m *Schema) SetNextPageID(pageID types.Page_id_t) {
+	m.RLock.Lock()
+	defer m.RLock.Unlock()
+	binary.BigEndian.PutUint32(m.GetData()[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET], uint32(pageID))
+}
+
 func (m *Schema) GetTableName() string {
 	m.RLock.RLock()
 	defer m.RLock.RUnlock()
@@ -41,70 +84,92 @@ func (m *Schema) GetTableName() string {
 func (m *Schema) SetTableName(tableName string) {
 	m.RLock.Lock()
 	defer m.RLock.Unlock()
-	copy(m.GetData()[types.DATA_PAGE_ID_OFFSET:types.TABLE_NAME_OFFSET], []byte(tableName))
+	tableNameData := m.GetData()[types.DATA_PAGE_ID_OFFSET:types.TABLE_NAME_OFFSET]
+
+	for i := range tableNameData {
+		tableNameData[i] = 0
+	}
+
+	copy(tableNameData, []byte(tableName))
 }
 
 func (m *Schema) GetDataPageID() types.Page_id_t {
 	m.RLock.RLock()
 	defer m.RLock.RUnlock()
-	return types.Page_id_t(binary.BigEndian.Uint32(m.GetData()[types.NEXT_PAGE_ID_OFFSET:types.DATA_PAGE_ID_OFFSET]))
+	return types.Page_id_t(binary.BigEndian.Uint32(m.GetData()[types.PAGE_CHECKSUM_OFFSET:types.DATA_PAGE_ID_OFFSET]))
 }
 
 func (m *Schema) SetDataPageID(pageID types.Page_id_t) {
 	m.RLock.Lock()
 	defer m.RLock.Unlock()
-	binary.BigEndian.PutUint32(m.GetData()[types.NEXT_PAGE_ID_OFFSET:types.DATA_PAGE_ID_OFFSET], uint32(pageID))
+	binary.BigEndian.PutUint32(m.GetData()[types.PAGE_CHECKSUM_OFFSET:types.DATA_PAGE_ID_OFFSET], uint32(pageID))
 }
 
 func (m *Schema) GetColumnCount() int32 {
 	m.RLock.RLock()
 	defer m.RLock.RUnlock()
-	return int32(binary.BigEndian.Uint32(m.GetData()[types.TABLE_NAME_OFFSET:types.COLUMN_COUNT]))
+	return m.getColumnCount()
 }
 
 func (m *Schema) SetColumnCount(count int32) {
 	m.RLock.Lock()
 	defer m.RLock.Unlock()
-	binary.BigEndian.PutUint32(m.GetData()[types.TABLE_NAME_OFFSET:types.COLUMN_COUNT], uint32(count))
+	m.setColumnCount(count)
 }
 
-func (m *Schema) AddColumn(column *column.Column) bool {
-	columnCount := m.GetColumnCount()
+// AddColumn returns ErrNoSpace when the page is full, the column should go to the next page
+func (m *Schema) AddColumn(column *column.Column) error {
+	if len(column.Name) > types.COLUMN_NAME_MAX_SIZE {
+		return errors.ErrColumnNameTooLong
+	}
 
 	m.RLock.Lock()
+	defer m.RLock.Unlock()
 
-	columnOffset := int32(types.COLUMN_COUNT) + ((types.COLUMN_SIZE_OFFSET + types.COLUMN_TYPE_OFFSET + types.COLUMN_NAME_OFFSET) * columnCount)
+	columnCount := m.getColumnCount()
+	columnOffset, ok := m.getColumnOffset(columnCount)
 
-	if columnOffset+types.COLUMN_SIZE_OFFSET+types.COLUMN_TYPE_OFFSET+types.COLUMN_NAME_OFFSET > constant.PAGE_SIZE {
-		return false
+	if !ok || columnOffset+types.COLUMN_HEADER_SIZE+int32(len(column.Name)) > constant.PAGE_SIZE {
+		return errors.ErrNoSpace
 	}
 
-	copy(m.GetData()[columnOffset:m.getColumnNameOffset(columnOffset)], []byte(column.Name))
-	binary.BigEndian.PutUint32(m.GetData()[m.getColumnNameOffset(columnOffset):m.getColumnTypeOffset(columnOffset)], uint32(column.ColumnType))
+	binary.BigEndian.PutUint32(m.GetData()[columnOffset:m.getColumnNameSizeOffset(columnOffset)], uint32(len(column.Name)))
+	binary.BigEndian.PutUint32(m.GetData()[m.getColumnNameSizeOffset(columnOffset):m.getColumnTypeOffset(columnOffset)], packColumnType(column))
 	binary.BigEndian.PutUint32(m.GetData()[m.getColumnTypeOffset(columnOffset):m.getColumnSizeOffset(columnOffset)], uint32(column.Size))
-	m.RLock.Unlock()
-	m.SetColumnCount(columnCount + 1)
+	copy(m.GetData()[m.getColumnSizeOffset(columnOffset):], []byte(column.Name))
+	m.setColumnCount(columnCount + 1)
+
+	return nil
+}
+
+// ResetColumns removes every column on this page
+func (m *Schema) ResetColumns() {
+	m.RLock.Lock()
+	defer m.RLock.Unlock()
+
+	columnData := m.GetData()[types.COLUMN_COUNT:]
+
+	for i := range columnData {
+		columnData[i] = 0
+	}
 
-	return true
+	m.setColumnCount(0)
 }
 
+// GetColumns only returns the columns stored on this page
 func (m *Schema) GetColumns() []*column.Column {
 	m.RLock.RLock()
 	defer m.RLock.RUnlock()
 
-	columnCount := m.GetColumnCount()
+	columnCount := m.getColumnCount()
 
 	columns := make([]*column.Column, 0, columnCount)
 
 	columnOffset := int32(types.COLUMN_COUNT)
 
-	for i := 0; i < int(columnCount); i++ {
-		c := &column.Column{
-			Name:       utils.ConvertByteToString(m.GetData()[columnOffset:m.getColumnNameOffset(columnOffset)]),
-			ColumnType: types.COLUMN_TYPE(binary.BigEndian.Uint32(m.GetData()[m.getColumnNameOffset(columnOffset):m.getColumnTypeOffset(columnOffset)])),
-			Size:       int32(binary.BigEndian.Uint32(m.GetData()[m.getColumnTypeOffset(columnOffset):m.getColumnSizeOffset(columnOffset)])),
-		}
-		columnOffset += (types.COLUMN_NAME_OFFSET + types.COLUMN_TYPE_OFFSET + types.COLUMN_SIZE_OFFSET)
+	for i := int32(0); i < columnCount; i++ {
+		c := m.getColumn(columnOffset)
+		columnOffset = m.getColumnSizeOffset(columnOffset) + int32(len(c.Name))
 		columns = append(columns, c)
 	}
 
@@ -115,29 +180,91 @@ func (m *Schema) GetColumnByIndex(index int32) (*column.Column, error) {
 	m.RLock.RLock()
 	defer m.RLock.RUnlock()
 
-	if m.GetColumnCount() < index {
+	if index < 0 || m.getColumnCount() <= index {
+		return nil, errors.ErrColumnIndexOutOfRange
+	}
+
+	columnOffset, ok := m.getColumnOffset(index)
+
+	if !ok {
 		return nil, errors.ErrColumnIndexOutOfRange
 	}
 
-	columnOffset := int32(types.COLUMN_COUNT) + ((types.COLUMN_SIZE_OFFSET + types.COLUMN_TYPE_OFFSET + types.COLUMN_NAME_OFFSET) * index)
+	return m.getColumn(columnOffset), nil
+}
+
+// IsReadable reports whether every column entry lies inside the page
+func (m *Schema) IsReadable() bool {
+	m.RLock.RLock()
+	defer m.RLock.RUnlock()
+
+	columnCount := m.getColumnCount()
+
+	if columnCount < 0 {
+		return false
+	}
+
+	_, ok := m.getColumnOffset(columnCount)
+
+	return ok
+}
+
+// getColumnOffset walks the variable sized entries to the column at index
+func (m *Schema) getColumnOffset(index int32) (int32, bool) {
+	columnOffset := int32(types.COLUMN_COUNT)
 
-	c := &column.Column{
-		Name:       utils.ConvertByteToString(m.GetData()[columnOffset:m.getColumnNameOffset(columnOffset)]),
-		ColumnType: types.COLUMN_TYPE(binary.BigEndian.Uint32(m.GetData()[m.getColumnNameOffset(columnOffset):m.getColumnTypeOffset(columnOffset)])),
+	for i := int32(0); i < index; i++ {
+		if columnOffset+types.COLUMN_HEADER_SIZE > constant.PAGE_SIZE {
+			return -1, false
+		}
+
+		nameSize := int32(binary.BigEndian.Uint32(m.GetData()[columnOffset:m.getColumnNameSizeOffset(columnOffset)]))
+
+		if nameSize < 0 || nameSize > types.COLUMN_NAME_MAX_SIZE {
+			return -1, false
+		}
+
+		columnOffset = m.getColumnSizeOffset(columnOffset) + nameSize
+	}
+
+	return columnOffset, columnOffset <= constant.PAGE_SIZE
+}
+
+func (m *Schema) getColumn(columnOffset int32) *column.Column {
+	nameSize := int32(binary.BigEndian.Uint32(m.GetData()[columnOffset:m.getColumnNameSizeOffset(columnOffset)]))
+	nameOffset := m.getColumnSizeOffset(columnOffset)
+
+	columnType := binary.BigEndian.Uint32(m.GetData()[m.getColumnNameSizeOffset(columnOffset):m.getColumnTypeOffset(columnOffset)])
+
+	return &column.Column{
+		Name:       string(m.GetData()[nameOffset : nameOffset+nameSize]),
+		ColumnType: types.COLUMN_TYPE(columnType & 0xFFFF),
 		Size:       int32(binary.BigEndian.Uint32(m.GetData()[m.getColumnTypeOffset(columnOffset):m.getColumnSizeOffset(columnOffset)])),
+		Precision:  int32(columnType >> 16 & 0xFF),
+		Scale:      int32(columnType >> 24),
 	}
+}
+
+func packColumnType(c *column.Column) uint32 {
+	return uint32(c.ColumnType) | uint32(c.Precision)<<16 | uint32(c.Scale)<<24
+}
 
-	return c, nil
+func (m *Schema) getColumnCount() int32 {
+	return int32(binary.BigEndian.Uint32(m.GetData()[types.TABLE_NAME_OFFSET:types.COLUMN_COUNT]))
+}
+
+func (m *Schema) setColumnCount(count int32) {
+	binary.BigEndian.PutUint32(m.GetData()[types.TABLE_NAME_OFFSET:types.COLUMN_COUNT], uint32(count))
 }
 
-func (m *Schema) getColumnNameOffset(columnOffset int32) int32 {
-	return columnOffset + types.COLUMN_NAME_OFFSET
+func (m *Schema) getColumnNameSizeOffset(columnOffset int32) int32 {
+	return columnOffset + types.COLUMN_NAME_SIZE_OFFSET
 }
 
 func (m *Schema) getColumnTypeOffset(columnOffset int32) int32 {
-	return columnOffset + types.COLUMN_NAME_OFFSET + types.COLUMN_TYPE_OFFSET
+	return columnOffset + types.COLUMN_NAME_SIZE_OFFSET + types.COLUMN_TYPE_OFFSET
 }
 
 func (m *Schema) getColumnSizeOffset(columnOffset int32) int32 {
-	return columnOffset + types.COLUMN_NAME_OFFSET + types.COLUMN_TYPE_OFFSET + types.COLUMN_SIZE_OFFSET
+	return columnOffset + types.COLUMN_NAME_SIZE_OFFSET + types.COLUMN_TYPE_OFFSET + types.COLUMN_SIZE_OFFSET
 }
@@ -1,11 +1,14 @@
 package schema
 
 import (
+	"fmt"
 	"go-db/internal/buffer"
 	"go-db/internal/catalog/column"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"go-db/internal/storage/disk"
 	"log"
+	"strings"
 	"testing"
 )
 
@@ -64,6 +67,7 @@ func Test_SetSchema(t *testing.T) {
 	columns = append(columns, column.NewColumn(types.INT_TYPE, 0, "int_types"))
 	columns = append(columns, column.NewColumn(types.LONG_INT_TYPE, 0, "long_int_type"))
 	columns = append(columns, column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type"))
+	columns = append(columns, column.NewDecimalColumn(38, 12, "decimal_type"))
 
 	for _, c := range columns {
 		schema.AddColumn(c)
@@ -101,6 +105,10 @@ func Test_SetSchema(t *testing.T) {
 		if c.Size != columns[i].Size {
 			t.Error("column size not equal")
 		}
+
+		if c.Precision != columns[i].Precision || c.Scale != columns[i].Scale {
+			t.Error("column precision or scale not equal")
+		}
 	}
 
 	columnIndexTwo, err := schema.GetColumnByIndex(2)
@@ -121,7 +129,7 @@ func Test_SetSchema(t *testing.T) {
 		t.Error("column index two size not equal")
 	}
 
-	_, err = schema.GetColumnByIndex(6)
+	_, err = schema.GetColumnByIndex(7)
 
 	if err == nil {
 		t.Error("getColumnByIndex not catch overflow error")
@@ -208,10 +216,10 @@ func Test_SchemaOverflow(t *testing.T) {
 		schema.AddColumn(c)
 	}
 
-	overflow := schema.AddColumn(column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type_21"))
+	err = schema.AddColumn(column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type_21"))
 
-	if !overflow {
-		t.Error("detect overflow error")
+	if err != nil {
+		t.Error("detect overflow error", err)
 	}
 
 	columnCount = schema.GetColumnCount()
@@ -220,3 +228,58 @@ func Test_SchemaOverflow(t *testing.T) {
 		t.Error("add column wrong not match the expect columns len")
 	}
 }
+
+func Test_SchemaFull(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	newPage, err := bufferPool.NewPage()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := GetSchema(newPage)
+	schema.SchemaInit()
+
+	longName := strings.Repeat("c", 500)
+
+	for i := 0; ; i++ {
+		err = schema.AddColumn(column.NewColumn(types.INT_TYPE, 0, fmt.Sprintf("%s_%d", longName, i)))
+
+		if err == errors.ErrNoSpace {
+			break
+		}
+
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if !schema.IsReadable() {
+		t.Error("full schema page should be readable")
+	}
+
+	columns := schema.GetColumns()
+
+	if len(columns) != 7 || columns[6].Name != longName+"_6" || columns[6].ColumnType != types.INT_TYPE {
+		t.Error("long column names wrong", len(columns))
+	}
+
+	err = schema.AddColumn(column.NewColumn(types.INT_TYPE, 0, strings.Repeat("c", types.COLUMN_NAME_MAX_SIZE+1)))
+
+	if err != errors.ErrColumnNameTooLong {
+		t.Error("column name too long not detected", err)
+	}
+
+	schema.ResetColumns()
+
+	if schema.GetColumnCount() != 0 || schema.AddColumn(columns[0]) != nil {
+		t.Error("reset columns wrong")
+	}
+}
@@ -0,0 +1,75 @@
+package sequence
+
+import (
+	"go-db/internal/common/errors"
+	"math"
+)
+
+// Sequence hands out the numbers from Start by Increment between MinValue and MaxValue.
+// The sequence of a SERIAL column is owned by the column and dropped with it
+type Sequence struct {
+	Name      string
+	Start     int64
+	Increment int64
+	MinValue  int64
+	MaxValue  int64
+	Cycle     bool
+
+	OwnerTable  string
+	OwnerColumn string
+}
+
+// NewSequence counts up from 1 like PostgreSQL, a negative increment counts down from -1
+func NewSequence(name string, increment int64) *Sequence {
+	if increment < 0 {
+		return &Sequence{Name: name, Start: -1, Increment: increment, MinValue: math.MinInt64, MaxValue: -1}
+	}
+
+	return &Sequence{Name: name, Start: 1, Increment: increment, MinValue: 1, MaxValue: math.MaxInt64}
+}
+
+// NewSerial is the sequence behind a SERIAL column, it is named table_column_seq
+// and stops at the largest value of the column type
+func NewSerial(tableName string, columnName string, maxValue int64) *Sequence {
+	s := NewSequence(GetSerialName(tableName, columnName), 1)
+	s.MaxValue = maxValue
+	s.OwnerTable = tableName
+	s.OwnerColumn = columnName
+
+	return s
+}
+
+func GetSerialName(tableName string, columnName string) string {
+	return tableName + "_" + columnName + "_seq"
+}
+
+func (s *Sequence) Check() error {
+	if s.Increment == 0 || s.MinValue >= s.MaxValue || s.Start < s.MinValue || s.Start > s.MaxValue {
+		return errors.ErrInvalidSequence
+	}
+
+	return nil
+}
+
+// Next returns the value which follows the given one, a sequence which cycles starts again
+// at the other end and ok is false when the value was the last one of the sequence
+func (s *Sequence) Next(value int64) (next int64, ok bool) {
+	// the distance to the limit is counted unsigned so that it can not overflow
+	if s.Increment > 0 && uint64(s.MaxValue-value) >= uint64(s.Increment) {
+		return value + s.Increment, true
+	}
+
+	if s.Increment < 0 && uint64(value-s.MinValue) >= uint64(-s.Increment) {
+		return value + s.Increment, true
+	}
+
+	if !s.Cycle {
+		return 0, false
+	}
+
+	if s.Increment > 0 {
+		return s.MinValue, true
+	}
+
+	return s.MaxValue, true
+}
@@ -0,0 +1,208 @@
+package table
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+)
+
+/**
+ *  CHECK and DEFAULT keep their expression as text in the definition column of sys_constraints.
+ *
+ *  A CHECK is evaluated against every row which is written and only FALSE rejects the row,
+ *  its column_names are the columns the condition references. A DEFAULT belongs to one column
+ *  and can not reference any column, it is evaluated once for every statement which uses it.
+ */
+
+// GetDefaultValues evaluates the DEFAULT of every column which is not given, a column without
+// one is NULL. The given columns stay NULL so that a nextval is not used up for them
+func (t *TableManager) GetDefaultValues(tableName string, given []bool) ([]*tuple.Value, error) {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]*tuple.Value, len(columns))
+
+	for i, c := range columns {
+		values[i] = tuple.GetNullValue(c.ColumnType, c.Size)
+	}
+
+	for _, c := range constraints {
+		if c.Type != types.CONSTRAINT_DEFAULT {
+			continue
+		}
+
+		position := findColumn(columns, c.Columns[0])
+
+		if position == -1 {
+			return nil, errors.ErrColumnNotExist
+		}
+
+		if given != nil && given[position] {
+			continue
+		}
+
+		if values[position], err = evaluateDefault(c.Expression, columns[position], t); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// checkExpression parses the expression of a CHECK, DEFAULT or index and keeps it in the form
+// the parser prints, a CHECK or an index takes the columns it references and a DEFAULT has
+// to give a value of the type of its column
+func checkExpression(columns []*column.Column, c *constraint.Constraint) error {
+	expr, err := expression.ParseText(c.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	if expression.HasSubquery(expr) {
+		return errors.ErrSubquery
+	}
+
+	c.Expression = expr.String()
+
+	if c.Type == types.CONSTRAINT_CHECK {
+		c.Columns = expression.GetColumnNames(expr)
+		return nil
+	}
+
+	// an index on a constant would give every row the same key
+	if c.IsExpressionIndex() {
+		if c.Columns = expression.GetColumnNames(expr); len(c.Columns) == 0 {
+			return errors.ErrSyntax
+		}
+
+		return nil
+	}
+
+	if len(c.Columns) != 1 {
+		return errors.ErrSyntax
+	}
+
+	if len(expression.GetColumnNames(expr)) != 0 {
+		return errors.ErrInvalidDefault
+	}
+
+	position := findColumn(columns, c.Columns[0])
+
+	if position == -1 {
+		return errors.ErrColumnNotExist
+	}
+
+	return checkDefault(expr, columns[position])
+}
+
+// checkDefault evaluates the DEFAULT to see whether it fits the column type,
+// a DEFAULT which calls nextval is not evaluated so that it does not use up a value
+func checkDefault(expr expression.Expression, c *column.Column) error {
+	if expression.UsesSequence(expr) {
+		return nil
+	}
+
+	_, err := evaluateDefault(expr.String(), c, nil)
+
+	return err
+}
+
+func evaluateDefault(text string, c *column.Column, sequences expression.Sequences) (*tuple.Value, error) {
+	expr, err := expression.ParseText(text)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := expr.Evaluate(&expression.Row{Sequences: sequences})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if value, err = tuple.ConvertValue(value, c.ColumnType, c.Size); err != nil {
+		return nil, err
+	}
+
+	return tuple.FitValue(value, c)
+}
+
+// checkCondition fails when the condition of a CHECK is FALSE, NULL passes
+func checkCondition(c *tableConstraint, values []*tuple.Value) error {
+	if c.check == nil {
+		return nil
+	}
+
+	pass, err := c.check.Evaluate(expression.NewRow(c.columns, values))
+
+	if err != nil {
+		return err
+	}
+
+	if pass.IsNull() {
+		return nil
+	}
+
+	if pass.GetType() != types.BOOL_TYPE {
+		return errors.ErrTypeMismatch
+	}
+
+	if !pass.BOOL {
+		return &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrCheckViolation}
+	}
+
+	return nil
+}
+
+// checkColumnDefault makes sure the DEFAULT of the column still fits the new column type
+func (t *TableManager) checkColumnDefault(tableName string, newColumn *column.Column) error {
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, c := range constraints {
+		if c.Type != types.CONSTRAINT_DEFAULT || c.Columns[0] != newColumn.Name {
+			continue
+		}
+
+		expr, err := expression.ParseText(c.Expression)
+
+		if err != nil {
+			return err
+		}
+
+		if err := checkDefault(expr, newColumn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameCheckColumn rewrites the references to the column in the condition of a CHECK
+func renameCheckColumn(definition string, columnName string, newColumnName string) (string, error) {
+	expr, err := expression.ParseText(definition)
+
+	if err != nil {
+		return "", err
+	}
+
+	expression.RenameColumn(expr, columnName, newColumnName)
+
+	return expr.String(), nil
+}
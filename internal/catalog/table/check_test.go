@@ -0,0 +1,202 @@
+package table
+
+import (
+	stderrors "errors"
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"log"
+	"os"
+	"testing"
+)
+
+func Test_CheckConstraints(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("check_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("check_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "a"),
+		column.NewColumn(types.INT_TYPE, 0, "b"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 8, "c"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewExpressionConstraint("", types.CONSTRAINT_CHECK, nil, "a > 0"),
+		constraint.NewExpressionConstraint("", types.CONSTRAINT_CHECK, nil, "a < b"),
+		constraint.NewExpressionConstraint("", types.CONSTRAINT_DEFAULT, []string{"b"}, "10 * 10"),
+		constraint.NewExpressionConstraint("", types.CONSTRAINT_DEFAULT, []string{"c"}, "'none'"),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("testTable", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := tableManager.GetConstraints("testTable")
+
+	if err != nil || len(loaded) != 4 || loaded[0].Name != "testTable_a_check" || loaded[1].Name != "testTable_a_b_check" {
+		t.Fatal("CHECK should be named after its columns", loaded, err)
+	}
+
+	values, err := tableManager.GetDefaultValues("testTable", nil)
+
+	if err != nil || !values[0].IsNull() || values[1].INT != 100 || string(values[2].VAR_CHAR) != "none" {
+		t.Fatal("get the wrong defaults", values, err)
+	}
+
+	checkViolation := func(err error, constraintName string) {
+		t.Helper()
+
+		constraintError, ok := err.(*errors.ConstraintError)
+
+		if !ok || constraintError.Err != errors.ErrCheckViolation || constraintError.Constraint != constraintName {
+			t.Error("should violate", constraintName, err)
+		}
+	}
+
+	checkViolation(tableManager.InsertTuple("testTable", newRow(columns, int32(0), int32(1), "x")), "testTable_a_check")
+	checkViolation(tableManager.InsertTuple("testTable", newRow(columns, int32(2), int32(1), "x")), "testTable_a_b_check")
+
+	// a condition which is NULL does not reject the row
+	values[0] = tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE)
+
+	if err := tableManager.InsertTuple("testTable", values); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", newRow(columns, int32(1), int32(2), "x")); err != nil {
+		t.Fatal(err)
+	}
+
+	rids, _, err := tableManager.GetTuplesWithRID("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkViolation(tableManager.UpdateTuple("testTable", rids[1], newRow(columns, int32(3), int32(2), "x")), "testTable_a_b_check")
+
+	// the conditions follow the renamed column
+	if err := tableManager.RenameColumn("testTable", "a", "d"); err != nil {
+		t.Fatal(err)
+	}
+
+	columns, _ = tableManager.GetTableMeta("testTable")
+
+	checkViolation(tableManager.InsertTuple("testTable", newRow(columns, int32(-1), int32(2), "x")), "testTable_a_check")
+
+	if loaded, _ := tableManager.GetConstraints("testTable"); loaded[1].Expression != "(d < b)" {
+		t.Error("CHECK should reference the renamed column", loaded[1].Expression)
+	}
+
+	if err := tableManager.AlterColumnType("testTable", column.NewColumn(types.INT_TYPE, 0, "c")); !stderrors.Is(err, errors.ErrInvalidValue) {
+		t.Error("DEFAULT should fit the new column type", err)
+	}
+
+	if err := tableManager.AddNewColumnWithDefault("testTable", column.NewColumn(types.INT_TYPE, 0, "e"), int32(7)); err != nil {
+		t.Fatal(err)
+	}
+
+	if values, _ := tableManager.GetDefaultValues("testTable", nil); values[3].INT != 7 {
+		t.Error("added column should keep its DEFAULT", values[3])
+	}
+
+	rows, err := tableManager.GetTuples(types.INFORMATION_SCHEMA_COLUMNS)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaults := make(map[string]string)
+
+	for _, values := range rows {
+		if string(values[0].VAR_CHAR) == "testTable" && !values[5].IsNull() {
+			defaults[string(values[1].VAR_CHAR)] = string(values[5].VAR_CHAR)
+		}
+	}
+
+	if len(defaults) != 3 || defaults["b"] != "(10 * 10)" || defaults["e"] != "7" {
+		t.Error("information_schema should show the defaults", defaults)
+	}
+
+	// like PostgreSQL, every CHECK which references the column is dropped with it
+	if err := tableManager.DropColumn("testTable", "b"); err != nil {
+		t.Fatal(err)
+	}
+
+	if loaded, _ := tableManager.GetConstraints("testTable"); len(loaded) != 3 || loaded[0].Name != "testTable_a_check" {
+		t.Error("CHECK and DEFAULT of the column should be dropped", loaded)
+	}
+
+	columns, _ = tableManager.GetTableMeta("testTable")
+
+	if err := tableManager.InsertTuple("testTable", newRow(columns, int32(5), "x", int32(0))); err != nil {
+		t.Error("dropped CHECK should not be evaluated", err)
+	}
+}
+
+func Test_SetDefaultForeignKey(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("set_default_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("set_default_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	parent := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "id")}
+	child := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "id"), column.NewColumn(types.INT_TYPE, 0, "parent")}
+
+	if err := tableManager.CreateNewTableWithConstraints("parent", parent, []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"id"}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("child", child, []*constraint.Constraint{
+		constraint.NewForeignKey("", []string{"parent"}, "parent", nil, types.FOREIGN_KEY_SET_DEFAULT, ""),
+		constraint.NewExpressionConstraint("", types.CONSTRAINT_DEFAULT, []string{"parent"}, "1"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []int32{1, 2} {
+		if err := tableManager.InsertTuple("parent", newRow(parent, id)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tableManager.InsertTuple("child", newRow(child, int32(10), int32(2))); err != nil {
+		t.Fatal(err)
+	}
+
+	rids, _, err := tableManager.GetTuplesWithRID("parent")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DeleteTuple("parent", rids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("child"); tuples[0][1].INT != 1 {
+		t.Error("child should take the DEFAULT of its column", tuples[0][1])
+	}
+}
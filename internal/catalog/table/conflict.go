@@ -0,0 +1,231 @@
+package table
+
+import (
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"sort"
+)
+
+/**
+ *  INSERT ... ON CONFLICT
+ *  +-----------------------------+----------------------------------------------------+
+ *  | Conflict target             | Arbiters                                           |
+ *  +-----------------------------+----------------------------------------------------+
+ *  | (column1, column2)          | the unique constraints on exactly these columns    |
+ *  | ON CONSTRAINT name          | the unique constraint of the name                  |
+ *  | none                        | every unique constraint, only with DO NOTHING      |
+ *  +-----------------------------+----------------------------------------------------+
+ *
+ *  A row conflicts when the index of an arbiter holds its key. The search and the insert, or
+ *  the update of the row which holds the key, happen under the write lock of the table which
+ *  INSERT, UPDATE and DELETE take as well, so two statements can not both write the key. The
+ *  foreign key actions run on the child rows after the lock is released. A key with a NULL
+ *  value never conflicts, and a conflict in a constraint which is not an arbiter is still a
+ *  unique violation.
+ */
+
+// ConflictUpdate returns the new values of the row which holds the key, nil leaves the row as it was
+type ConflictUpdate func(rid types.RID, existing []*tuple.Value) ([]*tuple.Value, error)
+
+// GetArbiters returns the names of the unique constraints which decide a conflict,
+// nil without a target stands for all of them
+func (t *TableManager) GetArbiters(tableName string, columns []string, constraintName string) ([]string, error) {
+	if len(columns) == 0 && constraintName == "" {
+		return nil, nil
+	}
+
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	target := sortedNames(columns)
+	arbiters := make([]string, 0)
+
+	for _, c := range constraints {
+		if !c.IsUnique() {
+			continue
+		}
+
+		if constraintName != "" && c.Name == constraintName {
+			return []string{c.Name}, nil
+		}
+
+		if constraintName == "" && !c.IsExpressionIndex() && equalNames(sortedNames(c.Columns), target) {
+			arbiters = append(arbiters, c.Name)
+		}
+	}
+
+	if len(arbiters) == 0 {
+		return nil, errors.ErrNoConflictTarget
+	}
+
+	return arbiters, nil
+}
+
+// CheckProposedRows fits every row to the columns and checks NOT NULL and CHECK, like in
+// PostgreSQL these hold for a proposed row whether it conflicts or not
+func (t *TableManager) CheckProposedRows(tableName string, rows [][]*tuple.Value) error {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return err
+	}
+
+	for _, values := range rows {
+		if err := tuple.FitValues(columns, values); err != nil {
+			return err
+		}
+
+		for _, c := range constraints {
+			if err := checkNotNull(c, values); err != nil {
+				return err
+			}
+
+			if err := checkCondition(c, values); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// InsertOrConflict writes the row unless an arbiter already holds its key, then update decides
+// the new values of the row which holds the key and nil update skips it. It returns the RID and
+// the values written, nil when nothing was written. Without a conflict the RID is the one the
+// row was written to.
+func (t *TableManager) InsertOrConflict(tableName string, values []*tuple.Value, arbiters []string, update ConflictUpdate) (types.RID, []*tuple.Value, bool, error) {
+	if IsSystemTable(tableName) {
+		return types.RID{PageID: constant.INVALID_PAGE_ID}, nil, false, errors.ErrSystemTable
+	}
+
+	lock := t.getWriteLock(tableName)
+	lock.Lock()
+	rid, newValues, conflict, found, err := t.insertOrConflict(tableName, values, arbiters, update)
+	lock.Unlock()
+
+	if err != nil {
+		return rid, nil, conflict, err
+	}
+
+	return rid, newValues, conflict, t.runActions(found, newValues)
+}
+
+// insertOrConflict needs the write lock of the table, it returns the rows the ON UPDATE
+// actions change when the row which holds the key was updated
+func (t *TableManager) insertOrConflict(tableName string, values []*tuple.Value, arbiters []string, update ConflictUpdate) (types.RID, []*tuple.Value, bool, []*referencingRows, error) {
+	rid := types.RID{PageID: constant.INVALID_PAGE_ID}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	if err := tuple.FitValues(columns, values); err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	for _, c := range constraints {
+		if c.tree == nil || !c.IsUnique() || (arbiters != nil && !containsName(arbiters, c.Name)) {
+			continue
+		}
+
+		key, ok, err := c.getKey(values)
+
+		if err != nil {
+			return rid, nil, false, nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		found, exist, err := c.tree.Search(key)
+
+		if err != nil {
+			return rid, nil, false, nil, err
+		}
+
+		if exist {
+			newValues, references, err := t.updateConflict(tableName, found, update)
+
+			return found, newValues, true, references, err
+		}
+	}
+
+	keys, err := checkRow(constraints, values, nil)
+
+	if err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	if rid, err = t.insertTuple(tableName, values); err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	if err := insertKeys(constraints, keys, rid); err != nil {
+		return rid, nil, false, nil, err
+	}
+
+	return rid, values, false, nil, nil
+}
+
+func (t *TableManager) updateConflict(tableName string, rid types.RID, update ConflictUpdate) ([]*tuple.Value, []*referencingRows, error) {
+	if update == nil {
+		return nil, nil, nil
+	}
+
+	existing, err := t.GetTuple(tableName, rid)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	newValues, err := update(rid, existing)
+
+	if err != nil || newValues == nil {
+		return nil, nil, err
+	}
+
+	found, err := t.updateRow(tableName, rid, newValues)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return newValues, found, nil
+}
+
+func sortedNames(names []string) []string {
+	sorted := append([]string{}, names...)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,211 @@
+package table
+
+import (
+	stderrors "errors"
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"log"
+	"os"
+	"sync"
+	"testing"
+)
+
+func Test_InsertOrConflict(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("conflict_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("conflict_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "k"),
+		column.NewColumn(types.INT_TYPE, 0, "n"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"k"}),
+		constraint.NewExpressionConstraint("n_check", types.CONSTRAINT_CHECK, nil, "n > 0"),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("counter", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	arbiters, err := tableManager.GetArbiters("counter", []string{"k"}, "")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	increment := func(rid types.RID, existing []*tuple.Value) ([]*tuple.Value, error) {
+		return newRow(columns, int32(1), existing[1].INT+1), nil
+	}
+
+	if err := tableManager.CheckProposedRows("counter", [][]*tuple.Value{newRow(columns, int32(1), int32(1)), newRow(columns, int32(2), int32(0))}); err == nil {
+		t.Error("a proposed row should fail the check")
+	}
+
+	// every upsert of the key either inserts it once or adds one to the row which holds it
+	var group sync.WaitGroup
+	var lock sync.Mutex
+	inserted := 0
+
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			for j := 0; j < 25; j++ {
+				_, values, conflict, err := tableManager.InsertOrConflict("counter", newRow(columns, int32(1), int32(1)), arbiters, increment)
+
+				lock.Lock()
+
+				if err != nil || values == nil {
+					t.Error("upsert should write the row", err)
+				}
+
+				if !conflict {
+					inserted++
+				}
+
+				lock.Unlock()
+			}
+		}()
+	}
+
+	group.Wait()
+
+	tuples, err := tableManager.GetTuples("counter")
+
+	if err != nil || len(tuples) != 1 || tuples[0][1].INT != 200 || inserted != 1 {
+		t.Error("the key should be inserted once and updated by every other upsert", len(tuples), inserted, err)
+	}
+
+	// nil update leaves the row which holds the key as it was
+	if _, values, conflict, err := tableManager.InsertOrConflict("counter", newRow(columns, int32(1), int32(1)), arbiters, nil); err != nil || !conflict || values != nil {
+		t.Error("the conflicting row should be skipped", values, conflict, err)
+	}
+
+	if _, _, _, err := tableManager.InsertOrConflict(types.SYSTEM_TABLES, nil, nil, nil); err != errors.ErrSystemTable {
+		t.Error("a system table should not be written", err)
+	}
+
+	diskManager.ShutDown()
+}
+
+func Test_UpdateAgainstInsert(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("update_insert_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("update_insert_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "k"),
+		column.NewColumn(types.INT_TYPE, 0, "u"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"k"}),
+		constraint.NewConstraint("u_key", types.CONSTRAINT_UNIQUE, []string{"u"}),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("pairs", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	const count = 100
+
+	for i := int32(1); i <= count; i++ {
+		if err := tableManager.InsertTuple("pairs", newRow(columns, 1000+i, -i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rids, _, err := tableManager.GetTuplesWithRID("pairs")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an UPDATE and an INSERT write the same unique key, only one of them may win it
+	var group sync.WaitGroup
+	var lock sync.Mutex
+	written := 0
+
+	write := func(apply func(i int32) error) {
+		defer group.Done()
+
+		for i := int32(1); i <= count; i++ {
+			err := apply(i)
+
+			lock.Lock()
+
+			if err == nil {
+				written++
+			} else if !stderrors.Is(err, errors.ErrUniqueViolation) {
+				t.Error("write should only fail on the key", i, err)
+			}
+
+			lock.Unlock()
+		}
+	}
+
+	group.Add(2)
+
+	go write(func(i int32) error {
+		return tableManager.UpdateTuple("pairs", rids[i-1], newRow(columns, 1000+i, i))
+	})
+
+	go write(func(i int32) error {
+		return tableManager.InsertTuple("pairs", newRow(columns, i, i))
+	})
+
+	group.Wait()
+
+	tuples, err := tableManager.GetTuples("pairs")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seen := make(map[int32]bool)
+
+	for _, values := range tuples {
+		if seen[values[1].INT] {
+			t.Error("the key should be written once", values[1].INT)
+		}
+
+		seen[values[1].INT] = true
+	}
+
+	for i := int32(1); i <= count; i++ {
+		if !seen[i] {
+			t.Error("one of the writes of the key should win", i)
+		}
+	}
+
+	if written != count {
+		t.Error("only one of the writes of every key should win", written)
+	}
+
+	diskManager.ShutDown()
+}
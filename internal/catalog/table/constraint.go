@@ -0,0 +1,1012 @@
+package table
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/index"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strconv"
+)
+
+/**
+ *  The constraints of a table are rows of sys_constraints, PRIMARY KEY and UNIQUE
+ *  also have a row in sys_indexes with the root page of their B+ tree.
+ *
+ *  A row is checked against every constraint before it is written and the index
+ *  keys are written after the tuple, a key with a NULL value is not indexed.
+ *
+ *  A FOREIGN KEY has no index of its own, its index_name is the constraint of the
+ *  referenced table and its definition keeps the referenced columns and actions.
+ */
+
+// tableConstraint is a constraint with the positions of its columns in the tuple,
+// a FOREIGN KEY which references its own table also knows the referenced positions
+// and a CHECK or an expression index keeps its parsed expression with the columns
+// it is evaluated against
+type tableConstraint struct {
+	*constraint.Constraint
+	positions    []int
+	tree         *index.BPlusTree
+	reference    *index.BPlusTree
+	refPositions []int
+	check        expression.Expression
+	indexed      expression.Expression
+	columns      []*column.Column
+}
+
+// GetConstraints returns the constraints of the table in the order they were created
+func (t *TableManager) GetConstraints(tableName string) ([]*constraint.Constraint, error) {
+	if _, err := t.getMetaPageID(tableName); err != nil {
+		return nil, errors.ErrNoTable
+	}
+
+	rows, err := t.GetTuples(types.SYSTEM_CONSTRAINTS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	constraints := make([]*constraint.Constraint, 0)
+
+	for _, values := range rows {
+		if string(values[1].VAR_CHAR) != tableName {
+			continue
+		}
+
+		c, err := newConstraintFromRow(values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		constraints = append(constraints, c)
+	}
+
+	return constraints, nil
+}
+
+// newConstraintFromRow reads a row of sys_constraints
+func newConstraintFromRow(values []*tuple.Value) (*constraint.Constraint, error) {
+	c := constraint.NewConstraint(
+		string(values[0].VAR_CHAR),
+		string(values[2].VAR_CHAR),
+		constraint.SplitColumns(string(values[3].VAR_CHAR)),
+	)
+
+	if c.Type == types.CONSTRAINT_FOREIGN_KEY {
+		c.RefIndex = string(values[4].VAR_CHAR)
+	}
+
+	if err := c.SetDefinition(string(values[5].VAR_CHAR)); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// CreateNewTableWithConstraints fills in the default names of the constraints without one
+func (t *TableManager) CreateNewTableWithConstraints(tableName string, columns []*column.Column, constraints []*constraint.Constraint) error {
+	if IsSystemTable(tableName) || IsInformationSchema(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	if _, err := t.getMetaPageID(tableName); err == nil {
+		return errors.ErrTableExist
+	}
+
+	if err := checkTable(tableName, columns); err != nil {
+		return err
+	}
+
+	if err := t.checkConstraints(tableName, columns, constraints); err != nil {
+		return err
+	}
+
+	metaPageID, err := t.createTable(tableName, columns)
+
+	if err != nil {
+		return err
+	}
+
+	t.setMetaPageID(tableName, metaPageID)
+
+	if err := t.registerTable(tableName, metaPageID, columns); err != nil {
+		return err
+	}
+
+	for _, c := range constraints {
+		if err := t.addConstraint(tableName, columns, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// DeleteTuple removes the tuple and its index keys, then runs the ON DELETE
+// action of the foreign keys which reference it
+func (t *TableManager) DeleteTuple(tableName string, rid types.RID) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	lock := t.getWriteLock(tableName)
+	lock.Lock()
+	found, err := t.deleteRow(tableName, rid)
+	lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// the actions take the locks of the child tables, a table references itself as well
+	return t.runActions(found, nil)
+}
+
+// deleteRow needs the write lock of the table, it returns the rows the ON DELETE actions change
+func (t *TableManager) deleteRow(tableName string, rid types.RID) ([]*referencingRows, error) {
+	constraints, err := t.loadConstraints(tableName, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	values, err := t.GetTuple(tableName, rid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := t.findReferencingRows(tableName, rid, values, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if values, err = t.deleteTuple(tableName, rid); err != nil {
+		return nil, err
+	}
+
+	if err := deleteKeys(constraints, values, rid); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// UpdateTuple overwrites the tuple in place, the new values are checked against the
+// constraints as if the old tuple was already gone. The ON UPDATE action of the
+// foreign keys which reference the old key runs after the tuple is written
+func (t *TableManager) UpdateTuple(tableName string, rid types.RID, values []*tuple.Value) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	lock := t.getWriteLock(tableName)
+	lock.Lock()
+	found, err := t.updateRow(tableName, rid, values)
+	lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return t.runActions(found, values)
+}
+
+// updateRow needs the write lock of the table, it returns the rows the ON UPDATE actions change
+func (t *TableManager) updateRow(tableName string, rid types.RID, values []*tuple.Value) ([]*referencingRows, error) {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tuple.FitValues(columns, values); err != nil {
+		return nil, err
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	oldValues, err := t.GetTuple(tableName, rid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found, err := t.findReferencingRows(tableName, rid, oldValues, values)
+
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := checkRow(constraints, values, &rid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if oldValues, err = t.updateTuple(tableName, rid, values); err != nil {
+		return nil, err
+	}
+
+	if err := deleteKeys(constraints, oldValues, rid); err != nil {
+		return nil, err
+	}
+
+	if err := insertKeys(constraints, keys, rid); err != nil {
+		return nil, err
+	}
+
+	return found, nil
+}
+
+// GetTuplesWithRID returns the tuples together with the RID which locates each of them
+func (t *TableManager) GetTuplesWithRID(tableName string) ([]types.RID, [][]*tuple.Value, error) {
+	rids := make([]types.RID, 0)
+	tuples := make([][]*tuple.Value, 0)
+
+	err := t.scanTuples(tableName, func(dataTable *DataTable, index int32, values []*tuple.Value) (bool, error) {
+		rids = append(rids, types.RID{PageID: dataTable.GetPageID(), Index: index})
+		tuples = append(tuples, values)
+
+		return false, nil
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return rids, tuples, nil
+}
+
+// GetTuple reads the tuple at rid, a deleted tuple is ErrTupleNotExist
+func (t *TableManager) GetTuple(tableName string, rid types.RID) ([]*tuple.Value, error) {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, values, err := t.fetchTuple(rid, columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	t.bufferPoolManager.UnpinPage(rid.PageID)
+
+	return values, nil
+}
+
+// checkConstraints validates the constraints before anything is written
+func (t *TableManager) checkConstraints(tableName string, columns []*column.Column, constraints []*constraint.Constraint) error {
+	indexNames, err := t.getIndexNames()
+
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	defaults := make(map[string]bool)
+	hasPrimaryKey := false
+
+	// the constraints which the table already has when it is altered
+	if existing, err := t.GetConstraints(tableName); err == nil {
+		for _, c := range existing {
+			names[c.Name] = true
+			hasPrimaryKey = hasPrimaryKey || c.Type == types.CONSTRAINT_PRIMARY_KEY
+
+			if c.Type == types.CONSTRAINT_DEFAULT {
+				defaults[c.Columns[0]] = true
+			}
+		}
+	}
+
+	for _, c := range constraints {
+		if c.Type == types.CONSTRAINT_PRIMARY_KEY {
+			if hasPrimaryKey {
+				return errors.ErrMultiplePrimaryKey
+			}
+
+			hasPrimaryKey = true
+		}
+
+		if c.Type == types.CONSTRAINT_CHECK || c.Type == types.CONSTRAINT_DEFAULT || c.IsExpressionIndex() {
+			if err := checkExpression(columns, c); err != nil {
+				return err
+			}
+
+			if len(c.Expression) > SYSTEM_DEFINITION_SIZE {
+				return errors.ErrNameTooLong
+			}
+		}
+
+		if c.Type == types.CONSTRAINT_DEFAULT {
+			if defaults[c.Columns[0]] {
+				return errors.ErrSyntax
+			}
+
+			defaults[c.Columns[0]] = true
+		}
+
+		if c.Name == "" {
+			c.Name = getUnusedName(constraint.GetDefaultName(tableName, c.Type, c.Columns), names, indexNames)
+		}
+
+		if len(c.Name) > SYSTEM_NAME_SIZE || len(constraint.JoinColumns(c.Columns)) > SYSTEM_COLUMN_NAMES_SIZE {
+			return errors.ErrNameTooLong
+		}
+
+		if names[c.Name] || (c.HasIndex() && indexNames[c.Name]) {
+			return errors.ErrConstraintExist
+		}
+
+		names[c.Name] = true
+
+		// a CHECK may not reference any column
+		if c.Type == types.CONSTRAINT_CHECK && len(c.Columns) == 0 {
+			continue
+		}
+
+		if _, err := getPositions(columns, c.Columns); err != nil {
+			return err
+		}
+	}
+
+	// every constraint has a name now, a foreign key may reference one of them
+	for _, c := range constraints {
+		if c.Type != types.CONSTRAINT_FOREIGN_KEY {
+			continue
+		}
+
+		if err := t.checkForeignKey(tableName, columns, constraints, c); err != nil {
+			return err
+		}
+	}
+
+	return checkKeySize(columns, constraints)
+}
+
+// getUnusedName numbers a default name like PostgreSQL does when the name is taken,
+// the name is cut to fit into the catalog first
+func getUnusedName(name string, names map[string]bool, indexNames map[string]bool) string {
+	if len(name) > SYSTEM_NAME_SIZE {
+		name = name[:SYSTEM_NAME_SIZE]
+	}
+
+	unused := name
+
+	for i := 1; names[unused] || indexNames[unused]; i++ {
+		suffix := strconv.Itoa(i)
+
+		if len(name)+len(suffix) > SYSTEM_NAME_SIZE {
+			unused = name[:SYSTEM_NAME_SIZE-len(suffix)] + suffix
+		} else {
+			unused = name + suffix
+		}
+	}
+
+	return unused
+}
+
+// addConstraint creates the index of the constraint and records both in the catalog
+func (t *TableManager) addConstraint(tableName string, columns []*column.Column, c *constraint.Constraint) error {
+	var indexName string
+
+	if c.HasIndex() {
+		positions, _ := getPositions(columns, c.Columns)
+		keySize, err := getKeySize(c, selectColumns(columns, positions))
+
+		if err != nil {
+			return err
+		}
+
+		tree, err := index.NewBPlusTree(t.bufferPoolManager, keySize)
+
+		if err != nil {
+			return err
+		}
+
+		indexName = c.Name
+		row := newRow(GetSystemColumns(types.SYSTEM_INDEXES), indexName, tableName, constraint.JoinColumns(c.Columns), c.IsUnique(), int32(tree.GetRootPageID()))
+
+		if _, err := t.insertTuple(types.SYSTEM_INDEXES, row); err != nil {
+			return err
+		}
+	}
+
+	if c.Type == types.CONSTRAINT_FOREIGN_KEY {
+		indexName = c.RefIndex
+	}
+
+	row := newRow(GetSystemColumns(types.SYSTEM_CONSTRAINTS), c.Name, tableName, c.Type, constraint.JoinColumns(c.Columns), indexName, c.GetDefinition())
+	_, err := t.insertTuple(types.SYSTEM_CONSTRAINTS, row)
+
+	return err
+}
+
+// loadConstraints resolves the constraints against the columns, the current columns
+// of the table are used when columns is nil
+func (t *TableManager) loadConstraints(tableName string, columns []*column.Column) ([]*tableConstraint, error) {
+	if columns == nil {
+		var err error
+
+		if columns, err = t.GetTableMeta(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(constraints) == 0 {
+		return nil, nil
+	}
+
+	rootPageIDs, err := t.getIndexRootPageIDs(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableConstraints := make([]*tableConstraint, 0, len(constraints))
+
+	for _, c := range constraints {
+		tc, err := t.resolveConstraint(tableName, columns, c, rootPageIDs)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tableConstraints = append(tableConstraints, tc)
+	}
+
+	return tableConstraints, nil
+}
+
+// resolveConstraint finds the positions of the columns of the constraint and
+// loads its index or its expression
+func (t *TableManager) resolveConstraint(tableName string, columns []*column.Column, c *constraint.Constraint, rootPageIDs map[string]types.Page_id_t) (*tableConstraint, error) {
+	var (
+		positions []int
+		err       error
+	)
+
+	if len(c.Columns) > 0 {
+		if positions, err = getPositions(columns, c.Columns); err != nil {
+			return nil, err
+		}
+	}
+
+	tc := &tableConstraint{Constraint: c, positions: positions}
+
+	if c.Type == types.CONSTRAINT_CHECK {
+		if tc.check, err = expression.ParseText(c.Expression); err != nil {
+			return nil, err
+		}
+
+		tc.columns = columns
+	}
+
+	if c.IsExpressionIndex() {
+		if tc.indexed, err = expression.ParseText(c.Expression); err != nil {
+			return nil, err
+		}
+
+		tc.columns = columns
+	}
+
+	if rootPageID, exist := rootPageIDs[c.Name]; exist && c.HasIndex() {
+		tc.tree = index.GetBPlusTree(t.bufferPoolManager, rootPageID)
+	}
+
+	if c.Type == types.CONSTRAINT_FOREIGN_KEY {
+		if err := t.loadForeignKey(tableName, columns, tc); err != nil {
+			return nil, err
+		}
+	}
+
+	return tc, nil
+}
+
+// getIndexRootPageIDs maps the name of every index of the table to its root page
+func (t *TableManager) getIndexRootPageIDs(tableName string) (map[string]types.Page_id_t, error) {
+	rows, err := t.GetTuples(types.SYSTEM_INDEXES)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rootPageIDs := make(map[string]types.Page_id_t)
+
+	for _, values := range rows {
+		if string(values[1].VAR_CHAR) == tableName {
+			rootPageIDs[string(values[0].VAR_CHAR)] = types.Page_id_t(values[4].INT)
+		}
+	}
+
+	return rootPageIDs, nil
+}
+
+func (t *TableManager) getIndexNames() (map[string]bool, error) {
+	rows, err := t.GetTuples(types.SYSTEM_INDEXES)
+
+	if err != nil {
+		return nil, err
+	}
+
+	indexNames := make(map[string]bool, len(rows))
+
+	for _, values := range rows {
+		indexNames[string(values[0].VAR_CHAR)] = true
+	}
+
+	return indexNames, nil
+}
+
+// checkTuples checks the tuples of a rewritten table against the constraints in memory,
+// so nothing is written when any of them breaks a constraint
+func checkTuples(constraints []*tableConstraint, columns []*column.Column, tuples [][]*tuple.Value) error {
+	seen := make([]map[string]bool, len(constraints))
+
+	for i, c := range constraints {
+		seen[i] = make(map[string]bool)
+
+		if c.tree == nil {
+			continue
+		}
+
+		if _, err := getKeySize(c.Constraint, selectColumns(columns, c.positions)); err != nil {
+			return err
+		}
+	}
+
+	for _, values := range tuples {
+		for i, c := range constraints {
+			if err := checkNotNull(c, values); err != nil {
+				return err
+			}
+
+			if err := checkCondition(c, values); err != nil {
+				return err
+			}
+
+			if !c.HasIndex() {
+				continue
+			}
+
+			key, ok, err := c.getKey(values)
+
+			if err != nil {
+				return err
+			}
+
+			if !ok || !c.IsUnique() {
+				continue
+			}
+
+			if seen[i][string(key)] {
+				return &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrUniqueViolation}
+			}
+
+			seen[i][string(key)] = true
+		}
+	}
+
+	return nil
+}
+
+// checkRow returns the index key of every constraint, nil when the key has a NULL value,
+// the tuple at self does not count as a duplicate of itself
+func checkRow(constraints []*tableConstraint, values []*tuple.Value, self *types.RID) ([][]byte, error) {
+	keys := make([][]byte, len(constraints))
+
+	for i, c := range constraints {
+		if err := checkNotNull(c, values); err != nil {
+			return nil, err
+		}
+
+		if err := checkReference(c, values); err != nil {
+			return nil, err
+		}
+
+		if err := checkCondition(c, values); err != nil {
+			return nil, err
+		}
+
+		if c.tree == nil {
+			continue
+		}
+
+		key, ok, err := c.getKey(values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			continue
+		}
+
+		// the rows of a plain index only share a key with the RID appended
+		if !c.IsUnique() {
+			keys[i] = key
+			continue
+		}
+
+		rid, found, err := c.tree.Search(key)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if found && (self == nil || rid != *self) {
+			return nil, &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrUniqueViolation}
+		}
+
+		keys[i] = key
+	}
+
+	return keys, nil
+}
+
+func checkNotNull(c *tableConstraint, values []*tuple.Value) error {
+	if !c.IsNotNull() {
+		return nil
+	}
+
+	for _, position := range c.positions {
+		if values[position].IsNull() {
+			return &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrNotNullViolation}
+		}
+	}
+
+	return nil
+}
+
+func insertKeys(constraints []*tableConstraint, keys [][]byte, rid types.RID) error {
+	for i, c := range constraints {
+		if c.tree == nil || keys[i] == nil {
+			continue
+		}
+
+		if err := c.tree.Insert(c.getEntryKey(keys[i], rid), rid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// deleteKeys removes the keys of the tuple at the RID
+func deleteKeys(constraints []*tableConstraint, values []*tuple.Value, rid types.RID) error {
+	for _, c := range constraints {
+		if c.tree == nil {
+			continue
+		}
+
+		key, ok, err := c.getKey(values)
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			continue
+		}
+
+		if err := c.tree.Delete(c.getEntryKey(key, rid)); err != nil && err != errors.ErrKeyNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rebuildIndexes clears the indexes of the table and inserts the key of every tuple again,
+// the key size follows the current columns
+func (t *TableManager) rebuildIndexes(tableName string, constraints []*tableConstraint, columns []*column.Column) error {
+	for _, c := range constraints {
+		if c.tree == nil {
+			continue
+		}
+
+		keySize, err := getKeySize(c.Constraint, selectColumns(columns, c.positions))
+
+		if err != nil {
+			return err
+		}
+
+		if err := c.tree.Clear(keySize); err != nil {
+			return err
+		}
+	}
+
+	return t.scanTuples(tableName, func(dataTable *DataTable, i int32, values []*tuple.Value) (bool, error) {
+		for _, c := range constraints {
+			if c.tree == nil {
+				continue
+			}
+
+			key, ok, err := c.getKey(values)
+
+			if err != nil {
+				return false, err
+			}
+
+			if ok {
+				rid := types.RID{PageID: dataTable.GetPageID(), Index: i}
+
+				if err := c.tree.Insert(c.getEntryKey(key, rid), rid); err != nil {
+					return false, err
+				}
+			}
+		}
+
+		return false, nil
+	})
+}
+
+func destroyIndexes(constraints []*tableConstraint) error {
+	for _, c := range constraints {
+		if c.tree == nil {
+			continue
+		}
+
+		if err := c.tree.Destroy(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// renameConstraintColumn changes the column in the column_names of sys_constraints and sys_indexes
+func (t *TableManager) renameConstraintColumn(tableName string, columnName string, newColumnName string) error {
+	for _, systemTable := range []string{types.SYSTEM_CONSTRAINTS, types.SYSTEM_INDEXES} {
+		columns := GetSystemColumns(systemTable)
+		tableIndex := findColumn(columns, SYSTEM_TABLE_NAME_COLUMN)
+		namesIndex := findColumn(columns, "column_names")
+		definitionIndex := findColumn(columns, "definition")
+		var renameErr error
+
+		_, err := t.updateTuples(systemTable, func(values []*tuple.Value) []*tuple.Value {
+			if string(values[tableIndex].VAR_CHAR) != tableName {
+				return nil
+			}
+
+			columnNames := constraint.SplitColumns(string(values[namesIndex].VAR_CHAR))
+			changed := false
+
+			for i, name := range columnNames {
+				if name == columnName {
+					columnNames[i] = newColumnName
+					changed = true
+				}
+			}
+
+			if !changed {
+				return nil
+			}
+
+			values[namesIndex] = tuple.GetValue(constraint.JoinColumns(columnNames), columns[namesIndex].ColumnType, columns[namesIndex].Size)
+
+			if constraintType := string(values[2].VAR_CHAR); definitionIndex != -1 && (constraintType == types.CONSTRAINT_CHECK ||
+				constraintType == types.CONSTRAINT_INDEX || constraintType == types.CONSTRAINT_UNIQUE_INDEX) {
+				definition, err := renameCheckColumn(string(values[definitionIndex].VAR_CHAR), columnName, newColumnName)
+
+				if err != nil {
+					renameErr = err
+					return nil
+				}
+
+				values[definitionIndex] = tuple.GetValue(definition, columns[definitionIndex].ColumnType, columns[definitionIndex].Size)
+			}
+
+			return values
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if renameErr != nil {
+			return renameErr
+		}
+	}
+
+	return nil
+}
+
+// dropColumnConstraints removes the NOT NULL, CHECK and DEFAULT constraints of the column,
+// a column which is part of an index or a foreign key can not be dropped
+func (t *TableManager) dropColumnConstraints(tableName string, columnName string) error {
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	dropped := make(map[string]bool)
+
+	for _, c := range constraints {
+		if findName(c.Columns, columnName) == -1 {
+			continue
+		}
+
+		if c.HasIndex() || c.Type == types.CONSTRAINT_FOREIGN_KEY {
+			return errors.ErrColumnHasConstraint
+		}
+
+		dropped[c.Name] = true
+	}
+
+	if len(dropped) == 0 {
+		return nil
+	}
+
+	_, err = t.deleteTuples(types.SYSTEM_CONSTRAINTS, func(values []*tuple.Value) bool {
+		return string(values[1].VAR_CHAR) == tableName && dropped[string(values[0].VAR_CHAR)]
+	})
+
+	return err
+}
+
+func checkKeySize(columns []*column.Column, constraints []*constraint.Constraint) error {
+	for _, c := range constraints {
+		if !c.HasIndex() {
+			continue
+		}
+
+		positions, err := getPositions(columns, c.Columns)
+
+		if err != nil {
+			return err
+		}
+
+		if _, err := getKeySize(c, selectColumns(columns, positions)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPositions finds the columns of a constraint, a column can not appear twice
+func getPositions(columns []*column.Column, columnNames []string) ([]int, error) {
+	if len(columnNames) == 0 {
+		return nil, errors.ErrSyntax
+	}
+
+	positions := make([]int, 0, len(columnNames))
+
+	for i, name := range columnNames {
+		position := findColumn(columns, name)
+
+		if position == -1 {
+			return nil, errors.ErrColumnNotExist
+		}
+
+		if findName(columnNames[:i], name) != -1 {
+			return nil, errors.ErrSyntax
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+func selectColumns(columns []*column.Column, positions []int) []*column.Column {
+	selected := make([]*column.Column, 0, len(positions))
+
+	for _, position := range positions {
+		selected = append(selected, columns[position])
+	}
+
+	return selected
+}
+
+func selectValues(values []*tuple.Value, positions []int) []*tuple.Value {
+	selected := make([]*tuple.Value, 0, len(positions))
+
+	for _, position := range positions {
+		selected = append(selected, values[position])
+	}
+
+	return selected
+}
+
+func findName(names []string, name string) int {
+	for i, n := range names {
+		if n == name {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// fetchTuple pins the data page of the tuple, the caller unpins it
+func (t *TableManager) fetchTuple(rid types.RID, columns []*column.Column) (*DataTable, []*tuple.Value, error) {
+	if rid.PageID == constant.INVALID_PAGE_ID {
+		return nil, nil, errors.ErrTupleNotExist
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(rid.PageID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dataTable := GetDataTable(page)
+
+	if page.GetPageTye() != types.DATA_PAGE_TYPE || rid.Index < 0 || rid.Index >= dataTable.GetTupleCount() || dataTable.IsTupleDeleted(rid.Index) {
+		t.bufferPoolManager.UnpinPage(rid.PageID)
+		return nil, nil, errors.ErrTupleNotExist
+	}
+
+	return dataTable, dataTable.GetTupleByIndex(rid.Index, columns), nil
+}
+
+// deleteTuple returns the values of the deleted tuple
+func (t *TableManager) deleteTuple(tableName string, rid types.RID) ([]*tuple.Value, error) {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataTable, values, err := t.fetchTuple(rid, columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer t.bufferPoolManager.UnpinPage(rid.PageID)
+
+	if err := dataTable.DeleteTupleByIndex(rid.Index); err != nil {
+		return nil, err
+	}
+
+	t.bufferPoolManager.FlushPage(rid.PageID)
+
+	return values, nil
+}
+
+// updateTuple returns the values the tuple had before
+func (t *TableManager) updateTuple(tableName string, rid types.RID, values []*tuple.Value) ([]*tuple.Value, error) {
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataTable, oldValues, err := t.fetchTuple(rid, columns)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer t.bufferPoolManager.UnpinPage(rid.PageID)
+
+	if err := dataTable.UpdateTupleData(rid.Index, tuple.TupleSerialization(values)); err != nil {
+		return nil, err
+	}
+
+	t.bufferPoolManager.FlushPage(rid.PageID)
+
+	return oldValues, nil
+}
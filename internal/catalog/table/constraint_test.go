@@ -0,0 +1,246 @@
+package table
+
+import (
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"log"
+	"os"
+	"testing"
+)
+
+func Test_Constraints(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("constraint_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("constraint_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "a"),
+		column.NewColumn(types.INT_TYPE, 0, "b"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 8, "c"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"a", "b"}),
+		constraint.NewConstraint("c_key", types.CONSTRAINT_UNIQUE, []string{"c"}),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("testTable", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	row := func(a int32, b int32, c interface{}) []*tuple.Value {
+		if c == nil {
+			return []*tuple.Value{
+				tuple.GetValue(a, types.INT_TYPE, types.INT_SIZE),
+				tuple.GetValue(b, types.INT_TYPE, types.INT_SIZE),
+				tuple.GetNullValue(types.VAR_CHAR_TYPE, 8),
+			}
+		}
+
+		return newRow(columns, a, b, c)
+	}
+
+	for _, values := range [][]*tuple.Value{row(1, 1, "x"), row(1, 2, nil), row(2, 1, nil)} {
+		if err := tableManager.InsertTuple("testTable", values); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	checkViolation := func(err error, expected error, constraintName string) {
+		t.Helper()
+
+		constraintError, ok := err.(*errors.ConstraintError)
+
+		if !ok || constraintError.Err != expected || constraintError.Constraint != constraintName {
+			t.Error("should violate", constraintName, err)
+		}
+	}
+
+	checkViolation(tableManager.InsertTuple("testTable", row(1, 1, "y")), errors.ErrUniqueViolation, "testTable_pkey")
+	checkViolation(tableManager.InsertTuple("testTable", row(3, 3, "x")), errors.ErrUniqueViolation, "c_key")
+
+	nullKey := []*tuple.Value{tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE), tuple.GetValue(int32(9), types.INT_TYPE, types.INT_SIZE), tuple.GetNullValue(types.VAR_CHAR_TYPE, 8)}
+	checkViolation(tableManager.InsertTuple("testTable", nullKey), errors.ErrNotNullViolation, "testTable_pkey")
+
+	rids, tuples, err := tableManager.GetTuplesWithRID("testTable")
+
+	if err != nil || len(rids) != 3 {
+		t.Fatal("should have 3 tuples", err)
+	}
+
+	// an update may keep its own key but not take the key of another tuple
+	if err := tableManager.UpdateTuple("testTable", rids[0], row(1, 1, "z")); err != nil {
+		t.Fatal(err)
+	}
+
+	checkViolation(tableManager.UpdateTuple("testTable", rids[0], row(1, 2, "z")), errors.ErrUniqueViolation, "testTable_pkey")
+
+	if err := tableManager.InsertTuple("testTable", row(4, 4, "x")); err != nil {
+		t.Fatal("old key should be free after update", err)
+	}
+
+	if err := tableManager.DeleteTuple("testTable", rids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DeleteTuple("testTable", rids[1]); err != errors.ErrTupleNotExist {
+		t.Error("deleted tuple should not be deleted again", err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", row(1, 2, nil)); err != nil {
+		t.Fatal("key should be free after delete", err)
+	}
+
+	if len(tuples) != 3 || tuples[0][2].IsNull() || !tuples[1][2].IsNull() {
+		t.Error("NULL should be read back")
+	}
+
+	// the indexes are rebuilt with the wider key
+	if err := tableManager.AlterColumnType("testTable", column.NewColumn(types.LONG_INT_TYPE, 0, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DropColumn("testTable", "b"); err != errors.ErrColumnHasConstraint {
+		t.Error("indexed column should not be dropped", err)
+	}
+
+	if err := tableManager.RenameColumn("testTable", "c", "d"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.AddNewColumn("testTable", column.NewColumn(types.INT_TYPE, 0, "e")); err != nil {
+		t.Fatal(err)
+	}
+
+	columns, _ = tableManager.GetTableMeta("testTable")
+
+	checkViolation(tableManager.InsertTuple("testTable", newRow(columns, int32(1), int64(1), "x", int32(0))), errors.ErrUniqueViolation, "testTable_pkey")
+	checkViolation(tableManager.InsertTuple("testTable", newRow(columns, int32(5), int64(5), "x", int32(0))), errors.ErrUniqueViolation, "c_key")
+
+	loaded, err := tableManager.GetConstraints("testTable")
+
+	if err != nil || len(loaded) != 2 || loaded[1].Columns[0] != "d" {
+		t.Fatal("constraint should follow the renamed column", loaded, err)
+	}
+
+	if err := tableManager.TruncateTable("testTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", newRow(columns, int32(1), int64(1), "x", int32(0))); err != nil {
+		t.Fatal("truncate should clear the indexes", err)
+	}
+
+	if err := tableManager.DropTable("testTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if indexes := getSystemRows(t, tableManager, types.SYSTEM_INDEXES, "testTable"); len(indexes) != 0 {
+		t.Error("indexes should be dropped with the table")
+	}
+
+	if diskManager.GetFreeListHead() == constant.INVALID_PAGE_ID {
+		t.Error("index pages should be freed")
+	}
+}
+
+func Test_ConstraintRewrite(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("constraint_rewrite_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("constraint_rewrite_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.FLOAT_TYPE, 0, "a"),
+		column.NewColumn(types.INT_TYPE, 0, "b"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_UNIQUE, []string{"a"}),
+		constraint.NewConstraint("", types.CONSTRAINT_NOT_NULL, []string{"b"}),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("testTable", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range []float64{1.2, 1.4} {
+		if err := tableManager.InsertTuple("testTable", newRow(columns, a, int32(1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 1.2 and 1.4 are both rounded to the same INT
+	err = tableManager.AlterColumnType("testTable", column.NewColumn(types.INT_TYPE, 0, "a"))
+	constraintError, ok := err.(*errors.ConstraintError)
+
+	if !ok || constraintError.Constraint != "testTable_a_key" {
+		t.Fatal("rewrite should check the unique constraint", err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("testTable"); len(tuples) != 2 || tuples[0][0].FLOAT != 1.2 {
+		t.Fatal("failed rewrite should not change the table")
+	}
+
+	// "1.2" does not fit VARCHAR(1), it is not cut to "1"
+	if err := tableManager.AlterColumnType("testTable", column.NewColumn(types.VAR_CHAR_TYPE, 1, "a")); err != errors.ErrValueTooLong {
+		t.Fatal("narrowing a VARCHAR should not cut the values", err)
+	}
+
+	if err := tableManager.AlterColumnType("testTable", column.NewColumn(types.VAR_CHAR_TYPE, 3, "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("testTable"); len(tuples) != 2 || string(tuples[1][0].VAR_CHAR) != "1.4" {
+		t.Fatal("values which fit should be kept whole", tuples)
+	}
+
+	if err := tableManager.DropColumn("testTable", "b"); err != nil {
+		t.Fatal("NOT NULL column should be dropped with its constraint", err)
+	}
+
+	if loaded, _ := tableManager.GetConstraints("testTable"); len(loaded) != 1 {
+		t.Error("NOT NULL constraint should be dropped", loaded)
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("constraint_rewrite_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	columns, _ = tableManager.GetTableMeta("testTable")
+
+	if err := tableManager.InsertTuple("testTable", newRow(columns, "1.4")); err == nil {
+		t.Error("index should survive restart")
+	}
+}
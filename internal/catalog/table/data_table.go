@@ -2,7 +2,7 @@ package table
 
 import (
 	"encoding/binary"
-	"go-db/internal/catalog/schema"
+	"go-db/internal/catalog/column"
 	"go-db/internal/catalog/tuple"
 	"go-db/internal/common/constant"
 	"go-db/internal/common/errors"
@@ -20,18 +20,20 @@ import (
  *                                free space pointer
  *
  *  Header format (size in bytes):
- *  +-------------+---------------+---------------+---------------------+-------
- *  | PageType (4)| PrevPageId (4)| NextPageId (4)| FreeSpacePointer(4) |
- *  +-------------+---------------+---------------+---------------------+-------
+ *  +-------------+---------------+---------------+-------------+---------------------+-------
+ *  | PageType (4)| PrevPageId (4)| NextPageId (4)| Checksum (4)| FreeSpacePointer(4) |
+ *  +-------------+---------------+---------------+-------------+---------------------+-------
  *  +----------------+--------------------+-------------------------
  *  | TupleCount (4) | Tuple_1 offset (4) | Tuple_1 size (4) | ... |
  *  +----------------+--------------------+-------------------------
  *
  *
+ *  A deleted tuple keeps its slot with offset 0, the space is reclaimed when the table is rewritten.
+ *
  *  TUPLE format !! Carefully tuple should match the
- *  +-----------------+----------------+-------------+
- *  | Data 1 Payload  | Data 2 Payload | ...
- *  +-----------------+----------------+-------------+
+ *  +-------------+-----------------+----------------+-------------+
+ *  | NULL bitmap | Data 1 Payload  | Data 2 Payload | ...
+ *  +-------------+-----------------+----------------+-------------+
  *
  */
 
@@ -46,6 +48,7 @@ func GetDataTable(page *page.Page) *DataTable {
 
 func (p *DataTable) DataTableInit() {
 	p.SetFreeSpacePointer(constant.PAGE_SIZE)
+	p.SetPrevPageID(constant.INVALID_PAGE_ID)
 	p.SetNextPageID(constant.INVALID_PAGE_ID)
 	p.SetPageType(types.DATA_PAGE_TYPE)
 }
@@ -67,11 +70,11 @@ func (p *DataTable) SetNextPageID(pageID types.Page_id_t) {
 }
 
 func (p *DataTable) GetFreeSpacePointer() int32 {
-	return int32(binary.BigEndian.Uint32(p.GetData()[types.NEXT_PAGE_ID_OFFSET:types.FREE_SPACE_POINTER_OFFSET]))
+	return int32(binary.BigEndian.Uint32(p.GetData()[types.PAGE_CHECKSUM_OFFSET:types.FREE_SPACE_POINTER_OFFSET]))
 }
 
 func (p *DataTable) SetFreeSpacePointer(pointer int32) {
-	binary.BigEndian.PutUint32(p.GetData()[types.NEXT_PAGE_ID_OFFSET:types.FREE_SPACE_POINTER_OFFSET], uint32(pointer))
+	binary.BigEndian.PutUint32(p.GetData()[types.PAGE_CHECKSUM_OFFSET:types.FREE_SPACE_POINTER_OFFSET], uint32(pointer))
 }
 
 func (p *DataTable) GetTupleCount() int32 {
@@ -82,8 +85,8 @@ func (p *DataTable) SetTupleCount(tupleCount int32) {
 	binary.BigEndian.PutUint32(p.GetData()[types.FREE_SPACE_POINTER_OFFSET:types.TUPLE_COUNT_OFFSET], uint32(tupleCount))
 }
 
-func (p *DataTable) getTupleMetaByIndex(index int32) (int32, int32, error) {
-	if index > p.GetTupleCount() || index < 0 {
+func (p *DataTable) GetTupleMetaByIndex(index int32) (int32, int32, error) {
+	if index >= p.GetTupleCount() || index < 0 {
 		return -1, -1, errors.ErrIndexOutOfRange
 	}
 
@@ -107,11 +110,20 @@ func (p *DataTable) GetRemainSpace() int32 {
 	return p.GetFreeSpacePointer() - tupleMetaOffset
 }
 
-func (p *DataTable) InsertTuple(value []*tuple.Value, tupleSize int32) error {
-	tupleCount := p.GetTupleCount()
-
+func (p *DataTable) InsertTuple(value []*tuple.Value) error {
 	tupleData := tuple.TupleSerialization(value)
 
+	return p.InsertTupleData(tupleData, int32(len(tupleData)))
+}
+
+// InsertTupleData appends the already serialized tuple to the page
+func (p *DataTable) InsertTupleData(tupleData []byte, tupleSize int32) error {
+	if p.GetRemainSpace() < tupleSize+types.TUPLE_OFFSET+types.TUPLE_SIZE {
+		return errors.ErrNoSpace
+	}
+
+	tupleCount := p.GetTupleCount()
+
 	tupleOffset := types.TUPLE_COUNT_OFFSET + ((types.TUPLE_OFFSET + types.TUPLE_SIZE) * tupleCount)
 
 	freeSpacePoint := p.GetFreeSpacePointer()
@@ -127,13 +139,13 @@ func (p *DataTable) InsertTuple(value []*tuple.Value, tupleSize int32) error {
 	return nil
 }
 
-func (p *DataTable) GetTuple(schema *schema.Schema) [][]*tuple.Value {
+func (p *DataTable) GetTuple(columns []*column.Column) [][]*tuple.Value {
 	tupleCount := p.GetTupleCount()
 
 	tuples := make([][]*tuple.Value, 0, tupleCount)
 
 	for i := int32(0); i < tupleCount; i++ {
-		tuple := p.getTupleByIndex(i, schema)
+		tuple := p.getTupleByIndex(i, columns)
 
 		if tuple != nil {
 			tuples = append(tuples, tuple)
@@ -143,10 +155,53 @@ func (p *DataTable) GetTuple(schema *schema.Schema) [][]*tuple.Value {
 	return tuples
 }
 
-func (p *DataTable) getTupleByIndex(tupleIndex int32, schema *schema.Schema) []*tuple.Value {
-	offset, size, _ := p.getTupleMetaByIndex(tupleIndex)
+// GetTupleByIndex returns nil when the tuple is deleted
+func (p *DataTable) GetTupleByIndex(tupleIndex int32, columns []*column.Column) []*tuple.Value {
+	return p.getTupleByIndex(tupleIndex, columns)
+}
+
+func (p *DataTable) IsTupleDeleted(tupleIndex int32) bool {
+	offset, _, err := p.GetTupleMetaByIndex(tupleIndex)
+
+	return err == nil && offset == 0
+}
+
+func (p *DataTable) DeleteTupleByIndex(tupleIndex int32) error {
+	if _, _, err := p.GetTupleMetaByIndex(tupleIndex); err != nil {
+		return err
+	}
+
+	metaOffset := types.TUPLE_COUNT_OFFSET + (tupleIndex * (types.TUPLE_OFFSET + types.TUPLE_SIZE))
+	binary.BigEndian.PutUint32(p.GetData()[metaOffset:metaOffset+types.TUPLE_OFFSET], 0)
+
+	return nil
+}
+
+// UpdateTupleData overwrites the tuple in place, the size can not change
+func (p *DataTable) UpdateTupleData(tupleIndex int32, tupleData []byte) error {
+	offset, size, err := p.GetTupleMetaByIndex(tupleIndex)
+
+	if err != nil {
+		return err
+	}
+
+	if offset == 0 || size != int32(len(tupleData)) {
+		return errors.ErrIndexOutOfRange
+	}
+
+	copy(p.GetData()[offset:offset+size], tupleData)
+
+	return nil
+}
+
+func (p *DataTable) getTupleByIndex(tupleIndex int32, columns []*column.Column) []*tuple.Value {
+	offset, size, _ := p.GetTupleMetaByIndex(tupleIndex)
+
+	if offset == 0 {
+		return nil
+	}
 
 	tuplesData := p.GetData()[offset : offset+size]
 
-	return tuple.TupleDeserialization(schema, tuplesData)
+	return tuple.TupleDeserialization(columns, tuplesData)
 }
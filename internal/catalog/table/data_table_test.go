@@ -7,6 +7,7 @@ import (
 	"go-db/internal/catalog/tuple"
 	"go-db/internal/common/types"
 	"go-db/internal/storage/disk"
+	"go-db/internal/storage/page"
 	"go-db/internal/utils"
 	"log"
 	"strings"
@@ -75,19 +76,13 @@ func Test_InsertTuple(t *testing.T) {
 	tuples = append(tuples, tuple.GetValue(longInt, columns[3].GetColumnType(), columns[3].GetColumnSize()))
 	tuples = append(tuples, tuple.GetValue(varCharType, columns[4].GetColumnType(), columns[4].GetColumnSize()))
 
-	valueSize := 0
-
-	for _, t := range tuples {
-		valueSize += int(t.GetSize())
-	}
-
-	err = dataPage.InsertTuple(tuples, int32(valueSize))
+	err = dataPage.InsertTuple(tuples)
 
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	getTuples := dataPage.GetTuple(schema)
+	getTuples := dataPage.GetTuple(schema.GetColumns())
 
 	if len(getTuples) != 1 {
 		t.Error("get tuple wrong")
@@ -123,3 +118,38 @@ func Test_InsertTuple(t *testing.T) {
 	}
 
 }
+
+func Test_DeleteUpdateTuple(t *testing.T) {
+	dataPage := GetDataTable(page.NewPage())
+	dataPage.DataTableInit()
+
+	columns := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "int_types")}
+
+	for i := int32(0); i < 3; i++ {
+		if err := dataPage.InsertTuple([]*tuple.Value{tuple.GetValue(i, types.INT_TYPE, types.INT_SIZE)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := dataPage.DeleteTupleByIndex(1); err != nil {
+		t.Fatal(err)
+	}
+
+	if !dataPage.IsTupleDeleted(1) || dataPage.GetTupleByIndex(1, columns) != nil {
+		t.Error("tuple should be deleted")
+	}
+
+	if err := dataPage.UpdateTupleData(2, tuple.TupleSerialization([]*tuple.Value{tuple.GetValue(int32(5), types.INT_TYPE, types.INT_SIZE)})); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := dataPage.UpdateTupleData(1, make([]byte, tuple.GetTupleSize(columns))); err == nil {
+		t.Error("deleted tuple should not be updated")
+	}
+
+	getTuples := dataPage.GetTuple(columns)
+
+	if len(getTuples) != 2 || getTuples[0][0].INT != 0 || getTuples[1][0].INT != 5 {
+		t.Error("get tuples after delete and update wrong", getTuples)
+	}
+}
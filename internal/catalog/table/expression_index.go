@@ -0,0 +1,217 @@
+package table
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/index"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+)
+
+/**
+ *  EXPRESSION INDEX KEY
+ *  +-----------------------------------+--------------+-----------+
+ *  | SHA-256 of the text (32)          | Page ID (4)  | Index (4) |
+ *  +-----------------------------------+--------------+-----------+
+ *
+ *  CREATE INDEX on an expression such as doc ->> 'city' keeps the hash of the text of the value,
+ *  so the index only finds the rows whose value equals a text. The B+ tree is unique, a plain
+ *  index appends the RID of the row to the hash and a UNIQUE INDEX has the hash alone.
+ *  A row whose expression is NULL is not indexed.
+ */
+
+const RID_KEY_SIZE = 8
+
+// getKeySize is the size of the keys of the constraint on the columns
+func getKeySize(c *constraint.Constraint, columns []*column.Column) (int32, error) {
+	if !c.IsExpressionIndex() {
+		return index.GetKeySize(columns)
+	}
+
+	if c.IsUnique() {
+		return sha256.Size, nil
+	}
+
+	return sha256.Size + RID_KEY_SIZE, nil
+}
+
+// getKey returns the key of the values without the RID, false when it has a NULL value
+func (c *tableConstraint) getKey(values []*tuple.Value) ([]byte, bool, error) {
+	if c.indexed == nil {
+		key, ok := index.EncodeKey(selectValues(values, c.positions))
+
+		return key, ok, nil
+	}
+
+	value, err := c.indexed.Evaluate(expression.NewRow(c.columns, values))
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	if value.IsNull() {
+		return nil, false, nil
+	}
+
+	return hashText(tuple.GetValueText(value)), true, nil
+}
+
+// getEntryKey is the key written to the tree, a plain index appends the RID
+func (c *tableConstraint) getEntryKey(key []byte, rid types.RID) []byte {
+	if c.IsUnique() {
+		return key
+	}
+
+	entry := make([]byte, len(key)+RID_KEY_SIZE)
+	copy(entry, key)
+	binary.BigEndian.PutUint32(entry[len(key):], uint32(rid.PageID))
+	binary.BigEndian.PutUint32(entry[len(key)+4:], uint32(rid.Index))
+
+	return entry
+}
+
+func hashText(text string) []byte {
+	hash := sha256.Sum256([]byte(text))
+
+	return hash[:]
+}
+
+// CreateIndex builds the index of the expression over the rows the table already has,
+// nothing is written when two rows break a UNIQUE INDEX
+func (t *TableManager) CreateIndex(tableName string, c *constraint.Constraint) error {
+	if IsSystemTable(tableName) || IsInformationSchema(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	if err := t.checkConstraints(tableName, columns, []*constraint.Constraint{c}); err != nil {
+		return err
+	}
+
+	indexed, err := expression.ParseText(c.Expression)
+
+	if err != nil {
+		return err
+	}
+
+	tuples, err := t.GetTuples(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	tc := &tableConstraint{Constraint: c, indexed: indexed, columns: columns}
+
+	if err := checkTuples([]*tableConstraint{tc}, columns, tuples); err != nil {
+		return err
+	}
+
+	if err := t.addConstraint(tableName, columns, c); err != nil {
+		return err
+	}
+
+	rootPageIDs, err := t.getIndexRootPageIDs(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	tc.tree = index.GetBPlusTree(t.bufferPoolManager, rootPageIDs[c.Name])
+
+	return t.rebuildIndexes(tableName, []*tableConstraint{tc}, columns)
+}
+
+// DropIndex removes an index which CREATE INDEX built, the index of a
+// PRIMARY KEY or UNIQUE constraint goes away with its constraint only
+func (t *TableManager) DropIndex(indexName string) error {
+	rows, err := t.GetTuples(types.SYSTEM_CONSTRAINTS)
+
+	if err != nil {
+		return err
+	}
+
+	var tableName string
+
+	for _, values := range rows {
+		if string(values[0].VAR_CHAR) != indexName {
+			continue
+		}
+
+		constraintType := string(values[2].VAR_CHAR)
+
+		if constraintType != types.CONSTRAINT_INDEX && constraintType != types.CONSTRAINT_UNIQUE_INDEX {
+			return errors.ErrIndexConstraint
+		}
+
+		tableName = string(values[1].VAR_CHAR)
+	}
+
+	if tableName == "" {
+		return errors.ErrNoIndex
+	}
+
+	rootPageIDs, err := t.getIndexRootPageIDs(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	if err := index.GetBPlusTree(t.bufferPoolManager, rootPageIDs[indexName]).Destroy(); err != nil {
+		return err
+	}
+
+	for _, systemTable := range []string{types.SYSTEM_INDEXES, types.SYSTEM_CONSTRAINTS} {
+		_, err := t.deleteTuples(systemTable, func(values []*tuple.Value) bool {
+			return string(values[0].VAR_CHAR) == indexName && string(values[1].VAR_CHAR) == tableName
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SearchIndex returns the RIDs of the rows whose expression has the text of the value,
+// false when no index of the table is on the expression
+func (t *TableManager) SearchIndex(tableName string, expr expression.Expression, value *tuple.Value) ([]types.RID, bool, error) {
+	constraints, err := t.loadConstraints(tableName, nil)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, c := range constraints {
+		if c.indexed == nil || c.tree == nil || c.indexed.String() != expr.String() {
+			continue
+		}
+
+		key := hashText(tuple.GetValueText(value))
+
+		if !c.IsUnique() {
+			rids, err := c.tree.SearchPrefix(key)
+
+			return rids, true, err
+		}
+
+		rid, found, err := c.tree.Search(key)
+
+		if err != nil || !found {
+			return nil, true, err
+		}
+
+		return []types.RID{rid}, true, nil
+	}
+
+	return nil, false, nil
+}
@@ -0,0 +1,443 @@
+package table
+
+import (
+	"bytes"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/index"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+)
+
+/**
+ *  FOREIGN KEY
+ *
+ *  The key of a child row is looked up in the unique index of the referenced constraint
+ *  on INSERT and UPDATE, a key with a NULL value references nothing.
+ *
+ *  Deleting a parent row or changing its key first fails when a RESTRICT or NO ACTION
+ *  foreign key still references it, then the parent row is written and CASCADE, SET NULL
+ *  or SET DEFAULT runs on the child rows, which are found by scanning the child table.
+ *  SET DEFAULT writes the DEFAULT of the column, which is NULL for a column without one.
+ */
+
+// reference is a foreign key seen from the table it references
+type reference struct {
+	*constraint.Constraint
+	tableName    string
+	positions    []int
+	refPositions []int
+}
+
+// referencingRows are the child rows of a foreign key which reference a changed parent row
+type referencingRows struct {
+	*reference
+	action string
+	rids   []types.RID
+}
+
+// checkForeignKey resolves the referenced columns, the primary key when none are given,
+// and the constraint whose index the foreign key uses
+func (t *TableManager) checkForeignKey(tableName string, columns []*column.Column, constraints []*constraint.Constraint, c *constraint.Constraint) error {
+	if !constraint.IsForeignKeyAction(c.OnDelete) || !constraint.IsForeignKeyAction(c.OnUpdate) {
+		return errors.ErrSyntax
+	}
+
+	refColumns, refConstraints := columns, constraints
+
+	if c.RefTable != tableName {
+		if IsSystemTable(c.RefTable) || IsInformationSchema(c.RefTable) {
+			return errors.ErrSystemTable
+		}
+
+		var err error
+
+		if refColumns, err = t.GetTableMeta(c.RefTable); err != nil {
+			return err
+		}
+
+		if refConstraints, err = t.GetConstraints(c.RefTable); err != nil {
+			return err
+		}
+	}
+
+	if len(c.RefColumns) == 0 {
+		for _, rc := range refConstraints {
+			if rc.Type == types.CONSTRAINT_PRIMARY_KEY {
+				c.RefColumns = rc.Columns
+			}
+		}
+
+		if len(c.RefColumns) == 0 {
+			return errors.ErrNoUniqueConstraint
+		}
+	}
+
+	if len(c.RefColumns) != len(c.Columns) {
+		return errors.ErrForeignKeyMismatch
+	}
+
+	refPositions, err := getPositions(refColumns, c.RefColumns)
+
+	if err != nil {
+		return err
+	}
+
+	c.RefIndex = ""
+
+	for _, rc := range refConstraints {
+		if rc.HasIndex() && !rc.IsExpressionIndex() && equalNames(rc.Columns, c.RefColumns) {
+			c.RefIndex = rc.Name
+		}
+	}
+
+	if c.RefIndex == "" {
+		return errors.ErrNoUniqueConstraint
+	}
+
+	positions, _ := getPositions(columns, c.Columns)
+
+	for i, position := range positions {
+		child, parent := columns[position], refColumns[refPositions[i]]
+
+		// the keys are compared as bytes so both columns have to encode the same way
+		if child.ColumnType != parent.ColumnType || child.Size != parent.Size || child.Precision != parent.Precision || child.Scale != parent.Scale {
+			return errors.ErrForeignKeyMismatch
+		}
+	}
+
+	return nil
+}
+
+// loadForeignKey opens the index the foreign key is checked against
+func (t *TableManager) loadForeignKey(tableName string, columns []*column.Column, tc *tableConstraint) error {
+	rootPageIDs, err := t.getIndexRootPageIDs(tc.RefTable)
+
+	if err != nil {
+		return err
+	}
+
+	rootPageID, exist := rootPageIDs[tc.RefIndex]
+
+	if !exist {
+		return errors.ErrNoUniqueConstraint
+	}
+
+	tc.reference = index.GetBPlusTree(t.bufferPoolManager, rootPageID)
+
+	if tc.RefTable == tableName {
+		if tc.refPositions, err = getPositions(columns, tc.RefColumns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkReference fails when the key of the foreign key is not in the referenced index,
+// a row of a self referencing table may reference itself
+func checkReference(c *tableConstraint, values []*tuple.Value) error {
+	if c.reference == nil {
+		return nil
+	}
+
+	key, ok := index.EncodeKey(selectValues(values, c.positions))
+
+	if !ok {
+		return nil
+	}
+
+	if c.refPositions != nil {
+		if own, ok := index.EncodeKey(selectValues(values, c.refPositions)); ok && bytes.Equal(own, key) {
+			return nil
+		}
+	}
+
+	_, found, err := c.reference.Search(key)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrForeignKeyViolation}
+	}
+
+	return nil
+}
+
+// getReferences returns the foreign keys of every table which reference the table
+func (t *TableManager) getReferences(tableName string) ([]*reference, error) {
+	rows, err := t.GetTuples(types.SYSTEM_CONSTRAINTS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	references := make([]*reference, 0)
+	var columns []*column.Column
+
+	for _, values := range rows {
+		if string(values[2].VAR_CHAR) != types.CONSTRAINT_FOREIGN_KEY {
+			continue
+		}
+
+		c, err := newConstraintFromRow(values)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if c.RefTable != tableName {
+			continue
+		}
+
+		if columns == nil {
+			if columns, err = t.GetTableMeta(tableName); err != nil {
+				return nil, err
+			}
+		}
+
+		r := &reference{Constraint: c, tableName: string(values[1].VAR_CHAR)}
+		childColumns, err := t.GetTableMeta(r.tableName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if r.positions, err = getPositions(childColumns, c.Columns); err != nil {
+			return nil, err
+		}
+
+		if r.refPositions, err = getPositions(columns, c.RefColumns); err != nil {
+			return nil, err
+		}
+
+		references = append(references, r)
+	}
+
+	return references, nil
+}
+
+// findReferencingRows collects the child rows which reference the old key of the parent row,
+// newValues is nil when the row is deleted. A row which references itself is left out of a
+// delete and on an update the action is applied to newValues directly
+func (t *TableManager) findReferencingRows(tableName string, rid types.RID, oldValues []*tuple.Value, newValues []*tuple.Value) ([]*referencingRows, error) {
+	references, err := t.getReferences(tableName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*referencingRows, 0)
+
+	for _, r := range references {
+		key, ok := index.EncodeKey(selectValues(oldValues, r.refPositions))
+
+		if !ok {
+			continue
+		}
+
+		rows := &referencingRows{reference: r, action: r.OnDelete}
+
+		if newValues != nil {
+			rows.action = r.OnUpdate
+
+			if newKey, ok := index.EncodeKey(selectValues(newValues, r.refPositions)); ok && bytes.Equal(newKey, key) {
+				continue
+			}
+		}
+
+		restrict := rows.action == types.FOREIGN_KEY_RESTRICT || rows.action == types.FOREIGN_KEY_NO_ACTION
+
+		err := t.scanTuples(r.tableName, func(dataTable *DataTable, i int32, values []*tuple.Value) (bool, error) {
+			childKey, ok := index.EncodeKey(selectValues(values, r.positions))
+
+			if !ok || !bytes.Equal(childKey, key) {
+				return false, nil
+			}
+
+			childRid := types.RID{PageID: dataTable.GetPageID(), Index: i}
+
+			if r.tableName == tableName && childRid == rid {
+				if newValues == nil {
+					return false, nil
+				}
+
+				if !restrict {
+					return false, t.setReferenceValues(rows, newValues, newValues)
+				}
+			}
+
+			if restrict {
+				return false, &errors.ConstraintError{Constraint: r.Name, Err: errors.ErrForeignKeyReference}
+			}
+
+			rows.rids = append(rows.rids, childRid)
+
+			return false, nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(rows.rids) > 0 {
+			found = append(found, rows)
+		}
+	}
+
+	return found, nil
+}
+
+// runActions deletes or changes the child rows after the parent row was written,
+// a row which an earlier action already removed is skipped
+func (t *TableManager) runActions(found []*referencingRows, newValues []*tuple.Value) error {
+	for _, rows := range found {
+		for _, rid := range rows.rids {
+			var err error
+
+			if rows.action == types.FOREIGN_KEY_CASCADE && newValues == nil {
+				err = t.DeleteTuple(rows.tableName, rid)
+			} else {
+				var values []*tuple.Value
+
+				if values, err = t.GetTuple(rows.tableName, rid); err == nil {
+					if err = t.setReferenceValues(rows, values, newValues); err == nil {
+						err = t.UpdateTuple(rows.tableName, rid, values)
+					}
+				}
+			}
+
+			if err != nil && err != errors.ErrTupleNotExist {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setReferenceValues changes the foreign key columns of a child row, CASCADE copies the new
+// key of the parent, SET NULL clears them and SET DEFAULT takes the DEFAULT of each column
+func (t *TableManager) setReferenceValues(rows *referencingRows, values []*tuple.Value, newValues []*tuple.Value) error {
+	var defaults []*tuple.Value
+
+	if rows.action == types.FOREIGN_KEY_SET_DEFAULT {
+		var err error
+
+		given := make([]bool, len(values))
+
+		for i := range given {
+			given[i] = true
+		}
+
+		for _, position := range rows.positions {
+			given[position] = false
+		}
+
+		if defaults, err = t.GetDefaultValues(rows.tableName, given); err != nil {
+			return err
+		}
+	}
+
+	for i, position := range rows.positions {
+		switch rows.action {
+		case types.FOREIGN_KEY_CASCADE:
+			values[position] = newValues[rows.refPositions[i]]
+		case types.FOREIGN_KEY_SET_DEFAULT:
+			values[position] = defaults[position]
+		default:
+			values[position] = tuple.GetNullValue(values[position].GetType(), values[position].GetSize())
+		}
+	}
+
+	return nil
+}
+
+// checkReferenced fails when a foreign key of another table references the table
+func (t *TableManager) checkReferenced(tableName string) error {
+	references, err := t.getReferences(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range references {
+		if r.tableName != tableName {
+			return errors.ErrTableReferenced
+		}
+	}
+
+	return nil
+}
+
+// checkColumnReferenced fails when the column is part of a foreign key of the table
+// or is referenced by one, the types on both sides have to stay the same
+func (t *TableManager) checkColumnReferenced(tableName string, columnName string) error {
+	constraints, err := t.GetConstraints(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, c := range constraints {
+		if c.Type == types.CONSTRAINT_FOREIGN_KEY && findName(c.Columns, columnName) != -1 {
+			return errors.ErrColumnHasConstraint
+		}
+	}
+
+	references, err := t.getReferences(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, r := range references {
+		if findName(r.RefColumns, columnName) != -1 {
+			return errors.ErrColumnHasConstraint
+		}
+	}
+
+	return nil
+}
+
+// updateReferences rewrites the definition of every foreign key which references the table
+func (t *TableManager) updateReferences(tableName string, update func(c *constraint.Constraint)) error {
+	columns := GetSystemColumns(types.SYSTEM_CONSTRAINTS)
+	definitionIndex := findColumn(columns, "definition")
+
+	_, err := t.updateTuples(types.SYSTEM_CONSTRAINTS, func(values []*tuple.Value) []*tuple.Value {
+		if string(values[2].VAR_CHAR) != types.CONSTRAINT_FOREIGN_KEY {
+			return nil
+		}
+
+		c, err := newConstraintFromRow(values)
+
+		if err != nil || c.RefTable != tableName {
+			return nil
+		}
+
+		update(c)
+		values[definitionIndex] = tuple.GetValue(c.GetDefinition(), columns[definitionIndex].ColumnType, columns[definitionIndex].Size)
+
+		return values
+	})
+
+	return err
+}
+
+func equalNames(names []string, others []string) bool {
+	if len(names) != len(others) {
+		return false
+	}
+
+	for i := range names {
+		if names[i] != others[i] {
+			return false
+		}
+	}
+
+	return true
+}
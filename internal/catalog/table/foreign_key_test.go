@@ -0,0 +1,267 @@
+package table
+
+import (
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"log"
+	"os"
+	"testing"
+)
+
+func Test_ForeignKeys(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("foreign_key_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("foreign_key_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	parentColumns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 8, "name"),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("parent", parentColumns, []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"id"}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	childColumns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.INT_TYPE, 0, "parent_id"),
+	}
+
+	invalid := map[*constraint.Constraint]error{
+		constraint.NewForeignKey("", []string{"parent_id"}, "missing", nil, "", ""):                      errors.ErrNoTable,
+		constraint.NewForeignKey("", []string{"parent_id"}, "parent", []string{"name"}, "", ""):          errors.ErrNoUniqueConstraint,
+		constraint.NewForeignKey("", []string{"id", "parent_id"}, "parent", nil, "", ""):                 errors.ErrForeignKeyMismatch,
+		constraint.NewForeignKey("", []string{"parent_id"}, "parent", nil, "SET", ""):                    errors.ErrSyntax,
+		constraint.NewForeignKey("", []string{"parent_id"}, types.SYSTEM_TABLES, []string{"id"}, "", ""): errors.ErrSystemTable,
+	}
+
+	for fk, expected := range invalid {
+		if err := tableManager.CreateNewTableWithConstraints("child", childColumns, []*constraint.Constraint{fk}); err != expected {
+			t.Error("foreign key should fail with", expected, err)
+		}
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("mismatch", []*column.Column{column.NewColumn(types.LONG_INT_TYPE, 0, "parent_id")}, []*constraint.Constraint{
+		constraint.NewForeignKey("", []string{"parent_id"}, "parent", nil, "", ""),
+	}); err != errors.ErrForeignKeyMismatch {
+		t.Error("column types should match", err)
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("child", childColumns, []*constraint.Constraint{
+		constraint.NewForeignKey("", []string{"parent_id"}, "parent", nil, types.FOREIGN_KEY_CASCADE, types.FOREIGN_KEY_SET_NULL),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	restrictColumns := []*column.Column{column.NewColumn(types.INT_TYPE, 0, "parent_id")}
+
+	if err := tableManager.CreateNewTableWithConstraints("restricted", restrictColumns, []*constraint.Constraint{
+		constraint.NewForeignKey("restricted_fk", []string{"parent_id"}, "parent", []string{"id"}, "", types.FOREIGN_KEY_CASCADE),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	child := func(id int32, parentID interface{}) []*tuple.Value {
+		if parentID == nil {
+			return []*tuple.Value{tuple.GetValue(id, types.INT_TYPE, types.INT_SIZE), tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE)}
+		}
+
+		return newRow(childColumns, id, parentID)
+	}
+
+	for _, values := range [][]*tuple.Value{newRow(parentColumns, int32(1), "a"), newRow(parentColumns, int32(2), "b"), newRow(parentColumns, int32(3), "c")} {
+		if err := tableManager.InsertTuple("parent", values); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, values := range [][]*tuple.Value{child(1, int32(1)), child(2, int32(2)), child(3, int32(2)), child(4, nil)} {
+		if err := tableManager.InsertTuple("child", values); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tableManager.InsertTuple("restricted", newRow(restrictColumns, int32(3))); err != nil {
+		t.Fatal(err)
+	}
+
+	checkViolation := func(err error, expected error, constraintName string) {
+		t.Helper()
+
+		constraintError, ok := err.(*errors.ConstraintError)
+
+		if !ok || constraintError.Err != expected || constraintError.Constraint != constraintName {
+			t.Error("should violate", constraintName, err)
+		}
+	}
+
+	checkViolation(tableManager.InsertTuple("child", child(5, int32(9))), errors.ErrForeignKeyViolation, "child_parent_id_fkey")
+
+	parentRids, _, _ := tableManager.GetTuplesWithRID("parent")
+	childRids, _, _ := tableManager.GetTuplesWithRID("child")
+
+	checkViolation(tableManager.UpdateTuple("child", childRids[0], child(1, int32(9))), errors.ErrForeignKeyViolation, "child_parent_id_fkey")
+	checkViolation(tableManager.DeleteTuple("parent", parentRids[2]), errors.ErrForeignKeyReference, "restricted_fk")
+
+	// ON DELETE CASCADE removes both children of parent 2
+	if err := tableManager.DeleteTuple("parent", parentRids[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("child"); len(tuples) != 2 {
+		t.Error("children should be deleted with the parent", len(tuples))
+	}
+
+	// ON UPDATE SET NULL on child and CASCADE on restricted
+	if err := tableManager.UpdateTuple("parent", parentRids[0], newRow(parentColumns, int32(10), "a")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.UpdateTuple("parent", parentRids[2], newRow(parentColumns, int32(30), "c")); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("child"); !tuples[0][1].IsNull() {
+		t.Error("child key should be set to NULL")
+	}
+
+	if tuples, _ := tableManager.GetTuples("restricted"); tuples[0][0].INT != 30 {
+		t.Error("restricted key should follow the parent", tuples[0][0].INT)
+	}
+
+	if err := tableManager.UpdateTuple("parent", parentRids[2], newRow(parentColumns, int32(30), "d")); err != nil {
+		t.Error("a row whose key does not change is not checked", err)
+	}
+
+	if err := tableManager.DropTable("parent"); err != errors.ErrTableReferenced {
+		t.Error("referenced table should not be dropped", err)
+	}
+
+	if err := tableManager.TruncateTable("parent"); err != errors.ErrTableReferenced {
+		t.Error("referenced table should not be truncated", err)
+	}
+
+	if err := tableManager.DropColumn("child", "parent_id"); err != errors.ErrColumnHasConstraint {
+		t.Error("foreign key column should not be dropped", err)
+	}
+
+	if err := tableManager.AlterColumnType("parent", column.NewColumn(types.LONG_INT_TYPE, 0, "id")); err != errors.ErrColumnHasConstraint {
+		t.Error("referenced column should keep its type", err)
+	}
+
+	if err := tableManager.RenameTable("parent", "people"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.RenameColumn("people", "id", "person_id"); err != nil {
+		t.Fatal(err)
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("foreign_key_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := tableManager.GetConstraints("restricted")
+
+	if err != nil || loaded[0].RefTable != "people" || loaded[0].RefColumns[0] != "person_id" || loaded[0].OnDelete != types.FOREIGN_KEY_NO_ACTION || loaded[0].RefIndex != "parent_pkey" {
+		t.Fatal("foreign key should follow the renamed table", loaded, err)
+	}
+
+	checkViolation(tableManager.InsertTuple("restricted", newRow(restrictColumns, int32(3))), errors.ErrForeignKeyViolation, "restricted_fk")
+
+	if err := tableManager.InsertTuple("restricted", newRow(restrictColumns, int32(10))); err != nil {
+		t.Error(err)
+	}
+
+	if err := tableManager.DropTable("restricted"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DropTable("child"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DropTable("people"); err != nil {
+		t.Error("table should be dropped after its references", err)
+	}
+}
+
+func Test_SelfReferencingForeignKey(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("self_reference_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("self_reference_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.INT_TYPE, 0, "manager"),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("staff", columns, []*constraint.Constraint{
+		constraint.NewForeignKey("", []string{"manager"}, "staff", nil, types.FOREIGN_KEY_CASCADE, types.FOREIGN_KEY_CASCADE),
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"id"}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, values := range [][]*tuple.Value{newRow(columns, int32(1), int32(1)), newRow(columns, int32(2), int32(1)), newRow(columns, int32(3), int32(2))} {
+		if err := tableManager.InsertTuple("staff", values); err != nil {
+			t.Fatal("a row may reference itself or an earlier row", err)
+		}
+	}
+
+	rids, _, _ := tableManager.GetTuplesWithRID("staff")
+
+	// the row which references itself follows its own new key
+	if err := tableManager.UpdateTuple("staff", rids[0], newRow(columns, int32(7), int32(1))); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("staff"); tuples[0][1].INT != 7 || tuples[1][1].INT != 7 {
+		t.Error("references should cascade to the new key", tuples[0][1].INT, tuples[1][1].INT)
+	}
+
+	if err := tableManager.DeleteTuple("staff", rids[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if tuples, _ := tableManager.GetTuples("staff"); len(tuples) != 0 {
+		t.Error("delete should cascade down the chain", len(tuples))
+	}
+
+	if err := tableManager.DropTable("staff"); err != nil {
+		t.Error("a table which only references itself can be dropped", err)
+	}
+}
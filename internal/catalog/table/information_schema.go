@@ -0,0 +1,159 @@
+package table
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"sort"
+	"strings"
+)
+
+/**
+ *  INFORMATION_SCHEMA
+ *  +----------------------------+-----------------------------------------------------------------------------------+
+ *  | information_schema.tables  | table_name, table_type, column_count, row_count                                   |
+ *  | information_schema.columns | table_name, column_name, ordinal_position, data_type, column_size, column_default |
+ *  +----------------------------+-----------------------------------------------------------------------------------+
+ *
+ *  The views have no pages, their rows are built from the system tables every time they are read.
+ *  ordinal_position counts from 1 like the SQL standard, the ordinal of sys_columns from 0.
+ */
+
+func IsInformationSchema(tableName string) bool {
+	return strings.HasPrefix(tableName, types.INFORMATION_SCHEMA_PREFIX)
+}
+
+func getViewColumns(viewName string) ([]*column.Column, error) {
+	switch viewName {
+	case types.INFORMATION_SCHEMA_TABLES:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_TYPE_SIZE, "table_type"),
+			column.NewColumn(types.INT_TYPE, 0, "column_count"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "row_count"),
+		}, nil
+	case types.INFORMATION_SCHEMA_COLUMNS:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.COLUMN_NAME_MAX_SIZE, "column_name"),
+			column.NewColumn(types.INT_TYPE, 0, "ordinal_position"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_TYPE_SIZE, "data_type"),
+			column.NewColumn(types.INT_TYPE, 0, "column_size"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_DEFINITION_SIZE, "column_default"),
+		}, nil
+	}
+
+	return nil, errors.ErrNoTable
+}
+
+// getViewTuples orders the rows by table name and then by column position
+func (t *TableManager) getViewTuples(viewName string) ([][]*tuple.Value, error) {
+	columns, err := getViewColumns(viewName)
+
+	if err != nil {
+		return nil, err
+	}
+
+	columnRows, err := t.GetTuples(types.SYSTEM_COLUMNS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(columnRows, func(i, j int) bool {
+		if tableName, other := string(columnRows[i][0].VAR_CHAR), string(columnRows[j][0].VAR_CHAR); tableName != other {
+			return tableName < other
+		}
+
+		return columnRows[i][2].INT < columnRows[j][2].INT
+	})
+
+	tuples := make([][]*tuple.Value, 0)
+
+	if viewName == types.INFORMATION_SCHEMA_COLUMNS {
+		defaults, err := t.getColumnDefaults()
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, values := range columnRows {
+			row := newRow(columns[:5], string(values[0].VAR_CHAR), string(values[1].VAR_CHAR), values[2].INT+1, string(values[3].VAR_CHAR), values[4].INT)
+			definition, exist := defaults[string(values[0].VAR_CHAR)+"."+string(values[1].VAR_CHAR)]
+
+			if exist {
+				row = append(row, tuple.GetValue(definition, columns[5].ColumnType, columns[5].Size))
+			} else {
+				row = append(row, tuple.GetNullValue(columns[5].ColumnType, columns[5].Size))
+			}
+
+			tuples = append(tuples, row)
+		}
+
+		return tuples, nil
+	}
+
+	columnCount := make(map[string]int32)
+
+	for _, values := range columnRows {
+		columnCount[string(values[0].VAR_CHAR)]++
+	}
+
+	statisticRows, err := t.GetTuples(types.SYSTEM_STATISTICS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rowCount := make(map[string]int64)
+
+	for _, values := range statisticRows {
+		rowCount[string(values[0].VAR_CHAR)] = values[1].LONG_INT
+	}
+
+	tables, err := t.readCatalog()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tableNames := make([]string, 0, len(tables))
+
+	for tableName := range tables {
+		tableNames = append(tableNames, tableName)
+	}
+
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		tableType := types.TABLE_TYPE_BASE
+
+		if IsSystemTable(tableName) {
+			tableType = types.TABLE_TYPE_SYSTEM
+		}
+
+		tuples = append(tuples, newRow(columns, tableName, tableType, columnCount[tableName], rowCount[tableName]))
+	}
+
+	return tuples, nil
+}
+
+// getColumnDefaults maps table.column to the text of its DEFAULT
+func (t *TableManager) getColumnDefaults() (map[string]string, error) {
+	rows, err := t.GetTuples(types.SYSTEM_CONSTRAINTS)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defaults := make(map[string]string)
+
+	for _, values := range rows {
+		if string(values[2].VAR_CHAR) == types.CONSTRAINT_DEFAULT {
+			defaults[string(values[1].VAR_CHAR)+"."+string(values[3].VAR_CHAR)] = string(values[5].VAR_CHAR)
+		}
+	}
+
+	return defaults, nil
+}
@@ -0,0 +1,345 @@
+package table
+
+import (
+	"go-db/internal/catalog/sequence"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"sync"
+)
+
+/**
+ *  SEQUENCES
+ *
+ *  nextval reserves SEQUENCE_CACHE_SIZE values at a time, the last reserved value is written
+ *  to sys_sequences before the first of them is handed out. A restart continues after the
+ *  reserved values, so a value is never handed out twice though some of them may be skipped.
+ *
+ *  Every sequence has its own lock and the lock is only held while the page is written
+ *  once per reservation. The buffer pool is not safe to share, so the reads and writes of
+ *  sys_sequences wait for each other under sequencePageLock, the values which are already
+ *  reserved are handed out without it. currval returns the last value nextval handed out
+ *  since the database was started.
+ *
+ *  The locks are taken in the order sequenceLock, the lock of a sequence, sequencePageLock.
+ */
+
+const SEQUENCE_CACHE_SIZE = 32
+
+type sequenceState struct {
+	mutex    sync.Mutex
+	sequence *sequence.Sequence
+	// next is the value nextval returns, left counts the reserved values starting at next
+	next    int64
+	left    int32
+	done    bool
+	last    int64
+	called  bool
+	dropped bool
+}
+
+// CreateSequence fails when the sequence exists or its owner column does not
+func (t *TableManager) CreateSequence(s *sequence.Sequence) error {
+	if err := s.Check(); err != nil {
+		return err
+	}
+
+	if s.Name == "" || len(s.Name) > SYSTEM_NAME_SIZE {
+		return errors.ErrInvalidSequence
+	}
+
+	if s.OwnerTable != "" {
+		columns, err := t.GetTableMeta(s.OwnerTable)
+
+		if err != nil {
+			return err
+		}
+
+		if findColumn(columns, s.OwnerColumn) == -1 {
+			return errors.ErrColumnNotExist
+		}
+	}
+
+	t.sequenceLock.Lock()
+	defer t.sequenceLock.Unlock()
+
+	if _, err := t.findSequence(s.Name); err != errors.ErrNoSequence {
+		if err == nil {
+			return errors.ErrSequenceExist
+		}
+
+		return err
+	}
+
+	row := newRow(GetSystemColumns(types.SYSTEM_SEQUENCES), s.Name, s.OwnerTable, s.OwnerColumn,
+		s.Start, s.Increment, s.MinValue, s.MaxValue, s.Cycle, s.Start, false)
+
+	t.sequencePageLock.Lock()
+	_, err := t.insertTuple(types.SYSTEM_SEQUENCES, row)
+	t.sequencePageLock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	delete(t.sequences, s.Name)
+
+	return nil
+}
+
+// DropSequence only drops a sequence which no column owns
+func (t *TableManager) DropSequence(name string) error {
+	t.sequenceLock.Lock()
+	defer t.sequenceLock.Unlock()
+
+	s, err := t.findSequence(name)
+
+	if err != nil {
+		return err
+	}
+
+	if s.OwnerTable != "" {
+		return errors.ErrSequenceOwned
+	}
+
+	t.sequencePageLock.Lock()
+	_, err = t.deleteTuples(types.SYSTEM_SEQUENCES, func(values []*tuple.Value) bool {
+		return string(values[0].VAR_CHAR) == name
+	})
+	t.sequencePageLock.Unlock()
+
+	t.forgetSequence(name)
+
+	return err
+}
+
+func (t *TableManager) GetSequence(name string) (*sequence.Sequence, error) {
+	return t.findSequence(name)
+}
+
+// NextValue hands out the next value of the sequence, it only writes to sys_sequences
+// when the values it reserved before are used up
+func (t *TableManager) NextValue(name string) (int64, error) {
+	state, err := t.getSequenceState(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	if state.dropped {
+		return 0, errors.ErrNoSequence
+	}
+
+	if state.left == 0 {
+		if state.done {
+			return 0, errors.ErrSequenceExhausted
+		}
+
+		last, count := state.next, int32(1)
+
+		for count < SEQUENCE_CACHE_SIZE {
+			value, ok := state.sequence.Next(last)
+
+			// a short sequence which cycles must not reserve its values twice
+			if !ok || value == state.next {
+				break
+			}
+
+			last = value
+			count++
+		}
+
+		if err := t.writeSequence(name, last); err != nil {
+			return 0, err
+		}
+
+		state.left = count
+	}
+
+	value := state.next
+	next, ok := state.sequence.Next(value)
+
+	state.left--
+	state.last, state.called = value, true
+	state.next, state.done = next, !ok
+
+	return value, nil
+}
+
+func (t *TableManager) CurrentValue(name string) (int64, error) {
+	state, err := t.getSequenceState(name)
+
+	if err != nil {
+		return 0, err
+	}
+
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+
+	if state.dropped {
+		return 0, errors.ErrNoSequence
+	}
+
+	if !state.called {
+		return 0, errors.ErrSequenceNotCalled
+	}
+
+	return state.last, nil
+}
+
+// getSequenceState reads the sequence the first time it is used, the values
+// which were reserved before the restart are skipped
+func (t *TableManager) getSequenceState(name string) (*sequenceState, error) {
+	t.sequenceLock.Lock()
+	defer t.sequenceLock.Unlock()
+
+	if state, exist := t.sequences[name]; exist {
+		return state, nil
+	}
+
+	t.sequencePageLock.Lock()
+	rows, err := t.GetTuples(types.SYSTEM_SEQUENCES)
+	t.sequencePageLock.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, values := range rows {
+		if string(values[0].VAR_CHAR) != name {
+			continue
+		}
+
+		state := &sequenceState{sequence: newSequenceFromRow(values), next: values[8].LONG_INT}
+
+		if values[9].BOOL {
+			next, ok := state.sequence.Next(state.next)
+			state.next, state.done = next, !ok
+		}
+
+		t.sequences[name] = state
+
+		return state, nil
+	}
+
+	return nil, errors.ErrNoSequence
+}
+
+// writeSequence records the last value which may have been handed out
+func (t *TableManager) writeSequence(name string, last int64) error {
+	columns := GetSystemColumns(types.SYSTEM_SEQUENCES)
+
+	t.sequencePageLock.Lock()
+	defer t.sequencePageLock.Unlock()
+
+	count, err := t.updateTuples(types.SYSTEM_SEQUENCES, func(values []*tuple.Value) []*tuple.Value {
+		if string(values[0].VAR_CHAR) != name {
+			return nil
+		}
+
+		values[8] = tuple.GetValue(last, columns[8].ColumnType, columns[8].Size)
+		values[9] = tuple.GetValue(true, columns[9].ColumnType, columns[9].Size)
+
+		return values
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return errors.ErrNoSequence
+	}
+
+	return nil
+}
+
+func (t *TableManager) findSequence(name string) (*sequence.Sequence, error) {
+	t.sequencePageLock.Lock()
+	rows, err := t.GetTuples(types.SYSTEM_SEQUENCES)
+	t.sequencePageLock.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, values := range rows {
+		if string(values[0].VAR_CHAR) == name {
+			return newSequenceFromRow(values), nil
+		}
+	}
+
+	return nil, errors.ErrNoSequence
+}
+
+// dropOwnedSequences drops the sequences of the column or of every column when the name is empty
+func (t *TableManager) dropOwnedSequences(tableName string, columnName string) error {
+	t.sequenceLock.Lock()
+	defer t.sequenceLock.Unlock()
+
+	dropped := make([]string, 0)
+
+	t.sequencePageLock.Lock()
+	_, err := t.deleteTuples(types.SYSTEM_SEQUENCES, func(values []*tuple.Value) bool {
+		if string(values[1].VAR_CHAR) != tableName || (columnName != "" && string(values[2].VAR_CHAR) != columnName) {
+			return false
+		}
+
+		dropped = append(dropped, string(values[0].VAR_CHAR))
+
+		return true
+	})
+	t.sequencePageLock.Unlock()
+
+	for _, name := range dropped {
+		t.forgetSequence(name)
+	}
+
+	return err
+}
+
+func (t *TableManager) renameSequenceOwner(tableName string, columnName string, newColumnName string) error {
+	columns := GetSystemColumns(types.SYSTEM_SEQUENCES)
+
+	t.sequencePageLock.Lock()
+	defer t.sequencePageLock.Unlock()
+
+	_, err := t.updateTuples(types.SYSTEM_SEQUENCES, func(values []*tuple.Value) []*tuple.Value {
+		if string(values[1].VAR_CHAR) != tableName || string(values[2].VAR_CHAR) != columnName {
+			return nil
+		}
+
+		values[2] = tuple.GetValue(newColumnName, columns[2].ColumnType, columns[2].Size)
+
+		return values
+	})
+
+	return err
+}
+
+// forgetSequence needs the sequence lock, a nextval which already holds the state fails
+func (t *TableManager) forgetSequence(name string) {
+	if state, exist := t.sequences[name]; exist {
+		state.mutex.Lock()
+		state.dropped = true
+		state.mutex.Unlock()
+	}
+
+	delete(t.sequences, name)
+}
+
+func newSequenceFromRow(values []*tuple.Value) *sequence.Sequence {
+	return &sequence.Sequence{
+		Name:        string(values[0].VAR_CHAR),
+		OwnerTable:  string(values[1].VAR_CHAR),
+		OwnerColumn: string(values[2].VAR_CHAR),
+		Start:       values[3].LONG_INT,
+		Increment:   values[4].LONG_INT,
+		MinValue:    values[5].LONG_INT,
+		MaxValue:    values[6].LONG_INT,
+		Cycle:       values[7].BOOL,
+	}
+}
@@ -0,0 +1,268 @@
+package table
+
+import (
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/sequence"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"log"
+	"os"
+	"sync"
+	"testing"
+)
+
+func Test_Sequences(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("sequence_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("sequence_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	if err := tableManager.CreateSequence(sequence.NewSequence("ids", 1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.CreateSequence(sequence.NewSequence("ids", 1)); err != errors.ErrSequenceExist {
+		t.Error("sequence should not be created twice", err)
+	}
+
+	invalid := sequence.NewSequence("invalid", 1)
+	invalid.Start = 0
+
+	if err := tableManager.CreateSequence(invalid); err != errors.ErrInvalidSequence {
+		t.Error("start should be within the limits", err)
+	}
+
+	if _, err := tableManager.CurrentValue("ids"); err != errors.ErrSequenceNotCalled {
+		t.Error("currval should fail before nextval", err)
+	}
+
+	for i := int64(1); i <= SEQUENCE_CACHE_SIZE+2; i++ {
+		if value, err := tableManager.NextValue("ids"); err != nil || value != i {
+			t.Fatal("get the wrong value", i, value, err)
+		}
+	}
+
+	if value, err := tableManager.CurrentValue("ids"); err != nil || value != SEQUENCE_CACHE_SIZE+2 {
+		t.Error("currval should be the last value", value, err)
+	}
+
+	// values are handed out once however the goroutines interleave
+	var group sync.WaitGroup
+	var lock sync.Mutex
+	seen := make(map[int64]bool)
+
+	for i := 0; i < 8; i++ {
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			for j := 0; j < 50; j++ {
+				value, err := tableManager.NextValue("ids")
+
+				lock.Lock()
+
+				if err != nil || seen[value] {
+					t.Error("value should be handed out once", value, err)
+				}
+
+				seen[value] = true
+				lock.Unlock()
+			}
+		}()
+	}
+
+	group.Wait()
+
+	last, _ := tableManager.CurrentValue("ids")
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("sequence_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the values reserved before the restart are skipped
+	if value, err := tableManager.NextValue("ids"); err != nil || value <= last || value > last+SEQUENCE_CACHE_SIZE {
+		t.Error("sequence should continue after the reserved values", last, value, err)
+	}
+
+	short := sequence.NewSequence("short", -2)
+	short.MinValue, short.MaxValue, short.Start = 1, 5, 5
+
+	if err := tableManager.CreateSequence(short); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []int64{5, 3, 1} {
+		if value, err := tableManager.NextValue("short"); err != nil || value != expected {
+			t.Error("get the wrong value", expected, value, err)
+		}
+	}
+
+	if _, err := tableManager.NextValue("short"); err != errors.ErrSequenceExhausted {
+		t.Error("sequence should stop at its limit", err)
+	}
+
+	cycle := sequence.NewSequence("cycle", 1)
+	cycle.MaxValue, cycle.Cycle = 2, true
+
+	if err := tableManager.CreateSequence(cycle); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, expected := range []int64{1, 2, 1, 2} {
+		if value, err := tableManager.NextValue("cycle"); err != nil || value != expected {
+			t.Error("get the wrong value", expected, value, err)
+		}
+	}
+
+	if err := tableManager.DropSequence("cycle"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tableManager.NextValue("cycle"); err != errors.ErrNoSequence {
+		t.Error("dropped sequence should not be found", err)
+	}
+}
+
+func Test_OwnedSequences(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("owned_sequence_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("owned_sequence_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.INT_TYPE, 0, "other"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.CreateSequence(sequence.NewSerial("testTable", "missing", 10)); err != errors.ErrColumnNotExist {
+		t.Error("owner column should exist", err)
+	}
+
+	for _, columnName := range []string{"id", "other"} {
+		if err := tableManager.CreateSequence(sequence.NewSerial("testTable", columnName, 10)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tableManager.DropSequence("testTable_id_seq"); err != errors.ErrSequenceOwned {
+		t.Error("owned sequence should not be dropped alone", err)
+	}
+
+	if err := tableManager.RenameColumn("testTable", "id", "key"); err != nil {
+		t.Fatal(err)
+	}
+
+	if s, err := tableManager.GetSequence("testTable_id_seq"); err != nil || s.OwnerColumn != "key" {
+		t.Error("sequence should follow the renamed column", s, err)
+	}
+
+	if _, err := tableManager.NextValue("testTable_other_seq"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DropColumn("testTable", "other"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tableManager.NextValue("testTable_other_seq"); err != errors.ErrNoSequence {
+		t.Error("sequence should be dropped with its column", err)
+	}
+
+	if err := tableManager.DropTable("testTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := tableManager.GetSequence("testTable_id_seq"); err != errors.ErrNoSequence {
+		t.Error("sequence should be dropped with its table", err)
+	}
+}
+
+func Test_ConcurrentSequences(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("concurrent_sequence_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("concurrent_sequence_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	names := []string{"first", "second"}
+
+	for _, name := range names {
+		if err := tableManager.CreateSequence(sequence.NewSequence(name, 1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// two sequences reserve their values and write sys_sequences at the same time
+	var group sync.WaitGroup
+	var lock sync.Mutex
+	seen := map[string]map[int64]bool{"first": {}, "second": {}}
+
+	for i := 0; i < 8; i++ {
+		name := names[i%len(names)]
+		group.Add(1)
+
+		go func() {
+			defer group.Done()
+
+			for j := 0; j < SEQUENCE_CACHE_SIZE*3; j++ {
+				value, err := tableManager.NextValue(name)
+
+				lock.Lock()
+
+				if err != nil || seen[name][value] {
+					t.Error("value should be handed out once", name, value, err)
+				}
+
+				seen[name][value] = true
+				lock.Unlock()
+			}
+		}()
+	}
+
+	group.Wait()
+
+	for _, name := range names {
+		if value, err := tableManager.NextValue(name); err != nil || value != SEQUENCE_CACHE_SIZE*12+1 {
+			t.Error("every value should be handed out in order", name, value, err)
+		}
+	}
+
+	diskManager.ShutDown()
+}
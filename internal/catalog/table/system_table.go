@@ -0,0 +1,371 @@
+package table
+
+import (
+	"bytes"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strings"
+)
+
+/**
+ *  SYSTEM CATALOG
+ *  +-----------------+------------------------------------------------------------------------------------+
+ *  | sys_tables      | table_name, meta_page_id                                                           |
+ *  | sys_columns     | table_name, column_name, ordinal, column_type, column_size                         |
+ *  | sys_indexes     | index_name, table_name, column_names, is_unique, root_page_id                      |
+ *  | sys_constraints | constraint_name, table_name, constraint_type, column_names, index_name, definition |
+ *  | sys_statistics  | table_name, row_count, page_count                                                  |
+ *  | sys_sequences   | sequence_name, table_name, column_name, start_value, increment, min_value,         |
+ *  |                 | max_value, cycle, last_value, is_called                                            |
+ *  +-----------------+------------------------------------------------------------------------------------+
+ *
+ *  The system tables are ordinary heap tables which describe themselves as well,
+ *  the catalog root in the super block is the schema meta page of sys_tables.
+ *  Schema pages stay the tuple layout the storage reads, every DDL rewrites sys_columns
+ *  right after them and trashdb-check reports the two when they disagree.
+ *  sys_statistics is counted again by ANALYZE and by the DDL which rewrites the data pages,
+ *  plain INSERT and DELETE leave it behind like the estimates of other databases.
+ */
+
+var systemTables = []string{
+	types.SYSTEM_TABLES,
+	types.SYSTEM_COLUMNS,
+	types.SYSTEM_INDEXES,
+	types.SYSTEM_CONSTRAINTS,
+	types.SYSTEM_STATISTICS,
+	types.SYSTEM_SEQUENCES,
+}
+
+const (
+	SYSTEM_TABLE_NAME_COLUMN = "table_name"
+	SYSTEM_NAME_SIZE         = 128
+	SYSTEM_TYPE_SIZE         = 16
+	SYSTEM_COLUMN_NAMES_SIZE = 512
+	SYSTEM_DEFINITION_SIZE   = 1024
+)
+
+func IsSystemTable(tableName string) bool {
+	return strings.HasPrefix(tableName, types.SYSTEM_TABLE_PREFIX)
+}
+
+// GetSystemColumns returns new columns on every call so the caller can change them
+func GetSystemColumns(tableName string) []*column.Column {
+	switch tableName {
+	case types.SYSTEM_TABLES:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.INT_TYPE, 0, "meta_page_id"),
+		}
+	case types.SYSTEM_COLUMNS:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.COLUMN_NAME_MAX_SIZE, "column_name"),
+			column.NewColumn(types.INT_TYPE, 0, "ordinal"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_TYPE_SIZE, "column_type"),
+			column.NewColumn(types.INT_TYPE, 0, "column_size"),
+		}
+	case types.SYSTEM_INDEXES:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_NAME_SIZE, "index_name"),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_COLUMN_NAMES_SIZE, "column_names"),
+			column.NewColumn(types.BOOL_TYPE, 0, "is_unique"),
+			column.NewColumn(types.INT_TYPE, 0, "root_page_id"),
+		}
+	case types.SYSTEM_CONSTRAINTS:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_NAME_SIZE, "constraint_name"),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_TYPE_SIZE, "constraint_type"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_COLUMN_NAMES_SIZE, "column_names"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_NAME_SIZE, "index_name"),
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_DEFINITION_SIZE, "definition"),
+		}
+	case types.SYSTEM_STATISTICS:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "row_count"),
+			column.NewColumn(types.INT_TYPE, 0, "page_count"),
+		}
+	case types.SYSTEM_SEQUENCES:
+		return []*column.Column{
+			column.NewColumn(types.VAR_CHAR_TYPE, SYSTEM_NAME_SIZE, "sequence_name"),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.TABLE_NAME_MAX_SIZE, SYSTEM_TABLE_NAME_COLUMN),
+			column.NewColumn(types.VAR_CHAR_TYPE, types.COLUMN_NAME_MAX_SIZE, "column_name"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "start_value"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "increment"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "min_value"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "max_value"),
+			column.NewColumn(types.BOOL_TYPE, 0, "cycle"),
+			column.NewColumn(types.LONG_INT_TYPE, 0, "last_value"),
+			column.NewColumn(types.BOOL_TYPE, 0, "is_called"),
+		}
+	}
+
+	return nil
+}
+
+// AnalyzeTable counts the tuples and data pages of the table into sys_statistics
+func (t *TableManager) AnalyzeTable(tableName string) error {
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return errors.ErrNoTable
+	}
+
+	dataPageID, err := t.getDataPageID(metaPageID)
+
+	if err != nil {
+		return err
+	}
+
+	var rowCount int64
+	var pageCount int32
+
+	for dataPageID != constant.INVALID_PAGE_ID {
+		page, err := t.bufferPoolManager.FetchPage(dataPageID)
+
+		if err != nil {
+			return err
+		}
+
+		dataTable := GetDataTable(page)
+
+		for i := int32(0); i < dataTable.GetTupleCount(); i++ {
+			if !dataTable.IsTupleDeleted(i) {
+				rowCount++
+			}
+		}
+
+		pageCount++
+		t.bufferPoolManager.UnpinPage(dataPageID)
+		dataPageID = dataTable.GetNextPageID()
+	}
+
+	columns := GetSystemColumns(types.SYSTEM_STATISTICS)
+
+	_, err = t.updateTuples(types.SYSTEM_STATISTICS, func(values []*tuple.Value) []*tuple.Value {
+		if string(values[0].VAR_CHAR) != tableName {
+			return nil
+		}
+
+		return newRow(columns, tableName, rowCount, pageCount)
+	})
+
+	return err
+}
+
+// loadSystemTables finds the system tables through the catalog root,
+// a database without a catalog root is bootstrapped first
+func (t *TableManager) loadSystemTables() error {
+	rootPageID := t.bufferPoolManager.DiskManager.GetCatalogRootPageID()
+
+	if rootPageID == constant.INVALID_PAGE_ID {
+		return t.bootstrap()
+	}
+
+	t.setMetaPageID(types.SYSTEM_TABLES, rootPageID)
+
+	tables, err := t.readCatalog()
+
+	if err != nil {
+		return err
+	}
+
+	for _, tableName := range systemTables {
+		metaPageID, exist := tables[tableName]
+
+		if exist {
+			t.setMetaPageID(tableName, metaPageID)
+			continue
+		}
+
+		// the catalog was written before the system table was added
+		if metaPageID, err = t.createTable(tableName, GetSystemColumns(tableName)); err != nil {
+			return err
+		}
+
+		t.setMetaPageID(tableName, metaPageID)
+
+		if err := t.registerTable(tableName, metaPageID, GetSystemColumns(tableName)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readCatalog returns the meta page of every table recorded in sys_tables
+func (t *TableManager) readCatalog() (map[string]types.Page_id_t, error) {
+	rows, err := t.GetTuples(types.SYSTEM_TABLES)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make(map[string]types.Page_id_t, len(rows))
+
+	for _, values := range rows {
+		tables[string(values[0].VAR_CHAR)] = types.Page_id_t(values[1].INT)
+	}
+
+	return tables, nil
+}
+
+// bootstrap creates every system table before any of them is registered,
+// the catalog root is only set once sys_tables describes all of them
+func (t *TableManager) bootstrap() error {
+	for _, tableName := range systemTables {
+		metaPageID, err := t.createTable(tableName, GetSystemColumns(tableName))
+
+		if err != nil {
+			return err
+		}
+
+		t.setMetaPageID(tableName, metaPageID)
+	}
+
+	for _, tableName := range systemTables {
+		metaPageID, _ := t.getMetaPageID(tableName)
+
+		if err := t.registerTable(tableName, metaPageID, GetSystemColumns(tableName)); err != nil {
+			return err
+		}
+	}
+
+	rootPageID, _ := t.getMetaPageID(types.SYSTEM_TABLES)
+
+	return t.bufferPoolManager.DiskManager.SetCatalogRootPageID(rootPageID)
+}
+
+// registerTable records the table in sys_tables, sys_columns and sys_statistics
+// so the table can be found again after restart
+func (t *TableManager) registerTable(tableName string, metaPageID types.Page_id_t, columns []*column.Column) error {
+	if _, err := t.insertTuple(types.SYSTEM_TABLES, newRow(GetSystemColumns(types.SYSTEM_TABLES), tableName, int32(metaPageID))); err != nil {
+		return err
+	}
+
+	if err := t.insertColumnRows(tableName, columns); err != nil {
+		return err
+	}
+
+	_, err := t.insertTuple(types.SYSTEM_STATISTICS, newRow(GetSystemColumns(types.SYSTEM_STATISTICS), tableName, int64(0), int32(1)))
+
+	return err
+}
+
+// unregisterTable removes every system table row which belongs to the table
+func (t *TableManager) unregisterTable(tableName string) error {
+	for _, systemTable := range systemTables {
+		index := findColumn(GetSystemColumns(systemTable), SYSTEM_TABLE_NAME_COLUMN)
+
+		_, err := t.deleteTuples(systemTable, func(values []*tuple.Value) bool {
+			return string(values[index].VAR_CHAR) == tableName
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TableManager) renameTableRows(tableName string, newTableName string) error {
+	for _, systemTable := range systemTables {
+		columns := GetSystemColumns(systemTable)
+		index := findColumn(columns, SYSTEM_TABLE_NAME_COLUMN)
+
+		_, err := t.updateTuples(systemTable, func(values []*tuple.Value) []*tuple.Value {
+			if string(values[index].VAR_CHAR) != tableName {
+				return nil
+			}
+
+			values[index] = tuple.GetValue(newTableName, columns[index].ColumnType, columns[index].Size)
+
+			return values
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// replaceColumnRows keeps sys_columns in step with the schema pages after the columns changed,
+// the rows are changed in place so the catalog does not grow with every ALTER TABLE
+func (t *TableManager) replaceColumnRows(tableName string, columns []*column.Column) error {
+	systemColumns := GetSystemColumns(types.SYSTEM_COLUMNS)
+	exist := make([]bool, len(columns))
+
+	_, err := t.updateTuples(types.SYSTEM_COLUMNS, func(values []*tuple.Value) []*tuple.Value {
+		ordinal := values[2].INT
+
+		if string(values[0].VAR_CHAR) != tableName || int(ordinal) >= len(columns) {
+			return nil
+		}
+
+		exist[ordinal] = true
+		c := columns[ordinal]
+		newValues := newRow(systemColumns, tableName, c.Name, ordinal, c.GetTypeName(), c.Size)
+
+		if bytes.Equal(tuple.TupleSerialization(values), tuple.TupleSerialization(newValues)) {
+			return nil
+		}
+
+		return newValues
+	})
+
+	if err != nil {
+		return err
+	}
+
+	_, err = t.deleteTuples(types.SYSTEM_COLUMNS, func(values []*tuple.Value) bool {
+		return string(values[0].VAR_CHAR) == tableName && int(values[2].INT) >= len(columns)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for i, c := range columns {
+		if exist[i] {
+			continue
+		}
+
+		if _, err := t.insertTuple(types.SYSTEM_COLUMNS, newRow(systemColumns, tableName, c.Name, int32(i), c.GetTypeName(), c.Size)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TableManager) insertColumnRows(tableName string, columns []*column.Column) error {
+	systemColumns := GetSystemColumns(types.SYSTEM_COLUMNS)
+
+	for i, c := range columns {
+		values := newRow(systemColumns, tableName, c.Name, int32(i), c.GetTypeName(), c.Size)
+
+		if _, err := t.insertTuple(types.SYSTEM_COLUMNS, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newRow(columns []*column.Column, values ...interface{}) []*tuple.Value {
+	row := make([]*tuple.Value, 0, len(columns))
+
+	for i, c := range columns {
+		row = append(row, tuple.GetValue(values[i], c.ColumnType, c.Size))
+	}
+
+	return row
+}
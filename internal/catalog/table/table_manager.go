@@ -1,15 +1,17 @@
 package table
 
 import (
-	"fmt"
 	"go-db/internal/buffer"
 	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/expression"
 	"go-db/internal/catalog/schema"
 	"go-db/internal/catalog/tuple"
 	"go-db/internal/common/constant"
 	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"log"
+	"math"
 	"sync"
 )
 
@@ -18,195 +20,1178 @@ type TableManager struct {
 	bufferPoolManager *buffer.BufferPoolManager
 	TableMetaPageID   map[string]types.Page_id_t
 	RLock             sync.RWMutex
+
+	sequences    map[string]*sequenceState
+	sequenceLock sync.Mutex
+	// the pages of sys_sequences, taken last after the other locks of the sequences
+	sequencePageLock sync.Mutex
+
+	// the writes of a table which search a unique key before they insert it
+	writeLocks map[string]*sync.Mutex
+	writeLock  sync.Mutex
+}
+
+// NewTableManager only knows the tables in the map besides the system tables,
+// which are bootstrapped when the database has no catalog root yet
+func NewTableManager(bufferPoolManager *buffer.BufferPoolManager, tableMetaPageID map[string]types.Page_id_t) *TableManager {
+	t := &TableManager{
+		bufferPoolManager: bufferPoolManager,
+		TableMetaPageID:   tableMetaPageID,
+		sequences:         make(map[string]*sequenceState),
+		writeLocks:        make(map[string]*sync.Mutex),
+	}
+
+	if err := t.loadSystemTables(); err != nil {
+		log.Println(err)
+	}
+
+	return t
+}
+
+// LoadTableManager rebuilds the table map from the sys_tables rows
+// which start at the catalog root recorded in the super block
+func LoadTableManager(bufferPoolManager *buffer.BufferPoolManager) (*TableManager, error) {
+	t := &TableManager{
+		bufferPoolManager: bufferPoolManager,
+		TableMetaPageID:   make(map[string]types.Page_id_t),
+		sequences:         make(map[string]*sequenceState),
+		writeLocks:        make(map[string]*sync.Mutex),
+	}
+
+	if err := t.loadSystemTables(); err != nil {
+		return nil, err
+	}
+
+	tables, err := t.readCatalog()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for tableName, metaPageID := range tables {
+		t.setMetaPageID(tableName, metaPageID)
+	}
+
+	return t, nil
+}
+
+// GetTables only returns the user tables
+func (t *TableManager) GetTables() []string {
+	t.RLock.RLock()
+	defer t.RLock.RUnlock()
+
+	tableName := make([]string, 0, len(t.TableMetaPageID))
+
+	for k := range t.TableMetaPageID {
+		if !IsSystemTable(k) {
+			tableName = append(tableName, k)
+		}
+	}
+
+	return tableName
+}
+
+func (t *TableManager) GetTableMeta(tableName string) ([]*column.Column, error) {
+	if IsInformationSchema(tableName) {
+		return getViewColumns(tableName)
+	}
+
+	pageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return nil, errors.ErrNoTable
+	}
+
+	return t.readColumns(pageID)
+}
+
+func (t *TableManager) CreateNewTable(tableName string, columns []*column.Column) error {
+	return t.CreateNewTableWithConstraints(tableName, columns, nil)
+}
+
+// AddNewColumn backfills the existing tuples with the zero value of the column type
+func (t *TableManager) AddNewColumn(tableName string, column *column.Column) error {
+	return t.AddNewColumnWithConstraints(tableName, column, nil)
+}
+
+// AddNewColumnWithDefault backfills the existing tuples with the value and keeps it as the DEFAULT of the column
+func (t *TableManager) AddNewColumnWithDefault(tableName string, newColumn *column.Column, defaultValue interface{}) error {
+	value := tuple.GetValue(defaultValue, newColumn.ColumnType, newColumn.Size)
+
+	if value == nil {
+		return errors.ErrInvalidValue
+	}
+
+	defaultConstraint := constraint.NewExpressionConstraint("", types.CONSTRAINT_DEFAULT, []string{newColumn.Name}, (&expression.Literal{Value: value}).String())
+
+	return t.AddNewColumnWithConstraints(tableName, newColumn, []*constraint.Constraint{defaultConstraint})
+}
+
+// AddNewColumnWithConstraints backfills the existing tuples with the DEFAULT of the column, which is
+// evaluated for every tuple, or with the zero value of the column type. Only DEFAULT, NOT NULL and
+// CHECK can be added together with the column, the tuples are checked against them before anything is written
+func (t *TableManager) AddNewColumnWithConstraints(tableName string, newColumn *column.Column, constraints []*constraint.Constraint) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	if findColumn(columns, newColumn.Name) != -1 {
+		return errors.ErrColumnExist
+	}
+
+	zero, err := tuple.FitValue(tuple.GetValue(tuple.GetDefaultValue(newColumn.ColumnType), newColumn.ColumnType, newColumn.Size), newColumn)
+
+	if err != nil {
+		return err
+	}
+
+	columns = append(columns, newColumn)
+
+	for _, c := range constraints {
+		if c.Type != types.CONSTRAINT_DEFAULT && c.Type != types.CONSTRAINT_NOT_NULL && c.Type != types.CONSTRAINT_CHECK {
+			return errors.ErrAddColumnConstraint
+		}
+	}
+
+	if err := t.checkConstraints(tableName, columns, constraints); err != nil {
+		return err
+	}
+
+	defaultText := ""
+	added := make([]*tableConstraint, 0, len(constraints))
+
+	for _, c := range constraints {
+		if c.Type == types.CONSTRAINT_DEFAULT {
+			defaultText = c.Expression
+			continue
+		}
+
+		tc, err := t.resolveConstraint(tableName, columns, c, nil)
+
+		if err != nil {
+			return err
+		}
+
+		added = append(added, tc)
+	}
+
+	err = t.rewriteTable(tableName, columns, func(values []*tuple.Value) ([]*tuple.Value, error) {
+		value := zero
+
+		if defaultText != "" {
+			var err error
+
+			if value, err = evaluateDefault(defaultText, newColumn, t); err != nil {
+				return nil, err
+			}
+		}
+
+		values = append(values, value)
+
+		for _, c := range added {
+			if err := checkNotNull(c, values); err != nil {
+				return nil, err
+			}
+
+			if err := checkCondition(c, values); err != nil {
+				return nil, err
+			}
+		}
+
+		return values, nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, c := range constraints {
+		if err := t.addConstraint(tableName, columns, c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *TableManager) DropColumn(tableName string, columnName string) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	index := findColumn(columns, columnName)
+
+	if index == -1 {
+		return errors.ErrColumnNotExist
+	}
+
+	if err := t.dropColumnConstraints(tableName, columnName); err != nil {
+		return err
+	}
+
+	if err := t.dropOwnedSequences(tableName, columnName); err != nil {
+		return err
+	}
+
+	newColumns := append(append([]*column.Column{}, columns[:index]...), columns[index+1:]...)
+
+	return t.rewriteTable(tableName, newColumns, func(values []*tuple.Value) ([]*tuple.Value, error) {
+		return append(append([]*tuple.Value{}, values[:index]...), values[index+1:]...), nil
+	})
+}
+
+// AlterColumnType converts every stored value, nothing is changed when any value can not be converted
+// or does not fit the new size
+func (t *TableManager) AlterColumnType(tableName string, newColumn *column.Column) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	index := findColumn(columns, newColumn.Name)
+
+	if index == -1 {
+		return errors.ErrColumnNotExist
+	}
+
+	if err := t.checkColumnReferenced(tableName, newColumn.Name); err != nil {
+		return err
+	}
+
+	if err := t.checkColumnDefault(tableName, newColumn); err != nil {
+		return err
+	}
+
+	columns[index] = newColumn
+
+	// a VARCHAR is converted at its whole length, the rewrite then rejects a value
+	// which does not fit the new size instead of cutting it
+	size := newColumn.Size
+
+	if newColumn.ColumnType == types.VAR_CHAR_TYPE {
+		size = math.MaxInt32
+	}
+
+	return t.rewriteTable(tableName, columns, func(values []*tuple.Value) ([]*tuple.Value, error) {
+		value, err := tuple.ConvertValue(values[index], newColumn.ColumnType, size)
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[index] = value
+
+		return values, nil
+	})
+}
+
+// RenameColumn only changes the schema page, the tuple layout stays the same
+func (t *TableManager) RenameColumn(tableName string, columnName string, newColumnName string) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return errors.ErrNoTable
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
+
+	if err != nil {
+		return err
+	}
+	defer t.bufferPoolManager.UnpinPage(metaPageID)
+
+	metaPage := schema.GetSchema(page)
+	columns, err := t.readColumns(metaPageID)
+
+	if err != nil {
+		return err
+	}
+
+	index := findColumn(columns, columnName)
+
+	if index == -1 {
+		return errors.ErrColumnNotExist
+	}
+
+	if findColumn(columns, newColumnName) != -1 {
+		return errors.ErrColumnExist
+	}
+
+	columns[index].Name = newColumnName
+
+	if err := checkTable(tableName, columns); err != nil {
+		return err
+	}
+
+	if err := t.writeColumns(metaPage, columns); err != nil {
+		return err
+	}
+
+	if err := t.replaceColumnRows(tableName, columns); err != nil {
+		return err
+	}
+
+	if err := t.renameConstraintColumn(tableName, columnName, newColumnName); err != nil {
+		return err
+	}
+
+	if err := t.renameSequenceOwner(tableName, columnName, newColumnName); err != nil {
+		return err
+	}
+
+	return t.updateReferences(tableName, func(c *constraint.Constraint) {
+		for i, name := range c.RefColumns {
+			if name == columnName {
+				c.RefColumns[i] = newColumnName
+			}
+		}
+	})
+}
+
+func (t *TableManager) RenameTable(tableName string, newTableName string) error {
+	if IsSystemTable(tableName) || IsSystemTable(newTableName) || IsInformationSchema(newTableName) {
+		return errors.ErrSystemTable
+	}
+
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return errors.ErrNoTable
+	}
+
+	if _, err := t.getMetaPageID(newTableName); err == nil {
+		return errors.ErrTableExist
+	}
+
+	if len(newTableName) > types.TABLE_NAME_MAX_SIZE {
+		return errors.ErrTableNameTooLong
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
+
+	if err != nil {
+		return err
+	}
+
+	schema.GetSchema(page).SetTableName(newTableName)
+	t.bufferPoolManager.FlushPage(metaPageID)
+	t.bufferPoolManager.UnpinPage(metaPageID)
+
+	t.RLock.Lock()
+	delete(t.TableMetaPageID, tableName)
+	t.TableMetaPageID[newTableName] = metaPageID
+	t.RLock.Unlock()
+
+	if err := t.renameTableRows(tableName, newTableName); err != nil {
+		return err
+	}
+
+	return t.updateReferences(tableName, func(c *constraint.Constraint) {
+		c.RefTable = newTableName
+	})
+}
+
+// InsertTuple checks the row against the constraints of the table before it is written
+func (t *TableManager) InsertTuple(tableName string, value []*tuple.Value) error {
+	return t.InsertTuples(tableName, [][]*tuple.Value{value})
+}
+
+// InsertTuples checks every row before the first one is written, a row which fails leaves
+// the table as it was. A row may not reference another row of the batch by a foreign key.
+func (t *TableManager) InsertTuples(tableName string, values [][]*tuple.Value) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	lock := t.getWriteLock(tableName)
+	lock.Lock()
+	defer lock.Unlock()
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		if err := tuple.FitValues(columns, value); err != nil {
+			return err
+		}
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return err
+	}
+
+	keys := make([][][]byte, len(values))
+	// the unique keys of the batch which are not in the indexes yet
+	batchKeys := make([]map[string]bool, len(constraints))
+
+	for i, value := range values {
+		if keys[i], err = checkRow(constraints, value, nil); err != nil {
+			return err
+		}
+
+		for j, c := range constraints {
+			if keys[i][j] == nil || !c.IsUnique() {
+				continue
+			}
+
+			if batchKeys[j] == nil {
+				batchKeys[j] = make(map[string]bool)
+			}
+
+			if batchKeys[j][string(keys[i][j])] {
+				return &errors.ConstraintError{Constraint: c.Name, Err: errors.ErrUniqueViolation}
+			}
+
+			batchKeys[j][string(keys[i][j])] = true
+		}
+	}
+
+	rids, err := t.insertTuples(tableName, values)
+
+	if err != nil {
+		return err
+	}
+
+	for i, rid := range rids {
+		if err := insertKeys(constraints, keys[i], rid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getWriteLock returns the lock which keeps the unique keys of the table from being
+// inserted between the search for them and the write
+func (t *TableManager) getWriteLock(tableName string) *sync.Mutex {
+	t.writeLock.Lock()
+	defer t.writeLock.Unlock()
+
+	lock, exist := t.writeLocks[tableName]
+
+	if !exist {
+		lock = &sync.Mutex{}
+		t.writeLocks[tableName] = lock
+	}
+
+	return lock
+}
+
+// insertTuple returns the RID the tuple was written to
+func (t *TableManager) insertTuple(tableName string, value []*tuple.Value) (types.RID, error) {
+	rids, err := t.insertTuples(tableName, [][]*tuple.Value{value})
+
+	if err != nil {
+		return types.RID{PageID: constant.INVALID_PAGE_ID}, err
+	}
+
+	return rids[0], nil
+}
+
+// insertTuples returns the RIDs the tuples were written to, the data chain is walked once
+// and a page is flushed when the batch moves on to the next one
+func (t *TableManager) insertTuples(tableName string, values [][]*tuple.Value) ([]types.RID, error) {
+	metaTablePageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return nil, errors.ErrNoTable
+	}
+
+	for _, value := range values {
+		if tuple.GetValuesSize(value)+types.TUPLE_OFFSET+types.TUPLE_SIZE > constant.PAGE_SIZE-types.TUPLE_COUNT_OFFSET {
+			return nil, errors.ErrTupleTooLarge
+		}
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(metaTablePageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataTablePageID := schema.GetSchema(page).GetDataPageID()
+	t.bufferPoolManager.UnpinPage(metaTablePageID)
+
+	dataPage, err := t.bufferPoolManager.FetchPage(dataTablePageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataTablePage := GetDataTable(dataPage)
+	written := false
+	rids := make([]types.RID, 0, len(values))
+
+	for _, value := range values {
+		for dataTablePage.GetRemainSpace() < tuple.GetValuesSize(value)+types.TUPLE_OFFSET+types.TUPLE_SIZE {
+			if written {
+				t.bufferPoolManager.FlushPage(dataTablePage.GetPageID())
+				written = false
+			}
+
+			if dataTablePage, err = t.nextDataPage(dataTablePage); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := dataTablePage.InsertTuple(value); err != nil {
+			t.bufferPoolManager.UnpinPage(dataTablePage.GetPageID())
+			return nil, err
+		}
+
+		written = true
+		rids = append(rids, types.RID{PageID: dataTablePage.GetPageID(), Index: dataTablePage.GetTupleCount() - 1})
+	}
+
+	if written {
+		t.bufferPoolManager.FlushPage(dataTablePage.GetPageID())
+	}
+
+	t.bufferPoolManager.UnpinPage(dataTablePage.GetPageID())
+
+	return rids, nil
+}
+
+// nextDataPage unpins the data page and returns the next one pinned,
+// a new page is linked to the end of the chain
+func (t *TableManager) nextDataPage(dataTablePage *DataTable) (*DataTable, error) {
+	defer t.bufferPoolManager.UnpinPage(dataTablePage.GetPageID())
+
+	dataTablePageID := dataTablePage.GetNextPageID()
+
+	if dataTablePageID != constant.INVALID_PAGE_ID {
+		dataPage, err := t.bufferPoolManager.FetchPage(dataTablePageID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return GetDataTable(dataPage), nil
+	}
+
+	newDataPage, err := t.bufferPoolManager.NewPage()
+
+	if err != nil {
+		return nil, err
+	}
+
+	dataTablePage.SetNextPageID(newDataPage.GetPageID())
+	GetDataTable(newDataPage).DataTableInit()
+	GetDataTable(newDataPage).SetPrevPageID(dataTablePage.GetPageID())
+	t.bufferPoolManager.FlushPage(dataTablePage.GetPageID())
+	t.bufferPoolManager.FlushPage(newDataPage.GetPageID())
+
+	return GetDataTable(newDataPage), nil
+}
+
+func (t *TableManager) GetTuples(tableName string) ([][]*tuple.Value, error) {
+	if IsInformationSchema(tableName) {
+		return t.getViewTuples(tableName)
+	}
+
+	metaTablePageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return nil, errors.ErrNoTable
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(metaTablePageID)
+
+	if err != nil {
+		return nil, err
+	}
+	defer t.bufferPoolManager.UnpinPage(metaTablePageID)
+
+	columns, err := t.readColumns(metaTablePageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	metaTable := schema.GetSchema(page)
+	dataTablePageID := metaTable.GetDataPageID()
+
+	tuples := make([][]*tuple.Value, 0)
+
+	for dataTablePageID != constant.INVALID_PAGE_ID {
+		page, err = t.bufferPoolManager.FetchPage(dataTablePageID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dataTable := GetDataTable(page)
+
+		tuples = append(tuples, dataTable.GetTuple(columns)...)
+
+		t.bufferPoolManager.UnpinPage(dataTablePageID)
+		dataTablePageID = dataTable.GetNextPageID()
+	}
+
+	return tuples, nil
 }
 
-func NewTableManager(bufferPoolManager *buffer.BufferPoolManager, tableMetaPageID map[string]types.Page_id_t) *TableManager {
-	return &TableManager{
-		bufferPoolManager: bufferPoolManager,
-		TableMetaPageID:   tableMetaPageID,
+// DropTable removes the table from the catalog before its pages are freed,
+// a crash in between leaves orphaned pages rather than a dangling table
+func (t *TableManager) DropTable(tableName string) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return errors.ErrNoTable
+	}
+
+	if err := t.checkReferenced(tableName); err != nil {
+		return err
+	}
+
+	constraints, err := t.loadConstraints(tableName, nil)
+
+	if err != nil {
+		return err
+	}
+
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
+
+	if err != nil {
+		return err
 	}
-}
 
-func (t *TableManager) GetTables() []string {
-	tableName := make([]string, 0, len(t.TableMetaPageID))
+	dataPageID := schema.GetSchema(page).GetDataPageID()
+	t.bufferPoolManager.UnpinPage(metaPageID)
 
-	for k := range t.TableMetaPageID {
-		tableName = append(tableName, k)
+	if err := t.dropOwnedSequences(tableName, ""); err != nil {
+		return err
 	}
 
-	return tableName
+	if err := t.unregisterTable(tableName); err != nil {
+		return err
+	}
+
+	t.RLock.Lock()
+	delete(t.TableMetaPageID, tableName)
+	t.RLock.Unlock()
+
+	if err := destroyIndexes(constraints); err != nil {
+		return err
+	}
+
+	if err := t.deleteDataPages(dataPageID); err != nil {
+		return err
+	}
+
+	return t.deleteSchemaPages(metaPageID)
 }
 
-func (t *TableManager) GetTableMeta(tableName string) ([]*column.Column, error) {
-	pageID, err := t.getMetaPageID(tableName)
+// TruncateTable keeps the first data page empty and frees the rest of the chain,
+// a table which another table references can not be truncated
+func (t *TableManager) TruncateTable(tableName string) error {
+	if IsSystemTable(tableName) {
+		return errors.ErrSystemTable
+	}
+
+	metaPageID, err := t.getMetaPageID(tableName)
 
 	if err != nil {
-		return nil, err
+		return errors.ErrNoTable
+	}
+
+	if err := t.checkReferenced(tableName); err != nil {
+		return err
 	}
 
-	page, err := t.bufferPoolManager.FetchPage(pageID)
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
 
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	dataPageID := schema.GetSchema(page).GetDataPageID()
+	t.bufferPoolManager.UnpinPage(metaPageID)
+
+	dataPage, err := t.bufferPoolManager.FetchPage(dataPageID)
+
+	if err != nil {
+		return err
+	}
+
+	dataTable := GetDataTable(dataPage)
+	nextPageID := dataTable.GetNextPageID()
+
+	dataTable.ResetPageData()
+	dataTable.DataTableInit()
+	t.bufferPoolManager.FlushPage(dataPageID)
+	t.bufferPoolManager.UnpinPage(dataPageID)
+
+	if err := t.deleteDataPages(nextPageID); err != nil {
+		return err
+	}
+
+	columns, err := t.GetTableMeta(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return err
+	}
+
+	if err := t.rebuildIndexes(tableName, constraints, columns); err != nil {
+		return err
 	}
-	return schema.GetSchema(page).GetColumns(), nil
+
+	return t.AnalyzeTable(tableName)
 }
 
-func (t *TableManager) CreateNewTable(tableName string, columns []*column.Column) error {
+// createTable writes the schema meta page and the first data page of a new table
+func (t *TableManager) createTable(tableName string, columns []*column.Column) (types.Page_id_t, error) {
 	newPage, err := t.bufferPoolManager.NewPage()
 
 	if err != nil {
 		log.Println(err)
-		return err
+		return constant.INVALID_PAGE_ID, err
 	}
 
 	metaPage := schema.GetSchema(newPage)
-	defer t.bufferPoolManager.FlushPage(metaPage.GetPageID())
+	metaPage.SchemaInit()
 
 	// if any error occur should have the method to rollback
-	for _, c := range columns {
-		metaPage.AddColumn(c)
+	if err := t.writeColumns(metaPage, columns); err != nil {
+		t.bufferPoolManager.UnpinPage(metaPage.GetPageID())
+		return constant.INVALID_PAGE_ID, err
 	}
 
 	metaPage.SetTableName(tableName)
 
-	t.RLock.Lock()
-	t.TableMetaPageID[tableName] = metaPage.GetPageID()
-	t.RLock.Unlock()
-
 	dataPage, err := t.bufferPoolManager.NewPage()
 
 	if err != nil {
 		log.Println(err)
-		return err
+		t.bufferPoolManager.FlushPage(metaPage.GetPageID())
+		t.bufferPoolManager.UnpinPage(metaPage.GetPageID())
+		return constant.INVALID_PAGE_ID, err
 	}
 
 	metaPage.SetDataPageID(dataPage.GetPageID())
 	GetDataTable(dataPage).DataTableInit()
 	t.bufferPoolManager.FlushPage(dataPage.GetPageID())
 	t.bufferPoolManager.FlushPage(metaPage.GetPageID())
+	t.bufferPoolManager.UnpinPage(dataPage.GetPageID())
+	t.bufferPoolManager.UnpinPage(metaPage.GetPageID())
 
-	return nil
+	return metaPage.GetPageID(), nil
 }
 
-func (t *TableManager) AddNewColumn(tableName string, column *column.Column) error {
-	pageID, err := t.getMetaPageID(tableName)
+// scanTuples visits every tuple which is not deleted, the page is flushed when visit changed it
+func (t *TableManager) scanTuples(tableName string, visit func(dataTable *DataTable, index int32, values []*tuple.Value) (bool, error)) error {
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
+		return errors.ErrNoTable
+	}
+
+	columns, err := t.readColumns(metaPageID)
 
 	if err != nil {
 		return err
 	}
 
-	page, err := t.bufferPoolManager.FetchPage(pageID)
+	dataPageID, err := t.getDataPageID(metaPageID)
 
 	if err != nil {
 		return err
 	}
 
-	metaPage := schema.GetSchema(page)
-	defer t.bufferPoolManager.FlushPage(metaPage.GetPageID())
+	for dataPageID != constant.INVALID_PAGE_ID {
+		page, err := t.bufferPoolManager.FetchPage(dataPageID)
+
+		if err != nil {
+			return err
+		}
+
+		dataTable := GetDataTable(page)
+		dirty := false
+
+		for i := int32(0); i < dataTable.GetTupleCount() && err == nil; i++ {
+			values := dataTable.GetTupleByIndex(i, columns)
+
+			if values == nil {
+				continue
+			}
+
+			var changed bool
+			changed, err = visit(dataTable, i, values)
+			dirty = dirty || changed
+		}
+
+		if dirty {
+			t.bufferPoolManager.FlushPage(dataPageID)
+		}
+
+		t.bufferPoolManager.UnpinPage(dataPageID)
 
-	metaPage.AddColumn(column)
+		if err != nil {
+			return err
+		}
+
+		dataPageID = dataTable.GetNextPageID()
+	}
 
 	return nil
 }
 
-func (t *TableManager) InsertTuple(tableName string, value []*tuple.Value) error {
-	metaTablePageID, exist := t.TableMetaPageID[tableName]
+// deleteTuples marks every matched tuple deleted and returns how many were deleted
+func (t *TableManager) deleteTuples(tableName string, match func([]*tuple.Value) bool) (int32, error) {
+	var count int32
 
-	if !exist {
+	err := t.scanTuples(tableName, func(dataTable *DataTable, index int32, values []*tuple.Value) (bool, error) {
+		if !match(values) {
+			return false, nil
+		}
+
+		count++
+
+		return true, dataTable.DeleteTupleByIndex(index)
+	})
+
+	return count, err
+}
+
+// updateTuples overwrites every tuple which update returns new values for,
+// the new values must keep the tuple size so the tuple is changed in place
+func (t *TableManager) updateTuples(tableName string, update func([]*tuple.Value) []*tuple.Value) (int32, error) {
+	var count int32
+
+	err := t.scanTuples(tableName, func(dataTable *DataTable, index int32, values []*tuple.Value) (bool, error) {
+		newValues := update(values)
+
+		if newValues == nil {
+			return false, nil
+		}
+
+		count++
+
+		return true, dataTable.UpdateTupleData(index, tuple.TupleSerialization(newValues))
+	})
+
+	return count, err
+}
+
+// rewriteTable converts every tuple to the new columns and writes them to a new data page chain,
+// the schema page switches to the new chain in one page write and the old chain is freed after
+func (t *TableManager) rewriteTable(tableName string, columns []*column.Column, convert func([]*tuple.Value) ([]*tuple.Value, error)) error {
+	if err := checkTable(tableName, columns); err != nil {
+		return err
+	}
+
+	tuples, err := t.GetTuples(tableName)
+
+	if err != nil {
+		return err
+	}
+
+	for i, values := range tuples {
+		if tuples[i], err = convert(values); err != nil {
+			return err
+		}
+
+		if err := tuple.FitValues(columns, tuples[i]); err != nil {
+			return err
+		}
+	}
+
+	constraints, err := t.loadConstraints(tableName, columns)
+
+	if err != nil {
+		return err
+	}
+
+	if err := checkTuples(constraints, columns, tuples); err != nil {
+		return err
+	}
+
+	metaPageID, err := t.getMetaPageID(tableName)
+
+	if err != nil {
 		return errors.ErrNoTable
 	}
 
-	page, err := t.bufferPoolManager.FetchPage(metaTablePageID)
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
 
 	if err != nil {
 		return err
 	}
+	defer t.bufferPoolManager.UnpinPage(metaPageID)
 
-	metaTable := schema.GetSchema(page)
-	dataTablePageID := metaTable.GetDataPageID()
+	metaPage := schema.GetSchema(page)
+	oldDataPageID := metaPage.GetDataPageID()
 
-	tupleSize := t.getValueSize(value)
+	newDataPageID, err := t.writeDataPages(tuples)
 
-getPage:
-	dataPage, err := t.bufferPoolManager.FetchPage(dataTablePageID)
 	if err != nil {
 		return err
 	}
-	dataTablePage := GetDataTable(dataPage)
 
-	if dataTablePage.GetRemainSpace() < tupleSize {
-		dataTablePageID = dataTablePage.GetNextPageID()
-		if dataTablePageID == constant.INVALID_PAGE_ID {
-			newDataPage, err := t.bufferPoolManager.NewPage()
-			newDataPage.SetPageType(types.DATA_PAGE_TYPE)
+	if err := t.writeColumns(metaPage, columns); err != nil {
+		t.deleteDataPages(newDataPageID)
+		return err
+	}
+
+	metaPage.SetDataPageID(newDataPageID)
+	t.bufferPoolManager.FlushPage(metaPageID)
+
+	if err := t.deleteDataPages(oldDataPageID); err != nil {
+		return err
+	}
+
+	if err := t.rebuildIndexes(tableName, constraints, columns); err != nil {
+		return err
+	}
+
+	if err := t.replaceColumnRows(tableName, columns); err != nil {
+		return err
+	}
+
+	return t.AnalyzeTable(tableName)
+}
+
+// writeDataPages builds a new data page chain holding the tuples
+func (t *TableManager) writeDataPages(tuples [][]*tuple.Value) (types.Page_id_t, error) {
+	newPage, err := t.bufferPoolManager.NewPage()
+
+	if err != nil {
+		return constant.INVALID_PAGE_ID, err
+	}
+
+	firstPageID := newPage.GetPageID()
+	dataTable := GetDataTable(newPage)
+	dataTable.DataTableInit()
+
+	for _, values := range tuples {
+		tupleSize := tuple.GetValuesSize(values)
+
+		if dataTable.GetRemainSpace() < tupleSize+types.TUPLE_OFFSET+types.TUPLE_SIZE {
+			if dataTable.GetTupleCount() == 0 {
+				err = errors.ErrTupleTooLarge
+				break
+			}
+
+			newPage, err = t.bufferPoolManager.NewPage()
+
 			if err != nil {
-				return err
+				break
 			}
 
-			dataTablePage.SetNextPageID(newDataPage.GetPageID())
-			dataTablePageID = newDataPage.GetPageID()
-			GetDataTable(newDataPage).DataTableInit()
+			dataTable.SetNextPageID(newPage.GetPageID())
+			t.bufferPoolManager.FlushPage(dataTable.GetPageID())
+			t.bufferPoolManager.UnpinPage(dataTable.GetPageID())
+
+			prevPageID := dataTable.GetPageID()
+			dataTable = GetDataTable(newPage)
+			dataTable.DataTableInit()
+			dataTable.SetPrevPageID(prevPageID)
+		}
+
+		if err = dataTable.InsertTuple(values); err != nil {
+			break
 		}
-		goto getPage
 	}
 
-	if err := dataTablePage.InsertTuple(value, tupleSize); err != nil {
-		return err
+	t.bufferPoolManager.FlushPage(dataTable.GetPageID())
+	t.bufferPoolManager.UnpinPage(dataTable.GetPageID())
+
+	if err != nil {
+		t.deleteDataPages(firstPageID)
+		return constant.INVALID_PAGE_ID, err
 	}
 
-	tempTuples := dataTablePage.GetTuple(metaTable)
+	return firstPageID, nil
+}
+
+// readColumns collects the columns of the schema meta page and its continuation pages
+func (t *TableManager) readColumns(metaPageID types.Page_id_t) ([]*column.Column, error) {
+	columns := make([]*column.Column, 0)
+
+	for metaPageID != constant.INVALID_PAGE_ID {
+		page, err := t.bufferPoolManager.FetchPage(metaPageID)
 
-	for _, t := range tempTuples {
-		for _, a := range t {
-			fmt.Println(*a)
+		if err != nil {
+			return nil, err
 		}
+
+		metaPage := schema.GetSchema(page)
+		columns = append(columns, metaPage.GetColumns()...)
+
+		t.bufferPoolManager.UnpinPage(metaPageID)
+		metaPageID = metaPage.GetNextPageID()
 	}
 
-	t.bufferPoolManager.UnpinPage(dataTablePageID)
-	t.bufferPoolManager.FlushPage(dataTablePageID)
-	return nil
+	return columns, nil
 }
 
-func (t *TableManager) GetTuples(tableName string) ([][]*tuple.Value, error) {
-	metaTablePageID, exist := t.TableMetaPageID[tableName]
+// writeColumns replaces the columns of the schema meta page, the columns which do not fit
+// continue on the next page and the continuation pages no longer needed are freed
+func (t *TableManager) writeColumns(metaPage *schema.Schema, columns []*column.Column) error {
+	schemaPage := metaPage
+	schemaPage.ResetColumns()
 
-	if !exist {
-		return nil, errors.ErrNoTable
-	}
+	for _, c := range columns {
+		err := schemaPage.AddColumn(c)
 
-	page, err := t.bufferPoolManager.FetchPage(metaTablePageID)
+		if err == nil {
+			continue
+		}
 
-	if err != nil {
-		return nil, err
+		if err != errors.ErrNoSpace {
+			t.releaseSchemaPage(metaPage, schemaPage)
+			return err
+		}
+
+		var nextPage *schema.Schema
+
+		if nextPageID := schemaPage.GetNextPageID(); nextPageID != constant.INVALID_PAGE_ID {
+			page, err := t.bufferPoolManager.FetchPage(nextPageID)
+
+			if err != nil {
+				t.releaseSchemaPage(metaPage, schemaPage)
+				return err
+			}
+
+			nextPage = schema.GetSchema(page)
+			nextPage.ResetColumns()
+		} else {
+			page, err := t.bufferPoolManager.NewPage()
+
+			if err != nil {
+				t.releaseSchemaPage(metaPage, schemaPage)
+				return err
+			}
+
+			nextPage = schema.GetSchema(page)
+			nextPage.SchemaInit()
+			nextPage.SetPrevPageID(schemaPage.GetPageID())
+			schemaPage.SetNextPageID(nextPage.GetPageID())
+		}
+
+		t.releaseSchemaPage(metaPage, schemaPage)
+		schemaPage = nextPage
+
+		if err := schemaPage.AddColumn(c); err != nil {
+			t.releaseSchemaPage(metaPage, schemaPage)
+			return err
+		}
 	}
 
-	metaTable := schema.GetSchema(page)
-	dataTablePageID := metaTable.GetDataPageID()
+	unusedPageID := schemaPage.GetNextPageID()
+	schemaPage.SetNextPageID(constant.INVALID_PAGE_ID)
+	t.releaseSchemaPage(metaPage, schemaPage)
+	t.bufferPoolManager.FlushPage(metaPage.GetPageID())
 
-	tuples := make([][]*tuple.Value, 0)
+	return t.deleteSchemaPages(unusedPageID)
+}
 
-	for dataTablePageID != constant.INVALID_PAGE_ID {
-		page, err = t.bufferPoolManager.FetchPage(dataTablePageID)
+// releaseSchemaPage flushes a continuation page, the meta page stays pinned by the caller
+func (t *TableManager) releaseSchemaPage(metaPage *schema.Schema, schemaPage *schema.Schema) {
+	t.bufferPoolManager.FlushPage(schemaPage.GetPageID())
+
+	if schemaPage != metaPage {
+		t.bufferPoolManager.UnpinPage(schemaPage.GetPageID())
+	}
+}
+
+// deleteSchemaPages returns the schema meta page and its continuation pages to the free page list
+func (t *TableManager) deleteSchemaPages(metaPageID types.Page_id_t) error {
+	for metaPageID != constant.INVALID_PAGE_ID {
+		page, err := t.bufferPoolManager.FetchPage(metaPageID)
 
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		dataTable := GetDataTable(page)
+		nextPageID := schema.GetSchema(page).GetNextPageID()
+		t.bufferPoolManager.UnpinPage(metaPageID)
 
-		tuples = append(tuples, dataTable.GetTuple(metaTable)...)
+		if err := t.bufferPoolManager.DeletePage(metaPageID); err != nil {
+			return err
+		}
 
-		dataTablePageID = dataTable.GetNextPageID()
+		metaPageID = nextPageID
 	}
 
-	return tuples, nil
+	return nil
 }
 
-func (t *TableManager) UpdateTuple(tableName string, tupleID int32, values []*tuple.Value) error {
+// deleteDataPages returns every page of the data chain to the free page list
+func (t *TableManager) deleteDataPages(dataPageID types.Page_id_t) error {
+	for dataPageID != constant.INVALID_PAGE_ID {
+		page, err := t.bufferPoolManager.FetchPage(dataPageID)
+
+		if err != nil {
+			return err
+		}
+
+		nextPageID := GetDataTable(page).GetNextPageID()
+		t.bufferPoolManager.UnpinPage(dataPageID)
+
+		if err := t.bufferPoolManager.DeletePage(dataPageID); err != nil {
+			return err
+		}
+
+		dataPageID = nextPageID
+	}
+
 	return nil
 }
 
+func (t *TableManager) setMetaPageID(tableName string, metaPageID types.Page_id_t) {
+	t.RLock.Lock()
+	defer t.RLock.Unlock()
+	t.TableMetaPageID[tableName] = metaPageID
+}
+
+func (t *TableManager) getDataPageID(metaPageID types.Page_id_t) (types.Page_id_t, error) {
+	page, err := t.bufferPoolManager.FetchPage(metaPageID)
+
+	if err != nil {
+		return constant.INVALID_PAGE_ID, err
+	}
+	defer t.bufferPoolManager.UnpinPage(metaPageID)
+
+	return schema.GetSchema(page).GetDataPageID(), nil
+}
+
 func (t *TableManager) getMetaPageID(tableName string) (types.Page_id_t, error) {
 	t.RLock.RLock()
 	defer t.RLock.RUnlock()
@@ -219,11 +1204,37 @@ func (t *TableManager) getMetaPageID(tableName string) (types.Page_id_t, error)
 	return pageID, nil
 }
 
-func (t *TableManager) getValueSize(values []*tuple.Value) int32 {
-	var size int32
-	for _, v := range values {
-		size += v.GetSize()
+// checkTable validates the limits of the schema before anything is written
+func checkTable(tableName string, columns []*column.Column) error {
+	if len(tableName) > types.TABLE_NAME_MAX_SIZE {
+		return errors.ErrTableNameTooLong
+	}
+
+	for _, c := range columns {
+		if len(c.Name) > types.COLUMN_NAME_MAX_SIZE {
+			return errors.ErrColumnNameTooLong
+		}
+
+		// a bare DECIMAL has neither precision nor scale
+		if c.ColumnType == types.DECIMAL_TYPE && (c.Precision != 0 || c.Scale != 0) &&
+			(c.Precision < 1 || c.Precision > types.MAX_DECIMAL_PRECISION || c.Scale < 0 || c.Scale > c.Precision) {
+			return errors.ErrInvalidDecimal
+		}
+	}
+
+	if tuple.GetTupleSize(columns)+types.TUPLE_OFFSET+types.TUPLE_SIZE > constant.PAGE_SIZE-types.TUPLE_COUNT_OFFSET {
+		return errors.ErrTupleTooLarge
+	}
+
+	return nil
+}
+
+func findColumn(columns []*column.Column, columnName string) int {
+	for i, c := range columns {
+		if c.Name == columnName {
+			return i
+		}
 	}
 
-	return size
+	return -1
 }
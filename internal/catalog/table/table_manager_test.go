@@ -1,13 +1,19 @@
 package table
 
 import (
+	stderrors "errors"
+	"fmt"
 	"go-db/internal/buffer"
 	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
 	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"go-db/internal/storage/disk"
 	"go-db/internal/utils"
 	"log"
+	"os"
 	"strings"
 	"testing"
 )
@@ -363,3 +369,755 @@ func Test_TableManagerInsertTupleFullPage(t *testing.T) {
 	}
 
 }
+
+func Test_TableManagerInsertTuples(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("insert_tuples_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("insert_tuples_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 64, "name"),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("testTable", columns, []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"id"}),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	newRows := func(from int, count int) [][]*tuple.Value {
+		rows := make([][]*tuple.Value, 0, count)
+
+		for i := from; i < from+count; i++ {
+			name := fmt.Sprintf("row number %d with some padding", i)
+			rows = append(rows, []*tuple.Value{
+				tuple.GetValue(int32(i), types.INT_TYPE, types.INT_SIZE),
+				tuple.GetValue(name, types.VAR_CHAR_TYPE, 64),
+			})
+		}
+
+		return rows
+	}
+
+	// the batch fills several pages
+	if err := tableManager.InsertTuples("testTable", newRows(0, 500)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", newRows(500, 1)[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	failures := [][][]*tuple.Value{
+		append(newRows(600, 3), newRows(601, 1)...),
+		append(newRows(700, 3), newRows(5, 1)...),
+		append(newRows(800, 3), []*tuple.Value{tuple.GetNullValue(types.INT_TYPE, types.INT_SIZE), tuple.GetValue("x", types.VAR_CHAR_TYPE, 64)}),
+	}
+
+	for _, rows := range failures {
+		if err := tableManager.InsertTuples("testTable", rows); err == nil {
+			t.Error("the batch should fail")
+		}
+	}
+
+	tuples, err := tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tuples) != 501 {
+		t.Fatal("a failed batch should not write a row", len(tuples))
+	}
+
+	for i, values := range tuples {
+		if values[0].INT != int32(i) || string(values[1].VAR_CHAR) != fmt.Sprintf("row number %d with some padding", i) {
+			t.Fatal("get the wrong row", i, values[0].INT, string(values[1].VAR_CHAR))
+		}
+	}
+
+	if err := tableManager.InsertTuple("testTable", newRows(499, 1)[0]); !stderrors.Is(err, errors.ErrUniqueViolation) {
+		t.Error("the keys of the batch should be in the index", err)
+	}
+}
+
+func Test_LoadTableManager(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("catalog_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("catalog_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+	}
+
+	for _, tableName := range []string{"testTable", "testTableTwo"} {
+		if err := tableManager.CreateNewTable(tableName, columns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("catalog_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bufferPool = buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager, err = LoadTableManager(bufferPool)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tableManager.GetTables()) != 2 {
+		t.Error("load tables wrong", tableManager.GetTables())
+	}
+
+	testColumns, err := tableManager.GetTableMeta("testTableTwo")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testColumns) != 1 || testColumns[0].Name != "int_types" {
+		t.Error("load table meta wrong")
+	}
+}
+
+func Test_DropTable(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("drop_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("drop_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.LONG_INT_TYPE, 0, "long_int_type"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 100, "var_char_type"),
+	}
+
+	for _, tableName := range []string{"testTable", "testTableTwo"} {
+		if err := tableManager.CreateNewTable(tableName, columns); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tuples := []*tuple.Value{
+		tuple.GetValue(int64(5), columns[0].GetColumnType(), columns[0].GetColumnSize()),
+		tuple.GetValue([]byte("123"), columns[1].GetColumnType(), columns[1].GetColumnSize()),
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := tableManager.InsertTuple("testTable", tuples); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pageNumber := diskManager.GetPageNumber()
+
+	if err := tableManager.DropTable("testTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.DropTable("testTable"); err != errors.ErrNoTable {
+		t.Error("drop a dropped table should fail", err)
+	}
+
+	if _, err := tableManager.GetTuples("testTable"); err != errors.ErrNoTable {
+		t.Error("dropped table still readable")
+	}
+
+	if diskManager.GetFreeListHead() == constant.INVALID_PAGE_ID {
+		t.Error("dropped pages not freed")
+	}
+
+	// the freed pages are reused before the file grows
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	if diskManager.GetPageNumber() != pageNumber {
+		t.Error("freed pages not reused", pageNumber, diskManager.GetPageNumber())
+	}
+
+	getTuples, err := tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 0 {
+		t.Error("recreated table should be empty")
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("drop_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tableManager.GetTables()) != 2 {
+		t.Error("load tables wrong", tableManager.GetTables())
+	}
+}
+
+func Test_TruncateTable(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("truncate_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("truncate_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.VAR_CHAR_TYPE, 100, "var_char_type"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	tuples := []*tuple.Value{
+		tuple.GetValue([]byte("123"), columns[0].GetColumnType(), columns[0].GetColumnSize()),
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := tableManager.InsertTuple("testTable", tuples); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tableManager.TruncateTable("testTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	getTuples, err := tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 0 {
+		t.Error("truncated table should be empty", len(getTuples))
+	}
+
+	if diskManager.GetFreeListHead() == constant.INVALID_PAGE_ID {
+		t.Error("truncated pages not freed")
+	}
+
+	if err := tableManager.InsertTuple("testTable", tuples); err != nil {
+		t.Fatal(err)
+	}
+
+	getTuples, err = tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 1 {
+		t.Error("insert after truncate wrong", len(getTuples))
+	}
+}
+
+func Test_AlterTable(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("alter_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("alter_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 10, "var_char_type"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 500; i++ {
+		tuples := []*tuple.Value{
+			tuple.GetValue(int32(i), columns[0].GetColumnType(), columns[0].GetColumnSize()),
+			tuple.GetValue([]byte("12"), columns[1].GetColumnType(), columns[1].GetColumnSize()),
+		}
+
+		if err := tableManager.InsertTuple("testTable", tuples); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := tableManager.AddNewColumnWithDefault("testTable", column.NewColumn(types.LONG_INT_TYPE, 0, "long_int_type"), "7"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.AddNewColumn("testTable", column.NewColumn(types.INT_TYPE, 0, "int_types")); err != errors.ErrColumnExist {
+		t.Error("add an existing column should fail", err)
+	}
+
+	getTuples, err := tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 500 || len(getTuples[499]) != 3 || getTuples[499][0].INT != 499 || getTuples[499][2].LONG_INT != 7 {
+		t.Fatal("add column backfill wrong")
+	}
+
+	if err := tableManager.DropColumn("testTable", "int_types"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.AlterColumnType("testTable", column.NewColumn(types.INT_TYPE, 0, "var_char_type")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.RenameColumn("testTable", "var_char_type", "int_types"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.RenameTable("testTable", "newTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	testColumns, err := tableManager.GetTableMeta("newTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testColumns) != 2 || testColumns[0].Name != "int_types" || testColumns[0].ColumnType != types.INT_TYPE || testColumns[1].Name != "long_int_type" {
+		t.Fatal("alter table columns wrong")
+	}
+
+	getTuples, err = tableManager.GetTuples("newTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 500 || getTuples[0][0].INT != 12 || getTuples[0][1].LONG_INT != 7 {
+		t.Error("alter table tuples wrong")
+	}
+
+	// a value which can not be converted leaves the table unchanged
+	if err := tableManager.AlterColumnType("newTable", column.NewColumn(types.BOOL_TYPE, 0, "int_types")); !stderrors.Is(err, errors.ErrInvalidValue) {
+		t.Error("convert int to bool should fail", err)
+	}
+
+	testColumns, err = tableManager.GetTableMeta("newTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if testColumns[0].ColumnType != types.INT_TYPE {
+		t.Error("failed conversion changed the column")
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("alter_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tables := tableManager.GetTables(); len(tables) != 1 || tables[0] != "newTable" {
+		t.Error("renamed table not loaded", tables)
+	}
+}
+
+func Test_WideTable(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("wide_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("wide_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := make([]*column.Column, 0)
+	tuples := make([]*tuple.Value, 0)
+
+	// 200 columns with 300 bytes names need about 16 schema pages
+	for i := 0; i < 200; i++ {
+		c := column.NewColumn(types.INT_TYPE, 0, fmt.Sprintf("%s_%d", strings.Repeat("c", 300), i))
+		columns = append(columns, c)
+		tuples = append(tuples, tuple.GetValue(int32(i), c.GetColumnType(), c.GetColumnSize()))
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", tuples); err != nil {
+		t.Fatal(err)
+	}
+
+	pageNumber := diskManager.GetPageNumber()
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("wide_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testColumns, err := tableManager.GetTableMeta("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testColumns) != 200 || testColumns[199].Name != columns[199].Name {
+		t.Fatal("wide table columns wrong", len(testColumns))
+	}
+
+	getTuples, err := tableManager.GetTuples("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(getTuples) != 1 || len(getTuples[0]) != 200 || getTuples[0][199].INT != 199 {
+		t.Fatal("wide table tuple wrong")
+	}
+
+	// dropping the columns frees the continuation pages
+	for i := 1; i < 200; i++ {
+		if err := tableManager.DropColumn("testTable", columns[i].Name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if diskManager.GetFreeListHead() == constant.INVALID_PAGE_ID {
+		t.Error("continuation pages not freed")
+	}
+
+	testColumns, err = tableManager.GetTableMeta("testTable")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(testColumns) != 1 || testColumns[0].Name != columns[0].Name {
+		t.Error("drop columns of wide table wrong", len(testColumns))
+	}
+
+	// the second table starts in the pages freed by the first one
+	if err := tableManager.CreateNewTable("testTableTwo", columns[:150]); err != nil {
+		t.Fatal(err)
+	}
+
+	if metaPageID := tableManager.TableMetaPageID["testTableTwo"]; metaPageID >= types.Page_id_t(pageNumber) {
+		t.Error("continuation pages not reused", pageNumber, metaPageID)
+	}
+
+	if err := tableManager.CreateNewTable(strings.Repeat("t", types.TABLE_NAME_MAX_SIZE+1), columns); err != errors.ErrTableNameTooLong {
+		t.Error("table name too long not detected", err)
+	}
+
+	tooLong := []*column.Column{column.NewColumn(types.INT_TYPE, 0, strings.Repeat("c", types.COLUMN_NAME_MAX_SIZE+1))}
+
+	if err := tableManager.CreateNewTable("testTableThree", tooLong); err != errors.ErrColumnNameTooLong {
+		t.Error("column name too long not detected", err)
+	}
+
+	tooLarge := []*column.Column{column.NewColumn(types.VAR_CHAR_TYPE, constant.PAGE_SIZE, "var_char_type")}
+
+	if err := tableManager.CreateNewTable("testTableThree", tooLarge); err != errors.ErrTupleTooLarge {
+		t.Error("tuple too large not detected", err)
+	}
+
+	if len(tableManager.GetTables()) != 2 {
+		t.Error("failed create table should not be registered", tableManager.GetTables())
+	}
+}
+
+func Test_SystemTables(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("system_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("system_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	if diskManager.GetCatalogRootPageID() != tableManager.TableMetaPageID[types.SYSTEM_TABLES] {
+		t.Fatal("catalog root should be sys_tables")
+	}
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != errors.ErrTableExist {
+		t.Error("duplicate table should fail", err)
+	}
+
+	if err := tableManager.CreateNewTable(types.SYSTEM_INDEXES, columns); err != errors.ErrSystemTable {
+		t.Error("system table name should be reserved", err)
+	}
+
+	if err := tableManager.RenameColumn("testTable", "int_types", "id"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.RenameTable("testTable", "newTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("newTable", newRow(columns, int32(1), "abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.AnalyzeTable("newTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the system tables describe every table, themselves included
+	tables := getSystemRows(t, tableManager, types.SYSTEM_TABLES, "newTable")
+
+	if len(tables) != 1 || types.Page_id_t(tables[0][1].INT) != tableManager.TableMetaPageID["newTable"] {
+		t.Error("sys_tables row wrong", tables)
+	}
+
+	if len(getSystemRows(t, tableManager, types.SYSTEM_TABLES, types.SYSTEM_TABLES)) != 1 {
+		t.Error("sys_tables should describe itself")
+	}
+
+	tableColumns := getSystemRows(t, tableManager, types.SYSTEM_COLUMNS, "newTable")
+
+	if len(tableColumns) != 2 || string(tableColumns[0][1].VAR_CHAR) != "id" || string(tableColumns[1][3].VAR_CHAR) != types.COLUMN_TYPE_VAR_CHAR {
+		t.Error("sys_columns rows wrong", tableColumns)
+	}
+
+	statistics := getSystemRows(t, tableManager, types.SYSTEM_STATISTICS, "newTable")
+
+	if len(statistics) != 1 || statistics[0][1].LONG_INT != 1 || statistics[0][2].INT != 1 {
+		t.Error("sys_statistics row wrong", statistics)
+	}
+
+	if err := tableManager.InsertTuple(types.SYSTEM_TABLES, tables[0]); err != errors.ErrSystemTable {
+		t.Error("system table should be read only", err)
+	}
+
+	if err := tableManager.DropTable(types.SYSTEM_COLUMNS); err != errors.ErrSystemTable {
+		t.Error("system table should not be dropped", err)
+	}
+
+	diskManager.ShutDown()
+
+	diskManager, err = disk.NewDiskStorage("system_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tableManager, err = LoadTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tables := tableManager.GetTables(); len(tables) != 1 || tables[0] != "newTable" {
+		t.Fatal("load tables wrong", tables)
+	}
+
+	if err := tableManager.DropTable("newTable"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, systemTable := range []string{types.SYSTEM_TABLES, types.SYSTEM_COLUMNS, types.SYSTEM_STATISTICS} {
+		if rows := getSystemRows(t, tableManager, systemTable, "newTable"); len(rows) != 0 {
+			t.Error("drop table should remove the system rows", systemTable, rows)
+		}
+	}
+}
+
+func Test_SystemColumnsInStep(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("system_columns_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("system_columns_test.db")
+
+	tableManager := NewTableManager(buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024), map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 16, "name"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := int32(0); i < 3; i++ {
+		if err := tableManager.InsertTuple("testTable", newRow(columns, i, "abc")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tableName := "testTable"
+
+	changes := []struct {
+		name   string
+		change func() error
+	}{
+		{"add column", func() error {
+			return tableManager.AddNewColumn(tableName, column.NewColumn(types.LONG_INT_TYPE, 0, "amount"))
+		}},
+		{"alter column type", func() error {
+			return tableManager.AlterColumnType(tableName, column.NewColumn(types.VAR_CHAR_TYPE, 32, "name"))
+		}},
+		{"rename column", func() error {
+			return tableManager.RenameColumn(tableName, "amount", "total")
+		}},
+		{"drop column", func() error {
+			return tableManager.DropColumn(tableName, "id")
+		}},
+		{"rename table", func() error {
+			tableName = "newTable"
+			return tableManager.RenameTable("testTable", tableName)
+		}},
+	}
+
+	for _, c := range changes {
+		if err := c.change(); err != nil {
+			t.Fatal(c.name, err)
+		}
+
+		tableColumns, err := tableManager.GetTableMeta(tableName)
+
+		if err != nil {
+			t.Fatal(c.name, err)
+		}
+
+		rows := getSystemRows(t, tableManager, types.SYSTEM_COLUMNS, tableName)
+
+		if len(rows) != len(tableColumns) {
+			t.Fatal(c.name, "sys_columns should have a row per column", rows)
+		}
+
+		for _, row := range rows {
+			col := tableColumns[row[2].INT]
+
+			if string(row[1].VAR_CHAR) != col.Name || string(row[3].VAR_CHAR) != col.GetTypeName() || row[4].INT != col.Size {
+				t.Error(c.name, "sys_columns row does not match the schema", row, col)
+			}
+		}
+
+		statistics := getSystemRows(t, tableManager, types.SYSTEM_STATISTICS, tableName)
+
+		if len(statistics) != 1 || statistics[0][1].LONG_INT != 3 {
+			t.Error(c.name, "sys_statistics should follow the table", statistics)
+		}
+	}
+
+	if len(getSystemRows(t, tableManager, types.SYSTEM_COLUMNS, "testTable")) != 0 {
+		t.Error("renamed table should not keep its old sys_columns rows")
+	}
+
+	if err := tableManager.TruncateTable(tableName); err != nil {
+		t.Fatal(err)
+	}
+
+	if statistics := getSystemRows(t, tableManager, types.SYSTEM_STATISTICS, tableName); statistics[0][1].LONG_INT != 0 || statistics[0][2].INT != 1 {
+		t.Error("truncate should reset sys_statistics", statistics)
+	}
+}
+
+func getSystemRows(t *testing.T, tableManager *TableManager, systemTable string, tableName string) [][]*tuple.Value {
+	rows, err := tableManager.GetTuples(systemTable)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := findColumn(GetSystemColumns(systemTable), SYSTEM_TABLE_NAME_COLUMN)
+	matched := make([][]*tuple.Value, 0)
+
+	for _, values := range rows {
+		if string(values[index].VAR_CHAR) == tableName {
+			matched = append(matched, values)
+		}
+	}
+
+	return matched
+}
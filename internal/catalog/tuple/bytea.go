@@ -0,0 +1,55 @@
+package tuple
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"go-db/internal/common/types"
+	"strings"
+)
+
+/**
+ *  BYTEA format
+ *  +------------+-------------------------------+
+ *  | Length (4) | bytes (Length), zero padded   |
+ *  +------------+-------------------------------+
+ *
+ *  The text form is the hex format of PostgreSQL, \x and two hex digits for every byte.
+ *  A text without \x is taken as its bytes.
+ */
+
+const BYTEA_HEX_PREFIX = "\\x"
+
+// ParseBytea returns false when the text starts with \x but is not hex
+func ParseBytea(text string) ([]byte, bool) {
+	if !strings.HasPrefix(text, BYTEA_HEX_PREFIX) && !strings.HasPrefix(text, "\\X") {
+		return []byte(text), true
+	}
+
+	data, err := hex.DecodeString(text[len(BYTEA_HEX_PREFIX):])
+
+	if err != nil {
+		return nil, false
+	}
+
+	return data, true
+}
+
+func FormatBytea(data []byte) string {
+	return BYTEA_HEX_PREFIX + hex.EncodeToString(data)
+}
+
+func putBytea(data []byte, bytea []byte) {
+	binary.BigEndian.PutUint32(data, uint32(len(bytea)))
+	copy(data[types.BYTEA_LENGTH_SIZE:], bytea)
+}
+
+func getBytea(data []byte) []byte {
+	length := int(binary.BigEndian.Uint32(data))
+
+	// a broken length reads what the column holds
+	if length > len(data)-types.BYTEA_LENGTH_SIZE {
+		length = len(data) - types.BYTEA_LENGTH_SIZE
+	}
+
+	return append([]byte{}, data[types.BYTEA_LENGTH_SIZE:types.BYTEA_LENGTH_SIZE+length]...)
+}
@@ -0,0 +1,243 @@
+package tuple
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+/**
+ *  Implicit conversions
+ *  +-----------------------------+-------------------------------+
+ *  | From                        | To                            |
+ *  +-----------------------------+-------------------------------+
+ *  | SMALLINT, INT, BIGINT,      | any of the numbers            |
+ *  | DECIMAL, REAL, FLOAT        |                               |
+ *  | VARCHAR, CHAR               | any type, the text is read    |
+ *  | any type                    | VARCHAR, CHAR                 |
+ *  | DATE, TIMESTAMP             | TIMESTAMP, DATE               |
+ *  +-----------------------------+-------------------------------+
+ *
+ *  An operator widens the numbers along SMALLINT < INT < BIGINT < DECIMAL < REAL < FLOAT and reads
+ *  text such as a quoted literal as the type of the other operand. A value stored into a column
+ *  converts when the pair is in the table, the other pairs need CAST or ::, which also turns BOOL
+ *  and the integers into each other and TIMESTAMP into TIME. A DECIMAL rounds to an integer half
+ *  away from zero and a FLOAT half to even like PostgreSQL.
+ */
+
+// ParseValue reads the text as the type, the error names the type and the text
+func ParseValue(text string, valueType types.COLUMN_TYPE, valueSize int32) (*Value, error) {
+	if value := GetValue(text, valueType, valueSize); value != nil {
+		return value, nil
+	}
+
+	err := errors.ErrInvalidValue
+
+	if isOutOfRange(text, valueType) {
+		err = errors.ErrNumericOverflow
+	}
+
+	return nil, &errors.ValueError{Type: column.GetColumnTypeName(valueType), Value: text, Err: err}
+}
+
+// isOutOfRange reports whether the text is a number which the type can not hold
+func isOutOfRange(text string, valueType types.COLUMN_TYPE) bool {
+	switch valueType {
+	case types.SMALL_INT_TYPE, types.INT_TYPE, types.LONG_INT_TYPE:
+		_, ok := new(big.Int).SetString(text, 10)
+
+		return ok
+	case types.REAL_TYPE, types.FLOAT_TYPE:
+		_, err := strconv.ParseFloat(text, 64)
+
+		return err == nil || err.(*strconv.NumError).Err == strconv.ErrRange
+	case types.DECIMAL_TYPE:
+		_, err := strconv.ParseFloat(text, 64)
+
+		return err == nil
+	}
+
+	return false
+}
+
+func IsNumericType(valueType types.COLUMN_TYPE) bool {
+	switch valueType {
+	case types.SMALL_INT_TYPE, types.INT_TYPE, types.LONG_INT_TYPE, types.DECIMAL_TYPE, types.REAL_TYPE, types.FLOAT_TYPE:
+		return true
+	}
+
+	return false
+}
+
+func IsTextType(valueType types.COLUMN_TYPE) bool {
+	return valueType == types.VAR_CHAR_TYPE || valueType == types.CHAR_TYPE
+}
+
+func isIntegerType(valueType types.COLUMN_TYPE) bool {
+	return valueType == types.SMALL_INT_TYPE || valueType == types.INT_TYPE || valueType == types.LONG_INT_TYPE
+}
+
+// CanConvert reports whether a value of the type is converted without a CAST when it is stored
+func CanConvert(from types.COLUMN_TYPE, to types.COLUMN_TYPE) bool {
+	switch {
+	case from == to, from == types.INVALID_TYPE, IsTextType(from), IsTextType(to):
+		return true
+	case IsNumericType(from) && IsNumericType(to):
+		return true
+	case (from == types.DATE_TYPE && to == types.TIMESTAMP_TYPE) || (from == types.TIMESTAMP_TYPE && to == types.DATE_TYPE):
+		return true
+	}
+
+	return false
+}
+
+// ConvertValue is the conversion of a value which is stored into a column of the type
+func ConvertValue(value *Value, valueType types.COLUMN_TYPE, valueSize int32) (*Value, error) {
+	if !value.IsNull() && !CanConvert(value.GetType(), valueType) {
+		return nil, &errors.CastError{From: column.GetColumnTypeName(value.GetType()), To: column.GetColumnTypeName(valueType)}
+	}
+
+	return CastValue(value, valueType, valueSize)
+}
+
+// CastValue is the conversion of CAST and ::, the types without a rule of their own
+// convert through the text form of the value
+func CastValue(value *Value, valueType types.COLUMN_TYPE, valueSize int32) (*Value, error) {
+	if value.IsNull() {
+		return GetNullValue(valueType, valueSize), nil
+	}
+
+	from := value.GetType()
+
+	switch {
+	case from == valueType:
+		converted := *value
+		converted.size = valueSize
+
+		return &converted, nil
+	case IsNumericType(from) && IsNumericType(valueType):
+		return castNumber(value, valueType, valueSize)
+	case from == types.BOOL_TYPE && isIntegerType(valueType):
+		number := int32(0)
+
+		if value.BOOL {
+			number = 1
+		}
+
+		return castNumber(GetValue(number, types.INT_TYPE, types.INT_SIZE), valueType, valueSize)
+	case isIntegerType(from) && valueType == types.BOOL_TYPE:
+		return GetValue(getInteger(value) != 0, valueType, valueSize), nil
+	case from == types.DATE_TYPE && valueType == types.TIMESTAMP_TYPE:
+		return GetValue(int64(value.DATE)*MICROSECONDS_PER_DAY, valueType, valueSize), nil
+	case from == types.TIMESTAMP_TYPE && valueType == types.DATE_TYPE:
+		days := value.TIMESTAMP / MICROSECONDS_PER_DAY
+
+		if value.TIMESTAMP%MICROSECONDS_PER_DAY < 0 {
+			days--
+		}
+
+		return GetValue(int32(days), valueType, valueSize), nil
+	case from == types.TIMESTAMP_TYPE && valueType == types.TIME_TYPE:
+		return GetValue((value.TIMESTAMP%MICROSECONDS_PER_DAY+MICROSECONDS_PER_DAY)%MICROSECONDS_PER_DAY, valueType, valueSize), nil
+	case from == types.CHAR_TYPE:
+		// the padding of CHAR is dropped when it becomes another type
+		return ParseValue(string(value.VAR_CHAR), valueType, valueSize)
+	}
+
+	return ParseValue(GetValueText(value), valueType, valueSize)
+}
+
+func getInteger(value *Value) int64 {
+	if value.GetType() == types.LONG_INT_TYPE {
+		return value.LONG_INT
+	}
+
+	return int64(value.INT)
+}
+
+func castNumber(value *Value, valueType types.COLUMN_TYPE, valueSize int32) (*Value, error) {
+	from := value.GetType()
+	outOfRange := &errors.ValueError{Type: column.GetColumnTypeName(valueType), Value: GetValueText(value), Err: errors.ErrNumericOverflow}
+
+	switch valueType {
+	case types.FLOAT_TYPE, types.REAL_TYPE:
+		var number float64
+
+		switch {
+		case isIntegerType(from):
+			number = float64(getInteger(value))
+		case from == types.DECIMAL_TYPE:
+			number = value.DECIMAL.Float()
+		default:
+			number = value.FLOAT
+		}
+
+		if valueType == types.REAL_TYPE && math.IsInf(float64(float32(number)), 0) && !math.IsInf(number, 0) {
+			return nil, outOfRange
+		}
+
+		return GetValue(number, valueType, valueSize), nil
+	case types.DECIMAL_TYPE:
+		if isIntegerType(from) {
+			return GetValue(NewDecimal(getInteger(value), 0), valueType, valueSize), nil
+		}
+
+		if math.IsInf(value.FLOAT, 0) || math.IsNaN(value.FLOAT) {
+			return nil, outOfRange
+		}
+
+		bitSize := 64
+
+		if from == types.REAL_TYPE {
+			bitSize = 32
+		}
+
+		return ParseValue(strconv.FormatFloat(value.FLOAT, 'f', -1, bitSize), valueType, valueSize)
+	}
+
+	var integer *big.Int
+
+	switch {
+	case isIntegerType(from):
+		integer = big.NewInt(getInteger(value))
+	case from == types.DECIMAL_TYPE:
+		integer = value.DECIMAL.Rescale(0).Unscaled
+	default:
+		if math.IsInf(value.FLOAT, 0) || math.IsNaN(value.FLOAT) {
+			return nil, outOfRange
+		}
+
+		integer, _ = new(big.Float).SetFloat64(math.RoundToEven(value.FLOAT)).Int(nil)
+	}
+
+	limit := map[types.COLUMN_TYPE]int64{
+		types.SMALL_INT_TYPE: math.MaxInt16,
+		types.INT_TYPE:       math.MaxInt32,
+		types.LONG_INT_TYPE:  math.MaxInt64,
+	}[valueType]
+
+	if !integer.IsInt64() || integer.Int64() > limit || integer.Int64() < -limit-1 {
+		return nil, outOfRange
+	}
+
+	if valueType == types.LONG_INT_TYPE {
+		return GetValue(integer.Int64(), valueType, valueSize), nil
+	}
+
+	return GetValue(int32(integer.Int64()), valueType, valueSize), nil
+}
+
+// TruncateText cuts a VARCHAR or CHAR to the length like an explicit CAST to VARCHAR(n)
+func TruncateText(value *Value, length int32) *Value {
+	if value.IsNull() || !IsTextType(value.GetType()) || int32(len(value.VAR_CHAR)) <= length {
+		return value
+	}
+
+	truncated := *value
+	truncated.VAR_CHAR = value.VAR_CHAR[:length]
+
+	return &truncated
+}
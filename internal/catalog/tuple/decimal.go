@@ -0,0 +1,198 @@
+package tuple
+
+import (
+	"go-db/internal/common/types"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+/**
+ *  DECIMAL format
+ *  +------------------------------+---------------------------------------+
+ *  | scale (1, bare DECIMAL only) | unscaled value (16, two's complement) |
+ *  +------------------------------+---------------------------------------+
+ *
+ *  The value is stored as an integer at the scale of the column, 12.30 in DECIMAL(5,2) is 1230.
+ *  A bare DECIMAL has no scale of its own, every value keeps its scale in front of it.
+ *  A value in memory keeps its own scale, it takes the precision and scale of the column
+ *  when it is written to the table. The text form keeps the zeros of the scale.
+ */
+
+// the digits after the point of a division, unless an operand has more
+const DECIMAL_DIVISION_SCALE = 16
+
+var (
+	bigTen   = big.NewInt(10)
+	twoTo128 = new(big.Int).Lsh(big.NewInt(1), 128)
+)
+
+// Decimal is the integer without the point and the number of digits after it, 1.50 is 150 with scale 2
+type Decimal struct {
+	Unscaled *big.Int
+	Scale    int32
+}
+
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return Decimal{Unscaled: big.NewInt(unscaled), Scale: scale}
+}
+
+// ParseDecimal reads [+-]digits[.digits][e[+-]digits], the scale is the number of digits after the point
+func ParseDecimal(text string) (Decimal, bool) {
+	text = strings.TrimSpace(text)
+	exponent := int64(0)
+
+	if index := strings.IndexAny(text, "eE"); index != -1 {
+		value, err := strconv.ParseInt(text[index+1:], 10, 32)
+
+		if err != nil || value > types.MAX_DECIMAL_PRECISION || value < -types.MAX_DECIMAL_PRECISION {
+			return Decimal{}, false
+		}
+
+		text, exponent = text[:index], value
+	}
+
+	sign := ""
+
+	if strings.HasPrefix(text, "-") || strings.HasPrefix(text, "+") {
+		sign, text = text[:1], text[1:]
+	}
+
+	digits, fraction := text, ""
+
+	if index := strings.Index(text, "."); index != -1 {
+		digits, fraction = text[:index], text[index+1:]
+	}
+
+	if digits+fraction == "" || strings.Trim(digits+fraction, "0123456789") != "" {
+		return Decimal{}, false
+	}
+
+	unscaled, ok := new(big.Int).SetString(sign+digits+fraction, 10)
+
+	if !ok {
+		return Decimal{}, false
+	}
+
+	scale := int64(len(fraction)) - exponent
+
+	if scale < 0 {
+		unscaled.Mul(unscaled, new(big.Int).Exp(bigTen, big.NewInt(-scale), nil))
+		scale = 0
+	}
+
+	return Decimal{Unscaled: unscaled, Scale: int32(scale)}, true
+}
+
+func (d Decimal) String() string {
+	unscaled := d.getUnscaled()
+	digits := new(big.Int).Abs(unscaled).String()
+	sign := ""
+
+	if unscaled.Sign() < 0 {
+		sign = "-"
+	}
+
+	if d.Scale <= 0 {
+		return sign + digits
+	}
+
+	if int32(len(digits)) <= d.Scale {
+		digits = strings.Repeat("0", int(d.Scale)-len(digits)+1) + digits
+	}
+
+	point := len(digits) - int(d.Scale)
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Rescale rounds half away from zero when the scale gets smaller
+func (d Decimal) Rescale(scale int32) Decimal {
+	unscaled := d.getUnscaled()
+
+	if scale >= d.Scale {
+		factor := new(big.Int).Exp(bigTen, big.NewInt(int64(scale-d.Scale)), nil)
+
+		return Decimal{Unscaled: new(big.Int).Mul(unscaled, factor), Scale: scale}
+	}
+
+	return Decimal{Unscaled: DivideRound(unscaled, new(big.Int).Exp(bigTen, big.NewInt(int64(d.Scale-scale)), nil)), Scale: scale}
+}
+
+// Fit rounds the decimal to the scale and fails when it has more digits than the precision allows
+func (d Decimal) Fit(precision int32, scale int32) (Decimal, bool) {
+	fitted := d.Rescale(scale)
+
+	return fitted, fitted.Digits() <= precision
+}
+
+// Digits counts the digits of the unscaled value, zero has none
+func (d Decimal) Digits() int32 {
+	unscaled := d.getUnscaled()
+
+	if unscaled.Sign() == 0 {
+		return 0
+	}
+
+	return int32(len(new(big.Int).Abs(unscaled).String()))
+}
+
+func (d Decimal) Float() float64 {
+	value, _ := strconv.ParseFloat(d.String(), 64)
+
+	return value
+}
+
+func (d Decimal) getUnscaled() *big.Int {
+	if d.Unscaled == nil {
+		return new(big.Int)
+	}
+
+	return d.Unscaled
+}
+
+// DivideRound divides and rounds half away from zero
+func DivideRound(dividend *big.Int, divisor *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(dividend, divisor, new(big.Int))
+
+	if new(big.Int).Abs(new(big.Int).Lsh(remainder, 1)).Cmp(new(big.Int).Abs(divisor)) >= 0 {
+		if (dividend.Sign() < 0) != (divisor.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+
+	return quotient
+}
+
+// PutDecimal writes the unscaled value of a decimal which fits its column,
+// the scale goes in front of it when the data has room for it
+func PutDecimal(data []byte, d Decimal) {
+	if len(data) > types.DECIMAL_SIZE {
+		data[0] = byte(d.Scale)
+		data = data[types.DECIMAL_SCALE_SIZE:]
+	}
+
+	unscaled := new(big.Int).Set(d.getUnscaled())
+
+	if unscaled.Sign() < 0 {
+		unscaled.Add(unscaled, twoTo128)
+	}
+
+	unscaled.FillBytes(data)
+}
+
+func getDecimal(data []byte, scale int32) Decimal {
+	if len(data) > types.DECIMAL_SIZE {
+		scale, data = int32(data[0]), data[types.DECIMAL_SCALE_SIZE:]
+	}
+
+	unscaled := new(big.Int).SetBytes(data)
+
+	if data[0]&0x80 != 0 {
+		unscaled.Sub(unscaled, twoTo128)
+	}
+
+	return Decimal{Unscaled: unscaled, Scale: scale}
+}
@@ -0,0 +1,387 @@
+package tuple
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+/**
+ *  JSON format
+ *  +---------+-------------+--------------------+
+ *  | Tag (1) | Length (var)| Payload (Length)   |   number, string
+ *  +---------+-------------+--------------------+
+ *  +---------+-------------+------------+------------------------------------+
+ *  | Tag (1) | Length (var)| Count (var)| Element | Element | ...            |   array
+ *  +---------+-------------+------------+------------------------------------+
+ *  +---------+-------------+------------+-------------------------------------------------+
+ *  | Tag (1) | Length (var)| Count (var)| KeySize (var) | Key | Value | ...                 |   object
+ *  +---------+-------------+------------+-------------------------------------------------+
+ *
+ *  null, false and true are only their tag. The length of an array or object counts the bytes
+ *  after it so a value can be skipped without reading it, the lengths are unsigned varints.
+ *  Like PostgreSQL JSONB the keys of an object are sorted and unique, the last duplicate wins,
+ *  and a number keeps its digits. The document is padded with zero bytes to the column size.
+ */
+
+const (
+	JSON_NULL byte = iota + 1
+	JSON_FALSE
+	JSON_TRUE
+	JSON_NUMBER
+	JSON_STRING
+	JSON_ARRAY
+	JSON_OBJECT
+)
+
+const (
+	JSON_TYPE_NULL    = "null"
+	JSON_TYPE_BOOLEAN = "boolean"
+	JSON_TYPE_NUMBER  = "number"
+	JSON_TYPE_STRING  = "string"
+	JSON_TYPE_ARRAY   = "array"
+	JSON_TYPE_OBJECT  = "object"
+)
+
+// ParseJSON returns the binary form of a JSON text, false when the text is not a single JSON value
+func ParseJSON(text string) ([]byte, bool) {
+	if !json.Valid([]byte(text)) {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	var value interface{}
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+
+	return encodeJSON(nil, value), true
+}
+
+func encodeJSON(data []byte, value interface{}) []byte {
+	switch v := value.(type) {
+	case nil:
+		return append(data, JSON_NULL)
+	case bool:
+		if v {
+			return append(data, JSON_TRUE)
+		}
+
+		return append(data, JSON_FALSE)
+	case json.Number:
+		text := string(v)
+
+		// 1E2 and 100 are the same number, an exponent out of the DECIMAL range keeps its text
+		if decimal, ok := ParseDecimal(text); ok {
+			text = decimal.String()
+		}
+
+		return appendBytes(append(data, JSON_NUMBER), []byte(text))
+	case string:
+		return appendBytes(append(data, JSON_STRING), []byte(v))
+	case []interface{}:
+		payload := appendUvarint(nil, uint64(len(v)))
+
+		for _, element := range v {
+			payload = encodeJSON(payload, element)
+		}
+
+		return appendBytes(append(data, JSON_ARRAY), payload)
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+
+		for key := range v {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		payload := appendUvarint(nil, uint64(len(keys)))
+
+		for _, key := range keys {
+			payload = appendBytes(payload, []byte(key))
+			payload = encodeJSON(payload, v[key])
+		}
+
+		return appendBytes(append(data, JSON_OBJECT), payload)
+	}
+
+	return data
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	buffer := make([]byte, binary.MaxVarintLen64)
+
+	return append(data, buffer[:binary.PutUvarint(buffer, value)]...)
+}
+
+func appendBytes(data []byte, payload []byte) []byte {
+	return append(appendUvarint(data, uint64(len(payload))), payload...)
+}
+
+// readBytes returns the bytes after the length at the offset and the offset after them
+func readBytes(data []byte, offset int) ([]byte, int, bool) {
+	if offset >= len(data) {
+		return nil, 0, false
+	}
+
+	length, n := binary.Uvarint(data[offset:])
+
+	if n <= 0 || uint64(len(data)-offset-n) < length {
+		return nil, 0, false
+	}
+
+	start := offset + n
+
+	return data[start : start+int(length)], start + int(length), true
+}
+
+// splitJSON returns the value at the start of the data and the bytes after it
+func splitJSON(data []byte) ([]byte, []byte, bool) {
+	if len(data) == 0 {
+		return nil, nil, false
+	}
+
+	switch data[0] {
+	case JSON_NULL, JSON_FALSE, JSON_TRUE:
+		return data[:1], data[1:], true
+	case JSON_NUMBER, JSON_STRING, JSON_ARRAY, JSON_OBJECT:
+		_, end, ok := readBytes(data, 1)
+
+		if !ok {
+			return nil, nil, false
+		}
+
+		return data[:end], data[end:], true
+	}
+
+	return nil, nil, false
+}
+
+// TrimJSON drops the padding after the document
+func TrimJSON(data []byte) []byte {
+	value, _, ok := splitJSON(data)
+
+	if !ok {
+		return []byte{}
+	}
+
+	return value
+}
+
+// getElements returns the elements of an array, or the keys and values of an object one after the other
+func getElements(doc []byte) ([][]byte, bool) {
+	payload, _, ok := readBytes(doc, 1)
+
+	if !ok {
+		return nil, false
+	}
+
+	count, n := binary.Uvarint(payload)
+
+	if n <= 0 {
+		return nil, false
+	}
+
+	payload = payload[n:]
+
+	// every element takes at least a byte, a larger count is a broken document
+	if count > uint64(len(payload)) {
+		return nil, false
+	}
+
+	elements := make([][]byte, 0, count)
+
+	for i := uint64(0); i < count; i++ {
+		if doc[0] == JSON_OBJECT {
+			key, end, ok := readBytes(payload, 0)
+
+			if !ok {
+				return nil, false
+			}
+
+			elements = append(elements, key)
+			payload = payload[end:]
+		}
+
+		element, rest, ok := splitJSON(payload)
+
+		if !ok {
+			return nil, false
+		}
+
+		elements = append(elements, element)
+		payload = rest
+	}
+
+	return elements, true
+}
+
+func GetJSONType(doc []byte) string {
+	if len(doc) == 0 {
+		return ""
+	}
+
+	switch doc[0] {
+	case JSON_NULL:
+		return JSON_TYPE_NULL
+	case JSON_FALSE, JSON_TRUE:
+		return JSON_TYPE_BOOLEAN
+	case JSON_NUMBER:
+		return JSON_TYPE_NUMBER
+	case JSON_STRING:
+		return JSON_TYPE_STRING
+	case JSON_ARRAY:
+		return JSON_TYPE_ARRAY
+	case JSON_OBJECT:
+		return JSON_TYPE_OBJECT
+	}
+
+	return ""
+}
+
+// GetJSONField returns the value of the key, false when the document is not an object or has no such key
+func GetJSONField(doc []byte, key string) ([]byte, bool) {
+	if len(doc) == 0 || doc[0] != JSON_OBJECT {
+		return nil, false
+	}
+
+	elements, ok := getElements(doc)
+
+	if !ok {
+		return nil, false
+	}
+
+	for i := 0; i < len(elements); i += 2 {
+		if string(elements[i]) == key {
+			return elements[i+1], true
+		}
+	}
+
+	return nil, false
+}
+
+// GetJSONElement counts a negative index from the end of the array like PostgreSQL
+func GetJSONElement(doc []byte, index int) ([]byte, bool) {
+	if len(doc) == 0 || doc[0] != JSON_ARRAY {
+		return nil, false
+	}
+
+	elements, ok := getElements(doc)
+
+	if !ok {
+		return nil, false
+	}
+
+	if index < 0 {
+		index += len(elements)
+	}
+
+	if index < 0 || index >= len(elements) {
+		return nil, false
+	}
+
+	return elements[index], true
+}
+
+// GetJSONArrayLength returns false when the document is not an array
+func GetJSONArrayLength(doc []byte) (int, bool) {
+	if len(doc) == 0 || doc[0] != JSON_ARRAY {
+		return 0, false
+	}
+
+	elements, ok := getElements(doc)
+
+	return len(elements), ok
+}
+
+// GetJSONScalarText is the text ->> returns, a string loses its quotes and null has no text
+func GetJSONScalarText(doc []byte) (string, bool) {
+	switch GetJSONType(doc) {
+	case JSON_TYPE_NULL, "":
+		return "", false
+	case JSON_TYPE_STRING:
+		text, _, _ := readBytes(doc, 1)
+
+		return string(text), true
+	}
+
+	return FormatJSON(doc), true
+}
+
+// FormatJSON writes the document the way PostgreSQL prints JSONB
+func FormatJSON(doc []byte) string {
+	buffer := &bytes.Buffer{}
+	formatJSON(buffer, doc)
+
+	return buffer.String()
+}
+
+func formatJSON(buffer *bytes.Buffer, doc []byte) {
+	switch GetJSONType(doc) {
+	case JSON_TYPE_NULL:
+		buffer.WriteString("null")
+	case JSON_TYPE_BOOLEAN:
+		if doc[0] == JSON_TRUE {
+			buffer.WriteString("true")
+		} else {
+			buffer.WriteString("false")
+		}
+	case JSON_TYPE_NUMBER:
+		text, _, _ := readBytes(doc, 1)
+		buffer.Write(text)
+	case JSON_TYPE_STRING:
+		text, _, _ := readBytes(doc, 1)
+		buffer.WriteString(quoteJSON(string(text)))
+	case JSON_TYPE_ARRAY:
+		elements, _ := getElements(doc)
+
+		buffer.WriteByte('[')
+
+		for i, element := range elements {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+
+			formatJSON(buffer, element)
+		}
+
+		buffer.WriteByte(']')
+	case JSON_TYPE_OBJECT:
+		elements, _ := getElements(doc)
+
+		buffer.WriteByte('{')
+
+		for i := 0; i < len(elements); i += 2 {
+			if i > 0 {
+				buffer.WriteString(", ")
+			}
+
+			buffer.WriteString(quoteJSON(string(elements[i])))
+			buffer.WriteString(": ")
+			formatJSON(buffer, elements[i+1])
+		}
+
+		buffer.WriteByte('}')
+	}
+}
+
+// quoteJSON escapes the string without turning < > & into unicode escapes
+func quoteJSON(text string) string {
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(text)
+
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
+
+// NewJSONString is the binary form of a JSON string
+func NewJSONString(text string) []byte {
+	return encodeJSON(nil, text)
+}
@@ -0,0 +1,516 @@
+package tuple
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/**
+ *  TEMPORAL values
+ *  +-----------+----------+-----------------------------------------------+
+ *  | DATE      | 4 bytes  | days since 1970-01-01                         |
+ *  | TIME      | 8 bytes  | microseconds since midnight                   |
+ *  | TIMESTAMP | 8 bytes  | microseconds since 1970-01-01 00:00:00        |
+ *  | INTERVAL  | 16 bytes | months (4), days (4) and microseconds (8)     |
+ *  +-----------+----------+-----------------------------------------------+
+ *
+ *  TIMESTAMP has no time zone, a literal with an offset is converted to UTC.
+ *  The text form follows PostgreSQL, such as 2024-01-02 03:04:05 and 1 year 2 mons 04:05:06,
+ *  and the JSON form is ISO-8601, such as 2024-01-02T03:04:05 and P1Y2MT4H5M6S.
+ */
+
+const (
+	MICROSECONDS_PER_SECOND = 1000000
+	MICROSECONDS_PER_MINUTE = 60 * MICROSECONDS_PER_SECOND
+	MICROSECONDS_PER_HOUR   = 60 * MICROSECONDS_PER_MINUTE
+	MICROSECONDS_PER_DAY    = 24 * MICROSECONDS_PER_HOUR
+	DAYS_PER_MONTH          = 30
+	MIN_YEAR                = 1
+	MAX_YEAR                = 9999
+)
+
+// the units of INTERVAL text, date_trunc and date_part
+const (
+	UNIT_MICROSECOND = "microsecond"
+	UNIT_MILLISECOND = "millisecond"
+	UNIT_SECOND      = "second"
+	UNIT_MINUTE      = "minute"
+	UNIT_HOUR        = "hour"
+	UNIT_DAY         = "day"
+	UNIT_WEEK        = "week"
+	UNIT_MONTH       = "month"
+	UNIT_QUARTER     = "quarter"
+	UNIT_YEAR        = "year"
+	UNIT_DECADE      = "decade"
+	UNIT_CENTURY     = "century"
+	UNIT_MILLENNIUM  = "millennium"
+)
+
+const (
+	DATE_LAYOUT      = "2006-01-02"
+	TIME_LAYOUT      = "15:04:05.999999"
+	TIMESTAMP_LAYOUT = DATE_LAYOUT + " " + TIME_LAYOUT
+	ISO_LAYOUT       = DATE_LAYOUT + "T" + TIME_LAYOUT
+)
+
+// Interval keeps months and days apart from the time like PostgreSQL,
+// a month has no fixed number of days
+type Interval struct {
+	Months       int32
+	Days         int32
+	Microseconds int64
+}
+
+// a fractional second after the seconds is accepted by every layout
+var timestampLayouts = []string{
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	DATE_LAYOUT,
+}
+
+var timeLayouts = []string{"15:04:05", "15:04"}
+
+var units = map[string]string{
+	"microsecond": UNIT_MICROSECOND, "microseconds": UNIT_MICROSECOND, "us": UNIT_MICROSECOND,
+	"millisecond": UNIT_MILLISECOND, "milliseconds": UNIT_MILLISECOND, "ms": UNIT_MILLISECOND,
+	"second": UNIT_SECOND, "seconds": UNIT_SECOND, "sec": UNIT_SECOND, "secs": UNIT_SECOND,
+	"minute": UNIT_MINUTE, "minutes": UNIT_MINUTE, "min": UNIT_MINUTE, "mins": UNIT_MINUTE,
+	"hour": UNIT_HOUR, "hours": UNIT_HOUR,
+	"day": UNIT_DAY, "days": UNIT_DAY,
+	"week": UNIT_WEEK, "weeks": UNIT_WEEK,
+	"month": UNIT_MONTH, "months": UNIT_MONTH, "mon": UNIT_MONTH, "mons": UNIT_MONTH,
+	"quarter": UNIT_QUARTER, "quarters": UNIT_QUARTER,
+	"year": UNIT_YEAR, "years": UNIT_YEAR,
+	"decade": UNIT_DECADE, "decades": UNIT_DECADE,
+	"century": UNIT_CENTURY, "centuries": UNIT_CENTURY,
+	"millennium": UNIT_MILLENNIUM, "millennia": UNIT_MILLENNIUM,
+}
+
+// GetUnit returns the unit the name stands for in any case, plural or abbreviated, or an empty string
+func GetUnit(name string) string {
+	return units[strings.ToLower(name)]
+}
+
+func DateToTime(days int32) time.Time {
+	return time.Unix(int64(days)*86400, 0).UTC()
+}
+
+func TimeToDate(t time.Time) int32 {
+	return int32(floorDiv(t.Unix(), 86400))
+}
+
+func TimestampToTime(microseconds int64) time.Time {
+	return time.Unix(floorDiv(microseconds, MICROSECONDS_PER_SECOND), floorMod(microseconds, MICROSECONDS_PER_SECOND)*1000).UTC()
+}
+
+func TimeToTimestamp(t time.Time) int64 {
+	return t.Unix()*MICROSECONDS_PER_SECOND + int64(t.Nanosecond()/1000)
+}
+
+// TimeOfDay returns the microseconds since the midnight of the time
+func TimeOfDay(t time.Time) int64 {
+	hour, minute, second := t.Clock()
+
+	return int64(hour)*MICROSECONDS_PER_HOUR + int64(minute)*MICROSECONDS_PER_MINUTE +
+		int64(second)*MICROSECONDS_PER_SECOND + int64(t.Nanosecond()/1000)
+}
+
+// IsTimeInRange reports whether the year of the time has four digits
+func IsTimeInRange(t time.Time) bool {
+	return t.Year() >= MIN_YEAR && t.Year() <= MAX_YEAR
+}
+
+func ParseDate(text string) (int32, bool) {
+	t, ok := parseTimestamp(text)
+
+	if !ok {
+		return 0, false
+	}
+
+	return TimeToDate(t), true
+}
+
+// ParseTime also takes a timestamp and keeps its time of day
+func ParseTime(text string) (int64, bool) {
+	text = strings.TrimSpace(text)
+
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return TimeOfDay(t.Round(time.Microsecond)), true
+		}
+	}
+
+	t, ok := parseTimestamp(text)
+
+	if !ok {
+		return 0, false
+	}
+
+	return TimeOfDay(t), true
+}
+
+func ParseTimestamp(text string) (int64, bool) {
+	t, ok := parseTimestamp(text)
+
+	if !ok {
+		return 0, false
+	}
+
+	return TimeToTimestamp(t), true
+}
+
+func parseTimestamp(text string) (time.Time, bool) {
+	text = strings.TrimSpace(text)
+
+	for _, layout := range timestampLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			t = t.UTC().Round(time.Microsecond)
+
+			return t, IsTimeInRange(t)
+		}
+	}
+
+	return time.Time{}, false
+}
+
+/**
+ *  ParseInterval takes the PostgreSQL form, such as 1 year 2 months 3 days 04:05:06 or 2 hours ago,
+ *  and the ISO-8601 form, such as P1Y2M3DT4H5M6S. A fraction of a month becomes days
+ *  and a fraction of a day becomes microseconds.
+ */
+func ParseInterval(text string) (Interval, bool) {
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "P") {
+		return parseISOInterval(text[1:])
+	}
+
+	fields := strings.Fields(strings.ToLower(text))
+	builder := &intervalBuilder{}
+	ago := len(fields) > 0 && fields[len(fields)-1] == "ago"
+
+	if ago {
+		fields = fields[:len(fields)-1]
+	}
+
+	if len(fields) == 0 {
+		return Interval{}, false
+	}
+
+	for i := 0; i < len(fields); i++ {
+		if strings.Contains(fields[i], ":") {
+			microseconds, ok := parseClock(fields[i])
+
+			if !ok {
+				return Interval{}, false
+			}
+
+			builder.microseconds += float64(microseconds)
+			continue
+		}
+
+		number, err := strconv.ParseFloat(fields[i], 64)
+
+		if err != nil {
+			return Interval{}, false
+		}
+
+		// a number without a unit counts seconds
+		unit := UNIT_SECOND
+
+		if i+1 < len(fields) {
+			i++
+			unit = GetUnit(fields[i])
+		}
+
+		if !builder.add(number, unit) {
+			return Interval{}, false
+		}
+	}
+
+	if ago {
+		builder.months, builder.days, builder.microseconds = -builder.months, -builder.days, -builder.microseconds
+	}
+
+	return builder.build()
+}
+
+func parseISOInterval(text string) (Interval, bool) {
+	builder := &intervalBuilder{}
+	clock := false
+
+	if text == "" {
+		return Interval{}, false
+	}
+
+	for text != "" {
+		if text[0] == 'T' && !clock {
+			clock = true
+			text = text[1:]
+			continue
+		}
+
+		end := strings.IndexAny(text, "YMWDHS")
+
+		if end <= 0 {
+			return Interval{}, false
+		}
+
+		number, err := strconv.ParseFloat(text[:end], 64)
+
+		if err != nil {
+			return Interval{}, false
+		}
+
+		var unit string
+
+		switch text[end] {
+		case 'Y':
+			unit = UNIT_YEAR
+		case 'M':
+			unit = UNIT_MONTH
+
+			if clock {
+				unit = UNIT_MINUTE
+			}
+		case 'W':
+			unit = UNIT_WEEK
+		case 'D':
+			unit = UNIT_DAY
+		case 'H':
+			unit = UNIT_HOUR
+		case 'S':
+			unit = UNIT_SECOND
+		}
+
+		// the designators of the time follow T and the others come before it
+		if clock != (unit == UNIT_HOUR || unit == UNIT_MINUTE || unit == UNIT_SECOND) || !builder.add(number, unit) {
+			return Interval{}, false
+		}
+
+		text = text[end+1:]
+	}
+
+	return builder.build()
+}
+
+// parseClock reads [-]hh:mm[:ss[.ffffff]]
+func parseClock(text string) (int64, bool) {
+	sign := int64(1)
+
+	if strings.HasPrefix(text, "-") {
+		sign, text = -1, text[1:]
+	}
+
+	parts := strings.Split(text, ":")
+
+	if len(parts) > 3 {
+		return 0, false
+	}
+
+	var microseconds int64
+
+	for i, part := range parts {
+		if i == 2 {
+			seconds, err := strconv.ParseFloat(part, 64)
+
+			if err != nil || seconds < 0 || seconds >= 60 {
+				return 0, false
+			}
+
+			microseconds += int64(math.Round(seconds * MICROSECONDS_PER_SECOND))
+			break
+		}
+
+		number, err := strconv.ParseUint(part, 10, 32)
+
+		if err != nil || (i == 1 && number >= 60) {
+			return 0, false
+		}
+
+		if i == 0 {
+			microseconds += int64(number) * MICROSECONDS_PER_HOUR
+		} else {
+			microseconds += int64(number) * MICROSECONDS_PER_MINUTE
+		}
+	}
+
+	return sign * microseconds, true
+}
+
+// NewInterval moves the fractions of the months and days down like an interval text
+func NewInterval(months float64, days float64, microseconds float64) (Interval, bool) {
+	builder := &intervalBuilder{months: months, days: days, microseconds: microseconds}
+
+	return builder.build()
+}
+
+type intervalBuilder struct {
+	months       float64
+	days         float64
+	microseconds float64
+}
+
+func (b *intervalBuilder) add(number float64, unit string) bool {
+	switch unit {
+	case UNIT_MILLENNIUM:
+		b.months += number * 12000
+	case UNIT_CENTURY:
+		b.months += number * 1200
+	case UNIT_DECADE:
+		b.months += number * 120
+	case UNIT_YEAR:
+		b.months += number * 12
+	case UNIT_QUARTER:
+		b.months += number * 3
+	case UNIT_MONTH:
+		b.months += number
+	case UNIT_WEEK:
+		b.days += number * 7
+	case UNIT_DAY:
+		b.days += number
+	case UNIT_HOUR:
+		b.microseconds += number * MICROSECONDS_PER_HOUR
+	case UNIT_MINUTE:
+		b.microseconds += number * MICROSECONDS_PER_MINUTE
+	case UNIT_SECOND:
+		b.microseconds += number * MICROSECONDS_PER_SECOND
+	case UNIT_MILLISECOND:
+		b.microseconds += number * 1000
+	case UNIT_MICROSECOND:
+		b.microseconds += number
+	default:
+		return false
+	}
+
+	return true
+}
+
+// build moves the fractions down, the fraction of a month is rounded to
+// microseconds first so that 0.1 month is 3 days and not a little more
+func (b *intervalBuilder) build() (Interval, bool) {
+	months := math.Trunc(b.months)
+	monthFraction := math.Round((b.months - months) * DAYS_PER_MONTH * MICROSECONDS_PER_DAY)
+
+	days := math.Trunc(b.days)
+	dayFraction := math.Round((b.days - days) * MICROSECONDS_PER_DAY)
+
+	days += math.Trunc(monthFraction / MICROSECONDS_PER_DAY)
+	microseconds := math.Round(b.microseconds) + dayFraction + math.Mod(monthFraction, MICROSECONDS_PER_DAY)
+
+	if math.Abs(months) > math.MaxInt32 || math.Abs(days) > math.MaxInt32 || !(math.Abs(microseconds) < math.MaxInt64) {
+		return Interval{}, false
+	}
+
+	return Interval{Months: int32(months), Days: int32(days), Microseconds: int64(microseconds)}, true
+}
+
+func formatInterval(i Interval) string {
+	parts := make([]string, 0)
+
+	addPart := func(number int32, unit string) {
+		if number == 0 {
+			return
+		}
+
+		if number != 1 {
+			unit += "s"
+		}
+
+		parts = append(parts, fmt.Sprintf("%d %s", number, unit))
+	}
+
+	addPart(i.Months/12, "year")
+	addPart(i.Months%12, "mon")
+	addPart(i.Days, "day")
+
+	if i.Microseconds != 0 || len(parts) == 0 {
+		sign, microseconds := "", uint64(i.Microseconds)
+
+		if i.Microseconds < 0 {
+			sign, microseconds = "-", -microseconds
+		}
+
+		parts = append(parts, fmt.Sprintf("%s%02d:%02d:%s", sign, microseconds/MICROSECONDS_PER_HOUR,
+			microseconds/MICROSECONDS_PER_MINUTE%60, formatSeconds(microseconds%MICROSECONDS_PER_MINUTE, true)))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func formatISOInterval(i Interval) string {
+	var builder strings.Builder
+
+	builder.WriteString("P")
+
+	for _, part := range []struct {
+		number     int64
+		designator string
+	}{{int64(i.Months / 12), "Y"}, {int64(i.Months % 12), "M"}, {int64(i.Days), "D"}} {
+		if part.number != 0 {
+			builder.WriteString(strconv.FormatInt(part.number, 10) + part.designator)
+		}
+	}
+
+	if i.Microseconds != 0 {
+		sign, microseconds := "", uint64(i.Microseconds)
+
+		if i.Microseconds < 0 {
+			sign, microseconds = "-", -microseconds
+		}
+
+		builder.WriteString("T")
+
+		if hours := microseconds / MICROSECONDS_PER_HOUR; hours != 0 {
+			builder.WriteString(sign + strconv.FormatUint(hours, 10) + "H")
+		}
+
+		if minutes := microseconds / MICROSECONDS_PER_MINUTE % 60; minutes != 0 {
+			builder.WriteString(sign + strconv.FormatUint(minutes, 10) + "M")
+		}
+
+		if seconds := microseconds % MICROSECONDS_PER_MINUTE; seconds != 0 {
+			builder.WriteString(sign + formatSeconds(seconds, false) + "S")
+		}
+	}
+
+	if builder.Len() == 1 {
+		return "PT0S"
+	}
+
+	return builder.String()
+}
+
+// formatSeconds drops the zeros at the end of the fraction
+func formatSeconds(microseconds uint64, padded bool) string {
+	text := strconv.FormatUint(microseconds/MICROSECONDS_PER_SECOND, 10)
+
+	if padded && len(text) < 2 {
+		text = "0" + text
+	}
+
+	if fraction := microseconds % MICROSECONDS_PER_SECOND; fraction != 0 {
+		text += strings.TrimRight(fmt.Sprintf(".%06d", fraction), "0")
+	}
+
+	return text
+}
+
+func floorDiv(a int64, b int64) int64 {
+	if a%b != 0 && (a < 0) != (b < 0) {
+		return a/b - 1
+	}
+
+	return a / b
+}
+
+func floorMod(a int64, b int64) int64 {
+	return a - floorDiv(a, b)*b
+}
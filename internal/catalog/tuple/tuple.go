@@ -2,17 +2,54 @@ package tuple
 
 import (
 	"encoding/binary"
-	"go-db/internal/catalog/schema"
+	"go-db/internal/catalog/column"
 	"go-db/internal/common/types"
 	"go-db/internal/utils"
 	"math"
 )
 
-func TupleSerialization(values []*Value) []byte {
-	data := make([]byte, 0)
+/**
+ *  TUPLE format
+ *  +-------------------------------+----------------+----------------+-----+
+ *  | NULL bitmap ((count + 7) / 8) | Data 1 Payload | Data 2 Payload | ... |
+ *  +-------------------------------+----------------+----------------+-----+
+ *
+ *  Bit i of the bitmap is set when value i is NULL, a NULL value keeps its payload zeroed.
+ */
+
+// GetTupleSize returns the serialized size of every tuple of the columns
+func GetTupleSize(columns []*column.Column) int32 {
+	size := getNullBitmapSize(len(columns))
+
+	for _, c := range columns {
+		size += c.Size
+	}
+
+	return size
+}
+
+// GetValuesSize returns the serialized size of the values
+func GetValuesSize(values []*Value) int32 {
+	size := getNullBitmapSize(len(values))
+
 	for _, v := range values {
+		size += v.GetSize()
+	}
+
+	return size
+}
+
+func TupleSerialization(values []*Value) []byte {
+	data := make([]byte, getNullBitmapSize(len(values)))
+	for i, v := range values {
 		tempData := make([]byte, v.size)
 
+		if v.null {
+			data[i/8] |= 1 << (i % 8)
+			data = append(data, tempData...)
+			continue
+		}
+
 		switch v.types {
 		case types.BOOL_TYPE:
 			var boolValue uint32
@@ -28,6 +65,30 @@ func TupleSerialization(values []*Value) []byte {
 			copy(tempData, v.VAR_CHAR)
 		case types.FLOAT_TYPE:
 			binary.BigEndian.PutUint64(tempData, math.Float64bits(v.FLOAT))
+		case types.DATE_TYPE:
+			binary.BigEndian.PutUint32(tempData, uint32(v.DATE))
+		case types.TIME_TYPE:
+			binary.BigEndian.PutUint64(tempData, uint64(v.TIME))
+		case types.TIMESTAMP_TYPE:
+			binary.BigEndian.PutUint64(tempData, uint64(v.TIMESTAMP))
+		case types.INTERVAL_TYPE:
+			binary.BigEndian.PutUint32(tempData[0:4], uint32(v.INTERVAL.Months))
+			binary.BigEndian.PutUint32(tempData[4:8], uint32(v.INTERVAL.Days))
+			binary.BigEndian.PutUint64(tempData[8:16], uint64(v.INTERVAL.Microseconds))
+		case types.DECIMAL_TYPE:
+			PutDecimal(tempData, v.DECIMAL)
+		case types.JSON_TYPE:
+			copy(tempData, v.JSON)
+		case types.SMALL_INT_TYPE:
+			binary.BigEndian.PutUint16(tempData, uint16(v.INT))
+		case types.REAL_TYPE:
+			binary.BigEndian.PutUint32(tempData, math.Float32bits(float32(v.FLOAT)))
+		case types.UUID_TYPE:
+			copy(tempData, v.UUID[:])
+		case types.BYTEA_TYPE:
+			putBytea(tempData, v.BYTEA)
+		case types.CHAR_TYPE:
+			copy(tempData, v.VAR_CHAR)
 		}
 		data = append(data, tempData...)
 	}
@@ -35,15 +96,14 @@ func TupleSerialization(values []*Value) []byte {
 	return data
 }
 
-func TupleDeserialization(schema *schema.Schema, data []byte) []*Value {
-	columns := schema.GetColumns()
-
+func TupleDeserialization(columns []*column.Column, data []byte) []*Value {
 	values := make([]*Value, 0, len(columns))
-	byteOffset := 0
-	for _, c := range columns {
+	byteOffset := int(getNullBitmapSize(len(columns)))
+	for i, c := range columns {
 		v := &Value{
 			types: c.ColumnType,
 			size:  c.Size,
+			null:  data[i/8]&(1<<(i%8)) != 0,
 		}
 
 		switch v.types {
@@ -62,6 +122,32 @@ func TupleDeserialization(schema *schema.Schema, data []byte) []*Value {
 			v.VAR_CHAR = utils.TrimByteEmptySpace(v.VAR_CHAR)
 		case types.FLOAT_TYPE:
 			v.FLOAT = math.Float64frombits(binary.BigEndian.Uint64(data[byteOffset : byteOffset+int(v.size)]))
+		case types.DATE_TYPE:
+			v.DATE = int32(binary.BigEndian.Uint32(data[byteOffset : byteOffset+int(v.size)]))
+		case types.TIME_TYPE:
+			v.TIME = int64(binary.BigEndian.Uint64(data[byteOffset : byteOffset+int(v.size)]))
+		case types.TIMESTAMP_TYPE:
+			v.TIMESTAMP = int64(binary.BigEndian.Uint64(data[byteOffset : byteOffset+int(v.size)]))
+		case types.INTERVAL_TYPE:
+			v.INTERVAL.Months = int32(binary.BigEndian.Uint32(data[byteOffset : byteOffset+4]))
+			v.INTERVAL.Days = int32(binary.BigEndian.Uint32(data[byteOffset+4 : byteOffset+8]))
+			v.INTERVAL.Microseconds = int64(binary.BigEndian.Uint64(data[byteOffset+8 : byteOffset+16]))
+		case types.DECIMAL_TYPE:
+			v.DECIMAL = getDecimal(data[byteOffset:byteOffset+int(v.size)], c.Scale)
+		case types.JSON_TYPE:
+			v.JSON = append([]byte{}, TrimJSON(data[byteOffset:byteOffset+int(v.size)])...)
+		case types.SMALL_INT_TYPE:
+			v.INT = int32(int16(binary.BigEndian.Uint16(data[byteOffset : byteOffset+int(v.size)])))
+		case types.REAL_TYPE:
+			v.FLOAT = float64(math.Float32frombits(binary.BigEndian.Uint32(data[byteOffset : byteOffset+int(v.size)])))
+		case types.UUID_TYPE:
+			copy(v.UUID[:], data[byteOffset:byteOffset+int(v.size)])
+		case types.BYTEA_TYPE:
+			v.BYTEA = getBytea(data[byteOffset : byteOffset+int(v.size)])
+		case types.CHAR_TYPE:
+			v.VAR_CHAR = make([]byte, v.size)
+			copy(v.VAR_CHAR, data[byteOffset:byteOffset+int(v.size)])
+			v.VAR_CHAR = utils.TrimByteEmptySpace(v.VAR_CHAR)
 		}
 
 		byteOffset += int(c.Size)
@@ -70,3 +156,7 @@ func TupleDeserialization(schema *schema.Schema, data []byte) []*Value {
 
 	return values
 }
+
+func getNullBitmapSize(count int) int32 {
+	return int32((count + 7) / 8)
+}
@@ -1 +1,335 @@
 package tuple
+
+import (
+	stderrors "errors"
+	"go-db/internal/catalog/column"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"strings"
+	"testing"
+)
+
+func Test_NullValue(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "a"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 4, "b"),
+	}
+
+	values := []*Value{
+		GetNullValue(types.INT_TYPE, types.INT_SIZE),
+		GetValue("abc", types.VAR_CHAR_TYPE, 4),
+	}
+
+	data := TupleSerialization(values)
+
+	if int32(len(data)) != GetTupleSize(columns) || GetValuesSize(values) != GetTupleSize(columns) {
+		t.Fatal("tuple size should include the NULL bitmap", len(data))
+	}
+
+	result := TupleDeserialization(columns, data)
+
+	if !result[0].IsNull() || result[1].IsNull() || string(result[1].VAR_CHAR) != "abc" {
+		t.Error("NULL should be read back")
+	}
+
+	if GetValueInterface(result[0]) != nil {
+		t.Error("NULL should have no value")
+	}
+
+	converted, err := ConvertValue(result[0], types.VAR_CHAR_TYPE, 8)
+
+	if err != nil || !converted.IsNull() {
+		t.Error("NULL should stay NULL when converted")
+	}
+}
+
+func Test_TemporalValue(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.DATE_TYPE, 0, "d"),
+		column.NewColumn(types.TIME_TYPE, 0, "t"),
+		column.NewColumn(types.TIMESTAMP_TYPE, 0, "ts"),
+		column.NewColumn(types.INTERVAL_TYPE, 0, "i"),
+	}
+
+	texts := []string{"1969-12-31", "23:59:59.5", "2024-02-29T10:20:30.000001+02:00", "1 year 2 months -3 days 04:05:06"}
+	values := make([]*Value, len(columns))
+
+	for i, c := range columns {
+		if values[i] = GetValue(texts[i], c.ColumnType, c.Size); values[i] == nil {
+			t.Fatal(texts[i], "should be read")
+		}
+	}
+
+	result := TupleDeserialization(columns, TupleSerialization(values))
+
+	expected := []interface{}{"1969-12-31", "23:59:59.5", "2024-02-29T08:20:30.000001", "P1Y2M-3DT4H5M6S"}
+	expectedText := []string{"1969-12-31", "23:59:59.5", "2024-02-29 08:20:30.000001", "1 year 2 mons -3 days 04:05:06"}
+
+	for i, v := range result {
+		if GetValueInterface(v) != expected[i] {
+			t.Error("get the wrong JSON value", GetValueInterface(v), expected[i])
+		}
+
+		if GetValueText(v) != expectedText[i] {
+			t.Error("get the wrong text", GetValueText(v), expectedText[i])
+		}
+	}
+
+	intervals := map[string]Interval{
+		"1.5 years":           {Months: 18},
+		"1.1 months":          {Months: 1, Days: 3},
+		"1.5 days":            {Days: 1, Microseconds: 12 * MICROSECONDS_PER_HOUR},
+		"2 weeks 3 hours ago": {Days: -14, Microseconds: -3 * MICROSECONDS_PER_HOUR},
+		"-01:30":              {Microseconds: -90 * MICROSECONDS_PER_MINUTE},
+		"90":                  {Microseconds: 90 * MICROSECONDS_PER_SECOND},
+		"P1Y2M3DT4H5M6.5S":    {Months: 14, Days: 3, Microseconds: 4*MICROSECONDS_PER_HOUR + 5*MICROSECONDS_PER_MINUTE + 6500000},
+		"P2W":                 {Days: 14},
+	}
+
+	for text, want := range intervals {
+		if interval, ok := ParseInterval(text); !ok || interval != want {
+			t.Error(text, "is read as", interval, ok)
+		}
+	}
+
+	for i, invalid := range [][]string{
+		{"2024-02-30", "2024-13-01", "10000-01-01", "today"},
+		{"24:00:00", "10:60", "noon"},
+		{"2024-01-01 25:00", "2024-01-01 10:00 +2"},
+		{"", "ago", "1 fortnight", "P1H", "PT1D", "1:2:3:4", "P"},
+	} {
+		for _, text := range invalid {
+			if GetValue(text, columns[i].ColumnType, columns[i].Size) != nil {
+				t.Error(text, "should not be read as", column.GetColumnTypeName(columns[i].ColumnType))
+			}
+		}
+	}
+
+	converted, err := ConvertValue(result[2], types.DATE_TYPE, types.DATE_SIZE)
+
+	if err != nil || GetValueInterface(converted) != "2024-02-29" {
+		t.Error("TIMESTAMP should convert to its DATE", converted, err)
+	}
+
+	if _, err := ConvertValue(result[1], types.DATE_TYPE, types.DATE_SIZE); !stderrors.Is(err, errors.ErrInvalidValue) {
+		t.Error("TIME should not convert to DATE", err)
+	}
+}
+
+func Test_DecimalValue(t *testing.T) {
+	columns := []*column.Column{
+		column.NewDecimalColumn(10, 2, "price"),
+		column.NewDecimalColumn(38, 0, "big"),
+		column.NewColumn(types.DECIMAL_TYPE, 0, "bare"),
+		column.NewColumn(types.DECIMAL_TYPE, 0, "scaled"),
+	}
+
+	texts := []string{"-12.345", "-99999999999999999999999999999999999999", "1.5e3", "-1.50"}
+	values := make([]*Value, len(columns))
+
+	for i, c := range columns {
+		if values[i] = GetValue(texts[i], c.ColumnType, c.Size); values[i] == nil {
+			t.Fatal(texts[i], "should be read")
+		}
+	}
+
+	if err := FitValues(columns, values); err != nil {
+		t.Fatal(err)
+	}
+
+	result := TupleDeserialization(columns, TupleSerialization(values))
+
+	// the rounding goes half away from zero
+	// a bare DECIMAL keeps the scale of its value
+	expected := []string{"-12.35", "-99999999999999999999999999999999999999", "1500", "-1.50"}
+
+	for i, v := range result {
+		if GetValueInterface(v) != expected[i] {
+			t.Error("get the wrong JSON value", GetValueInterface(v), expected[i])
+		}
+	}
+
+	if value, _ := FitValue(GetValue("7", types.DECIMAL_TYPE, types.DECIMAL_SIZE), columns[0]); GetValueText(value) != "7.00" {
+		t.Error("text should keep the zeros of the scale", GetValueText(value))
+	}
+
+	if _, err := FitValue(GetValue("123456789.995", types.DECIMAL_TYPE, types.DECIMAL_SIZE), columns[0]); err != errors.ErrNumericOverflow {
+		t.Error("rounding should not exceed the precision", err)
+	}
+
+	for _, text := range []string{"", ".", "1.2.3", "1e", "e5", "--1", "1e99", "abc"} {
+		if GetValue(text, types.DECIMAL_TYPE, types.DECIMAL_SIZE) != nil {
+			t.Error(text, "should not be read as DECIMAL")
+		}
+	}
+}
+
+func Test_JSONValue(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.JSON_TYPE, 64, "doc"),
+		column.NewColumn(types.JSON_TYPE, 0, "other"),
+	}
+
+	values := []*Value{
+		GetValue(`{"b": [1, 2.50, 1E2], "a": "x<y", "a": null}`, types.JSON_TYPE, 64),
+		GetNullValue(types.JSON_TYPE, types.JSON_SIZE),
+	}
+
+	if values[0] == nil {
+		t.Fatal("document should be read")
+	}
+
+	if err := FitValues(columns, values); err != nil {
+		t.Fatal(err)
+	}
+
+	result := TupleDeserialization(columns, TupleSerialization(values))
+
+	// the keys are sorted, the last duplicate wins and a number keeps its digits
+	if text := GetValueText(result[0]); text != `{"a": null, "b": [1, 2.50, 100]}` {
+		t.Error("get the wrong document", text)
+	}
+
+	if !result[1].IsNull() {
+		t.Error("value should be NULL")
+	}
+
+	if field, ok := GetJSONField(result[0].JSON, "b"); !ok || GetJSONType(field) != JSON_TYPE_ARRAY {
+		t.Error("get the wrong field", FormatJSON(field))
+	}
+
+	if _, err := FitValue(GetValue(`"`+strings.Repeat("x", 64)+`"`, types.JSON_TYPE, types.JSON_SIZE), columns[0]); err != errors.ErrValueTooLong {
+		t.Error("document should not fit the column", err)
+	}
+
+	for _, text := range []string{"", "{", `{"a": 1} {}`, "[1,]", "nul", "'a'"} {
+		if GetValue(text, types.JSON_TYPE, types.JSON_SIZE) != nil {
+			t.Error(text, "should not be read as JSON")
+		}
+	}
+}
+
+func Test_TypeFamilyValue(t *testing.T) {
+	columns := []*column.Column{
+		column.NewColumn(types.SMALL_INT_TYPE, 0, "small"),
+		column.NewColumn(types.REAL_TYPE, 0, "real"),
+		column.NewColumn(types.UUID_TYPE, 0, "id"),
+		column.NewColumn(types.BYTEA_TYPE, 8, "data"),
+		column.NewColumn(types.CHAR_TYPE, 5, "code"),
+	}
+
+	values := []*Value{
+		GetValue("-32768", types.SMALL_INT_TYPE, types.SMALL_INT_SIZE),
+		GetValue("1.1", types.REAL_TYPE, types.REAL_SIZE),
+		GetValue("{A0EEBC99-9C0B-4EF8-BB6D-6BB9BD380A11}", types.UUID_TYPE, types.UUID_SIZE),
+		GetValue(`\x00ff10`, types.BYTEA_TYPE, types.BYTEA_SIZE),
+		GetValue("ab  ", types.CHAR_TYPE, 5),
+	}
+
+	for i, v := range values {
+		if v == nil {
+			t.Fatal(columns[i].Name, "should be read")
+		}
+	}
+
+	if err := FitValues(columns, values); err != nil {
+		t.Fatal(err)
+	}
+
+	result := TupleDeserialization(columns, TupleSerialization(values))
+
+	for i, expected := range []string{"-32768", "1.1", "a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11", `\x00ff10`, "ab   "} {
+		if text := GetValueText(result[i]); text != expected {
+			t.Error(columns[i].Name, "get the wrong text", text)
+		}
+	}
+
+	// a REAL keeps the precision of a float32
+	if result[1].FLOAT != float64(float32(1.1)) || GetValueInterface(result[1]) != float32(1.1) {
+		t.Error("get the wrong REAL", result[1].FLOAT)
+	}
+
+	if _, err := FitValue(GetValue(`\x0102030405`, types.BYTEA_TYPE, types.BYTEA_SIZE), columns[3]); err != errors.ErrValueTooLong {
+		t.Error("bytes should not fit the column", err)
+	}
+
+	if _, err := FitValue(GetValue("abcdef", types.CHAR_TYPE, types.CHAR_SIZE), columns[4]); err != errors.ErrValueTooLong {
+		t.Error("text should not fit the column", err)
+	}
+
+	uuid, err := NewRandomUUID()
+
+	if err != nil || uuid[6]>>4 != 4 || uuid[8]>>6 != 2 {
+		t.Error("random UUID should be version 4", uuid, err)
+	}
+
+	for text, valueType := range map[string]types.COLUMN_TYPE{
+		"32768":                                types.SMALL_INT_TYPE,
+		"1e39":                                 types.REAL_TYPE,
+		"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a1":  types.UUID_TYPE,
+		"a0eebc99x9c0b-4ef8-bb6d-6bb9bd380a11": types.UUID_TYPE,
+		`\x0`:                                  types.BYTEA_TYPE,
+		`\xzz`:                                 types.BYTEA_TYPE,
+	} {
+		if GetValue(text, valueType, column.NewColumn(valueType, 0, "").Size) != nil {
+			t.Error(text, "should not be read")
+		}
+	}
+}
+
+func Test_CastValue(t *testing.T) {
+	tests := []struct {
+		value     *Value
+		valueType types.COLUMN_TYPE
+		expected  string
+	}{
+		{GetValue("2.5", types.DECIMAL_TYPE, types.DECIMAL_SIZE), types.INT_TYPE, "3"},
+		{GetValue("-2.5", types.DECIMAL_TYPE, types.DECIMAL_SIZE), types.SMALL_INT_TYPE, "-3"},
+		{GetValue(2.5, types.FLOAT_TYPE, types.FLOAT_SIZE), types.INT_TYPE, "2"},
+		{GetValue(int32(7), types.INT_TYPE, types.INT_SIZE), types.DECIMAL_TYPE, "7"},
+		{GetValue("1.1", types.REAL_TYPE, types.REAL_SIZE), types.DECIMAL_TYPE, "1.1"},
+		{GetValue(int64(1)<<40, types.LONG_INT_TYPE, types.LONG_INT_SIZE), types.FLOAT_TYPE, "1099511627776"},
+		{GetValue(true, types.BOOL_TYPE, types.BOOL_SIZE), types.INT_TYPE, "1"},
+		{GetValue(int32(0), types.INT_TYPE, types.INT_SIZE), types.BOOL_TYPE, "false"},
+		{GetValue("2024-02-29 10:30:00", types.TIMESTAMP_TYPE, types.TIMESTAMP_SIZE), types.TIME_TYPE, "10:30:00"},
+		{GetValue("ab", types.CHAR_TYPE, 4), types.VAR_CHAR_TYPE, "ab"},
+		{GetValue("4x2", types.VAR_CHAR_TYPE, 3), types.INT_TYPE, ""},
+	}
+
+	for _, test := range tests {
+		converted, err := CastValue(test.value, test.valueType, column.NewColumn(test.valueType, 0, "").Size)
+
+		if test.expected == "" {
+			if !stderrors.Is(err, errors.ErrInvalidValue) {
+				t.Error(GetValueText(test.value), "should not be cast", err)
+			}
+
+			continue
+		}
+
+		if err != nil || GetValueText(converted) != test.expected {
+			t.Error(GetValueText(test.value), "should be cast to", test.expected, err)
+		}
+	}
+
+	if _, err := CastValue(GetValue(int32(40000), types.INT_TYPE, types.INT_SIZE), types.SMALL_INT_TYPE, types.SMALL_INT_SIZE); !stderrors.Is(err, errors.ErrNumericOverflow) {
+		t.Error("40000 should not fit SMALLINT", err)
+	}
+
+	if _, err := ParseValue("abc", types.INT_TYPE, types.INT_SIZE); err == nil || err.Error() != `invalid input syntax for type INT: "abc"` {
+		t.Error("get the wrong error", err)
+	}
+
+	if _, err := ParseValue("99999999999", types.INT_TYPE, types.INT_SIZE); err == nil || err.Error() != `value "99999999999" is out of range for type INT` {
+		t.Error("get the wrong error", err)
+	}
+
+	// a BOOL is only converted to an INT by a CAST
+	if _, err := ConvertValue(GetValue(true, types.BOOL_TYPE, types.BOOL_SIZE), types.INT_TYPE, types.INT_SIZE); !stderrors.Is(err, errors.ErrInvalidValue) {
+		t.Error("BOOL should not convert to INT implicitly", err)
+	}
+
+	if converted, err := ConvertValue(GetValue(9.5, types.FLOAT_TYPE, types.FLOAT_SIZE), types.LONG_INT_TYPE, types.LONG_INT_SIZE); err != nil || converted.LONG_INT != 10 {
+		t.Error("FLOAT should convert to BIGINT", err)
+	}
+}
@@ -0,0 +1,70 @@
+package tuple
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+/**
+ *  UUID format
+ *  +---------------------------------------------+
+ *  | bytes (16)                                  |
+ *  +---------------------------------------------+
+ *
+ *  The text form is lower case hex in groups of 8-4-4-4-12. Like PostgreSQL the input may be
+ *  upper case, in braces or without the hyphens.
+ */
+
+type UUID [16]byte
+
+// ParseUUID returns false when the text is not 32 hex digits in one of the accepted forms
+func ParseUUID(text string) (UUID, bool) {
+	var uuid UUID
+
+	text = strings.TrimSpace(text)
+
+	if strings.HasPrefix(text, "{") && strings.HasSuffix(text, "}") {
+		text = text[1 : len(text)-1]
+	}
+
+	if len(text) == 36 {
+		for _, position := range []int{8, 13, 18, 23} {
+			if text[position] != '-' {
+				return uuid, false
+			}
+		}
+
+		text = strings.ReplaceAll(text, "-", "")
+	}
+
+	if len(text) != 32 {
+		return uuid, false
+	}
+
+	if _, err := hex.Decode(uuid[:], []byte(text)); err != nil {
+		return uuid, false
+	}
+
+	return uuid, true
+}
+
+func (u UUID) String() string {
+	text := hex.EncodeToString(u[:])
+
+	return text[0:8] + "-" + text[8:12] + "-" + text[12:16] + "-" + text[16:20] + "-" + text[20:32]
+}
+
+// NewRandomUUID is a version 4 UUID like gen_random_uuid() of PostgreSQL
+func NewRandomUUID() (UUID, error) {
+	var uuid UUID
+
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return uuid, err
+	}
+
+	uuid[6] = uuid[6]&0x0f | 0x40
+	uuid[8] = uuid[8]&0x3f | 0x80
+
+	return uuid, nil
+}
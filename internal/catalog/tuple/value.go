@@ -1,20 +1,38 @@
 package tuple
 
 import (
+	"encoding/json"
+	"go-db/internal/catalog/column"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
+	"math"
 	"reflect"
 	"strconv"
+	"strings"
 )
 
 type Value struct {
 	types types.COLUMN_TYPE
 	size  int32
+	null  bool
 
 	INT      int32
 	LONG_INT int64
 	FLOAT    float64
 	VAR_CHAR []byte
 	BOOL     bool
+
+	DATE      int32
+	TIME      int64
+	TIMESTAMP int64
+	INTERVAL  Interval
+
+	DECIMAL Decimal
+	JSON    []byte
+
+	// SMALLINT is kept in INT, REAL in FLOAT and CHAR in VAR_CHAR
+	UUID  UUID
+	BYTEA []byte
 }
 
 func GetValue(value interface{}, ValueType types.COLUMN_TYPE, valueSize int32) *Value {
@@ -70,11 +88,131 @@ func GetValue(value interface{}, ValueType types.COLUMN_TYPE, valueSize int32) *
 		if len(v.VAR_CHAR) > int(valueSize) {
 			v.VAR_CHAR = v.VAR_CHAR[:valueSize]
 		}
+	case types.DATE_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			date, ok := ParseDate(value.(string))
+			if !ok {
+				return nil
+			}
+			value = date
+		}
+		v.DATE = value.(int32)
+	case types.TIME_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			timeValue, ok := ParseTime(value.(string))
+			if !ok {
+				return nil
+			}
+			value = timeValue
+		}
+		v.TIME = value.(int64)
+	case types.TIMESTAMP_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			timestamp, ok := ParseTimestamp(value.(string))
+			if !ok {
+				return nil
+			}
+			value = timestamp
+		}
+		v.TIMESTAMP = value.(int64)
+	case types.INTERVAL_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			interval, ok := ParseInterval(value.(string))
+			if !ok {
+				return nil
+			}
+			value = interval
+		}
+		v.INTERVAL = value.(Interval)
+	case types.DECIMAL_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			decimal, ok := ParseDecimal(value.(string))
+			if !ok {
+				return nil
+			}
+			value = decimal
+		}
+		v.DECIMAL = value.(Decimal)
+	case types.JSON_TYPE:
+		// text is validated, bytes are already the binary form
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			doc, ok := ParseJSON(value.(string))
+			if !ok {
+				return nil
+			}
+			value = doc
+		}
+		v.JSON = value.([]byte)
+	case types.SMALL_INT_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			int16Value, err := strconv.ParseInt(value.(string), 10, 16)
+			if err != nil {
+				return nil
+			}
+			value = int32(int16Value)
+		}
+		if value.(int32) > math.MaxInt16 || value.(int32) < math.MinInt16 {
+			return nil
+		}
+		v.INT = value.(int32)
+	case types.REAL_TYPE:
+		switch realValue := value.(type) {
+		case string:
+			float32Value, err := strconv.ParseFloat(realValue, 32)
+			if err != nil {
+				return nil
+			}
+			value = float32Value
+		case float32:
+			value = float64(realValue)
+		}
+		// a finite value which does not fit a float32 is out of range
+		if math.IsInf(float64(float32(value.(float64))), 0) && !math.IsInf(value.(float64), 0) {
+			return nil
+		}
+		v.FLOAT = float64(float32(value.(float64)))
+	case types.UUID_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			uuid, ok := ParseUUID(value.(string))
+			if !ok {
+				return nil
+			}
+			value = uuid
+		}
+		v.UUID = value.(UUID)
+	case types.BYTEA_TYPE:
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			bytea, ok := ParseBytea(value.(string))
+			if !ok {
+				return nil
+			}
+			value = bytea
+		}
+		v.BYTEA = value.([]byte)
+	case types.CHAR_TYPE:
+		// the padding of CHAR is not part of the value, it is added when the value is shown
+		if reflect.TypeOf(value).Kind() == reflect.String {
+			value = []byte(value.(string))
+		}
+		v.VAR_CHAR = []byte(strings.TrimRight(string(value.([]byte)), " "))
 	}
 	return &v
 }
 
+// GetNullValue returns the NULL of the column type, it still takes the size of the column in the tuple
+func GetNullValue(valueType types.COLUMN_TYPE, valueSize int32) *Value {
+	return &Value{
+		types: valueType,
+		size:  valueSize,
+		null:  true,
+	}
+}
+
 func GetValueInterface(value *Value) interface{} {
+	if value.IsNull() {
+		return nil
+	}
+
 	switch value.GetType() {
 	case types.BOOL_TYPE:
 		return value.BOOL
@@ -86,10 +224,75 @@ func GetValueInterface(value *Value) interface{} {
 		return value.LONG_INT
 	case types.VAR_CHAR_TYPE:
 		return string(value.VAR_CHAR)
+	case types.DATE_TYPE:
+		return DateToTime(value.DATE).Format(DATE_LAYOUT)
+	case types.TIME_TYPE:
+		return TimestampToTime(value.TIME).Format(TIME_LAYOUT)
+	case types.TIMESTAMP_TYPE:
+		return TimestampToTime(value.TIMESTAMP).Format(ISO_LAYOUT)
+	case types.INTERVAL_TYPE:
+		return formatISOInterval(value.INTERVAL)
+	case types.DECIMAL_TYPE:
+		// a string keeps every digit, a JSON number would be read back as a float
+		return value.DECIMAL.String()
+	case types.JSON_TYPE:
+		// the document is nested in the response instead of being a string
+		return json.RawMessage(FormatJSON(value.JSON))
+	case types.SMALL_INT_TYPE:
+		return int16(value.INT)
+	case types.REAL_TYPE:
+		return float32(value.FLOAT)
+	case types.UUID_TYPE:
+		return value.UUID.String()
+	case types.BYTEA_TYPE:
+		return FormatBytea(value.BYTEA)
+	case types.CHAR_TYPE:
+		return GetValueText(value)
 	}
 	return nil
 }
 
+// GetValueText returns the text form of a value which is not NULL, GetValue reads it back
+func GetValueText(value *Value) string {
+	switch value.GetType() {
+	case types.BOOL_TYPE:
+		return strconv.FormatBool(value.BOOL)
+	case types.FLOAT_TYPE:
+		return strconv.FormatFloat(value.FLOAT, 'f', -1, 64)
+	case types.INT_TYPE:
+		return strconv.FormatInt(int64(value.INT), 10)
+	case types.LONG_INT_TYPE:
+		return strconv.FormatInt(value.LONG_INT, 10)
+	case types.DATE_TYPE:
+		return DateToTime(value.DATE).Format(DATE_LAYOUT)
+	case types.TIME_TYPE:
+		return TimestampToTime(value.TIME).Format(TIME_LAYOUT)
+	case types.TIMESTAMP_TYPE:
+		return TimestampToTime(value.TIMESTAMP).Format(TIMESTAMP_LAYOUT)
+	case types.INTERVAL_TYPE:
+		return formatInterval(value.INTERVAL)
+	case types.DECIMAL_TYPE:
+		return value.DECIMAL.String()
+	case types.JSON_TYPE:
+		return FormatJSON(value.JSON)
+	case types.SMALL_INT_TYPE:
+		return strconv.FormatInt(int64(value.INT), 10)
+	case types.REAL_TYPE:
+		return strconv.FormatFloat(value.FLOAT, 'f', -1, 32)
+	case types.UUID_TYPE:
+		return value.UUID.String()
+	case types.BYTEA_TYPE:
+		return FormatBytea(value.BYTEA)
+	case types.CHAR_TYPE:
+		// CHAR(n) is padded with spaces to n characters
+		if padding := int(value.size) - len(value.VAR_CHAR); padding > 0 {
+			return string(value.VAR_CHAR) + strings.Repeat(" ", padding)
+		}
+	}
+
+	return string(value.VAR_CHAR)
+}
+
 func GetDefaultValue(columType types.COLUMN_TYPE) interface{} {
 	switch columType {
 	case types.BOOL_TYPE:
@@ -102,10 +305,99 @@ func GetDefaultValue(columType types.COLUMN_TYPE) interface{} {
 		return int64(0)
 	case types.VAR_CHAR_TYPE:
 		return []byte("")
+	case types.DATE_TYPE:
+		return int32(0)
+	case types.TIME_TYPE, types.TIMESTAMP_TYPE:
+		return int64(0)
+	case types.INTERVAL_TYPE:
+		return Interval{}
+	case types.DECIMAL_TYPE:
+		return NewDecimal(0, 0)
+	case types.JSON_TYPE:
+		return []byte{JSON_NULL}
+	case types.SMALL_INT_TYPE:
+		return int32(0)
+	case types.REAL_TYPE:
+		return 0.0
+	case types.UUID_TYPE:
+		return UUID{}
+	case types.BYTEA_TYPE, types.CHAR_TYPE:
+		return []byte("")
 	}
 	return nil
 }
 
+// FitValue rounds a DECIMAL to the scale of its column and checks that JSON, BYTEA, CHAR and
+// VARCHAR fit, the other types already fit
+func FitValue(value *Value, c *column.Column) (*Value, error) {
+	if value.IsNull() || value.GetType() != c.ColumnType {
+		return value, nil
+	}
+
+	if length, exist := map[types.COLUMN_TYPE]int{
+		types.JSON_TYPE:     len(value.JSON),
+		types.BYTEA_TYPE:    types.BYTEA_LENGTH_SIZE + len(value.BYTEA),
+		types.CHAR_TYPE:     len(value.VAR_CHAR),
+		types.VAR_CHAR_TYPE: len(value.VAR_CHAR),
+	}[c.ColumnType]; exist {
+		if int32(length) > c.Size {
+			return nil, errors.ErrValueTooLong
+		}
+
+		fitted := *value
+		fitted.size = c.Size
+
+		return &fitted, nil
+	}
+
+	if c.ColumnType != types.DECIMAL_TYPE {
+		return value, nil
+	}
+
+	precision, scale := c.Precision, c.Scale
+
+	// a bare DECIMAL keeps the scale of the value, as far as the digits fit
+	if precision == 0 {
+		precision, scale = types.MAX_DECIMAL_PRECISION, value.DECIMAL.Scale
+
+		if scale < 0 {
+			scale = 0
+		} else if scale > types.MAX_DECIMAL_PRECISION {
+			scale = types.MAX_DECIMAL_PRECISION
+		}
+	}
+
+	decimal, ok := value.DECIMAL.Fit(precision, scale)
+
+	if !ok {
+		return nil, errors.ErrNumericOverflow
+	}
+
+	fitted := *value
+	fitted.DECIMAL = decimal
+
+	return &fitted, nil
+}
+
+// FitValues fits every value of a row to its column
+func FitValues(columns []*column.Column, values []*Value) error {
+	for i, c := range columns {
+		if i >= len(values) {
+			break
+		}
+
+		fitted, err := FitValue(values[i], c)
+
+		if err != nil {
+			return err
+		}
+
+		values[i] = fitted
+	}
+
+	return nil
+}
+
 func (v *Value) GetSize() int32 {
 	return v.size
 }
@@ -113,3 +405,7 @@ func (v *Value) GetSize() int32 {
 func (v *Value) GetType() types.COLUMN_TYPE {
 	return v.types
 }
+
+func (v *Value) IsNull() bool {
+	return v.null
+}
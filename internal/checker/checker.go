@@ -0,0 +1,664 @@
+package checker
+
+import (
+	"fmt"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/index"
+	"go-db/internal/catalog/schema"
+	"go-db/internal/catalog/table"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"go-db/internal/storage/page"
+	"os"
+	"sort"
+)
+
+// Issue is one inconsistency found in the database file
+type Issue struct {
+	PageID   types.Page_id_t
+	Message  string
+	Repaired bool
+}
+
+func (i *Issue) String() string {
+	if i.Repaired {
+		return fmt.Sprintf("page %d: %s (repaired)", i.PageID, i.Message)
+	}
+
+	return fmt.Sprintf("page %d: %s", i.PageID, i.Message)
+}
+
+type Report struct {
+	PageCount  int32
+	TableCount int32
+	FreePages  int32
+	Issues     []*Issue
+}
+
+// IsClean reports whether every issue found was repaired
+func (r *Report) IsClean() bool {
+	for _, issue := range r.Issues {
+		if !issue.Repaired {
+			return false
+		}
+	}
+
+	return true
+}
+
+/**
+ *  Checker walks the database file offline without the buffer pool,
+ *  super block -> sys_tables -> schema meta pages -> data page chains,
+ *  the index trees recorded in sys_indexes and the free page list.
+ *  The rows of sys_columns must describe the schema pages column for column.
+ *  Any page not reached by the walk is orphaned.
+ */
+
+type Checker struct {
+	diskManager *disk.Disk
+	repair      bool
+	pageCount   int32
+	owners      map[types.Page_id_t]string
+	report      *Report
+}
+
+// OpenDatabase opens the file the way the server does, so a torn page the double write
+// buffer still has is restored before it is checked. A buffer left behind is not replayed
+// for a repair, the database may still be open, it has to be checked without repair first
+func OpenDatabase(fileName string, repair bool) (*disk.Disk, error) {
+	info, err := os.Stat(fileName + disk.DOUBLE_WRITE_FILE_SUFFIX)
+
+	if os.IsNotExist(err) {
+		return disk.NewDiskStorage(fileName)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if repair && info.Size() != 0 {
+		return nil, errors.ErrDoubleWritePending
+	}
+
+	return disk.NewDoubleWriteDiskStorage(fileName)
+}
+
+func NewChecker(diskManager *disk.Disk, repair bool) *Checker {
+	return &Checker{
+		diskManager: diskManager,
+		repair:      repair,
+		pageCount:   diskManager.GetPageNumber(),
+		owners:      make(map[types.Page_id_t]string),
+	}
+}
+
+func (c *Checker) Check() (*Report, error) {
+	c.report = &Report{
+		PageCount: c.pageCount,
+		Issues:    make([]*Issue, 0),
+	}
+
+	c.owners[constant.SUPER_BLOCK_PAGE_ID] = "super block"
+
+	if err := c.checkCatalog(); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkFreeList(); err != nil {
+		return nil, err
+	}
+
+	if err := c.checkOrphanPages(); err != nil {
+		return nil, err
+	}
+
+	return c.report, nil
+}
+
+// checkCatalog checks sys_tables at the catalog root first,
+// then every table recorded in its rows
+func (c *Checker) checkCatalog() error {
+	rootPageID := c.diskManager.GetCatalogRootPageID()
+
+	if rootPageID == constant.INVALID_PAGE_ID {
+		return nil
+	}
+
+	catalog, ok := c.checkTable(rootPageID)
+
+	if !ok {
+		return nil
+	}
+
+	if catalog.tableName != types.SYSTEM_TABLES {
+		c.addIssue(rootPageID, fmt.Sprintf("catalog root is table %q instead of %q", catalog.tableName, types.SYSTEM_TABLES))
+		return nil
+	}
+
+	tableNames := map[string]types.Page_id_t{catalog.tableName: rootPageID}
+	tables := map[string]*tableInfo{catalog.tableName: catalog}
+	var indexes, systemColumns *tableInfo
+
+	for _, row := range c.readRows(catalog) {
+		tableName, metaPageID := string(row[0].VAR_CHAR), types.Page_id_t(row[1].INT)
+
+		if metaPageID == rootPageID {
+			continue
+		}
+
+		info, ok := c.checkTable(metaPageID)
+
+		if !ok {
+			continue
+		}
+
+		if info.tableName != tableName {
+			c.addIssue(metaPageID, fmt.Sprintf("table %q is recorded as %q in %s", info.tableName, tableName, types.SYSTEM_TABLES))
+		}
+
+		if otherPageID, exist := tableNames[info.tableName]; exist {
+			c.addIssue(metaPageID, fmt.Sprintf("table %q already defined by page %d", info.tableName, otherPageID))
+		}
+
+		tableNames[info.tableName] = metaPageID
+		tables[info.tableName] = info
+
+		if info.tableName == types.SYSTEM_INDEXES {
+			indexes = info
+		}
+
+		if info.tableName == types.SYSTEM_COLUMNS {
+			systemColumns = info
+		}
+
+		if !table.IsSystemTable(info.tableName) {
+			c.report.TableCount++
+		}
+	}
+
+	if indexes != nil {
+		for _, row := range c.readRows(indexes) {
+			c.checkIndex(string(row[0].VAR_CHAR), types.Page_id_t(row[4].INT))
+		}
+	}
+
+	if systemColumns != nil {
+		c.checkSystemColumns(systemColumns, tables)
+	}
+
+	return nil
+}
+
+// checkSystemColumns compares the rows of sys_columns with the schema pages,
+// they drift apart when a crash hits between the two writes of an ALTER TABLE
+func (c *Checker) checkSystemColumns(systemColumns *tableInfo, tables map[string]*tableInfo) {
+	rows := make(map[string][][]*tuple.Value)
+
+	for _, row := range c.readRows(systemColumns) {
+		tableName := string(row[0].VAR_CHAR)
+		rows[tableName] = append(rows[tableName], row)
+	}
+
+	tableNames := make([]string, 0, len(rows)+len(tables))
+
+	for tableName := range rows {
+		if _, exist := tables[tableName]; !exist {
+			tableNames = append(tableNames, tableName)
+		}
+	}
+
+	for tableName := range tables {
+		tableNames = append(tableNames, tableName)
+	}
+
+	sort.Strings(tableNames)
+
+	for _, tableName := range tableNames {
+		info, exist := tables[tableName]
+
+		if !exist {
+			c.addIssue(systemColumns.metaPageID, fmt.Sprintf("%s describes unknown table %q", types.SYSTEM_COLUMNS, tableName))
+			continue
+		}
+
+		if !isColumnRowsMatch(info.columns, rows[tableName]) {
+			c.addIssue(info.metaPageID, fmt.Sprintf("%s does not match the schema of table %q", types.SYSTEM_COLUMNS, tableName))
+		}
+	}
+}
+
+// checkIndex walks the B+ tree from the root, every page must share the key size of the root
+func (c *Checker) checkIndex(indexName string, rootPageID types.Page_id_t) {
+	owner := fmt.Sprintf("index %q", indexName)
+	pageIDs := []types.Page_id_t{rootPageID}
+	keySize := int32(-1)
+
+	for len(pageIDs) > 0 {
+		pageID := pageIDs[0]
+		pageIDs = pageIDs[1:]
+
+		p, ok := c.visitPage(pageID, owner, types.INDEX_PAGE_TYPE)
+
+		if !ok {
+			continue
+		}
+
+		indexPage := index.GetIndexPage(p)
+
+		if keySize == -1 {
+			keySize = indexPage.GetKeySize()
+		}
+
+		if !indexPage.IsReadable() || indexPage.GetKeySize() != keySize {
+			c.addIssue(pageID, fmt.Sprintf("%s page entries do not match the index", owner))
+			continue
+		}
+
+		if indexPage.IsLeaf() {
+			continue
+		}
+
+		for i := int32(0); i < indexPage.GetKeyCount(); i++ {
+			pageIDs = append(pageIDs, indexPage.GetChildPageID(i))
+		}
+	}
+}
+
+type tableInfo struct {
+	metaPageID types.Page_id_t
+	tableName  string
+	columns    []*column.Column
+	dataPageID types.Page_id_t
+	tupleSize  int32
+}
+
+func (c *Checker) checkTable(metaPageID types.Page_id_t) (*tableInfo, bool) {
+	p, ok := c.visitPage(metaPageID, "schema", types.META_PAGE_TYPE)
+
+	if !ok {
+		return nil, false
+	}
+
+	metaPage := schema.GetSchema(p)
+	tableName := metaPage.GetTableName()
+	info := &tableInfo{metaPageID: metaPageID, tableName: tableName, dataPageID: metaPage.GetDataPageID()}
+
+	if tableName == "" {
+		c.addIssue(metaPageID, "table name is empty")
+	}
+
+	schemaPage := metaPage
+
+	for {
+		if !schemaPage.IsReadable() {
+			c.addIssue(schemaPage.GetPageID(), fmt.Sprintf("table %q column count %d overflows the page", tableName, schemaPage.GetColumnCount()))
+			return info, false
+		}
+
+		for _, col := range schemaPage.GetColumns() {
+			if !isColumnValid(col) {
+				c.addIssue(schemaPage.GetPageID(), fmt.Sprintf("table %q column %q has invalid type %d or size %d", tableName, col.Name, col.ColumnType, col.Size))
+			}
+
+			info.columns = append(info.columns, col)
+		}
+
+		nextPageID := schemaPage.GetNextPageID()
+
+		if nextPageID == constant.INVALID_PAGE_ID {
+			break
+		}
+
+		p, ok := c.visitPage(nextPageID, "table "+tableName+" schema", types.META_PAGE_TYPE)
+
+		if !ok {
+			return info, false
+		}
+
+		nextPage := schema.GetSchema(p)
+
+		if nextPage.GetPrevPageID() != schemaPage.GetPageID() {
+			issue := c.addIssue(nextPageID, fmt.Sprintf("prev page %d should be %d", nextPage.GetPrevPageID(), schemaPage.GetPageID()))
+
+			if c.repair {
+				nextPage.SetPrevPageID(schemaPage.GetPageID())
+				issue.Repaired = c.writePage(p)
+			}
+		}
+
+		schemaPage = nextPage
+	}
+
+	info.tupleSize = tuple.GetTupleSize(info.columns)
+	c.checkDataChain(tableName, info.dataPageID, info.tupleSize)
+
+	return info, true
+}
+
+// readRows decodes the tuples of a table which checkTable already walked,
+// tuples the check found broken are skipped
+func (c *Checker) readRows(info *tableInfo) [][]*tuple.Value {
+	rows := make([][]*tuple.Value, 0)
+	visited := make(map[types.Page_id_t]struct{})
+	dataPageID := info.dataPageID
+
+	for dataPageID != constant.INVALID_PAGE_ID && c.owners[dataPageID] == "table "+info.tableName {
+		if _, exist := visited[dataPageID]; exist {
+			break
+		}
+
+		visited[dataPageID] = struct{}{}
+
+		data, err := c.diskManager.ReadPage(dataPageID)
+
+		if err != nil || !page.IsChecksumValid(data) {
+			break
+		}
+
+		p := page.NewPage()
+		copy(p.GetData(), data)
+		dataTable := table.GetDataTable(p)
+		tupleCount := dataTable.GetTupleCount()
+
+		if tupleCount < 0 || types.TUPLE_COUNT_OFFSET+tupleCount*(types.TUPLE_OFFSET+types.TUPLE_SIZE) > constant.PAGE_SIZE {
+			break
+		}
+
+		for i := int32(0); i < tupleCount; i++ {
+			offset, size, _ := dataTable.GetTupleMetaByIndex(i)
+
+			if offset <= 0 || size != info.tupleSize || offset+size > constant.PAGE_SIZE {
+				continue
+			}
+
+			rows = append(rows, tuple.TupleDeserialization(info.columns, data[offset:offset+size]))
+		}
+
+		dataPageID = dataTable.GetNextPageID()
+	}
+
+	return rows
+}
+
+func (c *Checker) checkDataChain(tableName string, dataPageID types.Page_id_t, tupleSize int32) {
+	prevPageID := constant.INVALID_PAGE_ID
+
+	for dataPageID != constant.INVALID_PAGE_ID {
+		p, ok := c.visitPage(dataPageID, "table "+tableName, types.DATA_PAGE_TYPE)
+
+		if !ok {
+			return
+		}
+
+		dataTable := table.GetDataTable(p)
+
+		if dataTable.GetPrevPageID() != prevPageID {
+			issue := c.addIssue(dataPageID, fmt.Sprintf("prev page %d should be %d", dataTable.GetPrevPageID(), prevPageID))
+
+			if c.repair {
+				dataTable.SetPrevPageID(prevPageID)
+				issue.Repaired = c.writePage(p)
+			}
+		}
+
+		c.checkTuples(dataTable, tupleSize)
+
+		prevPageID = dataPageID
+		dataPageID = dataTable.GetNextPageID()
+	}
+}
+
+type tupleMeta struct {
+	index  int32
+	offset int32
+	size   int32
+}
+
+func (c *Checker) checkTuples(dataTable *table.DataTable, tupleSize int32) {
+	pageID := dataTable.GetPageID()
+	tupleCount := dataTable.GetTupleCount()
+	freeSpacePointer := dataTable.GetFreeSpacePointer()
+	directoryEnd := types.TUPLE_COUNT_OFFSET + tupleCount*(types.TUPLE_OFFSET+types.TUPLE_SIZE)
+
+	if tupleCount < 0 || directoryEnd > constant.PAGE_SIZE {
+		c.addIssue(pageID, fmt.Sprintf("tuple count %d overflows the page", tupleCount))
+		return
+	}
+
+	if freeSpacePointer < directoryEnd || freeSpacePointer > constant.PAGE_SIZE {
+		c.addIssue(pageID, fmt.Sprintf("free space pointer %d out of range [%d, %d]", freeSpacePointer, directoryEnd, constant.PAGE_SIZE))
+	}
+
+	metas := make([]*tupleMeta, 0, tupleCount)
+	broken := make(map[int32]*Issue)
+
+	for i := int32(0); i < tupleCount; i++ {
+		offset, size, _ := dataTable.GetTupleMetaByIndex(i)
+
+		// deleted tuple
+		if offset == 0 {
+			continue
+		}
+
+		if offset < directoryEnd || size < 0 || offset+size > constant.PAGE_SIZE {
+			broken[i] = c.addIssue(pageID, fmt.Sprintf("tuple %d at offset %d size %d out of page bounds", i, offset, size))
+			continue
+		}
+
+		if size != tupleSize {
+			c.addIssue(pageID, fmt.Sprintf("tuple %d size %d does not match schema size %d", i, size, tupleSize))
+		}
+
+		if offset < freeSpacePointer {
+			c.addIssue(pageID, fmt.Sprintf("tuple %d at offset %d is inside free space", i, offset))
+		}
+
+		metas = append(metas, &tupleMeta{index: i, offset: offset, size: size})
+	}
+
+	sort.Slice(metas, func(i, j int) bool {
+		return metas[i].offset < metas[j].offset
+	})
+
+	for i := 1; i < len(metas); i++ {
+		if metas[i-1].offset+metas[i-1].size > metas[i].offset {
+			broken[metas[i].index] = c.addIssue(pageID, fmt.Sprintf("tuple %d overlaps tuple %d", metas[i].index, metas[i-1].index))
+		}
+	}
+
+	if c.repair && len(broken) > 0 && c.rebuildDataTable(dataTable, broken) {
+		for _, issue := range broken {
+			issue.Repaired = true
+		}
+	}
+}
+
+// rebuildDataTable rewrites the page with every tuple except the broken ones,
+// which are left as deleted slots so the RIDs in the indexes stay valid
+func (c *Checker) rebuildDataTable(dataTable *table.DataTable, dropped map[int32]*Issue) bool {
+	tupleData := make([][]byte, dataTable.GetTupleCount())
+
+	for i := int32(0); i < dataTable.GetTupleCount(); i++ {
+		if _, exist := dropped[i]; exist || dataTable.IsTupleDeleted(i) {
+			continue
+		}
+
+		offset, size, _ := dataTable.GetTupleMetaByIndex(i)
+
+		tupleData[i] = make([]byte, size)
+		copy(tupleData[i], dataTable.GetData()[offset:offset+size])
+	}
+
+	prevPageID, nextPageID := dataTable.GetPrevPageID(), dataTable.GetNextPageID()
+
+	dataTable.ResetPageData()
+	dataTable.DataTableInit()
+	dataTable.SetPrevPageID(prevPageID)
+	dataTable.SetNextPageID(nextPageID)
+
+	for i, data := range tupleData {
+		dataTable.InsertTupleData(data, int32(len(data)))
+
+		if data == nil {
+			dataTable.DeleteTupleByIndex(int32(i))
+		}
+	}
+
+	return c.writePage(dataTable.Page)
+}
+
+func (c *Checker) checkFreeList() error {
+	freePageID := c.diskManager.GetFreeListHead()
+
+	for freePageID != constant.INVALID_PAGE_ID {
+		p, ok := c.visitPage(freePageID, "free list", types.FREE_PAGE_TYPE)
+
+		if !ok {
+			return nil
+		}
+
+		c.report.FreePages++
+		freePageID = disk.GetNextFreePageID(p.GetData())
+	}
+
+	return nil
+}
+
+func (c *Checker) checkOrphanPages() error {
+	for pageID := types.Page_id_t(0); pageID < types.Page_id_t(c.pageCount); pageID++ {
+		if _, exist := c.owners[pageID]; exist {
+			continue
+		}
+
+		issue := c.addIssue(pageID, "orphaned page is not reachable from the super block")
+
+		if c.repair {
+			err := c.diskManager.DeallocatePage(pageID)
+
+			// a free page which fell off the free list is cleared so it can be linked again
+			if err == errors.ErrPageAlreadyFree {
+				if err = c.diskManager.WritePage(pageID, make([]byte, constant.PAGE_SIZE)); err == nil {
+					err = c.diskManager.DeallocatePage(pageID)
+				}
+			}
+
+			if err != nil {
+				return err
+			}
+
+			issue.Repaired = true
+		}
+	}
+
+	return nil
+}
+
+// visitPage reads the page and validates its checksum and type,
+// a page reached twice means two chains share it or the chain has a cycle
+func (c *Checker) visitPage(pageID types.Page_id_t, owner string, pageType types.PAGE_TYPE) (*page.Page, bool) {
+	if pageID <= constant.SUPER_BLOCK_PAGE_ID || int32(pageID) >= c.pageCount {
+		c.addIssue(pageID, fmt.Sprintf("%s points to page out of range [1, %d)", owner, c.pageCount))
+		return nil, false
+	}
+
+	if otherOwner, exist := c.owners[pageID]; exist {
+		c.addIssue(pageID, fmt.Sprintf("page used by %s is already used by %s", owner, otherOwner))
+		return nil, false
+	}
+
+	c.owners[pageID] = owner
+
+	data, err := c.diskManager.ReadPage(pageID)
+
+	if err != nil {
+		c.addIssue(pageID, err.Error())
+		return nil, false
+	}
+
+	if !page.IsChecksumValid(data) {
+		c.addIssue(pageID, "checksum mismatch")
+		return nil, false
+	}
+
+	p := page.NewPage()
+	p.SetPageID(pageID)
+	copy(p.GetData(), data)
+
+	if p.GetPageTye() != pageType {
+		c.addIssue(pageID, fmt.Sprintf("%s expects page type %d but got %d", owner, pageType, p.GetPageTye()))
+		return nil, false
+	}
+
+	return p, true
+}
+
+func (c *Checker) writePage(p *page.Page) bool {
+	p.UpdateChecksum()
+
+	if err := c.diskManager.WritePage(p.GetPageID(), p.GetData()); err != nil {
+		c.addIssue(p.GetPageID(), err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (c *Checker) addIssue(pageID types.Page_id_t, message string) *Issue {
+	issue := &Issue{
+		PageID:  pageID,
+		Message: message,
+	}
+
+	c.report.Issues = append(c.report.Issues, issue)
+
+	return issue
+}
+
+// isColumnRowsMatch reports whether the sys_columns rows describe the columns in order
+func isColumnRowsMatch(columns []*column.Column, rows [][]*tuple.Value) bool {
+	if len(columns) != len(rows) {
+		return false
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i][2].INT < rows[j][2].INT
+	})
+
+	for i, col := range columns {
+		row := rows[i]
+
+		if row[2].INT != int32(i) || string(row[1].VAR_CHAR) != col.Name ||
+			string(row[3].VAR_CHAR) != col.GetTypeName() || row[4].INT != col.Size {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isColumnValid(col *column.Column) bool {
+	expect := column.NewColumn(col.ColumnType, col.Size, col.Name)
+
+	switch col.ColumnType {
+	case types.VAR_CHAR_TYPE, types.JSON_TYPE, types.CHAR_TYPE:
+		return col.Size > 0
+	case types.BYTEA_TYPE:
+		return col.Size > types.BYTEA_LENGTH_SIZE
+	case types.INT_TYPE, types.LONG_INT_TYPE, types.FLOAT_TYPE, types.BOOL_TYPE,
+		types.DATE_TYPE, types.TIME_TYPE, types.TIMESTAMP_TYPE, types.INTERVAL_TYPE,
+		types.SMALL_INT_TYPE, types.REAL_TYPE, types.UUID_TYPE:
+		return col.Size == expect.Size
+	case types.DECIMAL_TYPE:
+		if col.Precision == 0 {
+			return col.Size == types.DECIMAL_SIZE+types.DECIMAL_SCALE_SIZE && col.Scale == 0
+		}
+
+		return col.Size == types.DECIMAL_SIZE && col.Precision >= 1 && col.Precision <= types.MAX_DECIMAL_PRECISION &&
+			col.Scale >= 0 && col.Scale <= col.Precision
+	}
+
+	return false
+}
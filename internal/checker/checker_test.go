@@ -0,0 +1,367 @@
+package checker
+
+import (
+	"encoding/binary"
+	"fmt"
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/schema"
+	"go-db/internal/catalog/table"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"go-db/internal/storage/page"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Test_Checker(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []*tuple.Value{
+		tuple.GetValue(int32(1), columns[0].GetColumnType(), columns[0].GetColumnSize()),
+		tuple.GetValue([]byte("123"), columns[1].GetColumnType(), columns[1].GetColumnSize()),
+	}
+
+	for i := 0; i < 100; i++ {
+		if err := tableManager.InsertTuple("testTable", values); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 0 || report.TableCount != 1 {
+		t.Fatal("new database should be clean", report.Issues)
+	}
+
+	// orphaned page which no chain points to
+	orphanPageID := diskManager.AllocatePage()
+	orphanPage := page.NewPage()
+	table.GetDataTable(orphanPage).DataTableInit()
+	orphanPage.UpdateChecksum()
+
+	if err := diskManager.WritePage(orphanPageID, orphanPage.GetData()); err != nil {
+		t.Fatal(err)
+	}
+
+	// point the second tuple of the first data page at the first tuple
+	metaPageID := tableManager.TableMetaPageID["testTable"]
+	metaData, _ := diskManager.ReadPage(metaPageID)
+	metaPage := page.NewPage()
+	copy(metaPage.GetData(), metaData)
+	dataPageID := schema.GetSchema(metaPage).GetDataPageID()
+
+	data, err := diskManager.ReadPage(dataPageID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstTupleOffset := binary.BigEndian.Uint32(data[types.TUPLE_COUNT_OFFSET : types.TUPLE_COUNT_OFFSET+types.TUPLE_OFFSET])
+	secondTuple := types.TUPLE_COUNT_OFFSET + types.TUPLE_OFFSET + types.TUPLE_SIZE
+	binary.BigEndian.PutUint32(data[secondTuple:secondTuple+types.TUPLE_OFFSET], firstTupleOffset-1)
+	page.SetChecksum(data)
+
+	if err := diskManager.WritePage(dataPageID, data); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 2 || report.IsClean() {
+		t.Fatal("should find orphaned page and overlapping tuple", report.Issues)
+	}
+
+	report, err = NewChecker(diskManager, true).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.IsClean() {
+		t.Fatal("issues should be repaired", report.Issues)
+	}
+
+	report, err = NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 0 || report.FreePages != 1 {
+		t.Fatal("repaired database should be clean", report.Issues, report.FreePages)
+	}
+
+	// a free page which fell off the free list, the repair links it again. It is the page
+	// the list held before, so the list is empty until then
+	lostPageID := diskManager.AllocatePage()
+	lost := make([]byte, constant.PAGE_SIZE)
+	binary.BigEndian.PutUint32(lost[:types.PAGE_TYPE_OFFSET], uint32(types.FREE_PAGE_TYPE))
+	lastFreePageID := types.Page_id_t(constant.INVALID_PAGE_ID)
+	binary.BigEndian.PutUint32(lost[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET], uint32(lastFreePageID))
+	page.SetChecksum(lost)
+
+	if err := diskManager.WritePage(lostPageID, lost); err != nil {
+		t.Fatal(err)
+	}
+
+	if report, err = NewChecker(diskManager, true).Check(); err != nil || !report.IsClean() {
+		t.Fatal("lost free page should be repaired", report, err)
+	}
+
+	if report, err = NewChecker(diskManager, false).Check(); err != nil || len(report.Issues) != 0 || report.FreePages != 1 || diskManager.GetFreeListHead() != lostPageID {
+		t.Fatal("lost free page should be on the free list", report, err)
+	}
+}
+
+func Test_CheckerWideTable(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("wide_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("wide_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := make([]*column.Column, 0)
+
+	for i := 0; i < 100; i++ {
+		columns = append(columns, column.NewColumn(types.INT_TYPE, 0, fmt.Sprintf("%s_%d", strings.Repeat("c", 200), i)))
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !report.IsClean() || len(report.Issues) != 0 {
+		t.Error("continuation schema pages should be reachable", report.Issues)
+	}
+}
+
+func Test_CheckerIndex(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test_index.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test_index.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+	}
+
+	constraints := []*constraint.Constraint{
+		constraint.NewConstraint("", types.CONSTRAINT_PRIMARY_KEY, []string{"id"}),
+	}
+
+	if err := tableManager.CreateNewTableWithConstraints("testTable", columns, constraints); err != nil {
+		t.Fatal(err)
+	}
+
+	// enough keys to split the index into several pages
+	for i := 0; i < 2000; i++ {
+		values := []*tuple.Value{tuple.GetValue(int32(i), columns[0].GetColumnType(), columns[0].GetColumnSize())}
+
+		if err := tableManager.InsertTuple("testTable", values); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 0 {
+		t.Fatal("index pages should be reachable", report.Issues)
+	}
+}
+
+func Test_CheckerSystemColumns(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test_columns.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test_columns.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "id"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 16, "name"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	// rename a column on the schema page only, as a crash before sys_columns is written would
+	metaPageID := tableManager.TableMetaPageID["testTable"]
+	data, err := diskManager.ReadPage(metaPageID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metaPage := page.NewPage()
+	copy(metaPage.GetData(), data)
+	metaSchema := schema.GetSchema(metaPage)
+	metaSchema.ResetColumns()
+
+	for _, col := range []*column.Column{column.NewColumn(types.INT_TYPE, 0, "renamed"), columns[1]} {
+		if err := metaSchema.AddColumn(col); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metaPage.UpdateChecksum()
+
+	if err := diskManager.WritePage(metaPageID, metaPage.GetData()); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := NewChecker(diskManager, false).Check()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Issues) != 1 || report.Issues[0].PageID != metaPageID || !strings.Contains(report.Issues[0].Message, types.SYSTEM_COLUMNS) {
+		t.Fatal("should find sys_columns out of step with the schema page", report.Issues)
+	}
+}
+
+func Test_CheckerDoubleWrite(t *testing.T) {
+	diskManager, err := disk.NewDoubleWriteDiskStorage("test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+	defer os.Remove("test.db" + disk.DOUBLE_WRITE_FILE_SUFFIX)
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tableManager.InsertTuple("testTable", []*tuple.Value{tuple.GetValue(int32(1), types.INT_TYPE, types.INT_SIZE)}); err != nil {
+		t.Fatal(err)
+	}
+
+	metaData, _ := diskManager.ReadPage(tableManager.TableMetaPageID["testTable"])
+	metaPage := page.NewPage()
+	copy(metaPage.GetData(), metaData)
+	dataPageID := schema.GetSchema(metaPage).GetDataPageID()
+
+	data, err := diskManager.ReadPage(dataPageID)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diskManager.ShutDown()
+
+	// a crash while the data page is written leaves it in the buffer and torn in place
+	buffered := make([]byte, disk.DOUBLE_WRITE_PAGE_ID_SIZE, disk.DOUBLE_WRITE_PAGE_ID_SIZE+len(data))
+	binary.BigEndian.PutUint32(buffered, uint32(dataPageID))
+
+	if err := os.WriteFile("test.db"+disk.DOUBLE_WRITE_FILE_SUFFIX, append(buffered, data...), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	file, err := os.OpenFile("test.db", os.O_RDWR, 0755)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.WriteAt(make([]byte, len(data)/2), int64(dataPageID)*int64(len(data))+int64(len(data)/2)); err != nil {
+		t.Fatal(err)
+	}
+
+	file.Close()
+
+	if _, err := OpenDatabase("test.db", true); err != errors.ErrDoubleWritePending {
+		t.Fatal("repair should refuse a double write buffer which was not replayed", err)
+	}
+
+	for _, repair := range []bool{false, true} {
+		diskManager, err := OpenDatabase("test.db", repair)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		report, err := NewChecker(diskManager, repair).Check()
+		diskManager.ShutDown()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(report.Issues) != 0 {
+			t.Fatal("torn page should be restored from the double write buffer", repair, report.Issues)
+		}
+	}
+}
@@ -4,5 +4,10 @@ import "go-db/internal/common/types"
 
 const PAGE_SIZE = 4096
 
+const SUPER_BLOCK_PAGE_ID types.Page_id_t = 0
+
+const DB_MAGIC_NUMBER uint32 = 0x54524442
+const DB_FORMAT_VERSION uint32 = 5
+
 const INVALID_FRAME_ID types.Frame_id_t = -1
 const INVALID_PAGE_ID types.Page_id_t = -1
@@ -1,29 +1,169 @@
 package errors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrPageNotFound          = errors.New("page not found")
+	ErrPageCorrupted         = errors.New("page corrupted")
 	ErrColumnIndexOutOfRange = errors.New("column index out of range")
+	ErrPageOffsetOutOfRange  = errors.New("write past the end of the page")
+	ErrFreeSuperBlock        = errors.New("super block can not be freed")
+	ErrPageAlreadyFree       = errors.New("page is already free")
 )
 
 var (
 	ErrNoPageCanReplace = errors.New("no page can replace")
+	ErrPagePinned       = errors.New("page is pinned")
+)
+
+var (
+	ErrNoSpace       = errors.New("no enough space for insert tuple")
+	ErrTupleTooLarge = errors.New("tuple is larger than a page")
+	ErrTupleNotExist = errors.New("tuple not exist")
 )
 
 var (
-	ErrNoSpace = errors.New("no enough space for insert tuple")
+	ErrNoTable     = errors.New("table not exist")
+	ErrTableExist  = errors.New("table already exist")
+	ErrSystemTable = errors.New("system table can not be modified")
 )
 
 var (
-	ErrNoTable = errors.New("table not exist")
+	ErrTableNameTooLong  = errors.New("table name is too long")
+	ErrColumnNameTooLong = errors.New("column name is too long")
+	ErrNameTooLong       = errors.New("constraint name is too long")
 )
 
 var (
 	ErrIndexOutOfRange = errors.New("index out of range")
+	ErrDuplicateKey    = errors.New("duplicate key")
+	ErrKeyNotFound     = errors.New("key not found")
+	ErrKeyTooLarge     = errors.New("index key is too large")
+)
+
+var (
+	ErrConstraintExist     = errors.New("constraint already exist")
+	ErrMultiplePrimaryKey  = errors.New("multiple primary keys are not allowed")
+	ErrColumnHasConstraint = errors.New("column is used by a constraint")
+	ErrAddColumnConstraint = errors.New("only DEFAULT, NOT NULL and CHECK can be added together with a column")
+	ErrUniqueViolation     = errors.New("duplicate key value violates unique constraint")
+	ErrNotNullViolation    = errors.New("null value violates not-null constraint")
+	ErrForeignKeyViolation = errors.New("insert or update violates foreign key constraint")
+	ErrForeignKeyReference = errors.New("update or delete violates foreign key constraint")
+	ErrNoUniqueConstraint  = errors.New("no unique constraint matches the referenced columns")
+	ErrNoConflictTarget    = errors.New("no unique constraint matches the ON CONFLICT specification")
+	ErrConflictTwice       = errors.New("ON CONFLICT DO UPDATE command can not affect a row a second time")
+	ErrForeignKeyMismatch  = errors.New("foreign key columns do not match the referenced columns")
+	ErrTableReferenced     = errors.New("table is referenced by a foreign key constraint")
+	ErrCheckViolation      = errors.New("new row violates check constraint")
+	ErrInvalidDefault      = errors.New("default expression can not reference a column")
+	ErrNoIndex             = errors.New("index does not exist")
+	ErrIndexConstraint     = errors.New("index belongs to a constraint, drop the constraint instead")
 )
 
 var (
 	ErrSyntax         = errors.New("syntax error")
 	ErrColumnNotExist = errors.New("column not exist")
+	ErrColumnExist    = errors.New("column already exist")
+	ErrInvalidValue   = errors.New("invalid input value for column type")
+	ErrValueTooLong   = errors.New("value too long for the column")
+	ErrInsertColumns  = errors.New("INSERT has a different number of values than target columns")
 )
+
+var (
+	ErrTypeMismatch    = errors.New("operator does not match the operand types")
+	ErrDivisionByZero  = errors.New("division by zero")
+	ErrNumericOverflow = errors.New("numeric value out of range")
+	ErrNoFunction      = errors.New("function does not exist")
+	ErrFunctionExist   = errors.New("function already exist")
+	ErrAggregateCall   = errors.New("aggregate functions are not allowed here")
+	ErrUngroupedColumn = errors.New("column must be used in an aggregate function")
+	ErrDatetimeRange   = errors.New("date/time value out of range")
+	ErrInvalidUnit     = errors.New("date/time unit is not recognized")
+	ErrInvalidDecimal  = errors.New("DECIMAL precision must be between 1 and 38 and scale between 0 and precision")
+	ErrSubqueryRows    = errors.New("more than one row returned by a subquery used as an expression")
+	ErrSubqueryColumns = errors.New("subquery must return only one column")
+	ErrSubquery        = errors.New("cannot use subquery here")
+)
+
+var (
+	ErrSequenceExist     = errors.New("sequence already exist")
+	ErrNoSequence        = errors.New("sequence does not exist")
+	ErrInvalidSequence   = errors.New("invalid sequence options")
+	ErrSequenceExhausted = errors.New("nextval: reached the limit of the sequence")
+	ErrSequenceNotCalled = errors.New("currval of the sequence is not yet defined")
+	ErrSequenceOwned     = errors.New("sequence is owned by a column")
+)
+
+var (
+	ErrNotDatabaseFile     = errors.New("not a database file")
+	ErrIncompatibleVersion = errors.New("incompatible database format version")
+	ErrPageSizeMismatch    = errors.New("database page size mismatch")
+	ErrDoubleWritePending  = errors.New("double write buffer is not empty, the database crashed or is still open")
+)
+
+// ConstraintError names the constraint which rejected the row
+type ConstraintError struct {
+	Constraint string
+	Err        error
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("%s \"%s\"", e.Err.Error(), e.Constraint)
+}
+
+func (e *ConstraintError) Unwrap() error {
+	return e.Err
+}
+
+// ValueError names the type and the text which could not be read as it
+type ValueError struct {
+	Type  string
+	Value string
+	Err   error
+}
+
+func (e *ValueError) Error() string {
+	if e.Err == ErrNumericOverflow {
+		return fmt.Sprintf("value \"%s\" is out of range for type %s", e.Value, e.Type)
+	}
+
+	return fmt.Sprintf("invalid input syntax for type %s: \"%s\"", e.Type, e.Value)
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// CastError is a value stored into a column whose type it does not convert to without a CAST
+type CastError struct {
+	From string
+	To   string
+}
+
+func (e *CastError) Error() string {
+	return fmt.Sprintf("type %s can not be converted to %s without a cast", e.From, e.To)
+}
+
+func (e *CastError) Unwrap() error {
+	return ErrInvalidValue
+}
+
+// ArgumentError is an argument of a call whose type the function does not take
+type ArgumentError struct {
+	Function string
+	Position int
+	Type     string
+	Expected string
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("argument %d of %s is %s, the function takes %s", e.Position, e.Function, e.Type, e.Expected)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return ErrTypeMismatch
+}
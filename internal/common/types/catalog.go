@@ -0,0 +1,43 @@
+package types
+
+const SYSTEM_TABLE_PREFIX = "sys_"
+
+const (
+	SYSTEM_TABLES      = "sys_tables"
+	SYSTEM_COLUMNS     = "sys_columns"
+	SYSTEM_INDEXES     = "sys_indexes"
+	SYSTEM_CONSTRAINTS = "sys_constraints"
+	SYSTEM_STATISTICS  = "sys_statistics"
+	SYSTEM_SEQUENCES   = "sys_sequences"
+)
+
+const INFORMATION_SCHEMA_PREFIX = "information_schema."
+
+const (
+	INFORMATION_SCHEMA_TABLES  = "information_schema.tables"
+	INFORMATION_SCHEMA_COLUMNS = "information_schema.columns"
+)
+
+const (
+	TABLE_TYPE_BASE   = "BASE TABLE"
+	TABLE_TYPE_SYSTEM = "SYSTEM TABLE"
+)
+
+const (
+	CONSTRAINT_PRIMARY_KEY  = "PRIMARY KEY"
+	CONSTRAINT_UNIQUE       = "UNIQUE"
+	CONSTRAINT_NOT_NULL     = "NOT NULL"
+	CONSTRAINT_FOREIGN_KEY  = "FOREIGN KEY"
+	CONSTRAINT_CHECK        = "CHECK"
+	CONSTRAINT_DEFAULT      = "DEFAULT"
+	CONSTRAINT_INDEX        = "INDEX"
+	CONSTRAINT_UNIQUE_INDEX = "UNIQUE INDEX"
+)
+
+const (
+	FOREIGN_KEY_RESTRICT    = "RESTRICT"
+	FOREIGN_KEY_NO_ACTION   = "NO ACTION"
+	FOREIGN_KEY_CASCADE     = "CASCADE"
+	FOREIGN_KEY_SET_NULL    = "SET NULL"
+	FOREIGN_KEY_SET_DEFAULT = "SET DEFAULT"
+)
@@ -9,6 +9,17 @@ const (
 	LONG_INT_TYPE
 	FLOAT_TYPE
 	BOOL_TYPE
+	DATE_TYPE
+	TIME_TYPE
+	TIMESTAMP_TYPE
+	INTERVAL_TYPE
+	DECIMAL_TYPE
+	JSON_TYPE
+	SMALL_INT_TYPE
+	REAL_TYPE
+	UUID_TYPE
+	BYTEA_TYPE
+	CHAR_TYPE
 )
 
 const (
@@ -21,9 +32,59 @@ const (
 )
 
 const (
-	COLUMN_NAME_OFFSET = 128
-	COLUMN_TYPE_OFFSET = 4
-	COLUMN_SIZE_OFFSET = 4
+	COLUMN_TYPE_DATE      = "DATE"
+	COLUMN_TYPE_TIME      = "TIME"
+	COLUMN_TYPE_TIMESTAMP = "TIMESTAMP"
+	COLUMN_TYPE_INTERVAL  = "INTERVAL"
+)
+
+// NUMERIC is another name of DECIMAL
+const (
+	COLUMN_TYPE_DECIMAL = "DECIMAL"
+	COLUMN_TYPE_NUMERIC = "NUMERIC"
+)
+
+// JSON is kept in the binary form of PostgreSQL JSONB, so JSONB is another name of it
+const (
+	COLUMN_TYPE_JSON  = "JSON"
+	COLUMN_TYPE_JSONB = "JSONB"
+)
+
+// DOUBLE PRECISION and FLOAT8 are other names of FLOAT, FLOAT4 of REAL and INT2 of SMALLINT
+const (
+	COLUMN_TYPE_SMALLINT  = "SMALLINT"
+	COLUMN_TYPE_INT2      = "INT2"
+	COLUMN_TYPE_REAL      = "REAL"
+	COLUMN_TYPE_FLOAT4    = "FLOAT4"
+	COLUMN_TYPE_FLOAT8    = "FLOAT8"
+	COLUMN_TYPE_DOUBLE    = "DOUBLE"
+	COLUMN_TYPE_PRECISION = "PRECISION"
+)
+
+// CHARACTER is another name of CHAR
+const (
+	COLUMN_TYPE_UUID      = "UUID"
+	COLUMN_TYPE_BYTEA     = "BYTEA"
+	COLUMN_TYPE_CHAR      = "CHAR"
+	COLUMN_TYPE_CHARACTER = "CHARACTER"
+)
+
+// SERIAL and BIGSERIAL are INT and BIGINT which take their DEFAULT from a sequence
+const (
+	COLUMN_TYPE_SERIAL    = "SERIAL"
+	COLUMN_TYPE_BIGSERIAL = "BIGSERIAL"
+)
+
+const (
+	COLUMN_NAME_SIZE_OFFSET = 4
+	COLUMN_TYPE_OFFSET      = 4
+	COLUMN_SIZE_OFFSET      = 4
+	COLUMN_HEADER_SIZE      = COLUMN_NAME_SIZE_OFFSET + COLUMN_TYPE_OFFSET + COLUMN_SIZE_OFFSET
+)
+
+const (
+	COLUMN_NAME_MAX_SIZE = 1024
+	TABLE_NAME_MAX_SIZE  = 240
 )
 
 const (
@@ -33,3 +94,35 @@ const (
 	LONG_INT_SIZE = 8
 	VAR_CHAR_SIZE = 52
 )
+
+// DATE counts days and TIME and TIMESTAMP count microseconds, INTERVAL keeps months, days and microseconds
+const (
+	DATE_SIZE      = 4
+	TIME_SIZE      = 8
+	TIMESTAMP_SIZE = 8
+	INTERVAL_SIZE  = 16
+)
+
+// DECIMAL keeps the value without the point, a bare DECIMAL keeps the scale of every value in front of it
+const (
+	DECIMAL_SIZE          = 16
+	DECIMAL_SCALE_SIZE    = 1
+	MAX_DECIMAL_PRECISION = 38
+)
+
+// JSON takes its size like VARCHAR, the binary document has to fit it
+const JSON_SIZE = 256
+
+// SMALLINT and REAL keep their values in the fields of INT and FLOAT
+const (
+	SMALL_INT_SIZE = 2
+	REAL_SIZE      = 4
+	UUID_SIZE      = 16
+)
+
+// the size of BYTEA includes the length of the bytes in front of them, CHAR without a size is CHAR(1)
+const (
+	BYTEA_SIZE        = 256
+	BYTEA_LENGTH_SIZE = 4
+	CHAR_SIZE         = 1
+)
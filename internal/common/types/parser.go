@@ -1,9 +1,17 @@
 package types
 
 const (
-	SELECT_QUERY_TYPE = "SELECT"
-	INSERT_QUERY_TYPE = "INSERT"
-	CREATE_QUERY_TYPE = "CREATE"
+	SELECT_QUERY_TYPE   = "SELECT"
+	INSERT_QUERY_TYPE   = "INSERT"
+	CREATE_QUERY_TYPE   = "CREATE"
+	DROP_QUERY_TYPE     = "DROP"
+	TRUNCATE_QUERY_TYPE = "TRUNCATE"
+	ALTER_QUERY_TYPE    = "ALTER"
+	ANALYZE_QUERY_TYPE  = "ANALYZE"
+	SHOW_QUERY_TYPE     = "SHOW"
+	DESCRIBE_QUERY_TYPE = "DESCRIBE"
+	UPDATE_QUERY_TYPE   = "UPDATE"
+	DELETE_QUERY_TYPE   = "DELETE"
 )
 
 const (
@@ -16,4 +24,75 @@ const (
 	QUERY_CHAR_LEFT_PARE_BRACKETS  = "("
 	QUERY_CHAR_RIGHT_PARE_BRACKETS = ")"
 	QUERY_CHAR_COMMA               = ","
+	QUERY_CHAR_IF                  = "IF"
+	QUERY_CHAR_EXISTS              = "EXISTS"
+	QUERY_CHAR_ADD                 = "ADD"
+	QUERY_CHAR_DROP                = "DROP"
+	QUERY_CHAR_RENAME              = "RENAME"
+	QUERY_CHAR_ALTER               = "ALTER"
+	QUERY_CHAR_COLUMN              = "COLUMN"
+	QUERY_CHAR_TO                  = "TO"
+	QUERY_CHAR_TYPE                = "TYPE"
+	QUERY_CHAR_DEFAULT             = "DEFAULT"
+	QUERY_CHAR_MINUS               = "-"
+	QUERY_CHAR_DOT                 = "."
+	QUERY_CHAR_DESC                = "DESC"
+	QUERY_CHAR_TABLES              = "TABLES"
+	QUERY_CHAR_COLUMNS             = "COLUMNS"
+	QUERY_CHAR_INDEX               = "INDEX"
+	QUERY_CHAR_INDEXES             = "INDEXES"
+	QUERY_CHAR_CONSTRAINT          = "CONSTRAINT"
+	QUERY_CHAR_PRIMARY             = "PRIMARY"
+	QUERY_CHAR_KEY                 = "KEY"
+	QUERY_CHAR_UNIQUE              = "UNIQUE"
+	QUERY_CHAR_NOT                 = "NOT"
+	QUERY_CHAR_NULL                = "NULL"
+	QUERY_CHAR_FOREIGN             = "FOREIGN"
+	QUERY_CHAR_REFERENCES          = "REFERENCES"
+	QUERY_CHAR_ON                  = "ON"
+	QUERY_CHAR_DELETE              = "DELETE"
+	QUERY_CHAR_UPDATE              = "UPDATE"
+	QUERY_CHAR_RESTRICT            = "RESTRICT"
+	QUERY_CHAR_CASCADE             = "CASCADE"
+	QUERY_CHAR_NO                  = "NO"
+	QUERY_CHAR_ACTION              = "ACTION"
+	QUERY_CHAR_SET                 = "SET"
+	QUERY_CHAR_WHERE               = "WHERE"
+	QUERY_CHAR_AS                  = "AS"
+	QUERY_CHAR_EQUAL               = "="
+	QUERY_CHAR_CHECK               = "CHECK"
+	QUERY_CHAR_SEQUENCE            = "SEQUENCE"
+	QUERY_CHAR_INCREMENT           = "INCREMENT"
+	QUERY_CHAR_BY                  = "BY"
+	QUERY_CHAR_MINVALUE            = "MINVALUE"
+	QUERY_CHAR_MAXVALUE            = "MAXVALUE"
+	QUERY_CHAR_START               = "START"
+	QUERY_CHAR_WITH                = "WITH"
+	QUERY_CHAR_CYCLE               = "CYCLE"
+	QUERY_CHAR_AUTO_INCREMENT      = "AUTO_INCREMENT"
+	QUERY_CHAR_SEMICOLON           = ";"
+	QUERY_CHAR_CONFLICT            = "CONFLICT"
+	QUERY_CHAR_DO                  = "DO"
+	QUERY_CHAR_NOTHING             = "NOTHING"
+	QUERY_CHAR_EXCLUDED            = "EXCLUDED"
+	QUERY_CHAR_RETURNING           = "RETURNING"
+	QUERY_CHAR_ORDER               = "ORDER"
+	QUERY_CHAR_GROUP               = "GROUP"
+	QUERY_CHAR_HAVING              = "HAVING"
+	QUERY_CHAR_JOIN                = "JOIN"
+	QUERY_CHAR_UNION               = "UNION"
+)
+
+const (
+	ALTER_ADD_COLUMN    = "ADD COLUMN"
+	ALTER_DROP_COLUMN   = "DROP COLUMN"
+	ALTER_RENAME_COLUMN = "RENAME COLUMN"
+	ALTER_RENAME_TABLE  = "RENAME TABLE"
+	ALTER_COLUMN_TYPE   = "ALTER COLUMN TYPE"
+)
+
+const (
+	SHOW_TABLES  = "SHOW TABLES"
+	SHOW_COLUMNS = "SHOW COLUMNS"
+	SHOW_INDEXES = "SHOW INDEXES"
 )
@@ -3,3 +3,9 @@ package types
 type Page_id_t int32
 type Frame_id_t int32
 type Type int32
+
+// RID locates a tuple by its data page and its slot on the page
+type RID struct {
+	PageID Page_id_t
+	Index  int32
+}
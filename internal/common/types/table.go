@@ -3,28 +3,48 @@ package types
 type PAGE_TYPE int32
 
 const (
-	META_PAGE_TYPE PAGE_TYPE = iota
+	INVALID_PAGE_TYPE PAGE_TYPE = iota
+	SUPER_BLOCK_PAGE_TYPE
+	META_PAGE_TYPE
 	DATA_PAGE_TYPE
+	FREE_PAGE_TYPE
+	INDEX_PAGE_TYPE
 )
 
 const (
-	PAGE_TYPE_OFFSET    = 4
-	PREV_PAGE_ID_OFFSET = 8
-	NEXT_PAGE_ID_OFFSET = 12
+	PAGE_TYPE_OFFSET     = 4
+	PREV_PAGE_ID_OFFSET  = 8
+	NEXT_PAGE_ID_OFFSET  = 12
+	PAGE_CHECKSUM_OFFSET = 16
 )
 
 const (
-	FREE_SPACE_POINTER_OFFSET = 16
-	TUPLE_COUNT_OFFSET        = 20
+	FREE_SPACE_POINTER_OFFSET = 20
+	TUPLE_COUNT_OFFSET        = 24
 )
 
 const (
-	DATA_PAGE_ID_OFFSET = 16
-	TABLE_NAME_OFFSET   = 256
-	COLUMN_COUNT        = 260
+	DATA_PAGE_ID_OFFSET = 20
+	TABLE_NAME_OFFSET   = 260
+	COLUMN_COUNT        = 264
+)
+
+const (
+	INDEX_IS_LEAF_OFFSET   = 20
+	INDEX_KEY_COUNT_OFFSET = 24
+	INDEX_KEY_SIZE_OFFSET  = 28
 )
 
 const (
 	TUPLE_OFFSET = 4
 	TUPLE_SIZE   = 4
 )
+
+const (
+	SUPER_BLOCK_MAGIC_OFFSET          = 8
+	SUPER_BLOCK_VERSION_OFFSET        = 12
+	SUPER_BLOCK_PAGE_SIZE_OFFSET      = 20
+	SUPER_BLOCK_CATALOG_ROOT_OFFSET   = 24
+	SUPER_BLOCK_FREE_LIST_HEAD_OFFSET = 28
+	SUPER_BLOCK_CHECKPOINT_LSN_OFFSET = 36
+)
@@ -2,28 +2,92 @@ package ast
 
 import (
 	"fmt"
+	"go-db/internal/catalog/expression"
 	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"strconv"
 	"strings"
 	"text/scanner"
+	"unicode"
 )
 
 type Ast struct {
+	Type        string
+	Table       string
+	Column      []string
+	ColumnType  []string
+	Value       []interface{}
+	Limit       int
+	IfExists    bool
+	Action      string
+	NewName     string
+	Constraints []*Constraint
+	Set         []expression.Expression
+	Where       expression.Expression
+	Select      []expression.Expression
+	Sequence    *SequenceOptions
+	Rows        [][]expression.Expression
+	Query       *Ast
+	OnConflict  *OnConflict
+	Returning   *Ast
+	From        *Ast
+	Alias       string
+}
+
+func init() {
+	// a subquery in an expression is a SELECT
+	expression.SetQueryParser(func(scan *scanner.Scanner) (expression.Query, string, error) {
+		query, end, err := scanSelect(scan)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		return query, end, nil
+	})
+}
+
+// SequenceOptions keeps the options CREATE SEQUENCE was given, the missing ones are nil
+type SequenceOptions struct {
+	Name      string
+	Increment *int64
+	MinValue  *int64
+	MaxValue  *int64
+	Start     *int64
+	Cycle     bool
+}
+
+// OnConflict is the conflict target of INSERT ... ON CONFLICT, the row is skipped
+// without Update and the conflicting row is updated with it
+type OnConflict struct {
+	Columns    []string
+	Constraint string
+	Update     *Ast
+}
+
+// Constraint has no name when the query did not give one,
+// a FOREIGN KEY without referenced columns references the primary key
+// and CHECK or DEFAULT keep their expression
+type Constraint struct {
+	Name       string
 	Type       string
-	Table      string
-	Column     []string
-	ColumnType []string
-	Value      []interface{}
-	Limit      int
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+	Expression expression.Expression
 }
 
 /*
-INSERT INTO table_name (column1, column2, column3...)
-VALUES (value1, value2, value3...);
+INSERT INTO table_name [(column1, column2, column3...)]
+VALUES (expression1 | DEFAULT, ...) [, (expression1 | DEFAULT, ...)...] [ON CONFLICT ...] [RETURNING ...];
+INSERT INTO table_name [(column1, column2, column3...)] SELECT ... [ON CONFLICT ...] [RETURNING ...]
 
 */
 
+// InsertAst keeps every VALUES list in Rows or the SELECT in Query,
+// without a column list Column is empty and the values go to the columns in order
 func InsertAst(query string, scan *scanner.Scanner) (*Ast, error) {
 	ast := &Ast{
 		Type: types.INSERT_QUERY_TYPE,
@@ -49,13 +113,9 @@ func InsertAst(query string, scan *scanner.Scanner) (*Ast, error) {
 
 	if token := scan.Scan(); token == scanner.EOF {
 		return nil, errors.ErrSyntax
-	} else {
-		tokenString = scan.TokenText()
-
-		if tokenString != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
-			return nil, errors.ErrSyntax
-		}
+	}
 
+	if scan.TokenText() == types.QUERY_CHAR_LEFT_PARE_BRACKETS {
 		for {
 			if token := scan.Scan(); token == scanner.EOF {
 				return nil, errors.ErrSyntax
@@ -70,203 +130,1623 @@ func InsertAst(query string, scan *scanner.Scanner) (*Ast, error) {
 				}
 			}
 		}
-	}
 
-	if token := scan.Scan(); token == scanner.EOF {
-		return nil, errors.ErrSyntax
-	} else {
-		if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_VALUE {
+		if token := scan.Scan(); token == scanner.EOF {
 			return nil, errors.ErrSyntax
 		}
 	}
 
-	if token := scan.Scan(); token == scanner.EOF {
+	if strings.ToUpper(scan.TokenText()) == types.SELECT_QUERY_TYPE {
+		selectAst, end, err := scanSelect(scan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ast.Query = selectAst
+
+		return scanOnConflict(ast, scan, end)
+	}
+
+	if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_VALUE {
 		return nil, errors.ErrSyntax
-	} else {
-		tokenString = scan.TokenText()
+	}
 
-		if tokenString != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
+	for {
+		if token := scan.Scan(); token == scanner.EOF || scan.TokenText() != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
 			return nil, errors.ErrSyntax
 		}
 
-		for {
-			if token := scan.Scan(); token == scanner.EOF {
-				return nil, errors.ErrSyntax
-			} else {
-				tokenString = scan.TokenText()
-				if tokenString == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
-					break
-				} else if tokenString == types.QUERY_CHAR_COMMA {
-					continue
-				} else {
-					ast.Value = append(ast.Value, tokenString)
+		values, err := scanValueList(scan)
 
-				}
-			}
+		if err != nil {
+			return nil, err
+		}
+
+		ast.Rows = append(ast.Rows, values)
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return ast, nil
+		} else if scan.TokenText() != types.QUERY_CHAR_COMMA {
+			return scanOnConflict(ast, scan, scan.TokenText())
 		}
 	}
-	return ast, nil
 }
 
 /*
-
-SELECT * FROM `table`
-SELECT * FROM `table` LIMIT `number`
+ON CONFLICT [(column1, column2...) | ON CONSTRAINT name] DO NOTHING
+ON CONFLICT (column1, column2...) | ON CONSTRAINT name DO UPDATE SET column1 = expression1... [WHERE condition]
 
 */
 
-func SelectAst(query string, scan *scanner.Scanner) (*Ast, error) {
-	ast := &Ast{
-		Type: types.SELECT_QUERY_TYPE,
+// scanOnConflict reads the ON CONFLICT clause which starts at the token, DO UPDATE keeps
+// its SET and WHERE in an UPDATE of the table. RETURNING may follow either clause.
+func scanOnConflict(ast *Ast, scan *scanner.Scanner, token string) (*Ast, error) {
+	if strings.ToUpper(token) != types.QUERY_CHAR_ON {
+		return scanReturning(ast, scan, token)
 	}
 
-	for {
-		if token := scan.Scan(); token == scanner.EOF {
+	if token := scan.Scan(); token != scanner.Ident || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_CONFLICT {
+		return nil, errors.ErrSyntax
+	}
+
+	onConflict := &OnConflict{}
+	skipWhitespace(scan)
+
+	if scan.Peek() == '(' {
+		columns, err := scanColumnNames(scan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		onConflict.Columns = columns
+	}
+
+	scan.Scan()
+
+	if len(onConflict.Columns) == 0 && strings.ToUpper(scan.TokenText()) == types.QUERY_CHAR_ON {
+		if token := scan.Scan(); token != scanner.Ident || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_CONSTRAINT {
 			return nil, errors.ErrSyntax
-		} else {
-			tokenString := scan.TokenText()
+		}
 
-			if tokenString == types.QUERY_CHAR_STAR {
-				ast.Column = append(ast.Column, types.QUERY_CHAR_STAR)
-			} else {
-				if tokenString == types.QUERY_CHAR_COMMA {
-					continue
-				} else if strings.ToUpper(tokenString) == types.QUERY_CHAR_FROM {
-					break
-				} else {
-					ast.Column = append(ast.Column, tokenString)
-				}
-			}
+		if token := scan.Scan(); token != scanner.Ident {
+			return nil, errors.ErrSyntax
 		}
+
+		onConflict.Constraint = scan.TokenText()
+		scan.Scan()
 	}
 
-	if token := scan.Scan(); token == scanner.EOF {
+	if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_DO {
 		return nil, errors.ErrSyntax
-	} else {
-		ast.Table = scan.TokenText()
 	}
 
-	limitFlag := false
-	for {
+	scan.Scan()
+	ast.OnConflict = onConflict
+
+	switch strings.ToUpper(scan.TokenText()) {
+	case types.QUERY_CHAR_NOTHING:
 		if token := scan.Scan(); token == scanner.EOF {
-			break
-		} else {
-			tokenText := scan.TokenText()
+			return ast, nil
+		}
+
+		return scanReturning(ast, scan, scan.TokenText())
+	case types.QUERY_CHAR_UPDATE:
+		// PostgreSQL needs the target to know which row is updated
+		if len(onConflict.Columns) == 0 && onConflict.Constraint == "" {
+			return nil, errors.ErrSyntax
+		}
 
-			if limitFlag {
-				limitNumber, err := strconv.Atoi(tokenText)
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_SET {
+			return nil, errors.ErrSyntax
+		}
 
-				if err != nil {
-					return nil, err
-				}
+		onConflict.Update = &Ast{Type: types.UPDATE_QUERY_TYPE, Table: ast.Table}
+		end, err := scanSet(onConflict.Update, scan)
 
-				ast.Limit = limitNumber
-			} else if strings.ToUpper(tokenText) == types.QUERY_CHAR_LIMIT {
-				limitFlag = true
-			}
+		if err != nil {
+			return nil, err
 		}
+
+		if end, err = scanWhere(onConflict.Update, scan, end); err != nil {
+			return nil, err
+		}
+
+		return scanReturning(ast, scan, end)
 	}
 
-	return ast, nil
+	return nil, errors.ErrSyntax
+}
+
+// scanValueList reads the expressions up to the closing bracket, DEFAULT is kept as nil
+func scanValueList(scan *scanner.Scanner) ([]expression.Expression, error) {
+	values := make([]expression.Expression, 0)
+
+	for {
+		expr, end, err := expression.Parse(scan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ref, ok := expr.(*expression.ColumnRef); ok && ref.Table == "" && strings.ToUpper(ref.Name) == types.QUERY_CHAR_DEFAULT {
+			expr = nil
+		}
+
+		values = append(values, expr)
+
+		if end == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+			return values, nil
+		} else if end != types.QUERY_CHAR_COMMA {
+			return nil, errors.ErrSyntax
+		}
+	}
 }
 
 /*
 
-CREATE TABLE table_name (
-    column1 datatype,
-    column2 datatype,
-    column3 datatype,
-);
+UPDATE table_name SET column1 = expression1, column2 = expression2... [WHERE condition] [RETURNING ...]
 
 */
-func CreateTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+
+// UpdateAst keeps the expression of each column in Set at the index of the column
+func UpdateAst(query string, scan *scanner.Scanner) (*Ast, error) {
 	ast := &Ast{
-		Type: types.CREATE_QUERY_TYPE,
+		Type: types.UPDATE_QUERY_TYPE,
 	}
 
 	if token := scan.Scan(); token == scanner.EOF {
 		return nil, errors.ErrSyntax
 	} else {
-		if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_TABLE {
-			return nil, errors.ErrSyntax
+		ast.Table = scan.TokenText()
+	}
+
+	if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_SET {
+		return nil, errors.ErrSyntax
+	}
+
+	end, err := scanSet(ast, scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end, err = scanWhere(ast, scan, end); err != nil {
+		return nil, err
+	}
+
+	return scanReturning(ast, scan, end)
+}
+
+// scanSet reads column = expression, ... after SET and returns the token which follows
+func scanSet(ast *Ast, scan *scanner.Scanner) (string, error) {
+	for {
+		if token := scan.Scan(); token != scanner.Ident {
+			return "", errors.ErrSyntax
 		}
 
-		if token := scan.Scan(); token == scanner.EOF {
-			return nil, errors.ErrSyntax
-		} else {
-			ast.Table = scan.TokenText()
+		ast.Column = append(ast.Column, scan.TokenText())
+
+		if token := scan.Scan(); token == scanner.EOF || scan.TokenText() != types.QUERY_CHAR_EQUAL {
+			return "", errors.ErrSyntax
+		}
+
+		expr, end, err := expression.Parse(scan)
+
+		if err != nil {
+			return "", err
+		}
+
+		ast.Set = append(ast.Set, expr)
+
+		if end != types.QUERY_CHAR_COMMA {
+			return end, nil
 		}
 	}
+}
+
+/*
+
+DELETE FROM table_name [WHERE condition] [RETURNING ...]
+
+*/
+func DeleteAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.DELETE_QUERY_TYPE,
+	}
 
-	var (
-		columnName string
-		columnType string
-	)
+	if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_FROM {
+		return nil, errors.ErrSyntax
+	}
 
 	if token := scan.Scan(); token == scanner.EOF {
 		return nil, errors.ErrSyntax
 	} else {
-		if scan.TokenText() != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
-			return nil, errors.ErrSyntax
-		}
+		ast.Table = scan.TokenText()
 	}
 
-	for {
-		if token := scan.Scan(); token == scanner.EOF {
-			return nil, errors.ErrSyntax
-		} else {
-			columnName = scan.TokenText()
+	if token := scan.Scan(); token == scanner.EOF {
+		return ast, nil
+	}
 
-			if columnName == types.QUERY_CHAR_COMMA {
-				continue
-			}
+	end, err := scanWhere(ast, scan, scan.TokenText())
 
-			if columnName == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
-				break
-			}
+	if err != nil {
+		return nil, err
+	}
 
-			if token := scan.Scan(); token == scanner.EOF {
-				return nil, errors.ErrSyntax
-			} else {
+	return scanReturning(ast, scan, end)
+}
 
-				columnType = checkColumnTypeIsValid(scan.TokenText())
+// scanWhere reads the WHERE clause starting at the token which ended the previous clause,
+// it returns the token which follows the condition, or the token itself without WHERE
+func scanWhere(ast *Ast, scan *scanner.Scanner, token string) (string, error) {
+	if strings.ToUpper(token) != types.QUERY_CHAR_WHERE {
+		return token, nil
+	}
 
-				if columnType == types.COLUMN_TYPE_INVALID {
-					return nil, errors.ErrSyntax
-				}
+	expr, end, err := expression.Parse(scan)
 
-				if columnType == types.COLUMN_TYPE_VAR_CHAR {
-					for {
-						if token := scan.Scan(); token == scanner.EOF {
-							break
-						}
-
-						tokenString := scan.TokenText()
-
-						if tokenString == types.QUERY_CHAR_LEFT_PARE_BRACKETS {
-							continue
-						} else if tokenString == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
-							break
-						} else {
-							varcharSize, err := strconv.Atoi(tokenString)
-							if err != nil {
-								return nil, errors.ErrSyntax
-							}
-
-							columnType = fmt.Sprintf("%s(%d)", types.COLUMN_TYPE_VAR_CHAR, varcharSize)
-						}
-					}
-				}
+	if err != nil {
+		return "", err
+	}
 
-				ast.Column = append(ast.Column, columnName)
-				ast.ColumnType = append(ast.ColumnType, columnType)
-			}
-		}
+	ast.Where = expr
+
+	return end, nil
+}
+
+/*
+
+INSERT | UPDATE | DELETE ... RETURNING * | expression [AS alias], ...
+
+*/
+
+// scanReturning reads the RETURNING clause which ends a write statement, its list is kept
+// in Returning like the list of a SELECT
+func scanReturning(ast *Ast, scan *scanner.Scanner, token string) (*Ast, error) {
+	if token == "" || token == types.QUERY_CHAR_SEMICOLON {
+		return ast, nil
+	}
+
+	if strings.ToUpper(token) != types.QUERY_CHAR_RETURNING {
+		return nil, errors.ErrSyntax
+	}
+
+	ast.Returning = &Ast{Type: types.SELECT_QUERY_TYPE, Table: ast.Table}
+	end, err := scanSelectList(ast.Returning, scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end != "" && end != types.QUERY_CHAR_SEMICOLON {
+		return nil, errors.ErrSyntax
+	}
+
+	return ast, nil
+}
+
+/*
+
+SELECT * FROM `table`
+SELECT * FROM `table` LIMIT `number`
+SELECT * FROM information_schema.`view`
+SELECT column, expression [AS alias] FROM `table` [[AS] alias] [WHERE condition] [LIMIT `number`]
+SELECT ... FROM (SELECT ...) [AS] alias ...
+
+*/
+
+// SelectAst keeps the name of every selected item in Column and its expression in Select,
+// the expression of * is nil
+func SelectAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast, end, err := scanSelect(scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end != "" {
+		return nil, errors.ErrSyntax
 	}
 
 	return ast, nil
 }
 
+// scanSelect reads a SELECT which may be followed by another clause such as
+// ON CONFLICT, it returns the first token after the SELECT or "" at the end
+func scanSelect(scan *scanner.Scanner) (*Ast, string, error) {
+	ast := &Ast{
+		Type: types.SELECT_QUERY_TYPE,
+	}
+
+	end, err := scanSelectList(ast, scan)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if strings.ToUpper(end) != types.QUERY_CHAR_FROM {
+		return nil, "", errors.ErrSyntax
+	}
+
+	tokenText, err := scanFrom(ast, scan)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if tokenText == "" {
+		return ast, "", nil
+	}
+
+	if strings.ToUpper(tokenText) == types.QUERY_CHAR_WHERE {
+		expr, end, err := expression.Parse(scan)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		ast.Where = expr
+		tokenText = end
+	}
+
+	if strings.ToUpper(tokenText) != types.QUERY_CHAR_LIMIT {
+		return ast, tokenText, nil
+	}
+
+	if token := scan.Scan(); token != scanner.Int {
+		return nil, "", errors.ErrSyntax
+	}
+
+	limitNumber, err := strconv.Atoi(scan.TokenText())
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	ast.Limit = limitNumber
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return ast, "", nil
+	}
+
+	return ast, scan.TokenText(), nil
+}
+
+// the words which may follow the table of a SELECT, or which are no alias because a clause
+// of SQL starts with them, any other word is an alias
+var selectKeywords = map[string]bool{
+	types.QUERY_CHAR_WHERE:     true,
+	types.QUERY_CHAR_LIMIT:     true,
+	types.QUERY_CHAR_ON:        true,
+	types.QUERY_CHAR_RETURNING: true,
+	types.QUERY_CHAR_ORDER:     true,
+	types.QUERY_CHAR_GROUP:     true,
+	types.QUERY_CHAR_HAVING:    true,
+	types.QUERY_CHAR_JOIN:      true,
+	types.QUERY_CHAR_UNION:     true,
+}
+
+// scanFrom reads the table after FROM with its alias, a derived table (SELECT ...) has to
+// have one and the alias is its Table. It returns the token which follows or "" at the end.
+func scanFrom(ast *Ast, scan *scanner.Scanner) (string, error) {
+	if token := scan.Scan(); token == scanner.EOF {
+		return "", errors.ErrSyntax
+	}
+
+	if scan.TokenText() == types.QUERY_CHAR_LEFT_PARE_BRACKETS {
+		if token := scan.Scan(); token != scanner.Ident || strings.ToUpper(scan.TokenText()) != types.SELECT_QUERY_TYPE {
+			return "", errors.ErrSyntax
+		}
+
+		from, end, err := scanSelect(scan)
+
+		if err != nil {
+			return "", err
+		}
+
+		if end != types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+			return "", errors.ErrSyntax
+		}
+
+		ast.From = from
+	} else {
+		tableName, err := scanTableName(scan)
+
+		if err != nil {
+			return "", err
+		}
+
+		ast.Table = tableName
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		if ast.From != nil {
+			return "", errors.ErrSyntax
+		}
+
+		return "", nil
+	}
+
+	tokenText := scan.TokenText()
+	as := strings.ToUpper(tokenText) == types.QUERY_CHAR_AS
+
+	if as {
+		if token := scan.Scan(); token != scanner.Ident {
+			return "", errors.ErrSyntax
+		}
+
+		tokenText = scan.TokenText()
+	}
+
+	if !as && (selectKeywords[strings.ToUpper(tokenText)] || !isIdent(tokenText)) {
+		if ast.From != nil {
+			return "", errors.ErrSyntax
+		}
+
+		return tokenText, nil
+	}
+
+	ast.Alias = tokenText
+
+	if ast.From != nil {
+		ast.Table = tokenText
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return "", nil
+	}
+
+	return scan.TokenText(), nil
+}
+
+func isIdent(text string) bool {
+	for i, r := range text {
+		if r != '_' && !unicode.IsLetter(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	return text != ""
+}
+
+// String prints a SELECT the way SelectAst reads it back
+func (a *Ast) String() string {
+	items := make([]string, 0, len(a.Select))
+
+	for i, expr := range a.Select {
+		if expr == nil {
+			items = append(items, types.QUERY_CHAR_STAR)
+			continue
+		}
+
+		item := expr.String()
+
+		if a.Column[i] != item {
+			item += " AS " + a.Column[i]
+		}
+
+		items = append(items, item)
+	}
+
+	text := "SELECT " + strings.Join(items, ", ") + " FROM "
+
+	if a.From != nil {
+		text += "(" + a.From.String() + ")"
+	} else {
+		text += a.Table
+	}
+
+	if a.Alias != "" {
+		text += " AS " + a.Alias
+	}
+
+	if a.Where != nil {
+		text += " WHERE " + a.Where.String()
+	}
+
+	if a.Limit != 0 {
+		text += fmt.Sprintf(" LIMIT %d", a.Limit)
+	}
+
+	return text
+}
+
+// scanSelectList reads item [AS alias], ... into Column and Select, it returns
+// the token which follows the last item or "" at the end
+func scanSelectList(ast *Ast, scan *scanner.Scanner) (string, error) {
+	for {
+		var end string
+
+		skipWhitespace(scan)
+
+		if scan.Peek() == '*' {
+			scan.Scan()
+			ast.Column = append(ast.Column, types.QUERY_CHAR_STAR)
+			ast.Select = append(ast.Select, nil)
+
+			if token := scan.Scan(); token != scanner.EOF {
+				end = scan.TokenText()
+			}
+		} else {
+			expr, exprEnd, err := expression.Parse(scan)
+
+			if err != nil {
+				return "", err
+			}
+
+			name := expr.String()
+			end = exprEnd
+
+			// a column is named without its table like in PostgreSQL, so x.id of a derived table is id
+			if ref, ok := expr.(*expression.ColumnRef); ok {
+				name = ref.Name
+			}
+
+			if strings.ToUpper(end) == types.QUERY_CHAR_AS {
+				if token := scan.Scan(); token != scanner.Ident {
+					return "", errors.ErrSyntax
+				}
+
+				name = scan.TokenText()
+				end = ""
+
+				if token := scan.Scan(); token != scanner.EOF {
+					end = scan.TokenText()
+				}
+			}
+
+			ast.Column = append(ast.Column, name)
+			ast.Select = append(ast.Select, expr)
+		}
+
+		if end != types.QUERY_CHAR_COMMA {
+			return end, nil
+		}
+	}
+}
+
+// skipWhitespace moves the scanner to the next character which starts a token
+func skipWhitespace(scan *scanner.Scanner) {
+	for scan.Whitespace&(1<<uint(scan.Peek())) != 0 {
+		scan.Next()
+	}
+}
+
+/*
+
+CREATE TABLE table_name (
+    column1 datatype,
+    column2 datatype,
+    column3 datatype,
+);
+
+CREATE TABLE table_name (
+    column1 datatype PRIMARY KEY,
+    column2 datatype NOT NULL UNIQUE,
+    column3 datatype CONSTRAINT name NOT NULL,
+    column4 datatype REFERENCES other_table [(column)] [ON DELETE action] [ON UPDATE action],
+    column5 datatype DEFAULT expression CHECK (condition),
+    column6 SERIAL | BIGSERIAL,
+    column7 INT | BIGINT AUTO_INCREMENT,
+    UNIQUE (column2, column3),
+    FOREIGN KEY (column1, column2) REFERENCES other_table [(column1, column2)],
+    CHECK (condition)
+);
+
+*/
+func CreateTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.CREATE_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		if strings.ToUpper(scan.TokenText()) == types.QUERY_CHAR_SEQUENCE {
+			return CreateSequenceAst(query, scan)
+		}
+
+		if keyword := strings.ToUpper(scan.TokenText()); keyword == types.QUERY_CHAR_INDEX || keyword == types.QUERY_CHAR_UNIQUE {
+			return CreateIndexAst(query, scan)
+		}
+
+		if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_TABLE {
+			return nil, errors.ErrSyntax
+		}
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		} else {
+			ast.Table = scan.TokenText()
+		}
+	}
+
+	// the column which the following column constraints belong to, none after a comma
+	var columnName, lastColumn string
+	// the token after a DEFAULT expression is scanned already
+	scanned := ""
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		if scan.TokenText() != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
+			return nil, errors.ErrSyntax
+		}
+	}
+
+	for {
+		token := scanned
+
+		if scanned != "" {
+			scanned = ""
+		} else if scan.Scan() == scanner.EOF {
+			return nil, errors.ErrSyntax
+		} else {
+			token = scan.TokenText()
+		}
+
+		if token == "" {
+			return nil, errors.ErrSyntax
+		} else {
+			columnName = token
+
+			if columnName == types.QUERY_CHAR_COMMA {
+				lastColumn = ""
+				continue
+			}
+
+			if columnName == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+				break
+			}
+
+			// AUTO_INCREMENT turns the column into a SERIAL
+			if strings.ToUpper(columnName) == types.QUERY_CHAR_AUTO_INCREMENT {
+				if lastColumn == "" {
+					return nil, errors.ErrSyntax
+				}
+
+				last := len(ast.ColumnType) - 1
+
+				switch ast.ColumnType[last] {
+				case types.COLUMN_TYPE_INT:
+					ast.ColumnType[last] = types.COLUMN_TYPE_SERIAL
+				case types.COLUMN_TYPE_LONGINT:
+					ast.ColumnType[last] = types.COLUMN_TYPE_BIGSERIAL
+				default:
+					return nil, errors.ErrSyntax
+				}
+
+				continue
+			}
+
+			if isConstraint(columnName) {
+				constraint, next, err := scanConstraint(scan, columnName, lastColumn)
+
+				if err != nil {
+					return nil, err
+				}
+
+				if constraint != nil {
+					ast.Constraints = append(ast.Constraints, constraint)
+				}
+
+				scanned = next
+
+				continue
+			}
+
+			if lastColumn != "" {
+				return nil, errors.ErrSyntax
+			}
+
+			if token := scan.Scan(); token == scanner.EOF {
+				return nil, errors.ErrSyntax
+			} else {
+				columnType, err := scanColumnType(scan)
+
+				if err != nil {
+					return nil, err
+				}
+
+				ast.Column = append(ast.Column, columnName)
+				ast.ColumnType = append(ast.ColumnType, columnType)
+				lastColumn = columnName
+			}
+		}
+	}
+
+	return ast, nil
+}
+
+func isConstraint(token string) bool {
+	switch strings.ToUpper(token) {
+	case types.QUERY_CHAR_CONSTRAINT, types.QUERY_CHAR_PRIMARY, types.QUERY_CHAR_UNIQUE, types.QUERY_CHAR_NOT, types.QUERY_CHAR_NULL,
+		types.QUERY_CHAR_FOREIGN, types.QUERY_CHAR_REFERENCES, types.QUERY_CHAR_CHECK, types.QUERY_CHAR_DEFAULT:
+		return true
+	}
+
+	return false
+}
+
+/*
+
+[CONSTRAINT name] PRIMARY KEY | UNIQUE | NOT NULL | NULL | REFERENCES ...   after a column
+[CONSTRAINT name] CHECK (condition) | DEFAULT expression                   after a column
+[CONSTRAINT name] PRIMARY KEY (column1, column2...) | UNIQUE (column1...)   as a table element
+[CONSTRAINT name] FOREIGN KEY (column1, column2...) REFERENCES ...          as a table element
+[CONSTRAINT name] CHECK (condition)                                          as a table element
+
+REFERENCES table_name [(column1...)] [ON DELETE action] [ON UPDATE action]
+action is RESTRICT | NO ACTION | CASCADE | SET NULL | SET DEFAULT
+
+*/
+
+// scanConstraint reads the constraint which starts at the token, a table constraint
+// has no column name and lists its columns, NULL only allows NULL and returns no constraint.
+// next is the token after a DEFAULT, which was scanned to find the end of its expression
+func scanConstraint(scan *scanner.Scanner, token string, columnName string) (constraint *Constraint, next string, err error) {
+	constraint = &Constraint{}
+	keyword := strings.ToUpper(token)
+
+	if keyword == types.QUERY_CHAR_CONSTRAINT {
+		if token := scan.Scan(); token != scanner.Ident {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Name = scan.TokenText()
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, "", errors.ErrSyntax
+		}
+
+		keyword = strings.ToUpper(scan.TokenText())
+	}
+
+	switch keyword {
+	case types.QUERY_CHAR_PRIMARY:
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_KEY {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Type = types.CONSTRAINT_PRIMARY_KEY
+	case types.QUERY_CHAR_UNIQUE:
+		constraint.Type = types.CONSTRAINT_UNIQUE
+	case types.QUERY_CHAR_NOT:
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_NULL {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Type = types.CONSTRAINT_NOT_NULL
+	case types.QUERY_CHAR_NULL:
+		if columnName == "" {
+			return nil, "", errors.ErrSyntax
+		}
+
+		return nil, "", nil
+	case types.QUERY_CHAR_FOREIGN:
+		if token := scan.Scan(); columnName != "" || token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_KEY {
+			return nil, "", errors.ErrSyntax
+		}
+
+		columns, err := scanColumnNames(scan)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_REFERENCES {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Type = types.CONSTRAINT_FOREIGN_KEY
+		constraint.Columns = columns
+
+		return constraint, "", scanReferences(scan, constraint)
+	case types.QUERY_CHAR_REFERENCES:
+		if columnName == "" {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Type = types.CONSTRAINT_FOREIGN_KEY
+		constraint.Columns = []string{columnName}
+
+		return constraint, "", scanReferences(scan, constraint)
+	case types.QUERY_CHAR_CHECK:
+		if token := scan.Scan(); token == scanner.EOF || scan.TokenText() != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
+			return nil, "", errors.ErrSyntax
+		}
+
+		expr, end, err := expression.Parse(scan)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		if end != types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+			return nil, "", errors.ErrSyntax
+		}
+
+		constraint.Type = types.CONSTRAINT_CHECK
+		constraint.Expression = expr
+
+		return constraint, "", nil
+	case types.QUERY_CHAR_DEFAULT:
+		if columnName == "" || constraint.Name != "" {
+			return nil, "", errors.ErrSyntax
+		}
+
+		expr, end, err := expression.Parse(scan)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		constraint.Type = types.CONSTRAINT_DEFAULT
+		constraint.Columns = []string{columnName}
+		constraint.Expression = expr
+
+		return constraint, end, nil
+	default:
+		return nil, "", errors.ErrSyntax
+	}
+
+	if columnName != "" {
+		constraint.Columns = []string{columnName}
+		return constraint, "", nil
+	}
+
+	if constraint.Type == types.CONSTRAINT_NOT_NULL {
+		return nil, "", errors.ErrSyntax
+	}
+
+	columns, err := scanColumnNames(scan)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	constraint.Columns = columns
+
+	return constraint, "", nil
+}
+
+// scanReferences reads what follows REFERENCES, the referenced columns and the
+// actions are optional so the scanner only peeks at the next token for them
+func scanReferences(scan *scanner.Scanner, constraint *Constraint) error {
+	if token := scan.Scan(); token != scanner.Ident {
+		return errors.ErrSyntax
+	}
+
+	constraint.RefTable = scan.TokenText()
+
+	if peekToken(scan) == '(' {
+		columns, err := scanColumnNames(scan)
+
+		if err != nil {
+			return err
+		}
+
+		constraint.RefColumns = columns
+	}
+
+	// ON is the only word which may follow that starts with O
+	for peek := peekToken(scan); peek == 'O' || peek == 'o'; peek = peekToken(scan) {
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_ON {
+			return errors.ErrSyntax
+		}
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return errors.ErrSyntax
+		}
+
+		event := strings.ToUpper(scan.TokenText())
+		action, err := scanAction(scan)
+
+		if err != nil {
+			return err
+		}
+
+		if event == types.QUERY_CHAR_DELETE && constraint.OnDelete == "" {
+			constraint.OnDelete = action
+		} else if event == types.QUERY_CHAR_UPDATE && constraint.OnUpdate == "" {
+			constraint.OnUpdate = action
+		} else {
+			return errors.ErrSyntax
+		}
+	}
+
+	return nil
+}
+
+func scanAction(scan *scanner.Scanner) (string, error) {
+	if token := scan.Scan(); token == scanner.EOF {
+		return "", errors.ErrSyntax
+	}
+
+	first := strings.ToUpper(scan.TokenText())
+
+	switch first {
+	case types.QUERY_CHAR_RESTRICT, types.QUERY_CHAR_CASCADE:
+		return first, nil
+	case types.QUERY_CHAR_NO, types.QUERY_CHAR_SET:
+		if token := scan.Scan(); token == scanner.EOF {
+			return "", errors.ErrSyntax
+		}
+
+		action := first + " " + strings.ToUpper(scan.TokenText())
+
+		switch action {
+		case types.FOREIGN_KEY_NO_ACTION, types.FOREIGN_KEY_SET_NULL, types.FOREIGN_KEY_SET_DEFAULT:
+			return action, nil
+		}
+	}
+
+	return "", errors.ErrSyntax
+}
+
+// peekToken skips the white space and returns the first character of the next token
+func peekToken(scan *scanner.Scanner) rune {
+	for scan.Peek() == ' ' || scan.Peek() == '\t' || scan.Peek() == '\n' || scan.Peek() == '\r' {
+		scan.Next()
+	}
+
+	return scan.Peek()
+}
+
+// scanColumnNames reads a bracketed list of column names which is not empty
+func scanColumnNames(scan *scanner.Scanner) ([]string, error) {
+	if token := scan.Scan(); token == scanner.EOF || scan.TokenText() != types.QUERY_CHAR_LEFT_PARE_BRACKETS {
+		return nil, errors.ErrSyntax
+	}
+
+	columns := make([]string, 0)
+
+	for {
+		if token := scan.Scan(); token != scanner.Ident {
+			return nil, errors.ErrSyntax
+		}
+
+		columns = append(columns, scan.TokenText())
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		if scan.TokenText() == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+			return columns, nil
+		}
+
+		if scan.TokenText() != types.QUERY_CHAR_COMMA {
+			return nil, errors.ErrSyntax
+		}
+	}
+}
+
+/*
+
+CREATE [UNIQUE] INDEX [index_name] ON table_name (expression)
+
+*/
+
+// CreateIndexAst keeps the index as a constraint of the type INDEX or UNIQUE INDEX,
+// the scanner is on UNIQUE or INDEX
+func CreateIndexAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type:   types.CREATE_QUERY_TYPE,
+		Action: types.QUERY_CHAR_INDEX,
+	}
+
+	index := &Constraint{Type: types.CONSTRAINT_INDEX}
+
+	if strings.ToUpper(scan.TokenText()) == types.QUERY_CHAR_UNIQUE {
+		index.Type = types.CONSTRAINT_UNIQUE_INDEX
+
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_INDEX {
+			return nil, errors.ErrSyntax
+		}
+	}
+
+	if token := scan.Scan(); token != scanner.Ident {
+		return nil, errors.ErrSyntax
+	}
+
+	if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_ON {
+		index.Name = scan.TokenText()
+
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_ON {
+			return nil, errors.ErrSyntax
+		}
+	}
+
+	if token := scan.Scan(); token != scanner.Ident {
+		return nil, errors.ErrSyntax
+	}
+
+	ast.Table = scan.TokenText()
+
+	// the brackets around the expression are read as part of it
+	skipWhitespace(scan)
+
+	if scan.Peek() != '(' {
+		return nil, errors.ErrSyntax
+	}
+
+	expr, end, err := expression.Parse(scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if end != "" {
+		return nil, errors.ErrSyntax
+	}
+
+	index.Expression = expr
+	ast.Constraints = append(ast.Constraints, index)
+
+	return ast, nil
+}
+
+/*
+
+CREATE SEQUENCE sequence_name
+    [INCREMENT [BY] increment]
+    [MINVALUE minvalue | NO MINVALUE] [MAXVALUE maxvalue | NO MAXVALUE]
+    [START [WITH] start] [[NO] CYCLE]
+
+*/
+func CreateSequenceAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type:     types.CREATE_QUERY_TYPE,
+		Action:   types.QUERY_CHAR_SEQUENCE,
+		Sequence: &SequenceOptions{},
+	}
+
+	if token := scan.Scan(); token != scanner.Ident {
+		return nil, errors.ErrSyntax
+	}
+
+	ast.Sequence.Name = scan.TokenText()
+
+	for token := scan.Scan(); token != scanner.EOF; token = scan.Scan() {
+		var (
+			value *int64
+			err   error
+		)
+
+		switch strings.ToUpper(scan.TokenText()) {
+		case types.QUERY_CHAR_INCREMENT:
+			if value, err = scanSequenceValue(scan, types.QUERY_CHAR_BY); err == nil {
+				ast.Sequence.Increment = value
+			}
+		case types.QUERY_CHAR_START:
+			if value, err = scanSequenceValue(scan, types.QUERY_CHAR_WITH); err == nil {
+				ast.Sequence.Start = value
+			}
+		case types.QUERY_CHAR_MINVALUE:
+			if value, err = scanSequenceValue(scan, ""); err == nil {
+				ast.Sequence.MinValue = value
+			}
+		case types.QUERY_CHAR_MAXVALUE:
+			if value, err = scanSequenceValue(scan, ""); err == nil {
+				ast.Sequence.MaxValue = value
+			}
+		case types.QUERY_CHAR_CYCLE:
+			ast.Sequence.Cycle = true
+		case types.QUERY_CHAR_NO:
+			if token := scan.Scan(); token == scanner.EOF {
+				return nil, errors.ErrSyntax
+			}
+
+			switch strings.ToUpper(scan.TokenText()) {
+			case types.QUERY_CHAR_MINVALUE:
+				ast.Sequence.MinValue = nil
+			case types.QUERY_CHAR_MAXVALUE:
+				ast.Sequence.MaxValue = nil
+			case types.QUERY_CHAR_CYCLE:
+				ast.Sequence.Cycle = false
+			default:
+				return nil, errors.ErrSyntax
+			}
+		default:
+			return nil, errors.ErrSyntax
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ast, nil
+}
+
+// scanSequenceValue reads a number which may have a sign and may follow the optional keyword
+func scanSequenceValue(scan *scanner.Scanner, keyword string) (*int64, error) {
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	if keyword != "" && strings.ToUpper(scan.TokenText()) == keyword {
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+	}
+
+	text := scan.TokenText()
+
+	if text == types.QUERY_CHAR_MINUS {
+		if token := scan.Scan(); token != scanner.Int {
+			return nil, errors.ErrSyntax
+		}
+
+		text += scan.TokenText()
+	}
+
+	value, err := strconv.ParseInt(text, 10, 64)
+
+	if err != nil {
+		return nil, errors.ErrSyntax
+	}
+
+	return &value, nil
+}
+
+/*
+
+DROP TABLE table_name
+DROP TABLE IF EXISTS table_name
+DROP SEQUENCE [IF EXISTS] sequence_name
+DROP INDEX [IF EXISTS] index_name
+
+*/
+func DropTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.DROP_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		switch strings.ToUpper(scan.TokenText()) {
+		case types.QUERY_CHAR_TABLE:
+		case types.QUERY_CHAR_SEQUENCE:
+			ast.Action = types.QUERY_CHAR_SEQUENCE
+		case types.QUERY_CHAR_INDEX:
+			ast.Action = types.QUERY_CHAR_INDEX
+		default:
+			return nil, errors.ErrSyntax
+		}
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		tokenString := scan.TokenText()
+
+		if strings.ToUpper(tokenString) == types.QUERY_CHAR_IF {
+			if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_EXISTS {
+				return nil, errors.ErrSyntax
+			}
+
+			if token := scan.Scan(); token == scanner.EOF {
+				return nil, errors.ErrSyntax
+			}
+
+			ast.IfExists = true
+			tokenString = scan.TokenText()
+		}
+
+		if ast.Action == types.QUERY_CHAR_SEQUENCE {
+			ast.Sequence = &SequenceOptions{Name: tokenString}
+		} else if ast.Action == types.QUERY_CHAR_INDEX {
+			ast.Constraints = []*Constraint{{Name: tokenString}}
+		} else {
+			ast.Table = tokenString
+		}
+	}
+
+	return ast, nil
+}
+
+/*
+
+TRUNCATE TABLE table_name
+TRUNCATE table_name
+
+*/
+func TruncateTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.TRUNCATE_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		tokenString := scan.TokenText()
+
+		if strings.ToUpper(tokenString) == types.QUERY_CHAR_TABLE {
+			if token := scan.Scan(); token == scanner.EOF {
+				return nil, errors.ErrSyntax
+			}
+
+			tokenString = scan.TokenText()
+		}
+
+		ast.Table = tokenString
+	}
+
+	return ast, nil
+}
+
+/*
+
+ANALYZE [TABLE] [table_name]
+
+*/
+
+// AnalyzeTableAst leaves the table empty when every table should be analyzed
+func AnalyzeTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.ANALYZE_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return ast, nil
+	}
+
+	tokenString := scan.TokenText()
+
+	if strings.ToUpper(tokenString) == types.QUERY_CHAR_TABLE {
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		tokenString = scan.TokenText()
+	}
+
+	ast.Table = tokenString
+
+	return ast, nil
+}
+
+/*
+
+SHOW TABLES
+SHOW COLUMNS FROM table_name
+SHOW INDEXES [FROM table_name]
+
+*/
+func ShowAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.SHOW_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	switch strings.ToUpper(scan.TokenText()) {
+	case types.QUERY_CHAR_TABLES:
+		ast.Action = types.SHOW_TABLES
+
+		return ast, nil
+	case types.QUERY_CHAR_COLUMNS:
+		ast.Action = types.SHOW_COLUMNS
+	case types.QUERY_CHAR_INDEX, types.QUERY_CHAR_INDEXES:
+		ast.Action = types.SHOW_INDEXES
+	default:
+		return nil, errors.ErrSyntax
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		if ast.Action == types.SHOW_INDEXES {
+			return ast, nil
+		}
+
+		return nil, errors.ErrSyntax
+	}
+
+	if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_FROM {
+		return nil, errors.ErrSyntax
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	tableName, err := scanTableName(scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ast.Table = tableName
+
+	return ast, nil
+}
+
+/*
+
+DESCRIBE table_name
+DESC table_name
+
+*/
+func DescribeAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type:   types.SHOW_QUERY_TYPE,
+		Action: types.SHOW_COLUMNS,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	tableName, err := scanTableName(scan)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ast.Table = tableName
+
+	return ast, nil
+}
+
+/*
+
+ALTER TABLE table_name ADD [COLUMN] column_name datatype [column constraints like CREATE TABLE]
+ALTER TABLE table_name DROP [COLUMN] column_name
+ALTER TABLE table_name RENAME [COLUMN] column_name TO new_column_name
+ALTER TABLE table_name RENAME TO new_table_name
+ALTER TABLE table_name ALTER [COLUMN] column_name TYPE datatype
+
+*/
+func AlterTableAst(query string, scan *scanner.Scanner) (*Ast, error) {
+	ast := &Ast{
+		Type: types.ALTER_QUERY_TYPE,
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	} else {
+		if strings.ToUpper(scan.TokenText()) == types.QUERY_CHAR_SEQUENCE {
+			return CreateSequenceAst(query, scan)
+		}
+
+		if strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_TABLE {
+			return nil, errors.ErrSyntax
+		}
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		} else {
+			ast.Table = scan.TokenText()
+		}
+	}
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	action := strings.ToUpper(scan.TokenText())
+
+	if token := scan.Scan(); token == scanner.EOF {
+		return nil, errors.ErrSyntax
+	}
+
+	tokenString := scan.TokenText()
+
+	// RENAME TO is the only action which is not followed by a column name
+	if action == types.QUERY_CHAR_RENAME && strings.ToUpper(tokenString) == types.QUERY_CHAR_TO {
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		ast.Action = types.ALTER_RENAME_TABLE
+		ast.NewName = scan.TokenText()
+
+		return ast, nil
+	}
+
+	if strings.ToUpper(tokenString) == types.QUERY_CHAR_COLUMN {
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		tokenString = scan.TokenText()
+	}
+
+	ast.Column = append(ast.Column, tokenString)
+
+	switch action {
+	case types.QUERY_CHAR_ADD:
+		ast.Action = types.ALTER_ADD_COLUMN
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		columnType, err := scanColumnType(scan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ast.ColumnType = append(ast.ColumnType, columnType)
+
+		return scanColumnConstraints(ast, scan, tokenString)
+	case types.QUERY_CHAR_DROP:
+		ast.Action = types.ALTER_DROP_COLUMN
+	case types.QUERY_CHAR_RENAME:
+		ast.Action = types.ALTER_RENAME_COLUMN
+
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_TO {
+			return nil, errors.ErrSyntax
+		}
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		ast.NewName = scan.TokenText()
+	case types.QUERY_CHAR_ALTER:
+		ast.Action = types.ALTER_COLUMN_TYPE
+
+		if token := scan.Scan(); token == scanner.EOF || strings.ToUpper(scan.TokenText()) != types.QUERY_CHAR_TYPE {
+			return nil, errors.ErrSyntax
+		}
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return nil, errors.ErrSyntax
+		}
+
+		columnType, err := scanColumnType(scan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ast.ColumnType = append(ast.ColumnType, columnType)
+	default:
+		return nil, errors.ErrSyntax
+	}
+
+	return ast, nil
+}
+
+// scanColumnConstraints reads the constraints of an added column with the parser of CREATE TABLE
+// up to the end of the query
+func scanColumnConstraints(ast *Ast, scan *scanner.Scanner, columnName string) (*Ast, error) {
+	token := ""
+
+	if scan.Scan() != scanner.EOF {
+		token = scan.TokenText()
+	}
+
+	for token != "" && token != types.QUERY_CHAR_SEMICOLON {
+		if !isConstraint(token) {
+			return nil, errors.ErrSyntax
+		}
+
+		constraint, next, err := scanConstraint(scan, token, columnName)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if constraint != nil {
+			ast.Constraints = append(ast.Constraints, constraint)
+		}
+
+		// the token after a DEFAULT expression is scanned already
+		if token = next; token == "" && scan.Scan() != scanner.EOF {
+			token = scan.TokenText()
+		}
+	}
+
+	return ast, nil
+}
+
+// scanTableName joins a schema qualified name such as information_schema.tables,
+// the scanner has to be on the first part of the name
+func scanTableName(scan *scanner.Scanner) (string, error) {
+	tableName := scan.TokenText()
+
+	if scan.Peek() != '.' {
+		return tableName, nil
+	}
+
+	scan.Scan()
+
+	if token := scan.Scan(); token != scanner.Ident {
+		return "", errors.ErrSyntax
+	}
+
+	return tableName + types.QUERY_CHAR_DOT + scan.TokenText(), nil
+}
+
+// scanColumnType reads the data type at the current token,
+// VARCHAR may be followed by its size in brackets
+func scanColumnType(scan *scanner.Scanner) (string, error) {
+	columnType := checkColumnTypeIsValid(scan.TokenText())
+
+	if columnType == types.COLUMN_TYPE_INVALID {
+		return "", errors.ErrSyntax
+	}
+
+	// DOUBLE PRECISION is FLOAT
+	if columnType == types.COLUMN_TYPE_DOUBLE {
+		if token := scan.Scan(); token != scanner.Ident || strings.ToUpper(scan.TokenText()) != types.COLUMN_TYPE_PRECISION {
+			return "", errors.ErrSyntax
+		}
+
+		return types.COLUMN_TYPE_FLOAT, nil
+	}
+
+	switch columnType {
+	case types.COLUMN_TYPE_VAR_CHAR, types.COLUMN_TYPE_JSON, types.COLUMN_TYPE_DECIMAL, types.COLUMN_TYPE_CHAR, types.COLUMN_TYPE_BYTEA:
+	default:
+		return columnType, nil
+	}
+
+	for scan.Peek() == ' ' || scan.Peek() == '\t' || scan.Peek() == '\n' {
+		scan.Next()
+	}
+
+	if scan.Peek() != '(' {
+		return columnType, nil
+	}
+
+	scan.Scan()
+
+	// VARCHAR, CHAR, BYTEA and JSON take their size, DECIMAL its precision and optionally its scale
+	sizes := make([]int, 0, 2)
+
+	for {
+		if token := scan.Scan(); token == scanner.EOF {
+			return "", errors.ErrSyntax
+		}
+
+		size, err := strconv.Atoi(scan.TokenText())
+
+		if err != nil {
+			return "", errors.ErrSyntax
+		}
+
+		sizes = append(sizes, size)
+
+		if token := scan.Scan(); token == scanner.EOF {
+			return "", errors.ErrSyntax
+		}
+
+		if scan.TokenText() == types.QUERY_CHAR_RIGHT_PARE_BRACKETS {
+			break
+		}
+
+		if scan.TokenText() != types.QUERY_CHAR_COMMA || columnType != types.COLUMN_TYPE_DECIMAL || len(sizes) == 2 {
+			return "", errors.ErrSyntax
+		}
+	}
+
+	if columnType != types.COLUMN_TYPE_DECIMAL {
+		return fmt.Sprintf("%s(%d)", columnType, sizes[0]), nil
+	}
+
+	// a DECIMAL without precision is written without brackets
+	if sizes[0] < 1 {
+		return "", errors.ErrInvalidDecimal
+	}
+
+	if len(sizes) == 1 {
+		sizes = append(sizes, 0)
+	}
+
+	return fmt.Sprintf("%s(%d,%d)", types.COLUMN_TYPE_DECIMAL, sizes[0], sizes[1]), nil
+}
+
 func checkColumnTypeIsValid(columnType string) string {
 
 	upperCaseColumn := strings.ToUpper(columnType)
@@ -274,12 +1754,40 @@ func checkColumnTypeIsValid(columnType string) string {
 	switch upperCaseColumn {
 	case types.COLUMN_TYPE_BOOL:
 		return types.COLUMN_TYPE_BOOL
-	case types.COLUMN_TYPE_FLOAT:
+	case types.COLUMN_TYPE_FLOAT, types.COLUMN_TYPE_FLOAT8:
 		return types.COLUMN_TYPE_FLOAT
+	case types.COLUMN_TYPE_DOUBLE:
+		return types.COLUMN_TYPE_DOUBLE
+	case types.COLUMN_TYPE_REAL, types.COLUMN_TYPE_FLOAT4:
+		return types.COLUMN_TYPE_REAL
+	case types.COLUMN_TYPE_SMALLINT, types.COLUMN_TYPE_INT2:
+		return types.COLUMN_TYPE_SMALLINT
+	case types.COLUMN_TYPE_UUID:
+		return types.COLUMN_TYPE_UUID
+	case types.COLUMN_TYPE_BYTEA:
+		return types.COLUMN_TYPE_BYTEA
+	case types.COLUMN_TYPE_CHAR, types.COLUMN_TYPE_CHARACTER:
+		return types.COLUMN_TYPE_CHAR
 	case types.COLUMN_TYPE_INT:
 		return types.COLUMN_TYPE_INT
 	case types.COLUMN_TYPE_LONGINT:
 		return types.COLUMN_TYPE_LONGINT
+	case types.COLUMN_TYPE_SERIAL:
+		return types.COLUMN_TYPE_SERIAL
+	case types.COLUMN_TYPE_BIGSERIAL:
+		return types.COLUMN_TYPE_BIGSERIAL
+	case types.COLUMN_TYPE_DATE:
+		return types.COLUMN_TYPE_DATE
+	case types.COLUMN_TYPE_TIME:
+		return types.COLUMN_TYPE_TIME
+	case types.COLUMN_TYPE_TIMESTAMP:
+		return types.COLUMN_TYPE_TIMESTAMP
+	case types.COLUMN_TYPE_INTERVAL:
+		return types.COLUMN_TYPE_INTERVAL
+	case types.COLUMN_TYPE_DECIMAL, types.COLUMN_TYPE_NUMERIC:
+		return types.COLUMN_TYPE_DECIMAL
+	case types.COLUMN_TYPE_JSON, types.COLUMN_TYPE_JSONB:
+		return types.COLUMN_TYPE_JSON
 	}
 
 	if strings.HasPrefix(upperCaseColumn, types.COLUMN_TYPE_VAR_CHAR) {
@@ -1,6 +1,8 @@
 package ast
 
 import (
+	"go-db/internal/catalog/expression"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
 	"reflect"
 	"strings"
@@ -129,6 +131,267 @@ func Test_InsertAst(t *testing.T) {
 
 }
 
+func Test_InsertRowsAst(t *testing.T) {
+	// the values are expressions, DEFAULT is kept as nil
+	tests := []struct {
+		query   string
+		columns []string
+		rows    [][]string
+	}{
+		{"INSERT INTO t (a, b) VALUES (1, 'x'), (2, DEFAULT);", []string{"a", "b"}, [][]string{{"1", `"x"`}, {"2", "DEFAULT"}}},
+		{"INSERT INTO t VALUES (1, NULL)", nil, [][]string{{"1", "NULL"}}},
+		{"INSERT INTO t VALUES (-2, 'x, y'), (1 + 1, now())", nil, [][]string{{"(-2)", `"x, y"`}, {"(1 + 1)", "now()"}}},
+		{"INSERT INTO t VALUES ((SELECT max(a) FROM s), upper('a'))", nil, [][]string{{"(SELECT max(a) FROM s)", `upper("a")`}}},
+	}
+
+	for _, test := range tests {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(test.query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		ast, err := InsertAst(test.query, &s)
+
+		if err != nil {
+			t.Fatal(test.query, err)
+		}
+
+		rows := make([][]string, 0, len(ast.Rows))
+
+		for _, row := range ast.Rows {
+			texts := make([]string, 0, len(row))
+
+			for _, expr := range row {
+				if expr == nil {
+					texts = append(texts, "DEFAULT")
+				} else {
+					texts = append(texts, expr.String())
+				}
+			}
+
+			rows = append(rows, texts)
+		}
+
+		if !reflect.DeepEqual(ast.Column, test.columns) || !reflect.DeepEqual(rows, test.rows) || ast.Query != nil {
+			t.Error("get the wrong rows", test.query, ast.Column, rows)
+		}
+	}
+
+	query := "INSERT INTO t (a) SELECT id * 2 AS a FROM s WHERE id > 1"
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := InsertAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.Query == nil || ast.Query.Table != "s" || !reflect.DeepEqual(ast.Query.Column, []string{"a"}) || ast.Query.Where == nil || len(ast.Rows) != 0 {
+		t.Error("get the wrong SELECT", ast.Query)
+	}
+
+	for _, query := range []string{
+		"INSERT INTO t VALUES (1), 2",
+		"INSERT INTO t VALUES (1) (2)",
+		"INSERT INTO t VALUES (1),",
+		"INSERT INTO t VALUES ()",
+		"INSERT INTO t VALUES (1 +)",
+		"INSERT INTO t VALUES (1 2)",
+		"INSERT INTO t (a) (1)",
+		"INSERT INTO t SELECT FROM",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		if _, err := InsertAst(query, &s); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
+
+func Test_OnConflictAst(t *testing.T) {
+	parse := func(query string) (*Ast, error) {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		return InsertAst(query, &s)
+	}
+
+	ast, err := parse("INSERT INTO t (a, b) VALUES (1, 2), (3, 4) ON CONFLICT (a) DO UPDATE SET b = EXCLUDED.b + t.b WHERE t.b < 10")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	update := ast.OnConflict.Update
+
+	if len(ast.Rows) != 2 || !reflect.DeepEqual(ast.OnConflict.Columns, []string{"a"}) || update == nil ||
+		!reflect.DeepEqual(update.Column, []string{"b"}) || update.Set[0].String() != "(EXCLUDED.b + t.b)" || update.Where.String() != "(t.b < 10)" {
+		t.Error("get the wrong ON CONFLICT", ast.OnConflict)
+	}
+
+	ast, err = parse("INSERT INTO t SELECT a, b FROM s WHERE a > 1 ON CONFLICT ON CONSTRAINT t_pkey DO NOTHING;")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.Query == nil || ast.Query.Where == nil || ast.OnConflict.Constraint != "t_pkey" || ast.OnConflict.Update != nil {
+		t.Error("get the wrong ON CONFLICT", ast.OnConflict)
+	}
+
+	if ast, err := parse("INSERT INTO t VALUES (1) ON CONFLICT DO NOTHING"); err != nil || ast.OnConflict == nil || len(ast.OnConflict.Columns) != 0 {
+		t.Error("ON CONFLICT without a target should be parsed", err)
+	}
+
+	for _, query := range []string{
+		"INSERT INTO t VALUES (1) ON CONFLICT DO UPDATE SET a = 1",
+		"INSERT INTO t VALUES (1) ON CONFLICT (a) DO",
+		"INSERT INTO t VALUES (1) ON CONFLICT (a) DO NOTHING extra",
+		"INSERT INTO t VALUES (1) ON CONFLICT (a) DO UPDATE a = 1",
+		"INSERT INTO t VALUES (1) ON CONFLICT (a DO NOTHING",
+		"INSERT INTO t VALUES (1) ON DUPLICATE KEY",
+		"INSERT INTO t SELECT a FROM s LIMIT 1 extra",
+	} {
+		if _, err := parse(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
+
+func Test_ReturningAst(t *testing.T) {
+	parse := func(query string) (*Ast, error) {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		switch strings.ToUpper(s.TokenText()) {
+		case types.INSERT_QUERY_TYPE:
+			return InsertAst(query, &s)
+		case types.UPDATE_QUERY_TYPE:
+			return UpdateAst(query, &s)
+		}
+
+		return DeleteAst(query, &s)
+	}
+
+	tests := map[string][]string{
+		"INSERT INTO t (a) VALUES (1), (2) RETURNING *":                                  {"*"},
+		"INSERT INTO t VALUES (1) ON CONFLICT DO NOTHING RETURNING a, b":                 {"a", "b"},
+		"INSERT INTO t VALUES (1) ON CONFLICT (a) DO UPDATE SET b = 1 RETURNING a AS id": {"id"},
+		"INSERT INTO t SELECT a FROM s WHERE a > 1 RETURNING a + 1;":                     {"(a + 1)"},
+		"UPDATE t SET a = a + 1 WHERE b = 2 RETURNING t.a, b AS c":                       {"a", "c"},
+		"UPDATE t SET a = 1 RETURNING *, a":                                              {"*", "a"},
+		"DELETE FROM t WHERE a = 1 RETURNING upper(b) AS name":                           {"name"},
+		"DELETE FROM t RETURNING *":                                                      {"*"},
+	}
+
+	for query, names := range tests {
+		ast, err := parse(query)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", query, err)
+			continue
+		}
+
+		if ast.Returning == nil || !reflect.DeepEqual(ast.Returning.Column, names) || ast.Returning.Table != "t" {
+			t.Errorf("%s should return %v, got %v", query, names, ast.Returning)
+		}
+	}
+
+	if ast, err := parse("UPDATE t SET a = 1 WHERE b = 2"); err != nil || ast.Returning != nil || ast.Where == nil {
+		t.Error("UPDATE without RETURNING should return nothing", err)
+	}
+
+	for _, query := range []string{
+		"INSERT INTO t VALUES (1) RETURNING",
+		"INSERT INTO t VALUES (1) RETURNING a b",
+		"UPDATE t SET a = 1 WHERE a = 2 RETURNING a FROM t",
+		"DELETE FROM t RETURNING a,",
+		"DELETE FROM t WHERE a = 1 extra",
+	} {
+		if _, err := parse(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
+
+func Test_SubqueryAst(t *testing.T) {
+	parse := func(query string) (*Ast, error) {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		return SelectAst(query, &s)
+	}
+
+	ast, err := parse("SELECT name, (SELECT max(pay) FROM pay p WHERE p.id = e.id) AS top FROM emp e WHERE dept IN (SELECT id FROM dept WHERE open) AND NOT EXISTS (SELECT * FROM ban WHERE ban.id = e.id LIMIT 1)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.Table != "emp" || ast.Alias != "e" || !reflect.DeepEqual(ast.Column, []string{"name", "top"}) {
+		t.Error("get the wrong table or columns", ast.Table, ast.Alias, ast.Column)
+	}
+
+	subquery, ok := ast.Select[1].(*expression.Subquery)
+
+	if !ok || subquery.Query.(*Ast).Alias != "p" || subquery.Query.String() != "SELECT max(pay) FROM pay AS p WHERE (p.id = e.id)" {
+		t.Error("get the wrong scalar subquery", ast.Select[1])
+	}
+
+	where := "((dept IN (SELECT id FROM dept WHERE open)) AND (NOT EXISTS (SELECT * FROM ban WHERE (ban.id = e.id) LIMIT 1)))"
+
+	if ast.Where.String() != where {
+		t.Error("get the wrong condition", ast.Where.String())
+	}
+
+	// the printed SELECT reads back the same
+	if again, err := parse(ast.String()); err != nil || again.String() != ast.String() {
+		t.Error("the printed SELECT should read back", ast.String(), err)
+	}
+
+	ast, err = parse("SELECT total FROM (SELECT id, price * 2 AS total FROM items WHERE id > 1) AS doubled WHERE total > 10 LIMIT 3")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.From == nil || ast.Table != "doubled" || ast.Alias != "doubled" || ast.Limit != 3 ||
+		!reflect.DeepEqual(ast.From.Column, []string{"id", "total"}) || ast.From.Where == nil {
+		t.Error("get the wrong derived table", ast.From)
+	}
+
+	if ast, err := parse("SELECT * FROM (SELECT * FROM items) d"); err != nil || ast.Alias != "d" {
+		t.Error("an alias does not need AS", err)
+	}
+
+	for _, query := range []string{
+		"SELECT * FROM (SELECT * FROM items)",
+		"SELECT * FROM (SELECT * FROM items) WHERE id = 1",
+		"SELECT * FROM (SELECT * FROM items AS",
+		"SELECT * FROM (items) AS i",
+		"SELECT * FROM items AS",
+		"SELECT * FROM items i j",
+		"SELECT * FROM items WHERE id IN (SELECT id FROM other",
+		"SELECT * FROM items WHERE EXISTS (SELECT FROM other)",
+		"SELECT (SELECT id FROM other) extra FROM items",
+	} {
+		if _, err := parse(query); err == nil {
+			t.Error(query, "should not be parsed")
+		}
+	}
+}
+
 func Test_CreateAst(t *testing.T) {
 	query := "CREATE TABLE table_name (column1 VARCHAR(10),column2 int,column3 bool, column4 BIGINT, column5 float);"
 
@@ -161,3 +424,565 @@ func Test_CreateAst(t *testing.T) {
 		t.Error("get the wrong column type", ast.ColumnType)
 	}
 }
+
+func Test_CreateAstConstraint(t *testing.T) {
+	query := "CREATE TABLE table_name (id INT PRIMARY KEY, name VARCHAR(10) NOT NULL UNIQUE, note VARCHAR(10) NULL, " +
+		"a INT CONSTRAINT a_not_null NOT NULL, b INT, CONSTRAINT a_b_key UNIQUE (a, b))"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := CreateTableAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ast.Column, []string{"id", "name", "note", "a", "b"}) {
+		t.Error("get the wrong column name", ast.Column)
+	}
+
+	expected := []*Constraint{
+		{Type: types.CONSTRAINT_PRIMARY_KEY, Columns: []string{"id"}},
+		{Type: types.CONSTRAINT_NOT_NULL, Columns: []string{"name"}},
+		{Type: types.CONSTRAINT_UNIQUE, Columns: []string{"name"}},
+		{Name: "a_not_null", Type: types.CONSTRAINT_NOT_NULL, Columns: []string{"a"}},
+		{Name: "a_b_key", Type: types.CONSTRAINT_UNIQUE, Columns: []string{"a", "b"}},
+	}
+
+	if !reflect.DeepEqual(ast.Constraints, expected) {
+		t.Error("get the wrong constraints", ast.Constraints)
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE t (id INT PRIMARY)",
+		"CREATE TABLE t (id INT, NOT NULL (id))",
+		"CREATE TABLE t (id INT, PRIMARY KEY ())",
+		"CREATE TABLE t (id INT, UNIQUE id)",
+		"CREATE TABLE t (id INT UNIQUE name INT)",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := CreateTableAst(query, &s); err != errors.ErrSyntax {
+			t.Error("should be syntax error", query)
+		}
+	}
+}
+
+func Test_CreateAstForeignKey(t *testing.T) {
+	query := "CREATE TABLE child (id INT REFERENCES parent ON DELETE CASCADE ON UPDATE SET NULL, a INT, b INT, " +
+		"CONSTRAINT child_fk FOREIGN KEY (a, b) REFERENCES parent (x, y) on update no action)"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := CreateTableAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []*Constraint{
+		{Type: types.CONSTRAINT_FOREIGN_KEY, Columns: []string{"id"}, RefTable: "parent", OnDelete: types.FOREIGN_KEY_CASCADE, OnUpdate: types.FOREIGN_KEY_SET_NULL},
+		{Name: "child_fk", Type: types.CONSTRAINT_FOREIGN_KEY, Columns: []string{"a", "b"}, RefTable: "parent", RefColumns: []string{"x", "y"}, OnUpdate: types.FOREIGN_KEY_NO_ACTION},
+	}
+
+	if !reflect.DeepEqual(ast.Constraints, expected) || !reflect.DeepEqual(ast.Column, []string{"id", "a", "b"}) {
+		t.Error("get the wrong foreign keys", ast.Constraints)
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE t (id INT REFERENCES)",
+		"CREATE TABLE t (id INT, REFERENCES p)",
+		"CREATE TABLE t (id INT FOREIGN KEY (id) REFERENCES p)",
+		"CREATE TABLE t (id INT, FOREIGN KEY (id) p)",
+		"CREATE TABLE t (id INT REFERENCES p ON DELETE DROP)",
+		"CREATE TABLE t (id INT REFERENCES p ON DELETE CASCADE ON DELETE RESTRICT)",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := CreateTableAst(query, &s); err != errors.ErrSyntax {
+			t.Error("should be syntax error", query, err)
+		}
+	}
+}
+
+func Test_CreateAstCheckDefault(t *testing.T) {
+	query := "CREATE TABLE t (a INT DEFAULT 1 + 2 NOT NULL, b VARCHAR(10) DEFAULT 'x', c INT CONSTRAINT c_positive CHECK (c > 0), CHECK (a < c))"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := CreateTableAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ast.Column, []string{"a", "b", "c"}) || len(ast.Constraints) != 5 {
+		t.Fatal("get the wrong columns", ast.Column, ast.Constraints)
+	}
+
+	expected := []struct {
+		name       string
+		columns    []string
+		expression string
+	}{
+		{"", []string{"a"}, "(1 + 2)"},
+		{"", []string{"a"}, ""},
+		{"", []string{"b"}, `"x"`},
+		{"c_positive", nil, "(c > 0)"},
+		{"", nil, "(a < c)"},
+	}
+
+	for i, c := range ast.Constraints {
+		text := ""
+
+		if c.Expression != nil {
+			text = c.Expression.String()
+		}
+
+		if c.Name != expected[i].name || !reflect.DeepEqual(c.Columns, expected[i].columns) || text != expected[i].expression {
+			t.Error("get the wrong constraint", i, c, text)
+		}
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE t (a INT CHECK a > 0)",
+		"CREATE TABLE t (a INT CHECK (a > 0)",
+		"CREATE TABLE t (a INT, DEFAULT 1)",
+		"CREATE TABLE t (a INT CONSTRAINT d DEFAULT 1)",
+		"CREATE TABLE t (a INT DEFAULT 1",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := CreateTableAst(query, &s); err != errors.ErrSyntax {
+			t.Error("should be syntax error", query, err)
+		}
+	}
+}
+
+func Test_SequenceAst(t *testing.T) {
+	query := "CREATE SEQUENCE ids INCREMENT BY -2 MINVALUE -100 NO MAXVALUE START WITH -1 CYCLE"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := CreateTableAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	options := ast.Sequence
+
+	if ast.Action != types.QUERY_CHAR_SEQUENCE || options.Name != "ids" || *options.Increment != -2 || *options.MinValue != -100 ||
+		options.MaxValue != nil || *options.Start != -1 || !options.Cycle {
+		t.Error("get the wrong sequence options", options)
+	}
+
+	query = "CREATE TABLE t (id INT AUTO_INCREMENT, big BIGSERIAL, a INT)"
+
+	s = scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	if ast, err = CreateTableAst(query, &s); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ast.ColumnType, []string{types.COLUMN_TYPE_SERIAL, types.COLUMN_TYPE_BIGSERIAL, types.COLUMN_TYPE_INT}) {
+		t.Error("get the wrong column types", ast.ColumnType)
+	}
+
+	query = "DROP SEQUENCE IF EXISTS ids"
+
+	s = scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	if ast, err = DropTableAst(query, &s); err != nil || ast.Sequence.Name != "ids" || !ast.IfExists || ast.Table != "" {
+		t.Error("get the wrong drop sequence", ast, err)
+	}
+
+	for _, query := range []string{
+		"CREATE SEQUENCE",
+		"CREATE SEQUENCE ids INCREMENT BY",
+		"CREATE SEQUENCE ids START WITH x",
+		"CREATE SEQUENCE ids NO START",
+		"CREATE TABLE t (a VARCHAR(10) AUTO_INCREMENT)",
+		"CREATE TABLE t (a INT, AUTO_INCREMENT)",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := CreateTableAst(query, &s); err != errors.ErrSyntax {
+			t.Error("should be syntax error", query, err)
+		}
+	}
+}
+
+func Test_UpdateDeleteAst(t *testing.T) {
+	query := "UPDATE table_name SET a = a + 1, b = 'x' WHERE id >= 2 AND b IS NULL"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Error = func(*scanner.Scanner, string) {}
+	s.Scan()
+
+	ast, err := UpdateAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.Table != "table_name" || !reflect.DeepEqual(ast.Column, []string{"a", "b"}) || len(ast.Set) != 2 {
+		t.Fatal("update parse wrong", ast.Table, ast.Column)
+	}
+
+	if ast.Set[0].String() != "(a + 1)" || ast.Set[1].String() != "\"x\"" || ast.Where.String() != "((id >= 2) AND (b IS NULL))" {
+		t.Error("update expressions wrong", ast.Set[0], ast.Set[1], ast.Where)
+	}
+
+	for query, where := range map[string]string{
+		"DELETE FROM table_name":                 "",
+		"DELETE FROM table_name WHERE NOT (a=1)": "(NOT (a = 1))",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		ast, err := DeleteAst(query, &s)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ast.Table != "table_name" || (where == "" && ast.Where != nil) || (where != "" && ast.Where.String() != where) {
+			t.Error("delete parse wrong", query)
+		}
+	}
+
+	for _, query := range []string{
+		"UPDATE t a = 1",
+		"UPDATE t SET a 1",
+		"UPDATE t SET a = 1 b = 2",
+		"UPDATE t SET a = 1 WHERE",
+		"UPDATE t SET a = 1 WHERE a = 1 LIMIT 1",
+		"DELETE t",
+		"DELETE FROM t a = 1",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		var err error
+
+		if strings.HasPrefix(query, "UPDATE") {
+			_, err = UpdateAst(query, &s)
+		} else {
+			_, err = DeleteAst(query, &s)
+		}
+
+		if err != errors.ErrSyntax {
+			t.Error("should be syntax error", query, err)
+		}
+	}
+}
+
+func Test_DropTableAst(t *testing.T) {
+	for query, ifExists := range map[string]bool{
+		"DROP TABLE table_name":           false,
+		"drop table if exists table_name": true,
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+
+		if token := s.Scan(); token == scanner.EOF {
+			t.Error("scan wrong")
+		}
+
+		ast, err := DropTableAst(query, &s)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ast.Type != types.DROP_QUERY_TYPE {
+			t.Error("drop get the wrong type")
+		}
+
+		if ast.Table != "table_name" || ast.IfExists != ifExists {
+			t.Error("drop get the wrong table", query)
+		}
+	}
+}
+
+func Test_TruncateTableAst(t *testing.T) {
+	for _, query := range []string{"TRUNCATE TABLE table_name", "truncate table_name"} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+
+		if token := s.Scan(); token == scanner.EOF {
+			t.Error("scan wrong")
+		}
+
+		ast, err := TruncateTableAst(query, &s)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ast.Type != types.TRUNCATE_QUERY_TYPE {
+			t.Error("truncate get the wrong type")
+		}
+
+		if ast.Table != "table_name" {
+			t.Error("truncate get the wrong table", query)
+		}
+	}
+}
+
+func Test_AlterTableAst(t *testing.T) {
+	testCases := []struct {
+		query      string
+		action     string
+		column     []string
+		columnType []string
+		value      []interface{}
+		newName    string
+	}{
+		{"ALTER TABLE table_name ADD COLUMN column1 VARCHAR(10) DEFAULT 'abc'", types.ALTER_ADD_COLUMN, []string{"column1"}, []string{"VARCHAR(10)"}, nil, ""},
+		{"alter table table_name add column1 int default -1", types.ALTER_ADD_COLUMN, []string{"column1"}, []string{"INT"}, nil, ""},
+		{"ALTER TABLE table_name ADD column1 VARCHAR", types.ALTER_ADD_COLUMN, []string{"column1"}, []string{"VARCHAR"}, nil, ""},
+		{"ALTER TABLE table_name DROP COLUMN column1", types.ALTER_DROP_COLUMN, []string{"column1"}, nil, nil, ""},
+		{"ALTER TABLE table_name RENAME COLUMN column1 TO column2", types.ALTER_RENAME_COLUMN, []string{"column1"}, nil, nil, "column2"},
+		{"ALTER TABLE table_name RENAME TO new_table", types.ALTER_RENAME_TABLE, nil, nil, nil, "new_table"},
+		{"ALTER TABLE table_name ALTER COLUMN column1 TYPE BIGINT", types.ALTER_COLUMN_TYPE, []string{"column1"}, []string{"BIGINT"}, nil, ""},
+	}
+
+	for _, testCase := range testCases {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(testCase.query))
+		s.Error = func(*scanner.Scanner, string) {}
+
+		if token := s.Scan(); token == scanner.EOF {
+			t.Error("scan wrong")
+		}
+
+		ast, err := AlterTableAst(testCase.query, &s)
+
+		if err != nil {
+			t.Fatal(testCase.query, err)
+		}
+
+		if ast.Type != types.ALTER_QUERY_TYPE || ast.Table != "table_name" || ast.Action != testCase.action || ast.NewName != testCase.newName {
+			t.Error("alter get the wrong action", testCase.query)
+		}
+
+		if !reflect.DeepEqual(ast.Column, testCase.column) || !reflect.DeepEqual(ast.ColumnType, testCase.columnType) || !reflect.DeepEqual(ast.Value, testCase.value) {
+			t.Error("alter get the wrong column", testCase.query, ast.Column, ast.ColumnType, ast.Value)
+		}
+	}
+
+	// the constraints of an added column are read like in CREATE TABLE
+	for query, expected := range map[string][]string{
+		"ALTER TABLE t ADD COLUMN c VARCHAR(10) DEFAULT 'abc'":             {"DEFAULT c \"abc\""},
+		"alter table t add c int default -1":                               {"DEFAULT c (-1)"},
+		"ALTER TABLE t ADD c TIMESTAMP DEFAULT now() NOT NULL;":            {"DEFAULT c now()", "NOT NULL c"},
+		"ALTER TABLE t ADD c TIMESTAMP DEFAULT CURRENT_TIMESTAMP":          {"DEFAULT c CURRENT_TIMESTAMP"},
+		"ALTER TABLE t ADD c INT NOT NULL CHECK (c > 0)":                   {"NOT NULL c", "CHECK  (c > 0)"},
+		"ALTER TABLE t ADD c INT NULL DEFAULT 1 + 2 CONSTRAINT n NOT NULL": {"DEFAULT c (1 + 2)", "NOT NULL c"},
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Error = func(*scanner.Scanner, string) {}
+		s.Scan()
+
+		ast, err := AlterTableAst(query, &s)
+
+		if err != nil {
+			t.Fatal(query, err)
+		}
+
+		got := make([]string, 0, len(ast.Constraints))
+
+		for _, c := range ast.Constraints {
+			text := c.Type + " " + strings.Join(c.Columns, ",")
+
+			if c.Expression != nil {
+				text += " " + c.Expression.String()
+			}
+
+			got = append(got, text)
+		}
+
+		if !reflect.DeepEqual(got, expected) {
+			t.Error("added column gets the wrong constraints", query, got)
+		}
+	}
+
+	for _, query := range []string{"ALTER TABLE table_name", "ALTER TABLE table_name ADD column1 TEXT", "ALTER TABLE table_name MODIFY column1",
+		"ALTER TABLE t ADD c INT DEFAULT", "ALTER TABLE t ADD c INT NOT", "ALTER TABLE t ADD c INT 5", "ALTER TABLE t ADD c INT CHECK c > 0"} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := AlterTableAst(query, &s); err == nil {
+			t.Error("alter should be syntax error", query)
+		}
+	}
+}
+
+func Test_ShowAst(t *testing.T) {
+	testCases := []struct {
+		query  string
+		action string
+		table  string
+	}{
+		{"SHOW TABLES", types.SHOW_TABLES, ""},
+		{"show columns from table_name", types.SHOW_COLUMNS, "table_name"},
+		{"SHOW INDEXES", types.SHOW_INDEXES, ""},
+		{"SHOW INDEX FROM table_name", types.SHOW_INDEXES, "table_name"},
+		{"DESCRIBE table_name", types.SHOW_COLUMNS, "table_name"},
+		{"DESC information_schema.columns", types.SHOW_COLUMNS, types.INFORMATION_SCHEMA_COLUMNS},
+	}
+
+	for _, testCase := range testCases {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(testCase.query))
+
+		if token := s.Scan(); token == scanner.EOF {
+			t.Error("scan wrong")
+		}
+
+		var (
+			ast *Ast
+			err error
+		)
+
+		if strings.ToUpper(s.TokenText()) == types.SHOW_QUERY_TYPE {
+			ast, err = ShowAst(testCase.query, &s)
+		} else {
+			ast, err = DescribeAst(testCase.query, &s)
+		}
+
+		if err != nil {
+			t.Fatal(testCase.query, err)
+		}
+
+		if ast.Type != types.SHOW_QUERY_TYPE || ast.Action != testCase.action || ast.Table != testCase.table {
+			t.Error("show parse wrong", testCase.query, ast.Action, ast.Table)
+		}
+	}
+
+	for _, query := range []string{"SHOW", "SHOW USERS", "SHOW COLUMNS", "SHOW COLUMNS table_name"} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := ShowAst(query, &s); err == nil {
+			t.Error("invalid show should fail", query)
+		}
+	}
+
+	query := "SELECT * FROM information_schema.tables LIMIT 1"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := SelectAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ast.Table != types.INFORMATION_SCHEMA_TABLES || ast.Limit != 1 {
+		t.Error("qualified table name wrong", ast.Table, ast.Limit)
+	}
+}
+
+func Test_SelectAstExpression(t *testing.T) {
+	query := "SELECT id, doc ->> 'name' AS name, * FROM people WHERE doc ->> 'city' = 'Oslo' LIMIT 2"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := SelectAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(ast.Column, []string{"id", "name", "*"}) || ast.Select[2] != nil {
+		t.Error("get the wrong select list", ast.Column)
+	}
+
+	if ast.Where == nil || ast.Limit != 2 {
+		t.Error("get the wrong condition or limit", ast.Where, ast.Limit)
+	}
+
+	for _, query := range []string{
+		"SELECT id FROM people WHERE",
+		"SELECT id FROM people LIMIT x",
+		"SELECT id FROM people ORDER",
+		"SELECT id name FROM people",
+		"SELECT id AS FROM people",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := SelectAst(query, &s); err == nil {
+			t.Error("invalid select should fail", query)
+		}
+	}
+}
+
+func Test_CreateIndexAst(t *testing.T) {
+	query := "CREATE UNIQUE INDEX people_name ON people ((doc ->> 'name'))"
+
+	s := scanner.Scanner{}
+	s.Init(strings.NewReader(query))
+	s.Scan()
+
+	ast, err := CreateTableAst(query, &s)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := ast.Constraints[0]
+
+	if ast.Table != "people" || index.Name != "people_name" || index.Type != types.CONSTRAINT_UNIQUE_INDEX || index.Expression == nil {
+		t.Error("get the wrong index", ast.Table, index)
+	}
+
+	for _, query := range []string{
+		"CREATE INDEX ON people doc",
+		"CREATE INDEX people_name people (doc)",
+		"CREATE UNIQUE people_name ON people (doc)",
+		"CREATE INDEX people_name ON people (doc) x",
+	} {
+		s := scanner.Scanner{}
+		s.Init(strings.NewReader(query))
+		s.Scan()
+
+		if _, err := CreateTableAst(query, &s); err == nil {
+			t.Error("invalid index should fail", query)
+		}
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"fmt"
 	"go-db/internal/buffer"
 	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/constraint"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/sequence"
 	"go-db/internal/catalog/table"
 	"go-db/internal/catalog/tuple"
 	"go-db/internal/common/errors"
@@ -12,6 +15,7 @@ import (
 	"go-db/internal/execution/ast"
 	"go-db/internal/execution/parser"
 	"go-db/internal/storage/disk"
+	"math"
 	"strings"
 )
 
@@ -55,6 +59,48 @@ func (e *Executor) QueryExecutor(query string) ([]byte, error) {
 	} else if ast.Type == types.CREATE_QUERY_TYPE {
 		response, err = e.createQueryExecutor(ast)
 
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.ALTER_QUERY_TYPE {
+		response, err = e.alterQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.DROP_QUERY_TYPE {
+		response, err = e.dropQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.TRUNCATE_QUERY_TYPE {
+		response, err = e.truncateQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.ANALYZE_QUERY_TYPE {
+		response, err = e.analyzeQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.SHOW_QUERY_TYPE {
+		response, err = e.showQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.UPDATE_QUERY_TYPE {
+		response, err = e.updateQueryExecutor(ast)
+
+		if err != nil {
+			return nil, err
+		}
+	} else if ast.Type == types.DELETE_QUERY_TYPE {
+		response, err = e.deleteQueryExecutor(ast)
+
 		if err != nil {
 			return nil, err
 		}
@@ -64,25 +110,283 @@ func (e *Executor) QueryExecutor(query string) ([]byte, error) {
 }
 
 func (e *Executor) selectQueryExecutor(ast *ast.Ast) ([]byte, error) {
-	columns, err := e.tableManager.GetTableMeta(ast.Table)
+	names, values, err := e.selectValues(ast, e.newSubqueries(), nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return getSelectResponse(names, values)
+}
+
+// selectValues returns the names of the selected items and the values of every selected row,
+// * selects all the columns of the table. The outer row is the row of the query around a subquery.
+func (e *Executor) selectValues(ast *ast.Ast, queries *subqueries, outer *expression.Row) ([]string, [][]*tuple.Value, error) {
+	columns, rows, err := e.getSourceRows(ast, queries, outer)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	matched := make([]*expression.Row, 0, len(rows))
+
+	for _, row := range rows {
+		if ok, err := expression.EvaluateCondition(ast.Where, row); err != nil {
+			return nil, nil, err
+		} else if ok {
+			matched = append(matched, row)
+		}
+	}
+
+	return e.finishSelect(ast, queries, columns, matched, outer)
+}
+
+// getSourceRows reads the rows of the table or of the derived table of the SELECT,
+// a column may be qualified with the alias, or with the table name without one
+func (e *Executor) getSourceRows(ast *ast.Ast, queries *subqueries, outer *expression.Row) ([]*column.Column, []*expression.Row, error) {
+	var (
+		columns []*column.Column
+		tuples  [][]*tuple.Value
+		err     error
+	)
+
+	if ast.From != nil {
+		names, values, err := e.selectValues(ast.From, queries, outer)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		columns, tuples = getDerivedColumns(names, values), values
+	} else {
+		if columns, err = e.tableManager.GetTableMeta(ast.Table); err != nil {
+			return nil, nil, err
+		}
+
+		if kept, exist := queries.kept[ast]; exist {
+			tuples = kept
+		} else if tuples, err = e.getSelectTuples(ast); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	name := ast.Table
+
+	if ast.Alias != "" {
+		name = ast.Alias
+	}
+
+	rows := make([]*expression.Row, 0, len(tuples))
+
+	for _, values := range tuples {
+		row := queries.newRow(name, columns, values)
+		row.Outer = outer
+		rows = append(rows, row)
+	}
+
+	return columns, rows, nil
+}
+
+// finishSelect groups the rows when the SELECT list calls an aggregate, then it applies
+// the LIMIT and evaluates the SELECT list
+func (e *Executor) finishSelect(ast *ast.Ast, queries *subqueries, columns []*column.Column, rows []*expression.Row, outer *expression.Row) ([]string, [][]*tuple.Value, error) {
+	if isAggregateSelect(ast) {
+		group, err := expression.Aggregate(ast.Select, rows)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		group.Sequences = e.tableManager
+		group.Queries = queries
+		group.Outer = outer
+		rows = []*expression.Row{group}
+	}
+
+	if ast.Limit != 0 && ast.Limit < len(rows) {
+		rows = rows[:ast.Limit]
+	}
+
+	return projectRows(ast, columns, rows)
+}
+
+// projectRows evaluates the list of a SELECT or of a RETURNING against every row
+func projectRows(ast *ast.Ast, columns []*column.Column, rows []*expression.Row) ([]string, [][]*tuple.Value, error) {
+	names := make([]string, 0, len(ast.Column))
+
+	for i, name := range ast.Column {
+		if ast.Select[i] != nil {
+			names = append(names, name)
+			continue
+		}
+
+		for _, c := range columns {
+			names = append(names, c.Name)
+		}
+	}
+
+	selected := make([][]*tuple.Value, 0, len(rows))
+
+	for _, row := range rows {
+		values := make([]*tuple.Value, 0, len(names))
+
+		for _, expr := range ast.Select {
+			if expr == nil {
+				values = append(values, row.Values...)
+				continue
+			}
+
+			value, err := expr.Evaluate(row)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			values = append(values, value)
+		}
+
+		selected = append(selected, values)
+	}
+
+	return names, selected, nil
+}
+
+// isAggregateSelect reports whether the SELECT list calls an aggregate, the rows are one group then
+func isAggregateSelect(ast *ast.Ast) bool {
+	for _, expr := range ast.Select {
+		if expr != nil && expression.IsAggregate(expr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getSelectTuples reads the rows an index finds for the condition, or else every row,
+// the rows are still checked against the whole condition
+func (e *Executor) getSelectTuples(ast *ast.Ast) ([][]*tuple.Value, error) {
+	rids, found, err := e.findIndexedRows(ast.Table, ast.Where)
 
 	if err != nil {
 		return nil, err
 	}
 
-	tuples, err := e.tableManager.GetTuples(ast.Table)
+	if !found {
+		return e.tableManager.GetTuples(ast.Table)
+	}
+
+	tuples := make([][]*tuple.Value, 0, len(rids))
+
+	for _, rid := range rids {
+		values, err := e.tableManager.GetTuple(ast.Table, rid)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples = append(tuples, values)
+	}
+
+	return tuples, nil
+}
+
+// findIndexedRows uses an expression index when the condition, or a part of it joined with AND,
+// is expression = 'text' and the expression extracts text from a document
+func (e *Executor) findIndexedRows(tableName string, condition expression.Expression) ([]types.RID, bool, error) {
+	b, ok := condition.(*expression.Binary)
+
+	if !ok {
+		return nil, false, nil
+	}
+
+	if b.Operator == expression.OPERATOR_AND {
+		if rids, found, err := e.findIndexedRows(tableName, b.Left); err != nil || found {
+			return rids, found, err
+		}
+
+		return e.findIndexedRows(tableName, b.Right)
+	}
+
+	if b.Operator != expression.OPERATOR_EQUAL {
+		return nil, false, nil
+	}
+
+	for _, operands := range [][]expression.Expression{{b.Left, b.Right}, {b.Right, b.Left}} {
+		literal, ok := operands[1].(*expression.Literal)
+
+		if ok && literal.Value.GetType() == types.VAR_CHAR_TYPE && expression.IsTextExtraction(operands[0]) {
+			return e.tableManager.SearchIndex(tableName, operands[0], literal.Value)
+		}
+	}
+
+	return nil, false, nil
+}
+
+// getReturningResponse returns the RETURNING list of the written rows in the shape of
+// a SELECT response, a statement without RETURNING returns nothing
+func (e *Executor) getReturningResponse(ast *ast.Ast, queries *subqueries, columns []*column.Column, written [][]*tuple.Value) ([]byte, error) {
+	if ast.Returning == nil {
+		return nil, nil
+	}
+
+	rows := make([]*expression.Row, 0, len(written))
+
+	for _, values := range written {
+		rows = append(rows, queries.newRow(ast.Table, columns, values))
+	}
+
+	names, values, err := projectRows(ast.Returning, columns, rows)
 
 	if err != nil {
 		return nil, err
 	}
 
-	if ast.Limit != 0 {
-		tuples = tuples[:ast.Limit]
+	return getSelectResponse(names, values)
+}
+
+// checkReturning fails the statement before it writes when the RETURNING list
+// references something the rows of the table do not have
+func checkReturning(ast *ast.Ast, columns []*column.Column) error {
+	if ast.Returning == nil {
+		return nil
+	}
+
+	for _, expr := range ast.Returning.Select {
+		if expr == nil {
+			continue
+		}
+
+		if err := expression.CheckReferences(expr, ast.Table, columns); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getSelectResponse returns the selected rows as name -> values, an item whose name
+// is used twice is returned once with the values of the last one
+func getSelectResponse(names []string, rows [][]*tuple.Value) ([]byte, error) {
+	jsonMap := make(map[string][]interface{})
+
+	for i, name := range names {
+		values := make([]interface{}, 0, len(rows))
+
+		for _, row := range rows {
+			values = append(values, tuple.GetValueInterface(row[i]))
+		}
+
+		jsonMap[name] = values
 	}
 
+	return json.Marshal(jsonMap)
+}
+
+// getResponse returns the selected columns as column name -> values
+func getResponse(columns []*column.Column, tuples [][]*tuple.Value, selected []string) ([]byte, error) {
 	jsonMap := make(map[string][]interface{})
 
-	for _, v := range ast.Column {
+	for _, v := range selected {
 		if v == types.QUERY_CHAR_STAR {
 			for _, c := range columns {
 				jsonMap[c.Name] = make([]interface{}, 0)
@@ -113,6 +417,8 @@ func (e *Executor) selectQueryExecutor(ast *ast.Ast) ([]byte, error) {
 	return response, nil
 }
 
+// insertQueryExecutor writes every row of VALUES or of the SELECT in one batch, the columns
+// which are not listed or are DEFAULT take their DEFAULT and the others are NULL
 func (e *Executor) insertQueryExecutor(ast *ast.Ast) ([]byte, error) {
 	columns, err := e.tableManager.GetTableMeta(ast.Table)
 
@@ -120,62 +426,746 @@ func (e *Executor) insertQueryExecutor(ast *ast.Ast) ([]byte, error) {
 		return nil, err
 	}
 
+	targets, err := getInsertColumns(columns, ast.Column)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReturning(ast, columns); err != nil {
+		return nil, err
+	}
+
+	var rows [][]*tuple.Value
+
+	queries := e.newSubqueries()
+
+	if ast.Query != nil {
+		rows, err = e.getSelectedRows(ast, queries, columns, targets)
+	} else {
+		rows, err = e.getValuesRows(ast, queries, columns, targets)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if ast.OnConflict != nil {
+		if rows, err = e.insertOnConflict(ast, queries, columns, rows); err != nil {
+			return nil, err
+		}
+
+		return e.getReturningResponse(ast, queries, columns, rows)
+	}
+
+	err = e.tableManager.InsertTuples(ast.Table, rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return e.getReturningResponse(ast, queries, columns, rows)
+}
+
+// insertOnConflict writes the rows one by one so that a row conflicts with the rows before it,
+// DO NOTHING skips a conflicting row and DO UPDATE updates the row which holds the key. The SET
+// and WHERE of DO UPDATE see the proposed row as EXCLUDED. It returns the inserted and the
+// updated rows, the skipped ones are left out.
+//
+// NOT NULL and CHECK of every proposed row are checked before the first write, but the
+// statement is not atomic: a later row which fails a unique constraint that is not an arbiter,
+// a foreign key or the checks of its updated values leaves the rows before it written.
+func (e *Executor) insertOnConflict(ast *ast.Ast, queries *subqueries, columns []*column.Column, rows [][]*tuple.Value) ([][]*tuple.Value, error) {
+	arbiters, err := e.tableManager.GetArbiters(ast.Table, ast.OnConflict.Columns, ast.OnConflict.Constraint)
+
+	if err != nil {
+		return nil, err
+	}
+
 	columMap := make(map[string]int)
-	values := make([]*tuple.Value, len(columns))
 
 	for i, c := range columns {
 		columMap[c.Name] = i
-		values[i] = tuple.GetValue(tuple.GetDefaultValue(c.ColumnType), c.GetColumnType(), c.GetColumnSize())
 	}
 
-	for i, value := range ast.Value {
-		if _, exist := columMap[ast.Column[i]]; !exist {
-			return nil, errors.ErrColumnNotExist
+	update := ast.OnConflict.Update
+
+	if update != nil {
+		for _, name := range update.Column {
+			if _, exist := columMap[name]; !exist {
+				return nil, errors.ErrColumnNotExist
+			}
 		}
-		values[columMap[ast.Column[i]]] = tuple.GetValue(value, columns[columMap[ast.Column[i]]].GetColumnType(), columns[columMap[ast.Column[i]]].GetColumnSize())
 	}
 
-	err = e.tableManager.InsertTuple(ast.Table, values)
-
-	if err != nil {
+	if err := e.tableManager.CheckProposedRows(ast.Table, rows); err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	// the rows the statement inserted or updated, DO UPDATE may not change them again
+	written := make(map[types.RID]bool)
+	result := make([][]*tuple.Value, 0, len(rows))
+
+	for _, values := range rows {
+		var onConflict table.ConflictUpdate
+
+		if update != nil {
+			proposed := values
+
+			onConflict = func(rid types.RID, existing []*tuple.Value) ([]*tuple.Value, error) {
+				if written[rid] {
+					return nil, errors.ErrConflictTwice
+				}
+
+				row := queries.newRow(ast.Table, columns, existing)
+				row.Tables[strings.ToLower(types.QUERY_CHAR_EXCLUDED)] = expression.NewRow(columns, proposed)
+
+				if matched, err := expression.EvaluateCondition(update.Where, row); err != nil || !matched {
+					return nil, err
+				}
+
+				return getUpdatedValues(update, columns, columMap, row)
+			}
+		}
+
+		rid, newValues, _, err := e.tableManager.InsertOrConflict(ast.Table, values, arbiters, onConflict)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if newValues == nil {
+			continue
+		}
+
+		written[rid] = true
+		result = append(result, newValues)
+	}
+
+	return result, nil
 }
 
-func (e *Executor) createQueryExecutor(ast *ast.Ast) ([]byte, error) {
-	tableColumns := make([]*column.Column, len(ast.Column))
+// getInsertColumns returns the positions of the listed columns, all the columns without a list
+func getInsertColumns(columns []*column.Column, names []string) ([]int, error) {
+	targets := make([]int, 0, len(columns))
 
-	for i, col := range ast.Column {
-		typeSize, columnType := int32(0), types.INVALID_TYPE
+	if len(names) == 0 {
+		for i := range columns {
+			targets = append(targets, i)
+		}
+
+		return targets, nil
+	}
+
+	for _, name := range names {
+		index := -1
+
+		for i, c := range columns {
+			if c.Name == name {
+				index = i
+			}
+		}
+
+		if index == -1 {
+			return nil, errors.ErrColumnNotExist
+		}
+
+		targets = append(targets, index)
+	}
+
+	return targets, nil
+}
+
+// getValuesRows evaluates the VALUES lists and converts their values to the types of the columns
+// like a value which is stored by UPDATE, a list may be shorter than the columns of the table when
+// the query does not name the columns
+func (e *Executor) getValuesRows(ast *ast.Ast, queries *subqueries, columns []*column.Column, targets []int) ([][]*tuple.Value, error) {
+	rows := make([][]*tuple.Value, 0, len(ast.Rows))
+
+	// the values can not refer to the columns of the table
+	empty := queries.newRow(ast.Table, nil, nil)
+
+	for _, row := range ast.Rows {
+		if len(row) > len(targets) || (len(row) < len(targets) && len(targets) != len(columns)) {
+			return nil, errors.ErrInsertColumns
+		}
+
+		given := make([]bool, len(columns))
+
+		for i, expr := range row {
+			if expr != nil {
+				given[targets[i]] = true
+			}
+		}
+
+		values, err := e.tableManager.GetDefaultValues(ast.Table, given)
 
-		switch ast.ColumnType[i] {
-		case types.COLUMN_TYPE_BOOL:
-			columnType = types.BOOL_TYPE
-		case types.COLUMN_TYPE_FLOAT:
-			columnType = types.FLOAT_TYPE
-		case types.COLUMN_TYPE_INT:
-			columnType = types.INT_TYPE
-		case types.COLUMN_TYPE_LONGINT:
-			columnType = types.LONG_INT_TYPE
+		if err != nil {
+			return nil, err
 		}
 
-		if columnType == types.INVALID_TYPE {
-			if strings.HasPrefix(ast.ColumnType[i], types.COLUMN_TYPE_VAR_CHAR) {
-				columnType = types.VAR_CHAR_TYPE
-				fmt.Sscanf(ast.ColumnType[i], types.COLUMN_TYPE_VAR_CHAR+"(%d)", &typeSize)
+		for i, expr := range row {
+			index := targets[i]
+
+			if expr == nil {
+				continue
+			}
+
+			value, err := expr.Evaluate(empty)
+
+			if err != nil {
+				return nil, err
+			}
+
+			if values[index], err = tuple.ConvertValue(value, columns[index].GetColumnType(), columns[index].GetColumnSize()); err != nil {
+				return nil, err
 			}
 		}
 
-		tableColumns[i] = column.NewColumn(columnType, typeSize, col)
+		rows = append(rows, values)
 	}
 
-	err := e.tableManager.CreateNewTable(ast.Table, tableColumns)
+	return rows, nil
+}
+
+// getSelectedRows converts the rows of INSERT ... SELECT to the types of the columns
+// like a value which is stored by UPDATE
+func (e *Executor) getSelectedRows(ast *ast.Ast, queries *subqueries, columns []*column.Column, targets []int) ([][]*tuple.Value, error) {
+	names, selected, err := e.selectValues(ast.Query, queries, nil)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return nil, nil
+	if len(names) > len(targets) || (len(names) < len(targets) && len(targets) != len(columns)) {
+		return nil, errors.ErrInsertColumns
+	}
+
+	given := make([]bool, len(columns))
+
+	for i := range names {
+		given[targets[i]] = true
+	}
+
+	rows := make([][]*tuple.Value, 0, len(selected))
+
+	for _, row := range selected {
+		values, err := e.tableManager.GetDefaultValues(ast.Table, given)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for i, value := range row {
+			index := targets[i]
+
+			if values[index], err = tuple.ConvertValue(value, columns[index].GetColumnType(), columns[index].GetColumnSize()); err != nil {
+				return nil, err
+			}
+		}
+
+		rows = append(rows, values)
+	}
+
+	return rows, nil
+}
+
+// updateQueryExecutor evaluates the SET expressions against the old row, every row is read
+// again before it is checked because a foreign key action may have changed it already.
+// RETURNING sees the rows as they were written
+func (e *Executor) updateQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	columns, err := e.tableManager.GetTableMeta(ast.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	columMap := make(map[string]int)
+
+	for i, c := range columns {
+		columMap[c.Name] = i
+	}
+
+	for _, name := range ast.Column {
+		if _, exist := columMap[name]; !exist {
+			return nil, errors.ErrColumnNotExist
+		}
+	}
+
+	if err := checkReturning(ast, columns); err != nil {
+		return nil, err
+	}
+
+	queries := e.newSubqueries()
+
+	rids, _, err := e.tableManager.GetTuplesWithRID(ast.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make([][]*tuple.Value, 0)
+
+	for _, rid := range rids {
+		values, err := e.tableManager.GetTuple(ast.Table, rid)
+
+		if err == errors.ErrTupleNotExist {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		row := queries.newRow(ast.Table, columns, values)
+
+		if matched, err := expression.EvaluateCondition(ast.Where, row); err != nil {
+			return nil, err
+		} else if !matched {
+			continue
+		}
+
+		newValues, err := getUpdatedValues(ast, columns, columMap, row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err := e.tableManager.UpdateTuple(ast.Table, rid, newValues); err != nil {
+			return nil, err
+		}
+
+		updated = append(updated, newValues)
+	}
+
+	return e.getReturningResponse(ast, queries, columns, updated)
+}
+
+// getUpdatedValues evaluates the SET expressions against the row and converts
+// their values to the types of the columns
+func getUpdatedValues(ast *ast.Ast, columns []*column.Column, columMap map[string]int, row *expression.Row) ([]*tuple.Value, error) {
+	newValues := append([]*tuple.Value{}, row.Values...)
+
+	for i, expr := range ast.Set {
+		value, err := expr.Evaluate(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		index := columMap[ast.Column[i]]
+
+		if newValues[index], err = tuple.ConvertValue(value, columns[index].GetColumnType(), columns[index].GetColumnSize()); err != nil {
+			return nil, err
+		}
+	}
+
+	return newValues, nil
+}
+
+// deleteQueryExecutor skips the rows which ON DELETE CASCADE already removed, RETURNING
+// sees the deleted rows as they were
+func (e *Executor) deleteQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	columns, err := e.tableManager.GetTableMeta(ast.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkReturning(ast, columns); err != nil {
+		return nil, err
+	}
+
+	queries := e.newSubqueries()
+
+	rids, _, err := e.tableManager.GetTuplesWithRID(ast.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := make([][]*tuple.Value, 0)
+
+	for _, rid := range rids {
+		values, err := e.tableManager.GetTuple(ast.Table, rid)
+
+		if err == errors.ErrTupleNotExist {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if matched, err := expression.EvaluateCondition(ast.Where, queries.newRow(ast.Table, columns, values)); err != nil {
+			return nil, err
+		} else if !matched {
+			continue
+		}
+
+		if err := e.tableManager.DeleteTuple(ast.Table, rid); err == errors.ErrTupleNotExist {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		deleted = append(deleted, values)
+	}
+
+	return e.getReturningResponse(ast, queries, columns, deleted)
+}
+
+// createQueryExecutor gives every SERIAL column a sequence which it owns, the column
+// is NOT NULL and takes its DEFAULT from the sequence
+func (e *Executor) createQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	if ast.Action == types.QUERY_CHAR_SEQUENCE {
+		return e.createSequenceExecutor(ast)
+	}
+
+	if ast.Action == types.QUERY_CHAR_INDEX {
+		c := ast.Constraints[0]
+
+		return nil, e.tableManager.CreateIndex(ast.Table, constraint.NewExpressionConstraint(c.Name, c.Type, nil, c.Expression.String()))
+	}
+
+	tableColumns := make([]*column.Column, len(ast.Column))
+	constraints := make([]*constraint.Constraint, 0, len(ast.Constraints))
+	sequences := make([]*sequence.Sequence, 0)
+
+	for i, col := range ast.Column {
+		tableColumns[i] = getColumn(col, ast.ColumnType[i])
+
+		if ast.ColumnType[i] != types.COLUMN_TYPE_SERIAL && ast.ColumnType[i] != types.COLUMN_TYPE_BIGSERIAL {
+			continue
+		}
+
+		maxValue := int64(math.MaxInt64)
+
+		if ast.ColumnType[i] == types.COLUMN_TYPE_SERIAL {
+			maxValue = math.MaxInt32
+		}
+
+		s := sequence.NewSerial(ast.Table, col, maxValue)
+		nextval := &expression.Function{Name: expression.FUNCTION_NEXTVAL, Args: []expression.Expression{&expression.Literal{Value: expression.NewText(s.Name)}}}
+
+		sequences = append(sequences, s)
+		constraints = append(constraints,
+			constraint.NewConstraint("", types.CONSTRAINT_NOT_NULL, []string{col}),
+			constraint.NewExpressionConstraint("", types.CONSTRAINT_DEFAULT, []string{col}, nextval.String()))
+	}
+
+	constraints = append(constraints, getConstraints(ast.Constraints)...)
+
+	err := e.tableManager.CreateNewTableWithConstraints(ast.Table, tableColumns, constraints)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sequences {
+		if err := e.tableManager.CreateSequence(s); err != nil {
+			e.tableManager.DropTable(ast.Table)
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// getConstraints turns the constraints of the query into the constraints of the catalog
+func getConstraints(astConstraints []*ast.Constraint) []*constraint.Constraint {
+	constraints := make([]*constraint.Constraint, 0, len(astConstraints))
+
+	for _, c := range astConstraints {
+		if c.Type == types.CONSTRAINT_FOREIGN_KEY {
+			constraints = append(constraints, constraint.NewForeignKey(c.Name, c.Columns, c.RefTable, c.RefColumns, c.OnDelete, c.OnUpdate))
+		} else if c.Type == types.CONSTRAINT_CHECK || c.Type == types.CONSTRAINT_DEFAULT {
+			constraints = append(constraints, constraint.NewExpressionConstraint(c.Name, c.Type, c.Columns, c.Expression.String()))
+		} else {
+			constraints = append(constraints, constraint.NewConstraint(c.Name, c.Type, c.Columns))
+		}
+	}
+
+	return constraints
+}
+
+// createSequenceExecutor starts an ascending sequence at its MINVALUE
+// and a descending one at its MAXVALUE like PostgreSQL
+func (e *Executor) createSequenceExecutor(ast *ast.Ast) ([]byte, error) {
+	options := ast.Sequence
+	increment := int64(1)
+
+	if options.Increment != nil {
+		increment = *options.Increment
+	}
+
+	s := sequence.NewSequence(options.Name, increment)
+	s.Cycle = options.Cycle
+
+	if options.MinValue != nil {
+		s.MinValue = *options.MinValue
+	}
+
+	if options.MaxValue != nil {
+		s.MaxValue = *options.MaxValue
+	}
+
+	if options.Start != nil {
+		s.Start = *options.Start
+	} else if increment > 0 {
+		s.Start = s.MinValue
+	} else {
+		s.Start = s.MaxValue
+	}
+
+	if err := e.tableManager.CreateSequence(s); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (e *Executor) alterQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	var err error
+
+	// only CREATE TABLE gives a column its sequence
+	if ast.ColumnType != nil && (ast.ColumnType[0] == types.COLUMN_TYPE_SERIAL || ast.ColumnType[0] == types.COLUMN_TYPE_BIGSERIAL) {
+		return nil, errors.ErrSyntax
+	}
+
+	switch ast.Action {
+	case types.ALTER_ADD_COLUMN:
+		err = e.tableManager.AddNewColumnWithConstraints(ast.Table, getColumn(ast.Column[0], ast.ColumnType[0]), getConstraints(ast.Constraints))
+	case types.ALTER_DROP_COLUMN:
+		err = e.tableManager.DropColumn(ast.Table, ast.Column[0])
+	case types.ALTER_RENAME_COLUMN:
+		err = e.tableManager.RenameColumn(ast.Table, ast.Column[0], ast.NewName)
+	case types.ALTER_RENAME_TABLE:
+		err = e.tableManager.RenameTable(ast.Table, ast.NewName)
+	case types.ALTER_COLUMN_TYPE:
+		err = e.tableManager.AlterColumnType(ast.Table, getColumn(ast.Column[0], ast.ColumnType[0]))
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (e *Executor) dropQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	if ast.Action == types.QUERY_CHAR_SEQUENCE {
+		err := e.tableManager.DropSequence(ast.Sequence.Name)
+
+		if err != nil && !(err == errors.ErrNoSequence && ast.IfExists) {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	if ast.Action == types.QUERY_CHAR_INDEX {
+		err := e.tableManager.DropIndex(ast.Constraints[0].Name)
+
+		if err != nil && !(err == errors.ErrNoIndex && ast.IfExists) {
+			return nil, err
+		}
+
+		return nil, nil
+	}
+
+	err := e.tableManager.DropTable(ast.Table)
+
+	if err == errors.ErrNoTable && ast.IfExists {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (e *Executor) truncateQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	err := e.tableManager.TruncateTable(ast.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (e *Executor) analyzeQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	tables := []string{ast.Table}
+
+	if ast.Table == "" {
+		tables = e.tableManager.GetTables()
+	}
+
+	for _, tableName := range tables {
+		if err := e.tableManager.AnalyzeTable(tableName); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// showQueryExecutor answers SHOW and DESCRIBE from the catalog in the SELECT response format
+func (e *Executor) showQueryExecutor(ast *ast.Ast) ([]byte, error) {
+	switch ast.Action {
+	case types.SHOW_TABLES:
+		columns, err := e.tableManager.GetTableMeta(types.INFORMATION_SCHEMA_TABLES)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples, err := e.tableManager.GetTuples(types.INFORMATION_SCHEMA_TABLES)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples = filterTuples(columns, tuples, "table_type", types.TABLE_TYPE_BASE)
+
+		return getResponse(columns, tuples, []string{"table_name"})
+	case types.SHOW_COLUMNS:
+		tableColumns, err := e.tableManager.GetTableMeta(ast.Table)
+
+		if err != nil {
+			return nil, err
+		}
+
+		columns, err := e.tableManager.GetTableMeta(types.INFORMATION_SCHEMA_COLUMNS)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples := make([][]*tuple.Value, 0, len(tableColumns))
+
+		for i, c := range tableColumns {
+			tuples = append(tuples, []*tuple.Value{
+				tuple.GetValue(ast.Table, columns[0].ColumnType, columns[0].Size),
+				tuple.GetValue(c.Name, columns[1].ColumnType, columns[1].Size),
+				tuple.GetValue(int32(i+1), columns[2].ColumnType, columns[2].Size),
+				tuple.GetValue(c.GetTypeName(), columns[3].ColumnType, columns[3].Size),
+				tuple.GetValue(c.Size, columns[4].ColumnType, columns[4].Size),
+			})
+		}
+
+		return getResponse(columns, tuples, []string{"column_name", "ordinal_position", "data_type", "column_size"})
+	case types.SHOW_INDEXES:
+		columns, err := e.tableManager.GetTableMeta(types.SYSTEM_INDEXES)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tuples, err := e.tableManager.GetTuples(types.SYSTEM_INDEXES)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if ast.Table != "" {
+			if _, err := e.tableManager.GetTableMeta(ast.Table); err != nil {
+				return nil, err
+			}
+
+			tuples = filterTuples(columns, tuples, "table_name", ast.Table)
+		}
+
+		return getResponse(columns, tuples, []string{"index_name", "table_name", "column_names", "is_unique"})
+	}
+
+	return nil, errors.ErrSyntax
+}
+
+// filterTuples keeps the tuples whose text column equals the value
+func filterTuples(columns []*column.Column, tuples [][]*tuple.Value, columnName string, value string) [][]*tuple.Value {
+	filtered := make([][]*tuple.Value, 0, len(tuples))
+
+	for i, c := range columns {
+		if c.Name != columnName {
+			continue
+		}
+
+		for _, t := range tuples {
+			if string(t[i].VAR_CHAR) == value {
+				filtered = append(filtered, t)
+			}
+		}
+	}
+
+	return filtered
+}
+
+// getColumn maps the data type parsed from the query to the column type and size
+func getColumn(name string, columnType string) *column.Column {
+	typeSize, colType := int32(0), types.INVALID_TYPE
+
+	switch columnType {
+	case types.COLUMN_TYPE_BOOL:
+		colType = types.BOOL_TYPE
+	case types.COLUMN_TYPE_FLOAT:
+		colType = types.FLOAT_TYPE
+	case types.COLUMN_TYPE_INT, types.COLUMN_TYPE_SERIAL:
+		colType = types.INT_TYPE
+	case types.COLUMN_TYPE_LONGINT, types.COLUMN_TYPE_BIGSERIAL:
+		colType = types.LONG_INT_TYPE
+	case types.COLUMN_TYPE_DATE:
+		colType = types.DATE_TYPE
+	case types.COLUMN_TYPE_TIME:
+		colType = types.TIME_TYPE
+	case types.COLUMN_TYPE_TIMESTAMP:
+		colType = types.TIMESTAMP_TYPE
+	case types.COLUMN_TYPE_INTERVAL:
+		colType = types.INTERVAL_TYPE
+	case types.COLUMN_TYPE_SMALLINT:
+		colType = types.SMALL_INT_TYPE
+	case types.COLUMN_TYPE_REAL:
+		colType = types.REAL_TYPE
+	case types.COLUMN_TYPE_UUID:
+		colType = types.UUID_TYPE
+	}
+
+	if colType == types.INVALID_TYPE {
+		if strings.HasPrefix(columnType, types.COLUMN_TYPE_VAR_CHAR) {
+			colType = types.VAR_CHAR_TYPE
+			fmt.Sscanf(columnType, types.COLUMN_TYPE_VAR_CHAR+"(%d)", &typeSize)
+		}
+
+		if strings.HasPrefix(columnType, types.COLUMN_TYPE_JSON) {
+			colType = types.JSON_TYPE
+			fmt.Sscanf(columnType, types.COLUMN_TYPE_JSON+"(%d)", &typeSize)
+		}
+
+		if strings.HasPrefix(columnType, types.COLUMN_TYPE_CHAR) {
+			colType = types.CHAR_TYPE
+			fmt.Sscanf(columnType, types.COLUMN_TYPE_CHAR+"(%d)", &typeSize)
+		}
+
+		// BYTEA(n) holds n bytes after their length
+		if strings.HasPrefix(columnType, types.COLUMN_TYPE_BYTEA) {
+			colType = types.BYTEA_TYPE
+
+			if _, err := fmt.Sscanf(columnType, types.COLUMN_TYPE_BYTEA+"(%d)", &typeSize); err == nil {
+				typeSize += types.BYTEA_LENGTH_SIZE
+			}
+		}
+
+		if strings.HasPrefix(columnType, types.COLUMN_TYPE_DECIMAL) {
+			var precision, scale int32
+			// a bare DECIMAL is left without precision
+			fmt.Sscanf(columnType, types.COLUMN_TYPE_DECIMAL+"(%d,%d)", &precision, &scale)
+
+			return column.NewDecimalColumn(precision, scale, name)
+		}
+	}
+
+	return column.NewColumn(colType, typeSize, name)
 }
@@ -1,14 +1,22 @@
 package executor
 
 import (
+	"encoding/json"
+	stderrors "errors"
 	"go-db/internal/buffer"
 	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/expression"
 	"go-db/internal/catalog/table"
 	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
+	"go-db/internal/execution/ast"
+	"go-db/internal/execution/parser"
 	"go-db/internal/storage/disk"
 	"log"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -172,7 +180,7 @@ func Test_InsertExecutor(t *testing.T) {
 
 	executor := NewExecutor(bufferPool, diskManager, tableManager)
 
-	_, err = executor.QueryExecutor(`INSERT INTO tableTest (bool_type, float_type, int_types,long_int_type,var_char_type) VALUES (true, 0.1, 1, 1000, "test")`)
+	_, err = executor.QueryExecutor(`INSERT INTO tableTest (bool_type, float_type, int_types,long_int_type,var_char_type) VALUES (true, 0.1, 1, 1000, 'test')`)
 
 	if err != nil {
 		t.Fatal(err)
@@ -184,7 +192,7 @@ func Test_InsertExecutor(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	expectResult := `{"bool_type":[true],"float_type":[0.1],"int_types":[1],"long_int_type":[1000],"var_char_type":["\"test\""]}`
+	expectResult := `{"bool_type":[true],"float_type":[0.1],"int_types":[1],"long_int_type":[1000],"var_char_type":["test"]}`
 
 	if string(result) != expectResult {
 		t.Error("get wrong response", string(result))
@@ -244,3 +252,1362 @@ func Test_CreateExecutor(t *testing.T) {
 		t.Error("create table wrong")
 	}
 }
+
+func Test_DropExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("drop_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("drop_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	_, err = executor.QueryExecutor("CREATE TABLE table_name (column1 VARCHAR(10), column2 int)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = executor.QueryExecutor("INSERT INTO table_name (column1, column2) VALUES ('abc', 1)")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = executor.QueryExecutor("TRUNCATE TABLE table_name")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM table_name")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"column1":[],"column2":[]}` {
+		t.Error("truncate table wrong", string(result))
+	}
+
+	_, err = executor.QueryExecutor("DROP TABLE table_name")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = executor.QueryExecutor("DROP TABLE table_name"); err != errors.ErrNoTable {
+		t.Error("drop missing table should fail", err)
+	}
+
+	if _, err = executor.QueryExecutor("DROP TABLE IF EXISTS table_name"); err != nil {
+		t.Error("drop if exists should not fail", err)
+	}
+}
+
+func Test_AlterExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("alter_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("alter_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE table_name (column1 VARCHAR(10), column2 int)",
+		"INSERT INTO table_name (column1, column2) VALUES (12, 1)",
+		"ALTER TABLE table_name ADD COLUMN column3 BIGINT DEFAULT 5",
+		"ALTER TABLE table_name DROP COLUMN column2",
+		"ALTER TABLE table_name ALTER COLUMN column1 TYPE INT",
+		"ALTER TABLE table_name RENAME COLUMN column1 TO column2",
+		"ALTER TABLE table_name RENAME TO new_table",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM new_table")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"column2":[12],"column3":[5]}` {
+		t.Error("alter table wrong", string(result))
+	}
+}
+
+func Test_SystemTableExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("system_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("system_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE table_name (column1 VARCHAR(10), column2 int)",
+		"INSERT INTO table_name (column1, column2) VALUES ('abc', 1)",
+		"INSERT INTO table_name (column1, column2) VALUES ('def', 2)",
+		"ANALYZE table_name",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT table_name FROM sys_tables")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(result), `"sys_columns"`) || !strings.Contains(string(result), `"table_name"]`) {
+		t.Error("sys_tables should list every table", string(result))
+	}
+
+	result, err = executor.QueryExecutor("SELECT * FROM sys_statistics")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statistics := make(map[string][]interface{})
+
+	if err := json.Unmarshal(result, &statistics); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, tableName := range statistics["table_name"] {
+		if tableName == "table_name" && statistics["row_count"][i] != float64(2) {
+			t.Error("analyze should count the rows", string(result))
+		}
+	}
+
+	if _, err := executor.QueryExecutor("INSERT INTO sys_tables (table_name, meta_page_id) VALUES ('abc', 1)"); err != errors.ErrSystemTable {
+		t.Error("insert into system table should fail", err)
+	}
+
+	if _, err := executor.QueryExecutor("DROP TABLE sys_tables"); err != errors.ErrSystemTable {
+		t.Error("drop system table should fail", err)
+	}
+}
+
+func Test_ShowExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("show_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("show_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE table_b (column1 VARCHAR(10), column2 int)",
+		"CREATE TABLE table_a (id int)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	testCases := []struct {
+		query  string
+		result string
+	}{
+		{"SHOW TABLES", `{"table_name":["table_a","table_b"]}`},
+		{"DESCRIBE table_b", `{"column_name":["column1","column2"],"column_size":[10,4],"data_type":["VARCHAR","INT"],"ordinal_position":[1,2]}`},
+		{"SHOW COLUMNS FROM table_a", `{"column_name":["id"],"column_size":[4],"data_type":["INT"],"ordinal_position":[1]}`},
+		{"SHOW INDEXES FROM table_a", `{"column_names":[],"index_name":[],"is_unique":[],"table_name":[]}`},
+	}
+
+	for _, testCase := range testCases {
+		result, err := executor.QueryExecutor(testCase.query)
+
+		if err != nil {
+			t.Fatal(testCase.query, err)
+		}
+
+		if string(result) != testCase.result {
+			t.Error(testCase.query, string(result))
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM information_schema.tables")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(result), `"column_count":[5,6,5,10,3,2,1,2]`) || !strings.Contains(string(result), `"SYSTEM TABLE","BASE TABLE","BASE TABLE"]`) {
+		t.Error("information_schema.tables wrong", string(result))
+	}
+
+	result, err = executor.QueryExecutor("SELECT column_name FROM information_schema.columns")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasSuffix(string(result), `"id","column1","column2"]}`) {
+		t.Error("information_schema.columns wrong", string(result))
+	}
+
+	result, err = executor.QueryExecutor("SELECT column_name, ordinal_position FROM information_schema.columns WHERE table_name = 'table_b'")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"column_name":["column1","column2"],"ordinal_position":[1,2]}` {
+		t.Error("information_schema.columns ordinal_position should start at 1", string(result))
+	}
+
+	if _, err := executor.QueryExecutor("DESCRIBE missing_table"); err != errors.ErrNoTable {
+		t.Error("describe missing table should fail", err)
+	}
+}
+
+func Test_ConstraintExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("constraint_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("constraint_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE users (id INT PRIMARY KEY, email VARCHAR(20) UNIQUE, name VARCHAR(20) NOT NULL)",
+		"INSERT INTO users (id, email, name) VALUES (1, 'a', 'alice')",
+		"INSERT INTO users (id, email, name) VALUES (2, NULL, 'bob')",
+		"INSERT INTO users (id, name) VALUES (3, 'carol')",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	testCases := []struct {
+		query      string
+		err        error
+		constraint string
+	}{
+		{"INSERT INTO users (id, email, name) VALUES (1, 'b', 'dave')", errors.ErrUniqueViolation, "users_pkey"},
+		{"INSERT INTO users (id, email, name) VALUES (4, 'a', 'dave')", errors.ErrUniqueViolation, "users_email_key"},
+		{"INSERT INTO users (id, email) VALUES (4, 'd')", errors.ErrNotNullViolation, "users_name_not_null"},
+		{"INSERT INTO users (email, name) VALUES ('d', 'dave')", errors.ErrNotNullViolation, "users_pkey"},
+	}
+
+	for _, testCase := range testCases {
+		_, err := executor.QueryExecutor(testCase.query)
+
+		constraintError, ok := err.(*errors.ConstraintError)
+
+		if !ok || constraintError.Err != testCase.err || constraintError.Constraint != testCase.constraint {
+			t.Error(testCase.query, err)
+			continue
+		}
+
+		if !strings.Contains(err.Error(), testCase.constraint) {
+			t.Error("error should name the constraint", err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM users")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"email":["a",null,null],"id":[1,2,3],"name":["alice","bob","carol"]}` {
+		t.Error("rejected rows should not be inserted", string(result))
+	}
+
+	result, err = executor.QueryExecutor("SHOW INDEXES FROM users")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"column_names":["id","email"],"index_name":["users_pkey","users_email_key"],"is_unique":[true,true],"table_name":["users","users"]}` {
+		t.Error("constraints should have indexes", string(result))
+	}
+
+	for query, expected := range map[string]error{
+		"CREATE TABLE t1 (a INT PRIMARY KEY, b INT PRIMARY KEY)": errors.ErrMultiplePrimaryKey,
+		"CREATE TABLE t2 (a INT, UNIQUE (c))":                    errors.ErrColumnNotExist,
+		"CREATE TABLE t3 (a INT CONSTRAINT users_pkey UNIQUE)":   errors.ErrConstraintExist,
+	} {
+		if _, err := executor.QueryExecutor(query); err != expected {
+			t.Error(query, err)
+		}
+	}
+}
+
+func Test_UpdateDeleteExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("update_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("update_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE items (id INT PRIMARY KEY, name VARCHAR(10), price FLOAT)",
+		"INSERT INTO items (id, name, price) VALUES (1, 'pen', 1.5)",
+		"INSERT INTO items (id, name, price) VALUES (2, 'ink', 4)",
+		"INSERT INTO items (id, name) VALUES (3, 'box')",
+		"UPDATE items SET price = price * 2, name = 'cheap' WHERE price < 2",
+		"UPDATE items SET id = id + 10 WHERE price IS NULL",
+		"DELETE FROM items WHERE name = 'ink'",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM items")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(result) != `{"id":[1,13],"name":["cheap","box"],"price":[3,null]}` {
+		t.Error("update and delete result wrong", string(result))
+	}
+
+	for query, expected := range map[string]error{
+		"UPDATE items SET id = 13 WHERE id = 1":   errors.ErrUniqueViolation,
+		"UPDATE items SET price = 'x'":            errors.ErrInvalidValue,
+		"UPDATE items SET missing = 1":            errors.ErrColumnNotExist,
+		"UPDATE items SET price = 1 WHERE id / 0": errors.ErrDivisionByZero,
+		"DELETE FROM items WHERE name":            errors.ErrTypeMismatch,
+		"DELETE FROM sys_tables":                  errors.ErrSystemTable,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("DELETE FROM items"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT * FROM items"); string(result) != `{"id":[],"name":[],"price":[]}` {
+		t.Error("every row should be deleted", string(result))
+	}
+}
+
+func Test_ForeignKeyExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("foreign_key_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("foreign_key_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE authors (id INT PRIMARY KEY, name VARCHAR(10))",
+		"CREATE TABLE books (id INT PRIMARY KEY, author INT REFERENCES authors ON DELETE CASCADE ON UPDATE CASCADE)",
+		"CREATE TABLE reviews (id INT, book INT, CONSTRAINT reviews_book FOREIGN KEY (book) REFERENCES books (id) ON DELETE SET NULL)",
+		"INSERT INTO authors (id, name) VALUES (1, 'ann')",
+		"INSERT INTO authors (id, name) VALUES (2, 'ben')",
+		"INSERT INTO books (id, author) VALUES (10, 1)",
+		"INSERT INTO books (id, author) VALUES (20, 2)",
+		"INSERT INTO reviews (id, book) VALUES (100, 10)",
+		"INSERT INTO reviews (id, book) VALUES (200, 20)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO books (id, author) VALUES (30, 3)":      errors.ErrForeignKeyViolation,
+		"UPDATE reviews SET book = 30":                       errors.ErrForeignKeyViolation,
+		"UPDATE books SET id = 11 WHERE id = 10":             errors.ErrForeignKeyReference,
+		"DROP TABLE authors":                                 errors.ErrTableReferenced,
+		"CREATE TABLE bad (a INT REFERENCES authors (name))": errors.ErrNoUniqueConstraint,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	for _, query := range []string{
+		"UPDATE authors SET id = 5 WHERE id = 1",
+		"DELETE FROM authors WHERE id = 2",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT * FROM books"); string(result) != `{"author":[5],"id":[10]}` {
+		t.Error("books should follow their author", string(result))
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT * FROM reviews"); string(result) != `{"book":[10,null],"id":[100,200]}` {
+		t.Error("review of the deleted book should lose its book", string(result))
+	}
+}
+
+func Test_CheckDefaultExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("check_default_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("check_default_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE items (id INT CHECK (id > 0), price INT DEFAULT 10 * 2 CHECK (price >= 0), name VARCHAR(20) DEFAULT 'none', created TIMESTAMP DEFAULT CURRENT_TIMESTAMP, CHECK (price < id * 100))",
+		"INSERT INTO items (id) VALUES (1)",
+		"INSERT INTO items (id, price, name) VALUES (2, DEFAULT, NULL)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO items (id) VALUES (0)":                errors.ErrCheckViolation,
+		"UPDATE items SET price = -1 WHERE id = 1":         errors.ErrCheckViolation,
+		"UPDATE items SET price = 500 WHERE id = 2":        errors.ErrCheckViolation,
+		"CREATE TABLE bad (a INT, b INT DEFAULT a)":        errors.ErrInvalidDefault,
+		"CREATE TABLE bad (a INT CHECK (c > 0))":           errors.ErrColumnNotExist,
+		"CREATE TABLE bad (a INT DEFAULT 'x')":             errors.ErrInvalidValue,
+		"CREATE TABLE bad (a INT DEFAULT 1 DEFAULT 2)":     errors.ErrSyntax,
+		"CREATE TABLE bad (a INT, CONSTRAINT d DEFAULT 1)": errors.ErrSyntax,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT id, price, name FROM items")
+
+	if err != nil || string(result) != `{"id":[1,2],"name":["none",null],"price":[20,20]}` {
+		t.Error("missing columns should take their DEFAULT", string(result), err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT created FROM items"); strings.Contains(string(result), "null") {
+		t.Error("CURRENT_TIMESTAMP should be filled in", string(result))
+	}
+}
+
+func Test_SequenceExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("sequence_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("sequence_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE items (id SERIAL PRIMARY KEY, code BIGINT AUTO_INCREMENT, name VARCHAR(10))",
+		"CREATE SEQUENCE tickets INCREMENT BY 10 START WITH 100",
+		"INSERT INTO items (name) VALUES ('a')",
+		"INSERT INTO items (id, name) VALUES (10, 'b')",
+		"INSERT INTO items (id, name) VALUES (DEFAULT, 'c')",
+		"UPDATE items SET code = nextval('tickets') WHERE name = 'b'",
+		"UPDATE items SET code = nextval('tickets') + currval('tickets') WHERE name = 'c'",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	// an id which is given does not use up a value of the sequence
+	result, err := executor.QueryExecutor("SELECT id, code FROM items")
+
+	if err != nil || string(result) != `{"code":[1,100,220],"id":[1,10,2]}` {
+		t.Error("get the wrong generated values", string(result), err)
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO items (id, name) VALUES (NULL, 'd')": errors.ErrNotNullViolation,
+		"CREATE SEQUENCE tickets":                         errors.ErrSequenceExist,
+		"CREATE SEQUENCE bad MINVALUE 10 MAXVALUE 1":      errors.ErrInvalidSequence,
+		"DROP SEQUENCE items_id_seq":                      errors.ErrSequenceOwned,
+		"ALTER TABLE items ADD COLUMN other SERIAL":       errors.ErrSyntax,
+		"UPDATE items SET code = nextval('missing')":      errors.ErrNoSequence,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	for _, query := range []string{
+		"DROP SEQUENCE tickets",
+		"DROP SEQUENCE IF EXISTS tickets",
+		"DROP TABLE items",
+		"CREATE TABLE items (id SERIAL)",
+		"INSERT INTO items (id) VALUES (DEFAULT)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT id FROM items"); string(result) != `{"id":[1]}` {
+		t.Error("sequence should be dropped with its table", string(result))
+	}
+}
+
+func Test_TemporalExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("temporal_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("temporal_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE events (day DATE PRIMARY KEY, starts TIME, at TIMESTAMP DEFAULT CURRENT_TIMESTAMP, length INTERVAL)",
+		"INSERT INTO events (day, starts, at, length) VALUES ('2024-01-31', '09:30', '2024-01-31 09:30:00.5', '1 hour 30 minutes')",
+		"INSERT INTO events (day, starts, length) VALUES ('2024-02-29', '14:00:00', 'P2D')",
+		"UPDATE events SET at = day + starts, length = length * 2 WHERE day > '2024-02-01'",
+		"UPDATE events SET day = day + 1 WHERE extract(month FROM at) = 1",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM events")
+
+	expected := `{"at":["2024-01-31T09:30:00.5","2024-02-29T14:00:00"],"day":["2024-02-01","2024-02-29"],` +
+		`"length":["PT1H30M","P4D"],"starts":["09:30:00","14:00:00"]}`
+
+	if err != nil || string(result) != expected {
+		t.Error("get the wrong temporal values", string(result), err)
+	}
+
+	if _, err := executor.QueryExecutor("UPDATE events SET starts = NULL WHERE at + length > TIMESTAMP '2024-03-01'"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT starts FROM events"); string(result) != `{"starts":["09:30:00",null]}` {
+		t.Error("get the wrong rows", string(result))
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO events (day) VALUES ('2024-02-01')":        errors.ErrUniqueViolation,
+		"INSERT INTO events (day) VALUES ('2024-02-30')":        errors.ErrInvalidValue,
+		"UPDATE events SET day = starts":                        errors.ErrInvalidValue,
+		"UPDATE events SET at = at + at":                        errors.ErrTypeMismatch,
+		"UPDATE events SET day = date_trunc('eon', at)":         errors.ErrInvalidUnit,
+		"CREATE TABLE bad (a DATE DEFAULT '2024-13-01')":        errors.ErrInvalidValue,
+		"ALTER TABLE events ALTER COLUMN length TYPE TIMESTAMP": errors.ErrInvalidValue,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("ALTER TABLE events ALTER COLUMN at TYPE DATE"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT at FROM events"); string(result) != `{"at":["2024-01-31","2024-02-29"]}` {
+		t.Error("TIMESTAMP should be converted to DATE", string(result))
+	}
+
+	if result, _ := executor.QueryExecutor("DESCRIBE events"); !strings.Contains(string(result), `"data_type":["DATE","TIME","DATE","INTERVAL"]`) {
+		t.Error("get the wrong data types", string(result))
+	}
+}
+
+func Test_DecimalExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("decimal_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("decimal_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE accounts (id NUMERIC(6) PRIMARY KEY, balance DECIMAL(10, 2) DEFAULT 0 CHECK (balance >= 0), rate DECIMAL)",
+		"INSERT INTO accounts (id, balance, rate) VALUES (1, 12.345, 3)",
+		"INSERT INTO accounts (id, balance, rate) VALUES (2.4, '-0', 4.5)",
+		"INSERT INTO accounts (id) VALUES (3)",
+		"UPDATE accounts SET balance = balance * 1.1 + 0.004 WHERE id = 1",
+		"UPDATE accounts SET balance = balance + 1 / 3.0 WHERE id > 1",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	result, err := executor.QueryExecutor("SELECT * FROM accounts")
+
+	// DECIMAL is written as a string so that no digit is lost to a float
+	expected := `{"balance":["13.59","0.33","0.33"],"id":["1","2","3"],"rate":["3","4.5",null]}`
+
+	if err != nil || string(result) != expected {
+		t.Error("get the wrong decimal values", string(result), err)
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO accounts (id) VALUES (1.49)":                      errors.ErrUniqueViolation,
+		"INSERT INTO accounts (id) VALUES (1000000)":                   errors.ErrNumericOverflow,
+		"INSERT INTO accounts (id, balance) VALUES (4, 99999999.995)":  errors.ErrNumericOverflow,
+		"INSERT INTO accounts (id, balance) VALUES (4, '-0.01')":       errors.ErrCheckViolation,
+		"INSERT INTO accounts (id) VALUES ('1.2.3')":                   errors.ErrInvalidValue,
+		"UPDATE accounts SET balance = balance / 0":                    errors.ErrDivisionByZero,
+		"CREATE TABLE bad (a DECIMAL(3, 5))":                           errors.ErrInvalidDecimal,
+		"CREATE TABLE bad (a DECIMAL(39))":                             errors.ErrInvalidDecimal,
+		"CREATE TABLE bad (a DECIMAL(0))":                              errors.ErrInvalidDecimal,
+		"ALTER TABLE accounts ALTER COLUMN balance TYPE DECIMAL(2, 1)": errors.ErrNumericOverflow,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("ALTER TABLE accounts ALTER COLUMN balance TYPE NUMERIC(12, 4)"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT balance FROM accounts"); string(result) != `{"balance":["13.5900","0.3300","0.3300"]}` {
+		t.Error("values should take the new scale", string(result))
+	}
+
+	if result, _ := executor.QueryExecutor("DESCRIBE accounts"); !strings.Contains(string(result), `"data_type":["DECIMAL(6,0)","DECIMAL(12,4)","DECIMAL"]`) {
+		t.Error("get the wrong data types", string(result))
+	}
+
+	// a bare NUMERIC keeps the scale of every value, the key of 1.50 is the key of 1.5
+	for _, query := range []string{
+		"CREATE TABLE prices (amount NUMERIC PRIMARY KEY)",
+		"INSERT INTO prices VALUES (1.5), (-2.25), (10), (0.001)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	for query, expected := range map[string]string{
+		"SELECT amount FROM prices":                                `{"amount":["1.5","-2.25","10","0.001"]}`,
+		"SELECT amount FROM prices WHERE amount = 1.50":            `{"amount":["1.5"]}`,
+		"SELECT amount * 2 AS amount FROM prices WHERE amount < 0": `{"amount":["-4.50"]}`,
+	} {
+		if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+			t.Error(query, "get the wrong bare NUMERIC values", string(result), err)
+		}
+	}
+
+	for _, query := range []string{"INSERT INTO prices VALUES (1.50)", "INSERT INTO prices VALUES ('1e-3')"} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, errors.ErrUniqueViolation) {
+			t.Error(query, "should conflict with the same number of another scale", err)
+		}
+	}
+}
+
+func Test_JSONExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("json_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("json_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE people (id INT PRIMARY KEY, doc JSONB(128))",
+		`INSERT INTO people (id, doc) VALUES (1, '{"name": "Ann", "city": "Oslo", "tags": ["a"]}')`,
+		`INSERT INTO people (id, doc) VALUES (2, '{"name": "Bob", "city": "Rome"}')`,
+		`INSERT INTO people (id, doc) VALUES (3, '{"name": "Cid", "city": "Oslo"}')`,
+		"INSERT INTO people (id) VALUES (4)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	query := "SELECT id, doc ->> 'name' AS name FROM people WHERE doc ->> 'city' = 'Oslo'"
+	expected := `{"id":[1,3],"name":["Ann","Cid"]}`
+
+	if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+		t.Error("get the wrong rows", string(result), err)
+	}
+
+	// the index finds the same rows as the scan
+	if _, err := executor.QueryExecutor("CREATE INDEX people_city ON people (doc ->> 'city')"); err != nil {
+		t.Fatal(err)
+	}
+
+	expr, _ := expression.ParseText("doc ->> 'city'")
+
+	if rids, found, err := tableManager.SearchIndex("people", expr, expression.NewText("Oslo")); err != nil || !found || len(rids) != 2 {
+		t.Error("index should find the rows", rids, found, err)
+	}
+
+	if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+		t.Error("get the wrong rows from the index", string(result), err)
+	}
+
+	if _, err := executor.QueryExecutor("UPDATE people SET doc = '{\"city\": \"Oslo\"}' WHERE id = 2"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executor.QueryExecutor("DELETE FROM people WHERE id = 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT id FROM people WHERE doc ->> 'city' = 'Oslo' AND id > 0 LIMIT 5"); string(result) != `{"id":[2,3]}` {
+		t.Error("index should follow UPDATE and DELETE", string(result))
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT doc -> 'tags' AS tags, doc FROM people WHERE id = 3"); string(result) != `{"doc":[{"city":"Oslo","name":"Cid"}],"tags":[null]}` {
+		t.Error("get the wrong documents", string(result))
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO people (id, doc) VALUES (5, '{\"a\": }')":       errors.ErrInvalidValue,
+		"CREATE UNIQUE INDEX people_name ON people (doc ->> 'city')": errors.ErrUniqueViolation,
+		"CREATE INDEX people_none ON people (1)":                     errors.ErrSyntax,
+		"CREATE INDEX people_city ON people (doc ->> 'name')":        errors.ErrConstraintExist,
+		"DROP INDEX people_pkey":                                     errors.ErrIndexConstraint,
+		"ALTER TABLE people DROP COLUMN doc":                         errors.ErrColumnHasConstraint,
+		"SELECT id FROM people WHERE doc ->> 'city'":                 errors.ErrTypeMismatch,
+		"SELECT id FROM people LIMIT":                                errors.ErrSyntax,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("CREATE UNIQUE INDEX people_name ON people (doc ->> 'name')"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := executor.QueryExecutor(`INSERT INTO people (id, doc) VALUES (6, '{"name": "Cid"}')`); !stderrors.Is(err, errors.ErrUniqueViolation) {
+		t.Error("unique index should reject the name", err)
+	}
+
+	for _, query := range []string{"DROP INDEX people_name", "DROP INDEX people_city", "DROP INDEX IF EXISTS people_city"} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	if result, _ := executor.QueryExecutor("SHOW INDEXES FROM people"); strings.Contains(string(result), "people_city") {
+		t.Error("index should be dropped", string(result))
+	}
+}
+
+func Test_TypeFamilyExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("type_family_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("type_family_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE files (id UUID DEFAULT gen_random_uuid() PRIMARY KEY, code CHAR(4), size SMALLINT, ratio REAL, score DOUBLE PRECISION, data BYTEA(4))",
+		`INSERT INTO files (code, size, ratio, score, data) VALUES ('ab', 12, 0.5, 0.1, '\xcafe')`,
+		`INSERT INTO files (code, data) VALUES ('it''s', '\x0102')`,
+		"INSERT INTO files (id, code, size) VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'cd', '-3')",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	query := "SELECT code, size, ratio, score, data FROM files WHERE code = 'ab'"
+	expected := `{"code":["ab  "],"data":["\\xcafe"],"ratio":[0.5],"score":[0.1],"size":[12]}`
+
+	if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+		t.Error("get the wrong row", string(result), err)
+	}
+
+	// a quote is written twice and the backslash of a hex BYTEA is not an escape
+	if result, err := executor.QueryExecutor("SELECT data FROM files WHERE code = 'it''s'"); err != nil || string(result) != `{"data":["\\x0102"]}` {
+		t.Error("get the wrong bytes", string(result), err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT id FROM files WHERE size < 0"); string(result) != `{"id":["a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11"]}` {
+		t.Error("get the wrong UUID", string(result))
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT code FROM files WHERE id <> 'a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11'"); string(result) != `{"code":["ab  ","it's"]}` {
+		t.Error("DEFAULT should generate a UUID", string(result))
+	}
+
+	for query, expected := range map[string]error{
+		"INSERT INTO files (code, size) VALUES ('abcde', 1)":                                errors.ErrValueTooLong,
+		`INSERT INTO files (code, data) VALUES ('x', '\x0102030405')`:                       errors.ErrValueTooLong,
+		"INSERT INTO files (code, size) VALUES ('x', 40000)":                                errors.ErrNumericOverflow,
+		"INSERT INTO files (id, code) VALUES ('a0eebc99-9c0b-4ef8-bb6d', 'x')":              errors.ErrInvalidValue,
+		"INSERT INTO files (id, code) VALUES ('a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11', 'x')": errors.ErrUniqueViolation,
+		"CREATE TABLE broken (score DOUBLE)":                                                errors.ErrSyntax,
+	} {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+}
+
+func Test_CastExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("cast_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("cast_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE items (id INT PRIMARY KEY, label VARCHAR(16), price DECIMAL(6,2), stock BIGINT)",
+		"INSERT INTO items (id, label, price, stock) VALUES (1, 7, 9.99, 3)",
+		"INSERT INTO items (id, label, price, stock) VALUES (2, 12, 20.5, 10)",
+		"UPDATE items SET stock = price * 2, label = CAST(price AS INT) WHERE id = 1",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	query := "SELECT id, label::INT + 1 AS next, price::INT AS rounded, stock FROM items WHERE label::INT > 9"
+	expected := `{"id":[1,2],"next":[11,13],"rounded":[10,21],"stock":[20,10]}`
+
+	if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+		t.Error("get the wrong rows", string(result), err)
+	}
+
+	for query, expected := range map[string]string{
+		"INSERT INTO items (id, label) VALUES ('x', 'a')":        `invalid input syntax for type INT: "x"`,
+		"INSERT INTO items (id, stock) VALUES (3, '1.5')":        `invalid input syntax for type BIGINT: "1.5"`,
+		"INSERT INTO items (id, label) VALUES (3000000000, 'a')": `value "3000000000" is out of range for type INT`,
+		"UPDATE items SET stock = TRUE":                          "type BOOL can not be converted to BIGINT without a cast",
+		"SELECT id FROM items WHERE label::INT > 'ten'":          `invalid input syntax for type INT: "ten"`,
+	} {
+		if _, err := executor.QueryExecutor(query); err == nil || err.Error() != expected {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("UPDATE items SET stock = TRUE::INT"); err != nil {
+		t.Error("an explicit cast should convert BOOL", err)
+	}
+}
+
+func Test_FunctionExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("function_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("function_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE users (id INT PRIMARY KEY, name VARCHAR(32), score DECIMAL(5,1))",
+		"INSERT INTO users (id, name, score) VALUES (1, 'Ann', 81.5)",
+		"INSERT INTO users (id, name, score) VALUES (2, 'bob', 42.25)",
+		"INSERT INTO users (id, name) VALUES (3, 'Cid')",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	if _, err := executor.QueryExecutor("UPDATE users SET name = concat(upper(substring(name, 1, 1)), lower(substring(name, 2)))"); err != nil {
+		t.Fatal(err)
+	}
+
+	query := "SELECT id, name, CASE WHEN score >= 50 THEN 'pass' WHEN score IS NULL THEN 'absent' ELSE 'fail' END AS grade, " +
+		"coalesce(round(score), 0) AS rounded FROM users WHERE length(name) = 3 AND lower(name) <> 'nobody'"
+	expected := `{"grade":["pass","fail","absent"],"id":[1,2,3],"name":["Ann","Bob","Cid"],"rounded":["82","42",0]}`
+
+	if result, err := executor.QueryExecutor(query); err != nil || string(result) != expected {
+		t.Error("get the wrong rows", string(result), err)
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT id FROM users WHERE greatest(score, 50) = 50"); string(result) != `{"id":[2,3]}` {
+		t.Error("get the wrong rows", string(result))
+	}
+
+	if _, err := executor.QueryExecutor("SELECT nope(id) AS x FROM users"); !stderrors.Is(err, errors.ErrNoFunction) {
+		t.Error("unknown function should fail", err)
+	}
+}
+
+func Test_InsertRowsExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("insert_rows_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("insert_rows_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE players (id INT PRIMARY KEY, name VARCHAR(16), score INT DEFAULT 10)",
+		"CREATE TABLE archive (id BIGINT PRIMARY KEY, label VARCHAR(32), points DECIMAL(6,1) DEFAULT 0, active BOOL)",
+		"INSERT INTO players (id, name, score) VALUES (1, 'ann', 30), (2, 'bob', DEFAULT), (3, 'cid', NULL)",
+		"INSERT INTO players VALUES (4, 'dan', 25), (5, 'eve')",
+		"INSERT INTO archive (id, label, points) SELECT id, upper(name), score / 2.0 FROM players WHERE score > 10",
+		"INSERT INTO archive SELECT id + 100, name FROM players WHERE id = 2",
+		"INSERT INTO archive VALUES (-2, 'quoted, text', -1.5, NOT false), (1 + 1 * 200, lower('MIXED'), abs(-4), NULL)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	tests := map[string]string{
+		"SELECT * FROM players":                         `{"id":[1,2,3,4,5],"name":["ann","bob","cid","dan","eve"],"score":[30,10,null,25,10]}`,
+		"SELECT id, label, points, active FROM archive": `{"active":[null,null,null,true,null],"id":[1,4,102,-2,201],"label":["ANN","DAN","bob","quoted, text","mixed"],"points":["15.0","12.5","0.0","-1.5","4.0"]}`,
+	}
+
+	for query, want := range tests {
+		if result, err := executor.QueryExecutor(query); err != nil || string(result) != want {
+			t.Errorf("%s should return %s, got %s %v", query, want, result, err)
+		}
+	}
+
+	failures := map[string]error{
+		"INSERT INTO players (id, name) VALUES (6, 'x', 1)":                           errors.ErrInsertColumns,
+		"INSERT INTO players (id, name) VALUES (6, 'x'), (7)":                         errors.ErrInsertColumns,
+		"INSERT INTO players VALUES (6, 'x', 1, 2)":                                   errors.ErrInsertColumns,
+		"INSERT INTO players VALUES (6, 'x'), (7, 'y'), (1, 'z')":                     errors.ErrUniqueViolation,
+		"INSERT INTO players VALUES (6, 'x'), (6, 'y')":                               errors.ErrUniqueViolation,
+		"INSERT INTO players (id, nope) VALUES (6, 'x')":                              errors.ErrColumnNotExist,
+		"INSERT INTO players (id, name) VALUES (6, name)":                             errors.ErrColumnNotExist,
+		"INSERT INTO players (id) SELECT id, label FROM archive":                      errors.ErrInsertColumns,
+		"INSERT INTO players (id, score) SELECT id + 10, active IS NULL FROM archive": errors.ErrInvalidValue,
+		"INSERT INTO players (id) SELECT id FROM archive":                             errors.ErrUniqueViolation,
+		"INSERT INTO players (id) SELECT id FROM missing":                             errors.ErrNoTable,
+	}
+
+	for query, want := range failures {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	if result, _ := executor.QueryExecutor("SELECT id FROM players"); string(result) != `{"id":[1,2,3,4,5]}` {
+		t.Error("a failed INSERT should not write a row", string(result))
+	}
+}
+
+func Test_OnConflictExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("on_conflict_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("on_conflict_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE stock (sku INT PRIMARY KEY, code VARCHAR(8) UNIQUE, qty INT CHECK (qty >= 0), note VARCHAR(16))",
+		"INSERT INTO stock VALUES (1, 'a', 5, 'first'), (2, 'b', 1, 'first')",
+		"INSERT INTO stock VALUES (1, 'x', 100, 'ignored'), (3, 'c', 7, 'new') ON CONFLICT (sku) DO NOTHING",
+		"INSERT INTO stock VALUES (4, 'a', 1, 'ignored'), (3, 'd', 1, 'ignored') ON CONFLICT DO NOTHING",
+		"INSERT INTO stock VALUES (5, 'e', 2, 'dup'), (5, 'f', 3, 'dup') ON CONFLICT DO NOTHING",
+		"INSERT INTO stock (sku, code, qty) VALUES (2, 'b', 4), (6, 'g', 1) ON CONFLICT (sku) DO UPDATE SET qty = stock.qty + EXCLUDED.qty, note = upper(EXCLUDED.code)",
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'a', 3) ON CONFLICT ON CONSTRAINT stock_code_key DO UPDATE SET qty = EXCLUDED.qty WHERE stock.qty > 10",
+		"INSERT INTO stock (sku, code, qty) SELECT sku + 4, upper(code), qty FROM stock WHERE sku = 1 OR sku = 3 ON CONFLICT (sku) DO UPDATE SET note = 'merged'",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	expected := `{"code":["a","b","c","e","g","C"],"note":["first","B","new","merged",null,null],"qty":[5,5,7,2,1,7],"sku":[1,2,3,5,6,7]}`
+
+	if result, err := executor.QueryExecutor("SELECT * FROM stock"); err != nil || string(result) != expected {
+		t.Error("get the wrong rows", string(result), err)
+	}
+
+	failures := map[string]error{
+		"INSERT INTO stock (sku, code, qty) VALUES (8, 'h', 1), (8, 'i', 2) ON CONFLICT (sku) DO UPDATE SET qty = 0": errors.ErrConflictTwice,
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'z', 1) ON CONFLICT (qty) DO NOTHING":                         errors.ErrNoConflictTarget,
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'z', 1) ON CONFLICT ON CONSTRAINT nope DO NOTHING":            errors.ErrNoConflictTarget,
+		"INSERT INTO stock (sku, code, qty) VALUES (9, 'a', 1) ON CONFLICT (sku) DO NOTHING":                         errors.ErrUniqueViolation,
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'a', 1) ON CONFLICT (sku) DO UPDATE SET qty = -1":             errors.ErrCheckViolation,
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'a', 1) ON CONFLICT (sku) DO UPDATE SET nope = 1":             errors.ErrColumnNotExist,
+		"INSERT INTO stock (sku, code, qty) VALUES (1, 'a', 1) ON CONFLICT (sku) DO UPDATE SET qty = other.qty":      errors.ErrColumnNotExist,
+		"INSERT INTO stock (sku, code, qty) VALUES (10, 'j', 1), (11, 'k', -1) ON CONFLICT DO NOTHING":               errors.ErrCheckViolation,
+	}
+
+	for query, want := range failures {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	// the proposed rows are checked before the first one is written
+	if result, err := executor.QueryExecutor("SELECT sku FROM stock WHERE sku = 10"); err != nil || string(result) != `{"sku":[]}` {
+		t.Error("the row before the failing one should not be written", string(result), err)
+	}
+}
+
+func Test_ReturningExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("returning_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("returning_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	if _, err := executor.QueryExecutor("CREATE TABLE tasks (id SERIAL PRIMARY KEY, title VARCHAR(16) UNIQUE, state VARCHAR(8) DEFAULT 'todo', points INT)"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"INSERT INTO tasks (title, points) VALUES ('write', 3), ('test', 5) RETURNING *", `{"id":[1,2],"points":[3,5],"state":["todo","todo"],"title":["write","test"]}`},
+		{"INSERT INTO tasks (title) VALUES ('ship') RETURNING id, tasks.state AS s", `{"id":[3],"s":["todo"]}`},
+		{"INSERT INTO tasks (title, points) VALUES ('review', 1)", ``},
+		{"INSERT INTO tasks (title, points) VALUES ('write', 8), ('deploy', 2) ON CONFLICT (title) DO NOTHING RETURNING id, title", `{"id":[6],"title":["deploy"]}`},
+		{"INSERT INTO tasks (title, points) VALUES ('test', 4) ON CONFLICT (title) DO UPDATE SET points = tasks.points + EXCLUDED.points RETURNING id, points", `{"id":[2],"points":[9]}`},
+		{"INSERT INTO tasks (title, points) SELECT upper(title), points * 2 FROM tasks WHERE id < 3 RETURNING id, title, points", `{"id":[8,9],"points":[6,18],"title":["WRITE","TEST"]}`},
+		{"UPDATE tasks SET state = 'done', points = points + 1 WHERE points > 5 RETURNING id, state, points * 10 AS score", `{"id":[2,8,9],"score":[100,70,190],"state":["done","done","done"]}`},
+		{"UPDATE tasks SET points = 0 WHERE id = 100 RETURNING id", `{"id":[]}`},
+		{"DELETE FROM tasks WHERE state = 'done' RETURNING title", `{"title":["test","WRITE","TEST"]}`},
+		{"SELECT id FROM tasks", `{"id":[1,3,4,6]}`},
+	}
+
+	for _, test := range tests {
+		result, err := executor.QueryExecutor(test.query)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", test.query, err)
+			continue
+		}
+
+		if string(result) != test.expected {
+			t.Errorf("%s should return %s, got %s", test.query, test.expected, result)
+		}
+	}
+
+	failures := map[string]error{
+		"INSERT INTO tasks (title) VALUES ('lint') RETURNING nope": errors.ErrColumnNotExist,
+		"UPDATE tasks SET points = 1 RETURNING other.id":           errors.ErrColumnNotExist,
+		"DELETE FROM tasks WHERE id = 1 RETURNING count_rows(id)":  errors.ErrNoFunction,
+		"DELETE FROM tasks WHERE id = 1 RETURNING id, excluded.id": errors.ErrColumnNotExist,
+		"INSERT INTO tasks (title) VALUES ('write') RETURNING id":  errors.ErrUniqueViolation,
+	}
+
+	for query, want := range failures {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	// a RETURNING list which can not be evaluated fails the statement before it writes
+	if result, err := executor.QueryExecutor("SELECT id, points FROM tasks"); err != nil || string(result) != `{"id":[1,3,4,6],"points":[3,null,1,2]}` {
+		t.Error("a failed statement should not change the table", string(result), err)
+	}
+}
+
+func Test_SubqueryExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("subquery_executor_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("subquery_executor_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE dept (id INT PRIMARY KEY, name VARCHAR(16), open BOOL)",
+		"CREATE TABLE emp (id INT PRIMARY KEY, name VARCHAR(16), dept INT, salary INT)",
+		"INSERT INTO dept VALUES (1, 'eng', true), (2, 'ops', false), (3, 'art', true), (4, 'hr', true)",
+		"INSERT INTO emp VALUES (1, 'ann', 1, 100), (2, 'bob', 1, 80), (3, 'cid', 2, 70), (4, 'dan', NULL, 60), (5, 'eve', 3, 90)",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT name FROM emp WHERE salary > (SELECT salary FROM emp WHERE id = 3)", `{"name":["ann","bob","eve"]}`},
+		{"SELECT name FROM emp WHERE dept IN (SELECT id FROM dept WHERE open)", `{"name":["ann","bob","eve"]}`},
+		{"SELECT name FROM emp WHERE dept NOT IN (SELECT id FROM dept WHERE open)", `{"name":["cid"]}`},
+		{"SELECT name FROM dept WHERE id NOT IN (SELECT dept FROM emp)", `{"name":[]}`},
+		{"SELECT name FROM dept d WHERE EXISTS (SELECT * FROM emp WHERE emp.dept = d.id AND salary > 85)", `{"name":["eng","art"]}`},
+		{"SELECT name FROM dept WHERE NOT EXISTS (SELECT id FROM emp WHERE emp.dept = dept.id)", `{"name":["hr"]}`},
+		{"SELECT name, (SELECT name FROM dept WHERE dept.id = emp.dept) AS unit FROM emp", `{"name":["ann","bob","cid","dan","eve"],"unit":["eng","eng","ops",null,"art"]}`},
+		{"SELECT name FROM emp e WHERE salary = (SELECT salary FROM emp WHERE emp.dept = e.dept LIMIT 1)", `{"name":["ann","cid","eve"]}`},
+		{"SELECT name FROM emp e WHERE EXISTS (SELECT id FROM emp WHERE emp.dept = e.dept AND emp.salary > e.salary)", `{"name":["bob"]}`},
+		{"SELECT name FROM emp e WHERE EXISTS (SELECT id FROM dept WHERE open AND id = e.dept AND EXISTS (SELECT id FROM emp WHERE dept = e.dept AND salary < 90))", `{"name":["ann","bob"]}`},
+		{"SELECT id, (SELECT name FROM dept WHERE id = 4) AS spare FROM emp WHERE id < 3", `{"id":[1,2],"spare":["hr","hr"]}`},
+		{"SELECT n, doubled FROM (SELECT name AS n, salary * 2 AS doubled FROM emp WHERE salary >= 80) AS rich WHERE doubled < 190", `{"doubled":[160,180],"n":["bob","eve"]}`},
+		{"SELECT r.id FROM (SELECT * FROM emp) r WHERE r.salary < 75", `{"id":[3,4]}`},
+		{"SELECT name FROM (SELECT name, dept FROM emp) AS x WHERE dept IN (SELECT id FROM dept WHERE name = 'eng')", `{"name":["ann","bob"]}`},
+		{"SELECT x.id FROM (SELECT emp.id FROM emp WHERE salary < 75) AS x", `{"id":[3,4]}`},
+		{"SELECT * FROM (SELECT e.name, e.salary AS pay FROM emp e WHERE id = 1) AS x", `{"name":["ann"],"pay":[100]}`},
+		{"UPDATE emp SET salary = salary + (SELECT id FROM dept WHERE name = 'art') WHERE dept IN (SELECT id FROM dept WHERE NOT open) RETURNING name, salary", `{"name":["cid"],"salary":[73]}`},
+		{"UPDATE emp SET salary = (SELECT salary FROM emp WHERE id = 1) WHERE id = 2 RETURNING salary", `{"salary":[100]}`},
+		{"DELETE FROM dept WHERE NOT EXISTS (SELECT id FROM emp WHERE emp.dept = dept.id) RETURNING name", `{"name":["hr"]}`},
+		{"INSERT INTO dept (id, name) SELECT id + 10, upper(name) FROM dept WHERE id IN (SELECT dept FROM emp WHERE salary < 90) RETURNING id", `{"id":[12]}`},
+		// every row is compared with the salaries before the statement, dan keeps the lowest one
+		{"UPDATE emp SET salary = salary - 100 WHERE EXISTS (SELECT id FROM emp AS i WHERE i.salary < emp.salary) RETURNING name, salary", `{"name":["ann","bob","cid","eve"],"salary":[0,0,-27,-10]}`},
+	}
+
+	for _, test := range tests {
+		result, err := executor.QueryExecutor(test.query)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", test.query, err)
+			continue
+		}
+
+		if string(result) != test.expected {
+			t.Errorf("%s should return %s, got %s", test.query, test.expected, result)
+		}
+	}
+
+	failures := map[string]error{
+		"SELECT name FROM emp WHERE salary = (SELECT salary FROM emp)":                errors.ErrSubqueryRows,
+		"SELECT name FROM emp WHERE dept IN (SELECT id, name FROM dept)":              errors.ErrSubqueryColumns,
+		"SELECT name FROM emp WHERE (SELECT * FROM dept WHERE id = 1) IS NULL":        errors.ErrSubqueryColumns,
+		"SELECT nope FROM (SELECT id FROM emp) AS x":                                  errors.ErrColumnNotExist,
+		"SELECT name FROM emp WHERE EXISTS (SELECT id FROM dept WHERE other.id = id)": errors.ErrColumnNotExist,
+		"CREATE TABLE bad (id INT CHECK (id IN (SELECT id FROM dept)))":               errors.ErrSubquery,
+		"CREATE TABLE bad (id INT DEFAULT (SELECT id FROM dept WHERE id = 1))":        errors.ErrSubquery,
+	}
+
+	for query, want := range failures {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	// every subquery is planned by the columns it takes from the outer query
+	plans := map[string]string{
+		"SELECT name FROM emp WHERE EXISTS (SELECT id FROM dept WHERE open)":                                     "once",
+		"SELECT name FROM dept d WHERE EXISTS (SELECT id FROM emp WHERE emp.dept = d.id AND salary > 85)":        "hash",
+		"SELECT name FROM emp e WHERE EXISTS (SELECT id FROM emp WHERE emp.dept = e.dept AND salary > e.salary)": "loop",
+		"SELECT name FROM emp e WHERE EXISTS (SELECT id FROM dept WHERE id > e.dept)":                            "loop",
+	}
+
+	for query, want := range plans {
+		selectAst, err := parser.ParseSQLQuery(query)
+
+		if err != nil {
+			t.Fatal(query, err)
+		}
+
+		plan, err := executor.newSubqueries().plan(selectAst.Where.(*expression.Exists).Query.(*ast.Ast))
+
+		if err != nil {
+			t.Fatal(query, err)
+		}
+
+		got := "once"
+
+		if plan.groups != nil {
+			got = "hash"
+		} else if plan.correlated {
+			got = "loop"
+		}
+
+		if got != want {
+			t.Errorf("%s should be planned as %s, got %s", query, want, got)
+		}
+	}
+}
+
+func Test_AddColumnExecutor(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("add_column_test.db")
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	defer os.Remove("add_column_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+
+	executor := NewExecutor(bufferPool, diskManager, tableManager)
+
+	for _, query := range []string{
+		"CREATE TABLE notes (id INT)",
+		"CREATE SEQUENCE note_seq",
+		"INSERT INTO notes VALUES (1), (2)",
+		"ALTER TABLE notes ADD COLUMN tag VARCHAR(8) DEFAULT 'q'",
+		"ALTER TABLE notes ADD COLUMN at TIMESTAMP DEFAULT now() NOT NULL;",
+		"ALTER TABLE notes ADD stamp TIMESTAMP DEFAULT CURRENT_TIMESTAMP",
+		"ALTER TABLE notes ADD seq BIGINT DEFAULT nextval('note_seq') CHECK (seq > 0)",
+		"ALTER TABLE notes ADD flag INT NOT NULL",
+	} {
+		if _, err := executor.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{"SELECT id, tag, seq, flag FROM notes WHERE tag = 'q' AND at IS NOT NULL AND stamp IS NOT NULL", `{"flag":[0,0],"id":[1,2],"seq":[1,2],"tag":["q","q"]}`},
+		{"INSERT INTO notes (id, flag) VALUES (3, 1) RETURNING tag, seq", `{"seq":[3],"tag":["q"]}`},
+	}
+
+	for _, test := range tests {
+		if result, err := executor.QueryExecutor(test.query); err != nil || string(result) != test.expected {
+			t.Error(test.query, "should return", test.expected, string(result), err)
+		}
+	}
+
+	failures := map[string]error{
+		"ALTER TABLE notes ADD c INT DEFAULT 0 CHECK (c > 0)": errors.ErrCheckViolation,
+		"ALTER TABLE notes ADD c INT DEFAULT NULL NOT NULL":   errors.ErrNotNullViolation,
+		"ALTER TABLE notes ADD c INT DEFAULT 'x'":             errors.ErrInvalidValue,
+		"ALTER TABLE notes ADD c INT DEFAULT id":              errors.ErrInvalidDefault,
+		"ALTER TABLE notes ADD c INT UNIQUE":                  errors.ErrAddColumnConstraint,
+		"ALTER TABLE notes ADD c INT REFERENCES notes":        errors.ErrAddColumnConstraint,
+		"INSERT INTO notes (id) VALUES (4)":                   errors.ErrNotNullViolation,
+	}
+
+	for query, expected := range failures {
+		if _, err := executor.QueryExecutor(query); !stderrors.Is(err, expected) {
+			t.Error(query, "should fail with", expected, err)
+		}
+	}
+
+	if columns, _ := tableManager.GetTableMeta("notes"); len(columns) != 6 {
+		t.Error("failed ALTER should not add the column", len(columns))
+	}
+}
@@ -0,0 +1,478 @@
+package executor
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/execution/ast"
+	"strings"
+)
+
+/**
+ *  Subqueries
+ *  +-------------------------------------------+--------------------------------------------+
+ *  | Form                                      | Plan                                       |
+ *  +-------------------------------------------+--------------------------------------------+
+ *  | no column of an outer query               | run once, the rows are kept                |
+ *  | WHERE inner = outer [AND uncorrelated...] | hash join, the inner rows are read once    |
+ *  |                                           | and grouped by the key, an outer row only  |
+ *  |                                           | reads the group of its own key             |
+ *  | any other correlated subquery             | run again for every outer row, the rows of |
+ *  |                                           | its table are read once and kept           |
+ *  +-------------------------------------------+--------------------------------------------+
+ *
+ *  A plan is made when the subquery is evaluated first and lives as long as the statement,
+ *  the kept rows are the table as it was then. An UPDATE which writes the table of its
+ *  subquery row by row does not change what the later rows see.
+ */
+
+// subqueries runs the subqueries of one statement
+type subqueries struct {
+	executor *Executor
+	plans    map[*ast.Ast]*subqueryPlan
+	// the table rows a correlated subquery reads every time it runs again
+	kept map[*ast.Ast][][]*tuple.Value
+}
+
+// subqueryPlan keeps the rows of an uncorrelated subquery, or the inner rows of a hash join
+// by the text of their key
+type subqueryPlan struct {
+	correlated bool
+	width      int
+	rows       [][]*tuple.Value
+	columns    []*column.Column
+	join       expression.Expression
+	outerKey   expression.Expression
+	groups     map[string][]*expression.Row
+}
+
+// scope is what a query resolves a column against, its table and the columns of the table
+type scope struct {
+	name    string
+	columns map[string]bool
+}
+
+func (e *Executor) newSubqueries() *subqueries {
+	return &subqueries{executor: e, plans: make(map[*ast.Ast]*subqueryPlan), kept: make(map[*ast.Ast][][]*tuple.Value)}
+}
+
+// newRow gives the row the sequences and the subqueries of the statement, the columns of
+// the row may be qualified with the table name
+func (s *subqueries) newRow(tableName string, columns []*column.Column, values []*tuple.Value) *expression.Row {
+	row := expression.NewRow(columns, values)
+	row.Sequences = s.executor.tableManager
+	row.Queries = s
+	row.Tables = map[string]*expression.Row{strings.ToLower(tableName): row}
+
+	return row
+}
+
+func (s *subqueries) RunQuery(query expression.Query, outer *expression.Row) ([][]*tuple.Value, int, error) {
+	selectAst, ok := query.(*ast.Ast)
+
+	if !ok {
+		return nil, 0, errors.ErrSyntax
+	}
+
+	plan, exist := s.plans[selectAst]
+
+	if !exist {
+		var err error
+
+		if plan, err = s.plan(selectAst); err != nil {
+			return nil, 0, err
+		}
+
+		s.plans[selectAst] = plan
+	}
+
+	if !plan.correlated {
+		return plan.rows, plan.width, nil
+	}
+
+	if plan.groups != nil {
+		return s.probe(selectAst, plan, outer)
+	}
+
+	names, rows, err := s.executor.selectValues(selectAst, s, outer)
+
+	return rows, len(names), err
+}
+
+// plan runs an uncorrelated subquery at once and groups the rows of a hash join
+func (s *subqueries) plan(query *ast.Ast) (*subqueryPlan, error) {
+	own, err := s.getScope(query)
+
+	if err != nil {
+		return nil, err
+	}
+
+	correlated, err := s.isCorrelated(query, []*scope{own})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !correlated {
+		names, rows, err := s.executor.selectValues(query, s, nil)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return &subqueryPlan{width: len(names), rows: rows}, nil
+	}
+
+	plan := &subqueryPlan{correlated: true}
+
+	join, innerKey, outerKey, rest, err := s.findJoin(query, own)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if join == nil {
+		return plan, s.keepTuples(query)
+	}
+
+	columns, rows, err := s.executor.getSourceRows(query, s, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	plan.columns = columns
+	plan.join = join
+	plan.outerKey = outerKey
+	plan.groups = make(map[string][]*expression.Row)
+
+	for _, row := range rows {
+		if matched, err := expression.EvaluateCondition(rest, row); err != nil {
+			return nil, err
+		} else if !matched {
+			continue
+		}
+
+		key, err := innerKey.Evaluate(row)
+
+		if err != nil {
+			return nil, err
+		}
+
+		// a NULL key equals nothing
+		if !key.IsNull() {
+			plan.groups[getHashKey(key)] = append(plan.groups[getHashKey(key)], row)
+		}
+	}
+
+	return plan, nil
+}
+
+// keepTuples reads the table under the derived tables of the query once, the runs for the
+// outer rows read the kept rows instead of the rows the statement wrote in the meantime
+func (s *subqueries) keepTuples(query *ast.Ast) error {
+	for query.From != nil {
+		query = query.From
+	}
+
+	tuples, err := s.executor.getSelectTuples(query)
+
+	if err != nil {
+		return err
+	}
+
+	s.kept[query] = tuples
+
+	return nil
+}
+
+// probe returns the rows of the group of the outer row, the join condition
+// is checked again because different values may have the same key text
+func (s *subqueries) probe(query *ast.Ast, plan *subqueryPlan, outer *expression.Row) ([][]*tuple.Value, int, error) {
+	key, err := plan.outerKey.Evaluate(outer)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	matched := make([]*expression.Row, 0)
+
+	if !key.IsNull() {
+		for _, row := range plan.groups[getHashKey(key)] {
+			row.Outer = outer
+
+			if ok, err := expression.EvaluateCondition(plan.join, row); err != nil {
+				return nil, 0, err
+			} else if ok {
+				matched = append(matched, row)
+			}
+		}
+	}
+
+	names, rows, err := s.executor.finishSelect(query, s, plan.columns, matched, outer)
+
+	return rows, len(names), err
+}
+
+// findJoin looks for inner = outer among the conditions joined with AND, the other
+// conditions have to be uncorrelated and are returned joined again
+func (s *subqueries) findJoin(query *ast.Ast, own *scope) (join, innerKey, outerKey, rest expression.Expression, err error) {
+	if query.From != nil {
+		fromScope, err := s.getScope(query.From)
+
+		if err != nil {
+			return nil, nil, nil, nil, err
+		}
+
+		if correlated, err := s.isCorrelated(query.From, []*scope{fromScope}); err != nil || correlated {
+			return nil, nil, nil, nil, err
+		}
+	}
+
+	for _, condition := range splitConjuncts(query.Where) {
+		b, ok := condition.(*expression.Binary)
+
+		if join == nil && ok && b.Operator == expression.OPERATOR_EQUAL {
+			for _, sides := range [][]expression.Expression{{b.Left, b.Right}, {b.Right, b.Left}} {
+				if isInnerSide(sides[0], own) && isOuterSide(sides[1], own) {
+					join, innerKey, outerKey = condition, sides[0], sides[1]
+					break
+				}
+			}
+
+			if join == condition {
+				continue
+			}
+		}
+
+		if correlated, err := s.isCorrelatedExpression(condition, []*scope{own}); err != nil || correlated {
+			return nil, nil, nil, nil, err
+		}
+
+		if rest == nil {
+			rest = condition
+		} else {
+			rest = &expression.Binary{Operator: expression.OPERATOR_AND, Left: rest, Right: condition}
+		}
+	}
+
+	return join, innerKey, outerKey, rest, nil
+}
+
+// getScope returns the table and the columns the rows of the query have
+func (s *subqueries) getScope(query *ast.Ast) (*scope, error) {
+	name := query.Table
+
+	if query.Alias != "" {
+		name = query.Alias
+	}
+
+	sc := &scope{name: strings.ToLower(name), columns: make(map[string]bool)}
+
+	if query.From == nil {
+		columns, err := s.executor.tableManager.GetTableMeta(query.Table)
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range columns {
+			sc.columns[c.Name] = true
+		}
+
+		return sc, nil
+	}
+
+	from, err := s.getScope(query.From)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for i, expr := range query.From.Select {
+		if expr != nil {
+			sc.columns[query.From.Column[i]] = true
+			continue
+		}
+
+		for name := range from.columns {
+			sc.columns[name] = true
+		}
+	}
+
+	return sc, nil
+}
+
+// isCorrelated reports whether the query, or a subquery inside of it, references a column
+// none of the scopes has, the last scope is the one of the query
+func (s *subqueries) isCorrelated(query *ast.Ast, scopes []*scope) (bool, error) {
+	for _, expr := range append(append([]expression.Expression{}, query.Select...), query.Where) {
+		if expr == nil {
+			continue
+		}
+
+		if correlated, err := s.isCorrelatedExpression(expr, scopes); err != nil || correlated {
+			return correlated, err
+		}
+	}
+
+	if query.From == nil {
+		return false, nil
+	}
+
+	from, err := s.getScope(query.From)
+
+	if err != nil {
+		return false, err
+	}
+
+	return s.isCorrelated(query.From, append(append([]*scope{}, scopes[:len(scopes)-1]...), from))
+}
+
+func (s *subqueries) isCorrelatedExpression(expr expression.Expression, scopes []*scope) (bool, error) {
+	refs, queries := getReferences(expr)
+
+	for _, ref := range refs {
+		if !resolves(ref, scopes) {
+			return true, nil
+		}
+	}
+
+	for _, query := range queries {
+		inner, err := s.getScope(query)
+
+		if err != nil {
+			return false, err
+		}
+
+		if correlated, err := s.isCorrelated(query, append(append([]*scope{}, scopes...), inner)); err != nil || correlated {
+			return correlated, err
+		}
+	}
+
+	return false, nil
+}
+
+// getReferences returns the columns the expression references and its subqueries,
+// the columns inside of a subquery are left to the subquery
+func getReferences(expr expression.Expression) ([]*expression.ColumnRef, []*ast.Ast) {
+	refs := make([]*expression.ColumnRef, 0)
+	queries := make([]*ast.Ast, 0)
+
+	expression.Walk(expr, func(e expression.Expression) {
+		var query expression.Query
+
+		switch e := e.(type) {
+		case *expression.ColumnRef:
+			refs = append(refs, e)
+		case *expression.Subquery:
+			query = e.Query
+		case *expression.Exists:
+			query = e.Query
+		case *expression.In:
+			query = e.Query
+		}
+
+		if selectAst, ok := query.(*ast.Ast); ok {
+			queries = append(queries, selectAst)
+		}
+	})
+
+	return refs, queries
+}
+
+func resolves(ref *expression.ColumnRef, scopes []*scope) bool {
+	for _, sc := range scopes {
+		if ref.Table != "" && strings.ToLower(ref.Table) == sc.name {
+			return true
+		}
+
+		if ref.Table == "" && sc.columns[ref.Name] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isInnerSide reports whether the expression only references the columns of the subquery
+func isInnerSide(expr expression.Expression, own *scope) bool {
+	refs, queries := getReferences(expr)
+
+	if len(refs) == 0 || len(queries) != 0 {
+		return false
+	}
+
+	for _, ref := range refs {
+		if !resolves(ref, []*scope{own}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// isOuterSide reports whether the expression only references the columns of outer queries
+func isOuterSide(expr expression.Expression, own *scope) bool {
+	refs, queries := getReferences(expr)
+
+	if len(refs) == 0 || len(queries) != 0 {
+		return false
+	}
+
+	for _, ref := range refs {
+		if resolves(ref, []*scope{own}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitConjuncts(condition expression.Expression) []expression.Expression {
+	if condition == nil {
+		return nil
+	}
+
+	if b, ok := condition.(*expression.Binary); ok && b.Operator == expression.OPERATOR_AND {
+		return append(splitConjuncts(b.Left), splitConjuncts(b.Right)...)
+	}
+
+	return []expression.Expression{condition}
+}
+
+// getHashKey returns the text of a join key, the numbers which compare equal have the same text
+func getHashKey(value *tuple.Value) string {
+	switch value.GetType() {
+	case types.SMALL_INT_TYPE, types.INT_TYPE, types.LONG_INT_TYPE, types.DECIMAL_TYPE, types.REAL_TYPE, types.FLOAT_TYPE:
+		if number, err := tuple.ConvertValue(value, types.FLOAT_TYPE, types.FLOAT_SIZE); err == nil {
+			return tuple.GetValueText(number)
+		}
+	case types.CHAR_TYPE:
+		return strings.TrimRight(tuple.GetValueText(value), " ")
+	}
+
+	return tuple.GetValueText(value)
+}
+
+// getDerivedColumns types the columns of a derived table by their first value which is not NULL
+func getDerivedColumns(names []string, rows [][]*tuple.Value) []*column.Column {
+	columns := make([]*column.Column, 0, len(names))
+
+	for i, name := range names {
+		columnType := types.VAR_CHAR_TYPE
+
+		for _, row := range rows {
+			if !row[i].IsNull() {
+				columnType = row[i].GetType()
+				break
+			}
+		}
+
+		columns = append(columns, column.NewColumn(columnType, 0, name))
+	}
+
+	return columns
+}
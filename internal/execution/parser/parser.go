@@ -17,6 +17,8 @@ var (
 func ParseSQLQuery(query string) (*ast.Ast, error) {
 	scan := scanner.Scanner{}
 	scan.Init(strings.NewReader(query))
+	// a string in single quotes is scanned as one char token, the expression parser reads it
+	scan.Error = func(*scanner.Scanner, string) {}
 
 	var (
 		_ast *ast.Ast
@@ -36,6 +38,22 @@ func ParseSQLQuery(query string) (*ast.Ast, error) {
 		_ast, err = ast.InsertAst(query, &scan)
 	case types.CREATE_QUERY_TYPE:
 		_ast, err = ast.CreateTableAst(query, &scan)
+	case types.DROP_QUERY_TYPE:
+		_ast, err = ast.DropTableAst(query, &scan)
+	case types.TRUNCATE_QUERY_TYPE:
+		_ast, err = ast.TruncateTableAst(query, &scan)
+	case types.ALTER_QUERY_TYPE:
+		_ast, err = ast.AlterTableAst(query, &scan)
+	case types.ANALYZE_QUERY_TYPE:
+		_ast, err = ast.AnalyzeTableAst(query, &scan)
+	case types.SHOW_QUERY_TYPE:
+		_ast, err = ast.ShowAst(query, &scan)
+	case types.DESCRIBE_QUERY_TYPE, types.QUERY_CHAR_DESC:
+		_ast, err = ast.DescribeAst(query, &scan)
+	case types.UPDATE_QUERY_TYPE:
+		_ast, err = ast.UpdateAst(query, &scan)
+	case types.DELETE_QUERY_TYPE:
+		_ast, err = ast.DeleteAst(query, &scan)
 	}
 
 	if err != nil {
@@ -0,0 +1,366 @@
+package inspector
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/index"
+	"go-db/internal/catalog/schema"
+	"go-db/internal/catalog/table"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"go-db/internal/storage/page"
+)
+
+type ColumnDump struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Size int32  `json:"size"`
+}
+
+type TupleDump struct {
+	Index   int32         `json:"index"`
+	Offset  int32         `json:"offset"`
+	Size    int32         `json:"size"`
+	Deleted bool          `json:"deleted,omitempty"`
+	Values  []interface{} `json:"values,omitempty"`
+	Error   string        `json:"error,omitempty"`
+}
+
+type SuperBlockDump struct {
+	Version           uint32          `json:"version"`
+	PageSize          uint32          `json:"page_size"`
+	CatalogRootPageID types.Page_id_t `json:"catalog_root_page_id"`
+	FreeListHead      types.Page_id_t `json:"free_list_head"`
+	CheckpointLSN     uint64          `json:"checkpoint_lsn"`
+}
+
+// PageDump only fills the fields which belong to the page type
+type PageDump struct {
+	PageID           types.Page_id_t  `json:"page_id"`
+	Type             string           `json:"type"`
+	Checksum         uint32           `json:"checksum"`
+	ChecksumValid    bool             `json:"checksum_valid"`
+	PrevPageID       *types.Page_id_t `json:"prev_page_id,omitempty"`
+	NextPageID       *types.Page_id_t `json:"next_page_id,omitempty"`
+	SuperBlock       *SuperBlockDump  `json:"super_block,omitempty"`
+	Table            string           `json:"table,omitempty"`
+	DataPageID       *types.Page_id_t `json:"data_page_id,omitempty"`
+	Columns          []*ColumnDump    `json:"columns,omitempty"`
+	FreeSpacePointer *int32           `json:"free_space_pointer,omitempty"`
+	TupleCount       *int32           `json:"tuple_count,omitempty"`
+	Tuples           []*TupleDump     `json:"tuples,omitempty"`
+	IsLeaf           *bool            `json:"is_leaf,omitempty"`
+	KeySize          *int32           `json:"key_size,omitempty"`
+	KeyCount         *int32           `json:"key_count,omitempty"`
+}
+
+/**
+ *  Inspector decodes pages straight from the database file, the data pages
+ *  are decoded with the schema of the table whose chain reaches them.
+ */
+
+type Inspector struct {
+	diskManager *disk.Disk
+	owners      map[types.Page_id_t]*owner
+	ownerLoaded bool
+}
+
+type owner struct {
+	tableName string
+	columns   []*column.Column
+}
+
+func NewInspector(diskManager *disk.Disk) *Inspector {
+	return &Inspector{
+		diskManager: diskManager,
+		owners:      make(map[types.Page_id_t]*owner),
+	}
+}
+
+func (i *Inspector) DumpPages() ([]*PageDump, error) {
+	pageNumber := i.diskManager.GetPageNumber()
+	dumps := make([]*PageDump, 0, pageNumber)
+
+	for pageID := types.Page_id_t(0); pageID < types.Page_id_t(pageNumber); pageID++ {
+		dump, err := i.DumpPage(pageID)
+
+		if err != nil {
+			return nil, err
+		}
+
+		dumps = append(dumps, dump)
+	}
+
+	return dumps, nil
+}
+
+func (i *Inspector) DumpPage(pageID types.Page_id_t) (*PageDump, error) {
+	if !i.ownerLoaded {
+		i.loadOwners()
+		i.ownerLoaded = true
+	}
+
+	p, err := i.readPage(pageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dump := &PageDump{
+		PageID:        pageID,
+		Type:          PageTypeName(p.GetPageTye()),
+		Checksum:      page.GetChecksum(p.GetData()),
+		ChecksumValid: page.IsChecksumValid(p.GetData()),
+	}
+
+	switch p.GetPageTye() {
+	case types.SUPER_BLOCK_PAGE_TYPE:
+		if superBlock, err := disk.SuperBlockDeserialization(p.GetData()); err == nil {
+			dump.SuperBlock = &SuperBlockDump{
+				Version:           superBlock.Version,
+				PageSize:          superBlock.PageSize,
+				CatalogRootPageID: superBlock.CatalogRootPageID,
+				FreeListHead:      superBlock.FreeListHead,
+				CheckpointLSN:     superBlock.CheckpointLSN,
+			}
+		}
+	case types.META_PAGE_TYPE:
+		metaPage := schema.GetSchema(p)
+		prevPageID, nextPageID := metaPage.GetPrevPageID(), metaPage.GetNextPageID()
+		dump.PrevPageID = &prevPageID
+		dump.NextPageID = &nextPageID
+
+		// only the first page of the chain holds the table name and the data page
+		if prevPageID == constant.INVALID_PAGE_ID {
+			dataPageID := metaPage.GetDataPageID()
+			dump.Table = metaPage.GetTableName()
+			dump.DataPageID = &dataPageID
+		}
+
+		if metaPage.IsReadable() {
+			for _, c := range metaPage.GetColumns() {
+				dump.Columns = append(dump.Columns, &ColumnDump{
+					Name: c.Name,
+					Type: c.GetTypeName(),
+					Size: c.Size,
+				})
+			}
+		}
+	case types.DATA_PAGE_TYPE:
+		i.dumpDataTable(table.GetDataTable(p), dump)
+	case types.INDEX_PAGE_TYPE:
+		indexPage := index.GetIndexPage(p)
+		prevPageID, nextPageID := indexPage.GetPrevPageID(), indexPage.GetNextPageID()
+		isLeaf, keySize, keyCount := indexPage.IsLeaf(), indexPage.GetKeySize(), indexPage.GetKeyCount()
+		dump.PrevPageID = &prevPageID
+		dump.NextPageID = &nextPageID
+		dump.IsLeaf = &isLeaf
+		dump.KeySize = &keySize
+		dump.KeyCount = &keyCount
+	case types.FREE_PAGE_TYPE:
+		nextPageID := disk.GetNextFreePageID(p.GetData())
+		dump.NextPageID = &nextPageID
+	}
+
+	return dump, nil
+}
+
+func (i *Inspector) dumpDataTable(dataTable *table.DataTable, dump *PageDump) {
+	prevPageID, nextPageID := dataTable.GetPrevPageID(), dataTable.GetNextPageID()
+	freeSpacePointer, tupleCount := dataTable.GetFreeSpacePointer(), dataTable.GetTupleCount()
+
+	dump.PrevPageID = &prevPageID
+	dump.NextPageID = &nextPageID
+	dump.FreeSpacePointer = &freeSpacePointer
+	dump.TupleCount = &tupleCount
+
+	owner, exist := i.owners[dataTable.GetPageID()]
+
+	if exist {
+		dump.Table = owner.tableName
+	}
+
+	if tupleCount < 0 || types.TUPLE_COUNT_OFFSET+tupleCount*(types.TUPLE_OFFSET+types.TUPLE_SIZE) > constant.PAGE_SIZE {
+		return
+	}
+
+	for index := int32(0); index < tupleCount; index++ {
+		offset, size, _ := dataTable.GetTupleMetaByIndex(index)
+
+		tupleDump := &TupleDump{
+			Index:  index,
+			Offset: offset,
+			Size:   size,
+		}
+
+		dump.Tuples = append(dump.Tuples, tupleDump)
+
+		if offset == 0 {
+			tupleDump.Deleted = true
+			continue
+		}
+
+		if !exist {
+			continue
+		}
+
+		if offset < 0 || size < 0 || offset+size > constant.PAGE_SIZE || size != tuple.GetTupleSize(owner.columns) {
+			tupleDump.Error = "tuple does not match the table schema"
+			continue
+		}
+
+		for _, value := range tuple.TupleDeserialization(owner.columns, dataTable.GetData()[offset:offset+size]) {
+			tupleDump.Values = append(tupleDump.Values, tuple.GetValueInterface(value))
+		}
+	}
+}
+
+// loadOwners maps every data page to the schema of the table which owns it,
+// the tables are found through the sys_tables rows at the catalog root
+func (i *Inspector) loadOwners() {
+	visited := make(map[types.Page_id_t]struct{})
+	rootPageID := i.diskManager.GetCatalogRootPageID()
+
+	catalog, dataPageIDs := i.loadTable(rootPageID, visited)
+
+	if catalog == nil {
+		return
+	}
+
+	for _, dataPageID := range dataPageIDs {
+		p, err := i.readPage(dataPageID)
+
+		if err != nil {
+			continue
+		}
+
+		dataTable := table.GetDataTable(p)
+		tupleCount := dataTable.GetTupleCount()
+
+		if tupleCount < 0 || types.TUPLE_COUNT_OFFSET+tupleCount*(types.TUPLE_OFFSET+types.TUPLE_SIZE) > constant.PAGE_SIZE {
+			continue
+		}
+
+		for index := int32(0); index < tupleCount; index++ {
+			offset, size, _ := dataTable.GetTupleMetaByIndex(index)
+
+			if offset <= 0 || size != tuple.GetTupleSize(catalog.columns) || offset+size > constant.PAGE_SIZE {
+				continue
+			}
+
+			values := tuple.TupleDeserialization(catalog.columns, p.GetData()[offset:offset+size])
+
+			if metaPageID := types.Page_id_t(values[1].INT); metaPageID != rootPageID {
+				i.loadTable(metaPageID, visited)
+			}
+		}
+	}
+}
+
+// loadTable records the table as the owner of its data pages and returns them
+func (i *Inspector) loadTable(metaPageID types.Page_id_t, visited map[types.Page_id_t]struct{}) (*owner, []types.Page_id_t) {
+	if _, exist := visited[metaPageID]; exist {
+		return nil, nil
+	}
+
+	visited[metaPageID] = struct{}{}
+
+	metaPage, err := i.readPage(metaPageID)
+
+	if err != nil || metaPage.GetPageTye() != types.META_PAGE_TYPE {
+		return nil, nil
+	}
+
+	owner, ok := i.loadOwner(schema.GetSchema(metaPage), visited)
+
+	if !ok {
+		return nil, nil
+	}
+
+	dataPageIDs := make([]types.Page_id_t, 0)
+	dataPageID := schema.GetSchema(metaPage).GetDataPageID()
+
+	for dataPageID != constant.INVALID_PAGE_ID {
+		if _, exist := visited[dataPageID]; exist {
+			break
+		}
+
+		visited[dataPageID] = struct{}{}
+
+		dataPage, err := i.readPage(dataPageID)
+
+		if err != nil || dataPage.GetPageTye() != types.DATA_PAGE_TYPE {
+			break
+		}
+
+		i.owners[dataPageID] = owner
+		dataPageIDs = append(dataPageIDs, dataPageID)
+		dataPageID = table.GetDataTable(dataPage).GetNextPageID()
+	}
+
+	return owner, dataPageIDs
+}
+
+// loadOwner collects the columns along the schema page chain
+func (i *Inspector) loadOwner(metaPage *schema.Schema, visited map[types.Page_id_t]struct{}) (*owner, bool) {
+	o := &owner{tableName: metaPage.GetTableName()}
+
+	for {
+		if !metaPage.IsReadable() {
+			return nil, false
+		}
+
+		o.columns = append(o.columns, metaPage.GetColumns()...)
+		nextPageID := metaPage.GetNextPageID()
+
+		if nextPageID == constant.INVALID_PAGE_ID {
+			return o, true
+		}
+
+		if _, exist := visited[nextPageID]; exist {
+			return nil, false
+		}
+
+		visited[nextPageID] = struct{}{}
+
+		p, err := i.readPage(nextPageID)
+
+		if err != nil || p.GetPageTye() != types.META_PAGE_TYPE {
+			return nil, false
+		}
+
+		metaPage = schema.GetSchema(p)
+	}
+}
+
+func (i *Inspector) readPage(pageID types.Page_id_t) (*page.Page, error) {
+	data, err := i.diskManager.ReadPage(pageID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := page.NewPage()
+	p.SetPageID(pageID)
+	copy(p.GetData(), data)
+
+	return p, nil
+}
+
+func PageTypeName(pageType types.PAGE_TYPE) string {
+	switch pageType {
+	case types.SUPER_BLOCK_PAGE_TYPE:
+		return "SUPER_BLOCK"
+	case types.META_PAGE_TYPE:
+		return "META"
+	case types.DATA_PAGE_TYPE:
+		return "DATA"
+	case types.FREE_PAGE_TYPE:
+		return "FREE"
+	case types.INDEX_PAGE_TYPE:
+		return "INDEX"
+	}
+
+	return "INVALID"
+}
@@ -0,0 +1,100 @@
+package inspector
+
+import (
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/table"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/disk"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func Test_DumpPages(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+
+	tableManager := table.NewTableManager(bufferPool, map[string]types.Page_id_t{})
+
+	columns := []*column.Column{
+		column.NewColumn(types.INT_TYPE, 0, "int_types"),
+		column.NewColumn(types.VAR_CHAR_TYPE, 0, "var_char_type"),
+	}
+
+	if err := tableManager.CreateNewTable("testTable", columns); err != nil {
+		t.Fatal(err)
+	}
+
+	values := []*tuple.Value{
+		tuple.GetValue(int32(7), columns[0].GetColumnType(), columns[0].GetColumnSize()),
+		tuple.GetValue([]byte("abc"), columns[1].GetColumnType(), columns[1].GetColumnSize()),
+	}
+
+	if err := tableManager.InsertTuple("testTable", values); err != nil {
+		t.Fatal(err)
+	}
+
+	dumps, err := NewInspector(diskManager).DumpPages()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metaDumps := make(map[string]*PageDump)
+	dataDumps := make(map[string]*PageDump)
+
+	for _, dump := range dumps {
+		if !dump.ChecksumValid {
+			t.Error("page checksum should be valid", dump.PageID)
+		}
+
+		switch dump.Type {
+		case "META":
+			metaDumps[dump.Table] = dump
+		case "DATA":
+			if _, exist := dataDumps[dump.Table]; !exist {
+				dataDumps[dump.Table] = dump
+			}
+		}
+	}
+
+	if dumps[0].Type != "SUPER_BLOCK" || dumps[0].SuperBlock == nil || dumps[0].SuperBlock.CatalogRootPageID != 1 {
+		t.Fatal("super block dump wrong")
+	}
+
+	if dumps[1].Table != types.SYSTEM_TABLES {
+		t.Error("catalog root should be sys_tables", dumps[1].Table)
+	}
+
+	metaDump, exist := metaDumps["testTable"]
+
+	if !exist || len(metaDump.Columns) != 2 || metaDump.Columns[1].Type != types.COLUMN_TYPE_VAR_CHAR {
+		t.Fatal("schema dump wrong")
+	}
+
+	dataDump, exist := dataDumps["testTable"]
+
+	if !exist || *dataDump.TupleCount != 1 || len(dataDump.Tuples) != 1 {
+		t.Fatal("data page dump wrong")
+	}
+
+	if !reflect.DeepEqual(dataDump.Tuples[0].Values, []interface{}{int32(7), "abc"}) {
+		t.Error("tuple decode wrong", dataDump.Tuples[0].Values)
+	}
+
+	catalogDump := dataDumps[types.SYSTEM_TABLES]
+	lastRow := catalogDump.Tuples[len(catalogDump.Tuples)-1].Values
+
+	if !reflect.DeepEqual(lastRow, []interface{}{"testTable", int32(metaDump.PageID)}) {
+		t.Error("sys_tables row wrong", lastRow)
+	}
+}
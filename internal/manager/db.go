@@ -3,9 +3,7 @@ package manager
 import (
 	"fmt"
 	"go-db/internal/buffer"
-	"go-db/internal/catalog/schema"
 	"go-db/internal/catalog/table"
-	"go-db/internal/common/types"
 	"go-db/internal/execution/executor"
 	"go-db/internal/storage/disk"
 	"log"
@@ -18,8 +16,17 @@ type DB struct {
 	executor *executor.Executor
 }
 
-func InitDatabase(dbBaseName string, bufferPoolSize int32) *DB {
-	diskManager, err := disk.NewDiskStorage(dbBaseName)
+func InitDatabase(dbBaseName string, bufferPoolSize int32, doubleWrite bool) *DB {
+	var (
+		diskManager *disk.Disk
+		err         error
+	)
+
+	if doubleWrite {
+		diskManager, err = disk.NewDoubleWriteDiskStorage(dbBaseName)
+	} else {
+		diskManager, err = disk.NewDiskStorage(dbBaseName)
+	}
 
 	if err != nil {
 		log.Fatal(err)
@@ -27,25 +34,12 @@ func InitDatabase(dbBaseName string, bufferPoolSize int32) *DB {
 
 	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, bufferPoolSize)
 
-	tablePageMap := make(map[string]types.Page_id_t)
-
-	pageNumber := diskManager.GetPageNumber()
+	tableManager, err := table.LoadTableManager(bufferPool)
 
-	for i := 0; i < int(pageNumber); i++ {
-		diskPage, err := bufferPool.FetchPage(types.Page_id_t(i))
-
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		if diskPage.GetPageTye() == types.META_PAGE_TYPE {
-			metaPage := schema.GetSchema(diskPage)
-			tablePageMap[metaPage.GetTableName()] = types.Page_id_t(i)
-		}
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	tableManager := table.NewTableManager(bufferPool, tablePageMap)
-
 	d := &DB{
 		executor: executor.NewExecutor(bufferPool, diskManager, tableManager),
 	}
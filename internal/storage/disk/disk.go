@@ -1,18 +1,49 @@
 package disk
 
 import (
+	"encoding/binary"
 	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
 	"go-db/internal/common/types"
+	"go-db/internal/storage/page"
+	"io"
+	"log"
 	"os"
 )
 
+/**
+ *  FREE_PAGE_TYPE
+ *  +-------------+---------------+-------------------+-------------+
+ *  | PageType (4)| PrevPageId (4)| NextFreePageId (4)| Checksum (4)|
+ *  +-------------+---------------+-------------------+-------------+
+ *
+ *  Deleted pages are chained together through NextFreePageId, bytes 8..12
+ *  of the header which hold NextPageId on the other pages, the head of the
+ *  chain is kept in the super block and AllocatePage takes it before
+ *  extending the file. A page taken from the chain is cleared on disk, so
+ *  only the pages on the chain are FREE_PAGE_TYPE and DeallocatePage can
+ *  refuse a page which is free already instead of linking it twice.
+ */
+
 type Disk struct {
-	fileName   string
-	nextPageID types.Page_id_t
-	file       *os.File
+	fileName    string
+	nextPageID  types.Page_id_t
+	superBlock  *SuperBlock
+	file        *os.File
+	doubleWrite *doubleWriteBuffer
 }
 
 func NewDiskStorage(DBFileName string) (*Disk, error) {
+	return openDiskStorage(DBFileName, false)
+}
+
+// NewDoubleWriteDiskStorage writes every page to the double write buffer
+// before writing it in place so a torn page can be recovered after crash
+func NewDoubleWriteDiskStorage(DBFileName string) (*Disk, error) {
+	return openDiskStorage(DBFileName, true)
+}
+
+func openDiskStorage(DBFileName string, doubleWrite bool) (*Disk, error) {
 
 	file, err := os.OpenFile(DBFileName, os.O_RDWR|os.O_CREATE|os.O_SYNC, 0755)
 
@@ -20,16 +51,59 @@ func NewDiskStorage(DBFileName string) (*Disk, error) {
 		return nil, err
 	}
 
+	d := &Disk{
+		fileName: DBFileName,
+		file:     file,
+	}
+
+	if doubleWrite {
+		d.doubleWrite, err = openDoubleWriteBuffer(DBFileName + DOUBLE_WRITE_FILE_SUFFIX)
+
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		if err := d.doubleWrite.recover(d); err != nil {
+			d.ShutDown()
+			return nil, err
+		}
+	}
+
 	fi, err := file.Stat()
 
 	if err != nil {
+		d.ShutDown()
 		return nil, err
 	}
 
-	d := &Disk{
-		fileName:   DBFileName,
-		nextPageID: types.Page_id_t(fi.Size() / int64(constant.PAGE_SIZE)),
-		file:       file,
+	d.nextPageID = types.Page_id_t(fi.Size() / int64(constant.PAGE_SIZE))
+
+	if d.nextPageID == 0 {
+		// new database file, page 0 is reserved for the super block
+		d.superBlock = NewSuperBlock()
+		d.nextPageID = constant.SUPER_BLOCK_PAGE_ID + 1
+
+		if err := d.writeSuperBlock(); err != nil {
+			d.ShutDown()
+			return nil, err
+		}
+
+		return d, nil
+	}
+
+	data, err := d.ReadPage(constant.SUPER_BLOCK_PAGE_ID)
+
+	if err != nil {
+		d.ShutDown()
+		return nil, err
+	}
+
+	d.superBlock, err = SuperBlockDeserialization(data)
+
+	if err != nil {
+		d.ShutDown()
+		return nil, err
 	}
 
 	return d, nil
@@ -37,9 +111,31 @@ func NewDiskStorage(DBFileName string) (*Disk, error) {
 
 func (D *Disk) ShutDown() {
 	D.file.Close()
+
+	if D.doubleWrite != nil {
+		// every page was synced in place, nothing is left to recover
+		if err := D.doubleWrite.clear(); err != nil {
+			log.Println(err)
+		}
+
+		D.doubleWrite.close()
+	}
 }
 
+// WritePage always writes a whole page so the file never ends with a short page
 func (D *Disk) WritePage(pageID types.Page_id_t, pageData []byte) error {
+	if len(pageData) < constant.PAGE_SIZE {
+		data := make([]byte, constant.PAGE_SIZE)
+		copy(data, pageData)
+		pageData = data
+	}
+
+	if D.doubleWrite != nil {
+		if err := D.doubleWrite.write(pageID, pageData); err != nil {
+			return err
+		}
+	}
+
 	offset := pageID * constant.PAGE_SIZE
 
 	_, err := D.file.Seek(int64(offset), 0)
@@ -58,36 +154,40 @@ func (D *Disk) WritePage(pageID types.Page_id_t, pageData []byte) error {
 	return nil
 }
 
+// WritePageOffset overwrites a part of the page, the page is read and written again
+// whole with a new checksum so it goes through the double write buffer as well
 func (D *Disk) WritePageOffset(pageID types.Page_id_t, offset uint32, pageData []byte) error {
-	pageOffset := pageID * constant.PAGE_SIZE
-
-	_, err := D.file.Seek(int64(pageOffset)+int64(offset), 0)
-
-	if err != nil {
-		return err
+	if int(offset)+len(pageData) > constant.PAGE_SIZE {
+		return errors.ErrPageOffsetOutOfRange
 	}
 
-	_, err = D.file.Write(pageData)
+	data, err := D.ReadPage(pageID)
 
 	if err != nil {
 		return err
 	}
 
-	D.file.Sync()
-	return nil
+	copy(data[offset:], pageData)
+	page.SetChecksum(data)
+
+	return D.WritePage(pageID, data)
 }
 
+// ReadPage returns a zero page when the page was allocated but never written,
+// a page cut off by the end of the file was torn and is reported as corrupted
 func (D *Disk) ReadPage(pageID types.Page_id_t) (data []byte, err error) {
 	data = make([]byte, constant.PAGE_SIZE)
 	offset := pageID * constant.PAGE_SIZE
 
-	_, err = D.file.Seek(int64(offset), 0)
+	n, err := D.file.ReadAt(data, int64(offset))
 
-	if err != nil {
-		return nil, err
-	}
+	if err == io.EOF {
+		if n == 0 {
+			return data, nil
+		}
 
-	D.file.Read(data)
+		return nil, errors.ErrPageCorrupted
+	}
 
 	if err != nil {
 		return nil, err
@@ -103,7 +203,107 @@ func (D *Disk) GetPageNumber() int32 {
 }
 
 func (D *Disk) AllocatePage() types.Page_id_t {
+	if D.superBlock.FreeListHead != constant.INVALID_PAGE_ID {
+		pageID := D.superBlock.FreeListHead
+
+		data, err := D.ReadPage(pageID)
+
+		if err == nil && isFreePage(data) {
+			D.superBlock.FreeListHead = GetNextFreePageID(data)
+
+			if err := D.writeSuperBlock(); err != nil {
+				log.Println(err)
+			}
+
+			// the page reads as allocated but never written until its owner flushes it
+			if err := D.WritePage(pageID, make([]byte, constant.PAGE_SIZE)); err != nil {
+				log.Println(err)
+			}
+
+			return pageID
+		}
+
+		// the broken head is dropped on disk as well, otherwise it is loaded again on restart
+		log.Println("free page list broken at page", pageID, err)
+		D.superBlock.FreeListHead = constant.INVALID_PAGE_ID
+
+		if err := D.writeSuperBlock(); err != nil {
+			log.Println(err)
+		}
+	}
+
 	pageID := D.nextPageID
 	D.nextPageID++
 	return pageID
 }
+
+// DeallocatePage marks the page as free on disk and pushes it
+// to the head of the free page list, the super block, a page past
+// the end of the file and a page which is free already are refused
+func (D *Disk) DeallocatePage(pageID types.Page_id_t) error {
+	if pageID == constant.SUPER_BLOCK_PAGE_ID {
+		return errors.ErrFreeSuperBlock
+	}
+
+	if pageID < 0 || pageID >= D.nextPageID {
+		return errors.ErrPageNotFound
+	}
+
+	data, err := D.ReadPage(pageID)
+
+	if err != nil {
+		return err
+	}
+
+	// freeing it again would close the chain into a cycle
+	if isFreePage(data) {
+		return errors.ErrPageAlreadyFree
+	}
+
+	data = make([]byte, constant.PAGE_SIZE)
+
+	binary.BigEndian.PutUint32(data[:types.PAGE_TYPE_OFFSET], uint32(types.FREE_PAGE_TYPE))
+	binary.BigEndian.PutUint32(data[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET], uint32(D.superBlock.FreeListHead))
+	page.SetChecksum(data)
+
+	if err := D.WritePage(pageID, data); err != nil {
+		return err
+	}
+
+	D.superBlock.FreeListHead = pageID
+	return D.writeSuperBlock()
+}
+
+func (D *Disk) GetFreeListHead() types.Page_id_t {
+	return D.superBlock.FreeListHead
+}
+
+func (D *Disk) GetCatalogRootPageID() types.Page_id_t {
+	return D.superBlock.CatalogRootPageID
+}
+
+func (D *Disk) SetCatalogRootPageID(pageID types.Page_id_t) error {
+	D.superBlock.CatalogRootPageID = pageID
+	return D.writeSuperBlock()
+}
+
+func (D *Disk) GetCheckpointLSN() uint64 {
+	return D.superBlock.CheckpointLSN
+}
+
+func (D *Disk) SetCheckpointLSN(lsn uint64) error {
+	D.superBlock.CheckpointLSN = lsn
+	return D.writeSuperBlock()
+}
+
+func (D *Disk) writeSuperBlock() error {
+	return D.WritePage(constant.SUPER_BLOCK_PAGE_ID, D.superBlock.Serialization())
+}
+
+func isFreePage(data []byte) bool {
+	return page.IsChecksumValid(data) && types.PAGE_TYPE(binary.BigEndian.Uint32(data[:types.PAGE_TYPE_OFFSET])) == types.FREE_PAGE_TYPE
+}
+
+func GetNextFreePageID(data []byte) types.Page_id_t {
+	return types.Page_id_t(binary.BigEndian.Uint32(data[types.PREV_PAGE_ID_OFFSET:types.NEXT_PAGE_ID_OFFSET]))
+}
@@ -1,6 +1,10 @@
 package disk
 
 import (
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/page"
 	"os"
 	"testing"
 )
@@ -73,3 +77,328 @@ func Test_PageIDOffSet_Disk(t *testing.T) {
 		t.Fatal("Wrong", string(nextdata))
 	}
 }
+
+func Test_FreePageList_Disk(t *testing.T) {
+	disk, err := NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	pageIDs := make([]types.Page_id_t, 0, 3)
+
+	for i := 0; i < 3; i++ {
+		pageID := disk.AllocatePage()
+
+		if err := disk.WritePage(pageID, []byte("12345")); err != nil {
+			t.Fatal(err)
+		}
+
+		pageIDs = append(pageIDs, pageID)
+	}
+
+	if err := disk.DeallocatePage(pageIDs[0]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disk.DeallocatePage(pageIDs[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	disk.ShutDown()
+
+	disk, err = NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if disk.GetFreeListHead() != pageIDs[2] {
+		t.Error("free list head not recovered", disk.GetFreeListHead())
+	}
+
+	if pageID := disk.AllocatePage(); pageID != pageIDs[2] {
+		t.Error("should reuse the last free page", pageID)
+	}
+
+	if pageID := disk.AllocatePage(); pageID != pageIDs[0] {
+		t.Error("should reuse the first free page", pageID)
+	}
+
+	if pageID := disk.AllocatePage(); pageID != pageIDs[2]+1 {
+		t.Error("should extend the file", pageID)
+	}
+}
+
+func Test_DoubleFreePage_Disk(t *testing.T) {
+	disk, err := NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+	defer disk.ShutDown()
+
+	first, second := disk.AllocatePage(), disk.AllocatePage()
+
+	for _, pageID := range []types.Page_id_t{first, second} {
+		if err := disk.DeallocatePage(pageID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := disk.DeallocatePage(first); err != errors.ErrPageAlreadyFree {
+		t.Error("page should not be freed twice", err)
+	}
+
+	if err := disk.DeallocatePage(constant.SUPER_BLOCK_PAGE_ID); err != errors.ErrFreeSuperBlock {
+		t.Error("super block should not be freed", err)
+	}
+
+	if err := disk.DeallocatePage(second + 1); err != errors.ErrPageNotFound {
+		t.Error("page past the end of the file should not be freed", err)
+	}
+
+	// a page taken from the free list may be freed again before it was ever written
+	if pageID := disk.AllocatePage(); pageID != second {
+		t.Fatal("should reuse the last free page", pageID)
+	}
+
+	if err := disk.DeallocatePage(second); err != nil {
+		t.Error("reused page should be freed", err)
+	}
+
+	// every page is handed out once, the list has no cycle
+	seen := make(map[types.Page_id_t]bool)
+
+	for i := 0; i < 4; i++ {
+		pageID := disk.AllocatePage()
+
+		if seen[pageID] {
+			t.Fatal("page should be handed out once", pageID)
+		}
+
+		seen[pageID] = true
+	}
+
+	if !seen[first] || !seen[second] {
+		t.Error("free pages should be reused", seen)
+	}
+}
+
+func Test_BrokenFreePageList_Disk(t *testing.T) {
+	disk, err := NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	pageID := disk.AllocatePage()
+
+	if err := disk.DeallocatePage(pageID); err != nil {
+		t.Fatal(err)
+	}
+
+	// the free page is overwritten, so the head of the list is no free page anymore
+	if err := disk.WritePage(pageID, []byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+
+	if newPageID := disk.AllocatePage(); newPageID != pageID+1 {
+		t.Error("should extend the file", newPageID)
+	}
+
+	disk.ShutDown()
+
+	disk, err = NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer disk.ShutDown()
+
+	if disk.GetFreeListHead() != constant.INVALID_PAGE_ID {
+		t.Error("broken free list head should not be loaded again", disk.GetFreeListHead())
+	}
+}
+
+func Test_SuperBlock_Disk(t *testing.T) {
+	disk, err := NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	if pageID := disk.AllocatePage(); pageID == constant.SUPER_BLOCK_PAGE_ID {
+		t.Error("super block page should never be allocated")
+	}
+
+	if disk.GetCatalogRootPageID() != constant.INVALID_PAGE_ID {
+		t.Error("new database should not have catalog root")
+	}
+
+	if err := disk.SetCatalogRootPageID(5); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := disk.SetCheckpointLSN(42); err != nil {
+		t.Fatal(err)
+	}
+
+	disk.ShutDown()
+
+	disk, err = NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if disk.GetCatalogRootPageID() != 5 {
+		t.Error("catalog root not persisted", disk.GetCatalogRootPageID())
+	}
+
+	if disk.GetCheckpointLSN() != 42 {
+		t.Error("checkpoint lsn not persisted", disk.GetCheckpointLSN())
+	}
+
+	superBlock := NewSuperBlock()
+	superBlock.Version = constant.DB_FORMAT_VERSION + 1
+
+	if err := disk.WritePage(constant.SUPER_BLOCK_PAGE_ID, superBlock.Serialization()); err != nil {
+		t.Fatal(err)
+	}
+
+	disk.ShutDown()
+
+	if _, err = NewDiskStorage("test.db"); err != errors.ErrIncompatibleVersion {
+		t.Error("should refuse incompatible version", err)
+	}
+
+	if err := os.WriteFile("test.db", make([]byte, constant.PAGE_SIZE), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = NewDiskStorage("test.db"); err != errors.ErrNotDatabaseFile {
+		t.Error("should refuse file without super block", err)
+	}
+}
+
+func Test_DoubleWrite_Disk(t *testing.T) {
+	disk, err := NewDoubleWriteDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+	defer os.Remove("test.db" + DOUBLE_WRITE_FILE_SUFFIX)
+
+	pageID := disk.AllocatePage()
+
+	data := make([]byte, constant.PAGE_SIZE)
+	copy(data[constant.PAGE_SIZE-5:], []byte("12345"))
+	page.SetChecksum(data)
+
+	if err := disk.WritePage(pageID, data); err != nil {
+		t.Fatal(err)
+	}
+
+	// a crash leaves the buffer behind, a clean shut down would clear it
+	disk.file.Close()
+	disk.doubleWrite.close()
+
+	if info, err := os.Stat("test.db" + DOUBLE_WRITE_FILE_SUFFIX); err != nil || info.Size() == 0 {
+		t.Fatal("double write buffer should be left after a crash", err)
+	}
+
+	// simulate a torn write which only reached the first half of the page
+	file, err := os.OpenFile("test.db", os.O_RDWR, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := file.WriteAt(make([]byte, constant.PAGE_SIZE/2), int64(pageID)*constant.PAGE_SIZE+constant.PAGE_SIZE/2); err != nil {
+		t.Fatal(err)
+	}
+
+	file.Close()
+
+	disk, err = NewDoubleWriteDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := disk.ReadPage(pageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !page.IsChecksumValid(recovered) || string(recovered[constant.PAGE_SIZE-5:]) != "12345" {
+		t.Error("torn page not recovered")
+	}
+
+	if info, err := os.Stat("test.db" + DOUBLE_WRITE_FILE_SUFFIX); err != nil || info.Size() != 0 {
+		t.Error("double write buffer should be cleared after recovery", err)
+	}
+
+	if err := disk.WritePage(pageID, data); err != nil {
+		t.Fatal(err)
+	}
+
+	disk.ShutDown()
+
+	if info, err := os.Stat("test.db" + DOUBLE_WRITE_FILE_SUFFIX); err != nil || info.Size() != 0 {
+		t.Error("double write buffer should be cleared on shut down", err)
+	}
+}
+
+func Test_ShortPage_Disk(t *testing.T) {
+	disk, err := NewDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+
+	pageID := disk.AllocatePage()
+
+	// a crash in the middle of the write leaves the page cut off by the end of the file
+	if _, err := disk.file.WriteAt([]byte("12345"), int64(pageID)*constant.PAGE_SIZE); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := disk.ReadPage(pageID); err != errors.ErrPageCorrupted {
+		t.Error("short page should be reported", err)
+	}
+}
+
+func Test_WritePageOffset_Disk(t *testing.T) {
+	disk, err := NewDoubleWriteDiskStorage("test.db")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("test.db")
+	defer os.Remove("test.db" + DOUBLE_WRITE_FILE_SUFFIX)
+
+	pageID := disk.AllocatePage()
+
+	if err := disk.WritePageOffset(pageID, constant.PAGE_SIZE-5, []byte("12345")); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := disk.ReadPage(pageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !page.IsChecksumValid(data) || string(data[constant.PAGE_SIZE-5:]) != "12345" {
+		t.Error("partial write should keep the checksum valid")
+	}
+
+	if err := disk.WritePageOffset(pageID, constant.PAGE_SIZE-4, []byte("12345")); err != errors.ErrPageOffsetOutOfRange {
+		t.Error("write past the page should fail", err)
+	}
+
+	disk.ShutDown()
+}
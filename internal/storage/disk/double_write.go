@@ -0,0 +1,106 @@
+package disk
+
+import (
+	"encoding/binary"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/page"
+	"io"
+	"os"
+)
+
+/**
+ *  DOUBLE WRITE BUFFER (<database file>.dwb)
+ *  +------------+------------------+
+ *  | PageID (4) | Page Data (4096) |
+ *  +------------+------------------+
+ *
+ *  The page is synced to the buffer before it is written in place, when the
+ *  in place copy is torn by a crash the buffer copy still has a valid checksum.
+ *  The buffer is cleared on shut down and after recovery, so it is only left
+ *  behind by a crash or by a database which is still open.
+ */
+
+const DOUBLE_WRITE_FILE_SUFFIX = ".dwb"
+
+const DOUBLE_WRITE_PAGE_ID_SIZE = 4
+
+type doubleWriteBuffer struct {
+	file *os.File
+	data []byte
+}
+
+func openDoubleWriteBuffer(fileName string) (*doubleWriteBuffer, error) {
+	file, err := os.OpenFile(fileName, os.O_RDWR|os.O_CREATE|os.O_SYNC, 0755)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &doubleWriteBuffer{
+		file: file,
+		data: make([]byte, DOUBLE_WRITE_PAGE_ID_SIZE+constant.PAGE_SIZE),
+	}, nil
+}
+
+func (b *doubleWriteBuffer) write(pageID types.Page_id_t, pageData []byte) error {
+	for i := range b.data {
+		b.data[i] = 0
+	}
+
+	binary.BigEndian.PutUint32(b.data[:DOUBLE_WRITE_PAGE_ID_SIZE], uint32(pageID))
+	copy(b.data[DOUBLE_WRITE_PAGE_ID_SIZE:], pageData)
+
+	_, err := b.file.WriteAt(b.data, 0)
+
+	return err
+}
+
+// recover restores the buffered page when the copy in the database file is torn,
+// a buffer which is torn itself means the in place write never started. The pages
+// in place are whole afterwards so the buffer is cleared
+func (b *doubleWriteBuffer) recover(d *Disk) error {
+	n, err := b.file.ReadAt(b.data, 0)
+
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	if n == len(b.data) {
+		if err := b.restore(d); err != nil {
+			return err
+		}
+	}
+
+	return b.clear()
+}
+
+func (b *doubleWriteBuffer) restore(d *Disk) error {
+	pageID := types.Page_id_t(binary.BigEndian.Uint32(b.data[:DOUBLE_WRITE_PAGE_ID_SIZE]))
+	pageData := b.data[DOUBLE_WRITE_PAGE_ID_SIZE:]
+
+	if !page.IsChecksumValid(pageData) {
+		return nil
+	}
+
+	data, err := d.ReadPage(pageID)
+
+	if err == nil && page.IsChecksumValid(data) {
+		return nil
+	}
+
+	offset := pageID * constant.PAGE_SIZE
+
+	_, err = d.file.WriteAt(pageData, int64(offset))
+
+	return err
+}
+
+// clear empties the buffer, an empty buffer means every page in place is whole
+func (b *doubleWriteBuffer) clear() error {
+	return b.file.Truncate(0)
+}
+
+func (b *doubleWriteBuffer) close() {
+	b.file.Close()
+}
@@ -0,0 +1,85 @@
+package disk
+
+import (
+	"encoding/binary"
+	"go-db/internal/common/constant"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/storage/page"
+)
+
+/**
+ *  SUPER_BLOCK_PAGE_TYPE (always page 0)
+ *  +-------------+----------+------------+-------------+-------------+----------------------+-------------------+
+ *  | PageType (4)| Magic (4)| Version (4)| Checksum (4)| PageSize (4)| CatalogRootPageID (4)| FreeListHead (4)  |
+ *  +-------------+----------+------------+-------------+-------------+----------------------+-------------------+
+ *  +-----------------------+
+ *  | CheckpointLSN (8)     |
+ *  +-----------------------+
+ */
+
+type SuperBlock struct {
+	Version           uint32
+	PageSize          uint32
+	CatalogRootPageID types.Page_id_t
+	FreeListHead      types.Page_id_t
+	CheckpointLSN     uint64
+}
+
+func NewSuperBlock() *SuperBlock {
+	return &SuperBlock{
+		Version:           constant.DB_FORMAT_VERSION,
+		PageSize:          constant.PAGE_SIZE,
+		CatalogRootPageID: constant.INVALID_PAGE_ID,
+		FreeListHead:      constant.INVALID_PAGE_ID,
+	}
+}
+
+func (s *SuperBlock) Serialization() []byte {
+	data := make([]byte, constant.PAGE_SIZE)
+
+	binary.BigEndian.PutUint32(data[:types.PAGE_TYPE_OFFSET], uint32(types.SUPER_BLOCK_PAGE_TYPE))
+	binary.BigEndian.PutUint32(data[types.PAGE_TYPE_OFFSET:types.SUPER_BLOCK_MAGIC_OFFSET], constant.DB_MAGIC_NUMBER)
+	binary.BigEndian.PutUint32(data[types.SUPER_BLOCK_MAGIC_OFFSET:types.SUPER_BLOCK_VERSION_OFFSET], s.Version)
+	binary.BigEndian.PutUint32(data[types.PAGE_CHECKSUM_OFFSET:types.SUPER_BLOCK_PAGE_SIZE_OFFSET], s.PageSize)
+	binary.BigEndian.PutUint32(data[types.SUPER_BLOCK_PAGE_SIZE_OFFSET:types.SUPER_BLOCK_CATALOG_ROOT_OFFSET], uint32(s.CatalogRootPageID))
+	binary.BigEndian.PutUint32(data[types.SUPER_BLOCK_CATALOG_ROOT_OFFSET:types.SUPER_BLOCK_FREE_LIST_HEAD_OFFSET], uint32(s.FreeListHead))
+	binary.BigEndian.PutUint64(data[types.SUPER_BLOCK_FREE_LIST_HEAD_OFFSET:types.SUPER_BLOCK_CHECKPOINT_LSN_OFFSET], s.CheckpointLSN)
+	page.SetChecksum(data)
+
+	return data
+}
+
+// SuperBlockDeserialization parses page 0 and refuses the file
+// when it was not written by a compatible version of the database
+func SuperBlockDeserialization(data []byte) (*SuperBlock, error) {
+	if types.PAGE_TYPE(binary.BigEndian.Uint32(data[:types.PAGE_TYPE_OFFSET])) != types.SUPER_BLOCK_PAGE_TYPE {
+		return nil, errors.ErrNotDatabaseFile
+	}
+
+	if binary.BigEndian.Uint32(data[types.PAGE_TYPE_OFFSET:types.SUPER_BLOCK_MAGIC_OFFSET]) != constant.DB_MAGIC_NUMBER {
+		return nil, errors.ErrNotDatabaseFile
+	}
+
+	if !page.IsChecksumValid(data) {
+		return nil, errors.ErrPageCorrupted
+	}
+
+	s := &SuperBlock{
+		Version:           binary.BigEndian.Uint32(data[types.SUPER_BLOCK_MAGIC_OFFSET:types.SUPER_BLOCK_VERSION_OFFSET]),
+		PageSize:          binary.BigEndian.Uint32(data[types.PAGE_CHECKSUM_OFFSET:types.SUPER_BLOCK_PAGE_SIZE_OFFSET]),
+		CatalogRootPageID: types.Page_id_t(binary.BigEndian.Uint32(data[types.SUPER_BLOCK_PAGE_SIZE_OFFSET:types.SUPER_BLOCK_CATALOG_ROOT_OFFSET])),
+		FreeListHead:      types.Page_id_t(binary.BigEndian.Uint32(data[types.SUPER_BLOCK_CATALOG_ROOT_OFFSET:types.SUPER_BLOCK_FREE_LIST_HEAD_OFFSET])),
+		CheckpointLSN:     binary.BigEndian.Uint64(data[types.SUPER_BLOCK_FREE_LIST_HEAD_OFFSET:types.SUPER_BLOCK_CHECKPOINT_LSN_OFFSET]),
+	}
+
+	if s.Version != constant.DB_FORMAT_VERSION {
+		return nil, errors.ErrIncompatibleVersion
+	}
+
+	if s.PageSize != constant.PAGE_SIZE {
+		return nil, errors.ErrPageSizeMismatch
+	}
+
+	return s, nil
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"go-db/internal/common/constant"
 	"go-db/internal/common/types"
+	"hash/crc32"
 	"sync"
 	"sync/atomic"
 )
@@ -16,6 +17,8 @@ import (
 
 const PAGE_TYPE_OFFSET = 4
 
+var checksumTable = crc32.MakeTable(crc32.Castagnoli)
+
 type Page struct {
 	mutex    sync.RWMutex
 	pageID   types.Page_id_t
@@ -79,3 +82,38 @@ func (p *Page) SetDirty(isDirty bool) {
 	defer p.mutex.Unlock()
 	p.isDirty = isDirty
 }
+
+func (p *Page) UpdateChecksum() {
+	SetChecksum(p.data)
+}
+
+// SetChecksum stores the CRC32C of the page, the checksum field
+// itself is skipped when the checksum is computed
+func SetChecksum(data []byte) {
+	binary.BigEndian.PutUint32(data[types.NEXT_PAGE_ID_OFFSET:types.PAGE_CHECKSUM_OFFSET], ComputeChecksum(data))
+}
+
+func GetChecksum(data []byte) uint32 {
+	return binary.BigEndian.Uint32(data[types.NEXT_PAGE_ID_OFFSET:types.PAGE_CHECKSUM_OFFSET])
+}
+
+func ComputeChecksum(data []byte) uint32 {
+	checksum := crc32.Update(0, checksumTable, data[:types.NEXT_PAGE_ID_OFFSET])
+	return crc32.Update(checksum, checksumTable, data[types.PAGE_CHECKSUM_OFFSET:])
+}
+
+// IsChecksumValid reports whether the stored checksum match the page content,
+// a page which was allocated but never written is all zero and also valid
+func IsChecksumValid(data []byte) bool {
+	if GetChecksum(data) == ComputeChecksum(data) {
+		return true
+	}
+
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,115 @@
+// Package udf registers user-defined functions which SQL calls by name like the built-in
+// functions, an embedding program adds its own functions without changing the engine.
+//
+//	udf.RegisterScalar("mask", udf.Signature{Args: []udf.Type{udf.Text}, Return: udf.Text}, true,
+//		func(args []*udf.Value) (*udf.Value, error) {
+//			return udf.NewText(strings.Repeat("*", len(args[0].VAR_CHAR))), nil
+//		})
+//
+// The arguments are converted to the types of the signature when the conversion needs no
+// CAST, an argument of another type fails the query with an ArgumentError.
+package udf
+
+import (
+	"go-db/internal/catalog/column"
+	"go-db/internal/catalog/expression"
+	"go-db/internal/catalog/tuple"
+	"go-db/internal/common/errors"
+	"go-db/internal/common/types"
+)
+
+// Value is the value of an argument or a result, NULL is a value whose IsNull is true
+type Value = tuple.Value
+
+// Type is the type of an argument or a result in a signature
+type Type = types.COLUMN_TYPE
+
+// Signature is the argument types and the return type, with Variadic the last argument type repeats
+type Signature = expression.Signature
+
+// Accumulator is the state of one aggregate call, Step gets the arguments of every row
+type Accumulator = expression.Accumulator
+
+// ArgumentError is the error of a call with an argument the signature does not take
+type ArgumentError = errors.ArgumentError
+
+const (
+	Any       Type = expression.ANY_TYPE
+	Bool      Type = types.BOOL_TYPE
+	SmallInt  Type = types.SMALL_INT_TYPE
+	Int       Type = types.INT_TYPE
+	BigInt    Type = types.LONG_INT_TYPE
+	Decimal   Type = types.DECIMAL_TYPE
+	Real      Type = types.REAL_TYPE
+	Float     Type = types.FLOAT_TYPE
+	Text      Type = types.VAR_CHAR_TYPE
+	Char      Type = types.CHAR_TYPE
+	Date      Type = types.DATE_TYPE
+	Time      Type = types.TIME_TYPE
+	Timestamp Type = types.TIMESTAMP_TYPE
+	Interval  Type = types.INTERVAL_TYPE
+	JSON      Type = types.JSON_TYPE
+	UUID      Type = types.UUID_TYPE
+	Bytea     Type = types.BYTEA_TYPE
+)
+
+var (
+	ErrFunctionExist = errors.ErrFunctionExist
+	ErrNoFunction    = errors.ErrNoFunction
+)
+
+// RegisterScalar adds a function which is called for every row, a strict function
+// is NULL without being called when an argument is NULL
+func RegisterScalar(name string, signature Signature, strict bool, call func(args []*Value) (*Value, error)) error {
+	return expression.RegisterFunction(name, signature, strict, call)
+}
+
+// RegisterAggregate adds a function over all the rows of a query, every call gets a new
+// accumulator and a strict aggregate skips the rows with a NULL argument
+func RegisterAggregate(name string, signature Signature, strict bool, newAccumulator func() Accumulator) error {
+	return expression.RegisterAggregate(name, signature, strict, newAccumulator)
+}
+
+// Unregister removes a user-defined function, the built-in functions can not be removed
+func Unregister(name string) error {
+	return expression.UnregisterFunction(name)
+}
+
+func NewNull() *Value {
+	return expression.NewNull()
+}
+
+func NewBool(value bool) *Value {
+	return expression.NewBool(value)
+}
+
+func NewInt(value int32) *Value {
+	return tuple.GetValue(value, types.INT_TYPE, types.INT_SIZE)
+}
+
+func NewBigInt(value int64) *Value {
+	return tuple.GetValue(value, types.LONG_INT_TYPE, types.LONG_INT_SIZE)
+}
+
+func NewFloat(value float64) *Value {
+	return tuple.GetValue(value, types.FLOAT_TYPE, types.FLOAT_SIZE)
+}
+
+func NewText(value string) *Value {
+	return expression.NewText(value)
+}
+
+// Parse reads the text as a value of the type, such as a DECIMAL or a TIMESTAMP
+func Parse(text string, valueType Type) (*Value, error) {
+	return tuple.ParseValue(text, valueType, column.NewColumn(valueType, 0, "").Size)
+}
+
+// Interface returns the value as SELECT returns it, nil for NULL
+func Interface(value *Value) interface{} {
+	return tuple.GetValueInterface(value)
+}
+
+// String returns the text form of the value
+func String(value *Value) string {
+	return tuple.GetValueText(value)
+}
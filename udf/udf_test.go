@@ -0,0 +1,135 @@
+package udf
+
+import (
+	"errors"
+	"go-db/internal/buffer"
+	"go-db/internal/catalog/table"
+	errs "go-db/internal/common/errors"
+	"go-db/internal/common/types"
+	"go-db/internal/execution/executor"
+	"go-db/internal/storage/disk"
+	"os"
+	"strings"
+	"testing"
+)
+
+type product struct {
+	result float64
+	rows   int
+}
+
+func (p *product) Step(args []*Value) error {
+	p.result *= args[0].FLOAT
+	p.rows++
+
+	return nil
+}
+
+func (p *product) Result() (*Value, error) {
+	if p.rows == 0 {
+		return NewNull(), nil
+	}
+
+	return NewFloat(p.result), nil
+}
+
+func Test_RegisterFunctions(t *testing.T) {
+	diskManager, err := disk.NewDiskStorage("udf_test.db")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Remove("udf_test.db")
+
+	bufferPool := buffer.NewBufferPoolManager(buffer.NewLRUReplacer(), diskManager, 1024)
+	tableManager := table.NewTableManager(bufferPool, make(map[string]types.Page_id_t))
+	e := executor.NewExecutor(bufferPool, diskManager, tableManager)
+
+	mask := func(args []*Value) (*Value, error) {
+		text := String(args[0])
+		keep := int(args[1].INT)
+
+		if keep > len(text) {
+			keep = len(text)
+		}
+
+		return NewText(strings.Repeat("*", len(text)-keep) + text[len(text)-keep:]), nil
+	}
+
+	if err := RegisterScalar("mask", Signature{Args: []Type{Text, Int}, Return: Text}, true, mask); err != nil {
+		t.Fatal(err)
+	}
+
+	defer Unregister("mask")
+
+	if err := RegisterAggregate("product", Signature{Args: []Type{Float}, Return: Decimal}, true, func() Accumulator { return &product{result: 1} }); err != nil {
+		t.Fatal(err)
+	}
+
+	defer Unregister("product")
+
+	if err := RegisterScalar("MASK", Signature{Args: []Type{Text}, Return: Text}, true, mask); err != ErrFunctionExist {
+		t.Error("a function should be registered once", err)
+	}
+
+	for _, query := range []string{
+		"CREATE TABLE accounts (id INT PRIMARY KEY, card VARCHAR(32), rate FLOAT)",
+		"INSERT INTO accounts (id, card, rate) VALUES (1, 4111222233334444, 1.5)",
+		"INSERT INTO accounts (id, card, rate) VALUES (2, 5500666677778888, 2)",
+		"INSERT INTO accounts (id, card) VALUES (3, 340012345678)",
+		"UPDATE accounts SET card = mask(card, 4) WHERE id = 3",
+	} {
+		if _, err := e.QueryExecutor(query); err != nil {
+			t.Fatal(query, err)
+		}
+	}
+
+	tests := map[string]string{
+		"SELECT id, mask(card, '6') AS card FROM accounts WHERE mask(card, 2) = '**************44'": `{"card":["**********334444"],"id":[1]}`,
+		"SELECT card FROM accounts WHERE id = 3":                                                    `{"card":["********5678"]}`,
+		"SELECT product(rate) AS total, product(rate) * 2 AS twice FROM accounts":                   `{"total":["3"],"twice":["6"]}`,
+		"SELECT product(rate) AS total FROM accounts WHERE id = 3":                                  `{"total":[null]}`,
+	}
+
+	for query, want := range tests {
+		result, err := e.QueryExecutor(query)
+
+		if err != nil {
+			t.Errorf("%s failed with %v", query, err)
+			continue
+		}
+
+		if string(result) != want {
+			t.Errorf("%s should return %s, got %s", query, want, result)
+		}
+	}
+
+	var argumentError *ArgumentError
+
+	if _, err := e.QueryExecutor("SELECT mask(card, TRUE) AS card FROM accounts"); !errors.As(err, &argumentError) || argumentError.Position != 2 {
+		t.Error("a BOOL argument should fail the query", err)
+	}
+
+	failures := map[string]error{
+		"SELECT id, product(rate) AS total FROM accounts":     errs.ErrUngroupedColumn,
+		"SELECT * FROM accounts WHERE product(rate) > 1":      errs.ErrAggregateCall,
+		"UPDATE accounts SET rate = product(rate)":            errs.ErrAggregateCall,
+		"SELECT mask(card) AS card FROM accounts":             errs.ErrSyntax,
+		"SELECT unknown_function(card) AS card FROM accounts": ErrNoFunction,
+	}
+
+	for query, want := range failures {
+		if _, err := e.QueryExecutor(query); !errors.Is(err, want) {
+			t.Errorf("%s should fail with %v, got %v", query, want, err)
+		}
+	}
+
+	if err := Unregister("mask"); err != nil {
+		t.Error(err)
+	}
+
+	if _, err := e.QueryExecutor("SELECT mask(card, 4) AS card FROM accounts"); !errors.Is(err, ErrNoFunction) {
+		t.Error("an unregistered function should not be called", err)
+	}
+}